@@ -0,0 +1,32 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/kitouo/taskhub/internal/config"
+)
+
+/*
+runConfig 处理config子命令，返回进程退出码
+  - config print [flags]：按启动时同样的规则解析配置，打印每一项的生效值与来源，敏感值隐藏
+*/
+func runConfig(stdout, stderr io.Writer, args []string) int {
+	if len(args) == 0 || args[0] != "print" {
+		fmt.Fprintln(stderr, "usage: taskhub-api config print [-config file] [-key value ...]")
+		return 2
+	}
+	settings, err := config.Describe(args[1:])
+	tw := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "KEY\tVALUE\tSOURCE")
+	for _, s := range settings {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", s.Key, s.Value, s.Source)
+	}
+	_ = tw.Flush()
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+	return 0
+}
@@ -2,6 +2,8 @@ package main
 
 import (
 	"context"
+	"fmt"
+	"os"
 
 	"github.com/kitouo/taskhub/internal/app"
 	"github.com/kitouo/taskhub/internal/config"
@@ -9,9 +11,16 @@ import (
 )
 
 func main() {
-	cfg, err := config.Load()
+	args := os.Args[1:]
+	if len(args) > 0 && args[0] == "config" {
+		os.Exit(runConfig(os.Stdout, os.Stderr, args[1:]))
+	}
+
+	cfg, err := config.Load(args)
 	if err != nil {
-		panic(err)
+		// 配置错误一次性全部列出，直接退出，不打印panic堆栈
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
 	}
 
 	logger := logx.New("server=api", logx.ParseLevel(cfg.LogLevel))
@@ -0,0 +1,225 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/kitouo/taskhub/internal/blob"
+	"github.com/kitouo/taskhub/internal/httpx"
+	"github.com/kitouo/taskhub/internal/service"
+)
+
+type AttachmentHandler struct {
+	svc *service.AttachmentService
+}
+
+func NewAttachmentHandler(svc *service.AttachmentService) *AttachmentHandler {
+	return &AttachmentHandler{svc: svc}
+}
+
+// multipartOverhead 除文件本身外，multipart边界与头部允许占用的字节数
+const multipartOverhead = 64 << 10
+
+/*
+Handle /tasks/{id}/attachments: GET 列表, POST 上传（multipart，字段名file）
+/tasks/{id}/attachments/{aid}: GET 下载（支持Range）, DELETE（仅上传者）
+*/
+func (h *AttachmentHandler) Handle(w http.ResponseWriter, r *http.Request, taskID, attachmentID string) {
+	switch {
+	case attachmentID == "" && r.Method == http.MethodGet:
+		list, err := h.svc.List(r.Context(), taskID)
+		if err != nil {
+			h.writeAttachmentError(w, r, err)
+			return
+		}
+		httpx.WriteJson(w, http.StatusOK, list)
+	case attachmentID == "" && r.Method == http.MethodPost:
+		h.upload(w, r, taskID)
+	case attachmentID != "" && (r.Method == http.MethodGet || r.Method == http.MethodHead):
+		h.download(w, r, taskID, attachmentID)
+	case attachmentID != "" && r.Method == http.MethodDelete:
+		uid, ok := requireUser(w, r)
+		if !ok {
+			return
+		}
+		found, err := h.svc.Delete(r.Context(), uid, taskID, attachmentID)
+		if err == nil && !found {
+			err = errAttachmentNotFound
+		}
+		if err != nil {
+			h.writeAttachmentError(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// upload 逐个读取multipart的part，不使用ParseMultipartForm，避免整个请求体进内存
+func (h *AttachmentHandler) upload(w http.ResponseWriter, r *http.Request, taskID string) {
+	uid, ok := requireUser(w, r)
+	if !ok {
+		return
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, h.svc.MaxBytes()+multipartOverhead)
+
+	mr, err := r.MultipartReader()
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, "INVALID_ARGUMENT", "multipart/form-data body is required")
+		return
+	}
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			writeError(w, r, http.StatusBadRequest, "INVALID_ARGUMENT", `missing "file" part`)
+			return
+		}
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			h.writeAttachmentError(w, r, err)
+			return
+		}
+		if err != nil {
+			writeError(w, r, http.StatusBadRequest, "INVALID_ARGUMENT", "malformed multipart body")
+			return
+		}
+		if part.FormName() != "file" {
+			part.Close()
+			continue
+		}
+
+		a, err := h.svc.Upload(r.Context(), uid, taskID, part.FileName(), part)
+		part.Close()
+		if err != nil {
+			h.writeAttachmentError(w, r, err)
+			return
+		}
+		httpx.WriteJson(w, http.StatusCreated, a)
+		return
+	}
+}
+
+func (h *AttachmentHandler) download(w http.ResponseWriter, r *http.Request, taskID, id string) {
+	a, ok, err := h.svc.Get(r.Context(), taskID, id)
+	if err == nil && !ok {
+		err = errAttachmentNotFound
+	}
+	if err != nil {
+		h.writeAttachmentError(w, r, err)
+		return
+	}
+
+	etag := `"` + a.SHA256 + `"`
+	hdr := w.Header()
+	hdr.Set("ETag", etag)
+	hdr.Set("Accept-Ranges", "bytes")
+	hdr.Set("Content-Type", a.ContentType)
+	hdr.Set("X-Content-Type-Options", "nosniff")
+	hdr.Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": a.Name}))
+
+	if match := r.Header.Get("If-None-Match"); match != "" && match == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	start, length := int64(0), a.Size
+	status := http.StatusOK
+	// If-Range与ETag不一致时忽略Range，返回完整内容
+	if rng := r.Header.Get("Range"); rng != "" && (r.Header.Get("If-Range") == "" || r.Header.Get("If-Range") == etag) {
+		s, n, ok := parseRange(rng, a.Size)
+		if !ok {
+			hdr.Set("Content-Range", fmt.Sprintf("bytes */%d", a.Size))
+			writeError(w, r, http.StatusRequestedRangeNotSatisfiable, "RANGE_NOT_SATISFIABLE", "requested range not satisfiable")
+			return
+		}
+		if n >= 0 {
+			start, length, status = s, n, http.StatusPartialContent
+			hdr.Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, start+length-1, a.Size))
+		}
+	}
+	hdr.Set("Content-Length", strconv.FormatInt(length, 10))
+
+	if r.Method == http.MethodHead {
+		w.WriteHeader(status)
+		return
+	}
+	rc, err := h.svc.Open(r.Context(), a, start, length)
+	if err != nil {
+		h.writeAttachmentError(w, r, err)
+		return
+	}
+	defer rc.Close()
+	w.WriteHeader(status)
+	_, _ = io.Copy(w, rc)
+}
+
+/*
+parseRange 解析单段Range：bytes=a-b、bytes=a-、bytes=-n
+  - 多段或格式无法识别时返回length=-1，调用方按完整内容响应（RFC 9110允许忽略Range）
+  - ok=false表示范围不可满足（416）
+*/
+func parseRange(h string, size int64) (start, length int64, ok bool) {
+	spec, found := strings.CutPrefix(h, "bytes=")
+	if !found || strings.Contains(spec, ",") {
+		return 0, -1, true
+	}
+	first, last, found := strings.Cut(strings.TrimSpace(spec), "-")
+	if !found {
+		return 0, -1, true
+	}
+
+	if first == "" {
+		// 后缀范围：最后n个字节
+		n, err := strconv.ParseInt(last, 10, 64)
+		if err != nil || n < 0 {
+			return 0, -1, true
+		}
+		if n == 0 || size == 0 {
+			return 0, 0, false
+		}
+		n = min(n, size)
+		return size - n, n, true
+	}
+
+	s, err := strconv.ParseInt(first, 10, 64)
+	if err != nil || s < 0 {
+		return 0, -1, true
+	}
+	if s >= size {
+		return 0, 0, false
+	}
+	e := size - 1
+	if last != "" {
+		v, err := strconv.ParseInt(last, 10, 64)
+		if err != nil || v < s {
+			return 0, -1, true
+		}
+		e = min(v, size-1)
+	}
+	return s, e - s + 1, true
+}
+
+var errAttachmentNotFound = errors.New("attachment not found")
+
+func (h *AttachmentHandler) writeAttachmentError(w http.ResponseWriter, r *http.Request, err error) {
+	var maxErr *http.MaxBytesError
+	switch {
+	case errors.Is(err, service.ErrTaskNotFound):
+		writeError(w, r, http.StatusNotFound, "NOT_FOUND", "task not found")
+	case errors.Is(err, errAttachmentNotFound), errors.Is(err, blob.ErrNotFound):
+		writeError(w, r, http.StatusNotFound, "NOT_FOUND", "attachment not found")
+	case errors.Is(err, service.ErrAttachmentTooLarge), errors.As(err, &maxErr):
+		writeError(w, r, http.StatusRequestEntityTooLarge, "PAYLOAD_TOO_LARGE",
+			fmt.Sprintf("attachment exceeds %d bytes", h.svc.MaxBytes()))
+	case errors.Is(err, service.ErrForbidden):
+		writeError(w, r, http.StatusForbidden, "FORBIDDEN", "only the uploader can delete this attachment")
+	default:
+		writeInternalError(w, r, err)
+	}
+}
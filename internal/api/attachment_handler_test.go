@@ -0,0 +1,29 @@
+package api
+
+import "testing"
+
+func TestParseRange(t *testing.T) {
+	cases := []struct {
+		h             string
+		start, length int64
+		ok            bool
+	}{
+		{"bytes=0-9", 0, 10, true},
+		{"bytes=10-", 10, 90, true},
+		{"bytes=-5", 95, 5, true},
+		{"bytes=-500", 0, 100, true},
+		{"bytes=90-200", 90, 10, true},
+		{"bytes=100-", 0, 0, false},
+		{"bytes=-0", 0, 0, false},
+		{"bytes=0-1,5-6", 0, -1, true},
+		{"bytes=5-1", 0, -1, true},
+		{"items=0-1", 0, -1, true},
+		{"bytes=abc", 0, -1, true},
+	}
+	for _, c := range cases {
+		s, n, ok := parseRange(c.h, 100)
+		if s != c.start || n != c.length || ok != c.ok {
+			t.Errorf("parseRange(%q) = %d, %d, %v; want %d, %d, %v", c.h, s, n, ok, c.start, c.length, c.ok)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package api
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/kitouo/taskhub/internal/httpx"
+	"github.com/kitouo/taskhub/internal/service"
+)
+
+type CalendarHandler struct {
+	svc *service.CalendarService
+}
+
+func NewCalendarHandler(svc *service.CalendarService) *CalendarHandler {
+	return &CalendarHandler{svc: svc}
+}
+
+type calendarTokenRequest struct {
+	Scope   string `json:"scope"`   // user | project
+	Project string `json:"project"` // scope=project时必填
+}
+
+type calendarTokenResponse struct {
+	Token string `json:"token"`
+	URL   string `json:"url"` // 相对路径，拼上服务地址即可在日历客户端订阅
+}
+
+/*
+HandleTokens POST /calendar/tokens
+scope=user 订阅调用者（X-User-ID）负责的任务；scope=project 订阅某个项目的任务
+*/
+func (h *CalendarHandler) HandleTokens(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	uid, ok := requireUser(w, r)
+	if !ok {
+		return
+	}
+	var req calendarTokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, r, http.StatusBadRequest, "INVALID_JSON", "invalid json body")
+		return
+	}
+	subject := uid
+	if service.CalendarScope(req.Scope) == service.CalendarProject {
+		subject = req.Project
+	}
+	token, err := h.svc.Token(service.CalendarScope(req.Scope), subject)
+	if err != nil {
+		h.writeCalendarError(w, r, err)
+		return
+	}
+	httpx.WriteJson(w, http.StatusCreated, calendarTokenResponse{Token: token, URL: "/calendar/" + token + ".ics"})
+}
+
+/*
+HandleFeed GET/HEAD /calendar/{token}.ics[?kind=todo|event]
+响应带ETag，If-None-Match命中时返回304
+*/
+func (h *CalendarHandler) HandleFeed(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	name := strings.TrimPrefix(r.URL.Path, "/calendar/")
+	token, ok := strings.CutSuffix(name, ".ics")
+	if !ok || token == "" || strings.Contains(token, "/") {
+		writeError(w, r, http.StatusNotFound, "NOT_FOUND", "calendar not found")
+		return
+	}
+	kind := service.CalendarTodo
+	if v := r.URL.Query().Get("kind"); v != "" {
+		kind = service.CalendarKind(v)
+	}
+
+	body, err := h.svc.Feed(r.Context(), token, kind)
+	if err != nil {
+		h.writeCalendarError(w, r, err)
+		return
+	}
+
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+	w.Header().Set("ETag", etag)
+	// token就是凭证，不允许共享缓存保存
+	w.Header().Set("Cache-Control", "private, max-age=300")
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Content-Disposition", `inline; filename="taskhub.ics"`)
+	// ServeContent按上面的ETag处理If-None-Match（304）、HEAD与Range
+	http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(body))
+}
+
+func (h *CalendarHandler) writeCalendarError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, service.ErrCalendarDisabled):
+		writeError(w, r, http.StatusNotFound, "NOT_FOUND", "calendar feeds are disabled")
+	case errors.Is(err, service.ErrInvalidCalendarToken):
+		// 不区分“格式错误”和“签名不对”，避免泄露信息
+		writeError(w, r, http.StatusNotFound, "NOT_FOUND", "calendar not found")
+	case errors.Is(err, service.ErrInvalidCalendarScope):
+		writeError(w, r, http.StatusBadRequest, "INVALID_ARGUMENT", `scope must be "user" or "project" (project requires a non-empty project)`)
+	case errors.Is(err, service.ErrInvalidCalendarKind):
+		writeError(w, r, http.StatusBadRequest, "INVALID_ARGUMENT", `kind must be "todo" or "event"`)
+	default:
+		writeInternalError(w, r, err)
+	}
+}
@@ -0,0 +1,129 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/kitouo/taskhub/internal/httpx"
+	"github.com/kitouo/taskhub/internal/model"
+	"github.com/kitouo/taskhub/internal/service"
+)
+
+type CommentHandler struct {
+	svc *service.CommentService
+}
+
+func NewCommentHandler(svc *service.CommentService) *CommentHandler {
+	return &CommentHandler{svc: svc}
+}
+
+type commentRequest struct {
+	Body string `json:"body"` // Markdown
+}
+
+type commentPageResponse struct {
+	Total    int             `json:"total"`
+	Limit    int             `json:"limit"`
+	Offset   int             `json:"offset"`
+	Comments []model.Comment `json:"comments"`
+}
+
+/*
+Handle /tasks/{id}/comments: GET（?limit=&offset=）, POST
+/tasks/{id}/comments/{cid}: PATCH, DELETE（仅作者）
+读取不要求身份，写操作需要X-User-ID
+*/
+func (h *CommentHandler) Handle(w http.ResponseWriter, r *http.Request, taskID, commentID string) {
+	switch {
+	case commentID == "" && r.Method == http.MethodGet:
+		q := r.URL.Query()
+		limit, ok1 := queryInt(q.Get("limit"), 0)
+		offset, ok2 := queryInt(q.Get("offset"), 0)
+		if !ok1 || !ok2 {
+			writeError(w, r, http.StatusBadRequest, "INVALID_ARGUMENT", "limit/offset must be non-negative integers")
+			return
+		}
+		page, err := h.svc.List(r.Context(), taskID, limit, offset)
+		if err != nil {
+			h.writeCommentError(w, r, err)
+			return
+		}
+		httpx.WriteJson(w, http.StatusOK, commentPageResponse{
+			Total: page.Total, Limit: page.Limit, Offset: page.Offset, Comments: page.Comments,
+		})
+
+	case commentID == "" && r.Method == http.MethodPost:
+		uid, ok := requireUser(w, r)
+		if !ok {
+			return
+		}
+		var req commentRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, r, http.StatusBadRequest, "INVALID_JSON", "invalid json body")
+			return
+		}
+		c, err := h.svc.Create(r.Context(), uid, taskID, req.Body)
+		if err != nil {
+			h.writeCommentError(w, r, err)
+			return
+		}
+		httpx.WriteJson(w, http.StatusCreated, c)
+
+	case commentID != "" && r.Method == http.MethodPatch:
+		uid, ok := requireUser(w, r)
+		if !ok {
+			return
+		}
+		var req commentRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, r, http.StatusBadRequest, "INVALID_JSON", "invalid json body")
+			return
+		}
+		c, found, err := h.svc.Update(r.Context(), uid, taskID, commentID, req.Body)
+		if err == nil && !found {
+			err = errCommentNotFound
+		}
+		if err != nil {
+			h.writeCommentError(w, r, err)
+			return
+		}
+		httpx.WriteJson(w, http.StatusOK, c)
+
+	case commentID != "" && r.Method == http.MethodDelete:
+		uid, ok := requireUser(w, r)
+		if !ok {
+			return
+		}
+		found, err := h.svc.Delete(r.Context(), uid, taskID, commentID)
+		if err == nil && !found {
+			err = errCommentNotFound
+		}
+		if err != nil {
+			h.writeCommentError(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// errCommentNotFound 仅用于在handler内统一走writeCommentError
+var errCommentNotFound = errors.New("comment not found")
+
+func (h *CommentHandler) writeCommentError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, service.ErrTaskNotFound):
+		writeError(w, r, http.StatusNotFound, "NOT_FOUND", "task not found")
+	case errors.Is(err, errCommentNotFound):
+		writeError(w, r, http.StatusNotFound, "NOT_FOUND", "comment not found")
+	case errors.Is(err, service.ErrInvalidComment):
+		writeError(w, r, http.StatusBadRequest, "INVALID_ARGUMENT", "body is required (<= 10000 bytes)")
+	case errors.Is(err, service.ErrForbidden):
+		writeError(w, r, http.StatusForbidden, "FORBIDDEN", "only the author can modify this comment")
+	default:
+		writeInternalError(w, r, err)
+	}
+}
@@ -0,0 +1,52 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/kitouo/taskhub/internal/filter"
+	"github.com/kitouo/taskhub/internal/httpx"
+	"github.com/kitouo/taskhub/internal/repo"
+	"github.com/kitouo/taskhub/internal/service"
+)
+
+// writeError 统一带上request_id的错误响应
+func writeError(w http.ResponseWriter, r *http.Request, status int, code, msg string) {
+	httpx.WriteError(w, status, code, msg, httpx.RequestIDFromContext(r.Context()))
+}
+
+// writeInternalError 兜底的错误响应：存储熔断时返回503，客户端可以稍后重试；其余一律500
+func writeInternalError(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, repo.ErrUnavailable) {
+		writeError(w, r, http.StatusServiceUnavailable, "DEPENDENCY_UNAVAILABLE", "task store is temporarily unavailable")
+		return
+	}
+	writeError(w, r, http.StatusInternalServerError, "INTERNAL", "internal server error")
+}
+
+/*
+writeListError 处理列表类查询（过滤/排序参数）的错误
+返回false表示err不属于这类错误，需要调用方继续处理
+*/
+func writeListError(w http.ResponseWriter, r *http.Request, err error) bool {
+	var ferr *filter.Error
+	switch {
+	case errors.As(err, &ferr):
+		writeError(w, r, http.StatusBadRequest, "INVALID_FILTER", "invalid filter at "+ferr.Error())
+	case errors.Is(err, service.ErrInvalidSort):
+		writeError(w, r, http.StatusBadRequest, "INVALID_ARGUMENT", "invalid sort")
+	default:
+		return false
+	}
+	return true
+}
+
+// requireUser 取出调用者id，缺失时写401并返回false
+func requireUser(w http.ResponseWriter, r *http.Request) (string, bool) {
+	uid := httpx.UserIDFromContext(r.Context())
+	if uid == "" {
+		writeError(w, r, http.StatusUnauthorized, "UNAUTHENTICATED", "X-User-ID header is required")
+		return "", false
+	}
+	return uid, true
+}
@@ -2,35 +2,116 @@ package api
 
 import (
 	"context"
+	"errors"
 	"net/http"
+	"strings"
 	"time"
 
+	"github.com/kitouo/taskhub/internal/httpx"
+	"github.com/kitouo/taskhub/internal/repo"
 	"github.com/kitouo/taskhub/internal/service"
 )
 
+// Services 路由依赖的业务服务
+type Services struct {
+	Task       *service.TaskService
+	View       *service.ViewService
+	Comment    *service.CommentService
+	Attachment *service.AttachmentService
+	User       *service.UserService
+	Time       *service.TimeService
+	Calendar   *service.CalendarService
+}
+
 type Router struct {
 	task       *TaskHandler
+	view       *ViewHandler
+	comment    *CommentHandler
+	attachment *AttachmentHandler
+	user       *UserHandler
+	time       *TimeHandler
+	calendar   *CalendarHandler
 	readyCheck func(context.Context) error
+	stats      func() map[string]any
 }
 
-func NewRouter(svc *service.TaskService, readyCheck func(context.Context) error) http.Handler {
+/*
+NewRouter readyCheck用于/readyz，stats用于/debug/stats（运行时计数，例如缓存命中率），
+两者都可以为nil
+*/
+func NewRouter(svcs Services, readyCheck func(context.Context) error, stats func() map[string]any) http.Handler {
 
 	r := &Router{
-		task:       NewTaskHandler(svc),
+		task:       NewTaskHandler(svcs.Task),
+		view:       NewViewHandler(svcs.View),
+		comment:    NewCommentHandler(svcs.Comment),
+		attachment: NewAttachmentHandler(svcs.Attachment),
+		user:       NewUserHandler(svcs.User),
+		time:       NewTimeHandler(svcs.Time),
+		calendar:   NewCalendarHandler(svcs.Calendar),
 		readyCheck: readyCheck,
+		stats:      stats,
 	}
 
 	mux := http.NewServeMux()
 	mux.HandleFunc("/healthz", r.healthz)
 	mux.HandleFunc("/readyz", r.readyz)
+	mux.HandleFunc("/debug/stats", r.debugStats)
 
 	// tasks
-	mux.HandleFunc("/tasks", r.task.HandleTasks)     // GET/POST
-	mux.HandleFunc("/tasks/", r.task.HandleTaskByID) // GET/PATCH
+	mux.HandleFunc("/tasks", r.task.HandleTasks)                   // GET/POST
+	mux.HandleFunc("/tasks/", r.taskSubtree)                       // GET/PATCH，以及各子资源
+	mux.HandleFunc("/tasks:batch", r.task.HandleBatch)             // POST
+	mux.HandleFunc("/tasks/search", r.task.HandleSearch)           // GET
+	mux.HandleFunc("/tasks/topological", r.task.HandleTopological) // GET
+	mux.HandleFunc("/tasks/export", r.task.HandleExport)           // GET
+	mux.HandleFunc("/tasks/import", r.task.HandleImport)           // POST
+
+	// saved views
+	mux.HandleFunc("/views", r.view.HandleViews)     // GET/POST
+	mux.HandleFunc("/views/", r.view.HandleViewByID) // GET/PATCH/DELETE, /tasks, /count
+
+	// users
+	mux.HandleFunc("/users", r.user.HandleUsers)                                      // GET/POST
+	mux.HandleFunc("/users/", r.user.HandleUserByID)                                  // GET/PATCH, /tasks
+	mux.HandleFunc("/reports/inactive-assignments", r.user.HandleInactiveAssignments) // GET
+
+	// time tracking
+	mux.HandleFunc("/reports/time", r.time.HandleReport) // GET
+
+	// calendar feeds
+	mux.HandleFunc("/calendar/tokens", r.calendar.HandleTokens) // POST
+	mux.HandleFunc("/calendar/", r.calendar.HandleFeed)         // GET /calendar/{token}.ics
 
 	return mux
 }
 
+/*
+taskSubtree /tasks/{id}/... 的分发
+不属于任务本身的子资源（评论等）交给各自的handler，其余交给TaskHandler
+*/
+func (r *Router) taskSubtree(w http.ResponseWriter, req *http.Request) {
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(req.URL.Path, "/tasks/"), "/"), "/")
+	if len(parts) >= 2 && len(parts) <= 3 && parts[0] != "" {
+		sub := ""
+		if len(parts) == 3 {
+			sub = parts[2]
+		}
+		switch parts[1] {
+		case "comments":
+			r.comment.Handle(w, req, parts[0], sub)
+			return
+		case "attachments":
+			r.attachment.Handle(w, req, parts[0], sub)
+			return
+		case "time":
+			r.time.Handle(w, req, parts[0], sub)
+			return
+		}
+	}
+	r.task.HandleTaskByID(w, req)
+}
+
 func (r *Router) healthz(w http.ResponseWriter, req *http.Request) {
 	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
 	w.WriteHeader(http.StatusOK)
@@ -48,7 +129,12 @@ func (r *Router) readyz(w http.ResponseWriter, req *http.Request) {
 
 		if err := r.readyCheck(ctx); err != nil {
 			w.WriteHeader(http.StatusServiceUnavailable)
-			_, _ = w.Write([]byte("not ready"))
+			// 熔断状态可以安全地返回给调用方；其它错误可能含有连接信息，不对外展示
+			msg := "not ready"
+			if errors.Is(err, repo.ErrUnavailable) {
+				msg += ": " + err.Error()
+			}
+			_, _ = w.Write([]byte(msg))
 			return
 		}
 	}
@@ -57,3 +143,19 @@ func (r *Router) readyz(w http.ResponseWriter, req *http.Request) {
 	w.WriteHeader(http.StatusOK)
 	_, _ = w.Write([]byte("ok"))
 }
+
+// debugStats 暴露缓存、熔断、连接池和副本状态，与其它需要身份的端点一样要求X-User-ID
+func (r *Router) debugStats(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if _, ok := requireUser(w, req); !ok {
+		return
+	}
+	out := map[string]any{}
+	if r.stats != nil {
+		out = r.stats()
+	}
+	httpx.WriteJson(w, http.StatusOK, out)
+}
@@ -0,0 +1,30 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kitouo/taskhub/internal/httpx"
+)
+
+// /debug/stats 含内部状态，未携带身份时返回401
+func TestDebugStatsRequiresUser(t *testing.T) {
+	r := &Router{stats: func() map[string]any { return map[string]any{"task_cache": 1} }}
+	h := httpx.WithPrincipal(http.HandlerFunc(r.debugStats))
+
+	for _, c := range []struct {
+		user string
+		want int
+	}{{"", http.StatusUnauthorized}, {"alice", http.StatusOK}} {
+		req := httptest.NewRequest(http.MethodGet, "/debug/stats", nil)
+		if c.user != "" {
+			req.Header.Set("X-User-ID", c.user)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != c.want {
+			t.Errorf("user %q: status %d, want %d", c.user, rec.Code, c.want)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/kitouo/taskhub/internal/httpx"
+)
+
+type assigneesRequest struct {
+	UserIDs []string `json:"user_ids"`
+}
+
+/*
+HandleAssignees PUT /tasks/{id}/assignees
+body: {"user_ids": ["alice", "bob"]}，整体替换，空数组表示清空
+*/
+func (h *TaskHandler) HandleAssignees(w http.ResponseWriter, r *http.Request, id string) {
+	if r.Method != http.MethodPut {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	var req assigneesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeBadRequest(w, r, "INVALID_JSON", "invalid json body")
+		return
+	}
+	t, ok, err := h.svc.SetAssignees(r.Context(), id, req.UserIDs)
+	if err != nil {
+		h.writeTaskError(w, r, err)
+		return
+	}
+	if !ok {
+		h.writeNotFound(w, r, "NOT_FOUND", "task not found")
+		return
+	}
+	httpx.WriteJson(w, http.StatusOK, t)
+}
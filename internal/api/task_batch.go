@@ -0,0 +1,134 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/kitouo/taskhub/internal/httpx"
+	"github.com/kitouo/taskhub/internal/model"
+	"github.com/kitouo/taskhub/internal/repo"
+	"github.com/kitouo/taskhub/internal/service"
+)
+
+type batchRequest struct {
+	Operations []batchOperation `json:"operations"`
+}
+
+type batchOperation struct {
+	Op    string  `json:"op"` // create/update/delete
+	ID    string  `json:"id,omitempty"`
+	Title *string `json:"title,omitempty"`
+	Done  *bool   `json:"done,omitempty"`
+}
+
+type batchItemResult struct {
+	Index  int                  `json:"index"`
+	Op     string               `json:"op"`
+	Status int                  `json:"status"`
+	Task   *model.Task          `json:"task,omitempty"`
+	Error  *httpx.ErrorResponse `json:"error,omitempty"`
+}
+
+type batchResponse struct {
+	Atomic    bool              `json:"atomic"`
+	Committed bool              `json:"committed"`
+	Results   []batchItemResult `json:"results"`
+}
+
+/*
+HandleBatch POST /tasks:batch[?atomic=true]
+每条操作都有独立的status；atomic模式下committed=false表示整批已回滚
+*/
+func (h *TaskHandler) HandleBatch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	atomic := false
+	if v := r.URL.Query().Get("atomic"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			h.writeBadRequest(w, r, "INVALID_ARGUMENT", "atomic must be a boolean")
+			return
+		}
+		atomic = b
+	}
+
+	var req batchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeBadRequest(w, r, "INVALID_JSON", "invalid json body")
+		return
+	}
+
+	ops := make([]service.BatchOp, len(req.Operations))
+	for i, o := range req.Operations {
+		ops[i] = service.BatchOp{Op: o.Op, ID: o.ID, Title: o.Title, Done: o.Done}
+	}
+
+	results, err := h.svc.Batch(r.Context(), ops, atomic)
+	switch {
+	case errors.Is(err, service.ErrEmptyBatch):
+		h.writeBadRequest(w, r, "INVALID_ARGUMENT", "operations must not be empty")
+		return
+	case errors.Is(err, service.ErrBatchTooLarge):
+		h.writeBadRequest(w, r, "BATCH_TOO_LARGE", "too many operations in one batch")
+		return
+	case err != nil:
+		h.writeInternal(w, r, err)
+		return
+	}
+
+	rid := httpx.RequestIDFromContext(r.Context())
+	resp := batchResponse{Atomic: atomic, Committed: true, Results: make([]batchItemResult, len(results))}
+	for i, res := range results {
+		item := batchItemResult{Index: i, Op: req.Operations[i].Op}
+		item.Status, item.Error = batchItemStatus(req.Operations[i].Op, res, rid)
+		if item.Error == nil && res.Task.ID != "" {
+			t := res.Task
+			item.Task = &t
+		}
+		if item.Error != nil && atomic {
+			resp.Committed = false
+		}
+		resp.Results[i] = item
+	}
+
+	httpx.WriteJson(w, http.StatusOK, resp)
+}
+
+func batchItemStatus(op string, res repo.BatchResult, rid string) (int, *httpx.ErrorResponse) {
+	e := func(status int, code, msg string) (int, *httpx.ErrorResponse) {
+		return status, &httpx.ErrorResponse{Code: code, Message: msg, RequestId: rid}
+	}
+
+	switch {
+	case errors.Is(res.Err, repo.ErrBatchAborted):
+		return e(http.StatusConflict, "ABORTED", "rolled back because another operation failed")
+	case errors.Is(res.Err, service.ErrInvalidTitle):
+		return e(http.StatusBadRequest, "INVALID_ARGUMENT", "title is required (<= 200)")
+	case errors.Is(res.Err, service.ErrMissingID):
+		return e(http.StatusBadRequest, "INVALID_ARGUMENT", "id is required")
+	case errors.Is(res.Err, service.ErrInvalidOp):
+		return e(http.StatusBadRequest, "INVALID_ARGUMENT", "op must be one of create/update/delete")
+	case errors.Is(res.Err, service.ErrOpenChildren):
+		return e(http.StatusConflict, "OPEN_CHILDREN", "task has open children")
+	case errors.Is(res.Err, service.ErrBlocked):
+		return e(http.StatusConflict, "BLOCKED", "task is blocked by open dependencies")
+	case res.Err != nil:
+		return e(http.StatusInternalServerError, "INTERNAL", "internal server error")
+	case !res.Found:
+		return e(http.StatusNotFound, "NOT_FOUND", "task not found")
+	}
+
+	switch op {
+	case string(repo.BatchCreate):
+		return http.StatusCreated, nil
+	case string(repo.BatchDelete):
+		return http.StatusNoContent, nil
+	default:
+		return http.StatusOK, nil
+	}
+}
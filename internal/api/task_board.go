@@ -0,0 +1,46 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/kitouo/taskhub/internal/httpx"
+	"github.com/kitouo/taskhub/internal/service"
+)
+
+type moveCardRequest struct {
+	Column *string `json:"column"` // 省略表示留在原列
+	After  string  `json:"after"`  // 移动后紧挨着的上一张卡片
+	Before string  `json:"before"` // 移动后紧挨着的下一张卡片
+}
+
+/*
+HandleMove POST /tasks/{id}/move
+body: {"column":"doing","after":"<id>","before":"<id>"}，邻居都省略表示移到列尾
+看板按 GET /tasks?filter=column=doing&sort=rank 读取
+*/
+func (h *TaskHandler) HandleMove(w http.ResponseWriter, r *http.Request, id string) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	var req moveCardRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeBadRequest(w, r, "INVALID_JSON", "invalid json body")
+		return
+	}
+	t, ok, err := h.svc.MoveCard(r.Context(), id, service.MoveCardInput{
+		Column: req.Column,
+		After:  req.After,
+		Before: req.Before,
+	})
+	if err != nil {
+		h.writeTaskError(w, r, err)
+		return
+	}
+	if !ok {
+		h.writeNotFound(w, r, "NOT_FOUND", "task not found")
+		return
+	}
+	httpx.WriteJson(w, http.StatusOK, t)
+}
@@ -0,0 +1,93 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/kitouo/taskhub/internal/httpx"
+	"github.com/kitouo/taskhub/internal/service"
+)
+
+type addChecklistItemRequest struct {
+	Text     string `json:"text"`
+	Position *int   `json:"position"` // 可选，默认追加到末尾
+}
+
+type updateChecklistItemRequest struct {
+	Text     *string `json:"text"`
+	Checked  *bool   `json:"checked"`
+	Position *int    `json:"position"`
+}
+
+type reorderChecklistRequest struct {
+	ItemIDs []string `json:"item_ids"`
+}
+
+/*
+HandleChecklist
+  - GET    /tasks/{id}/checklist            检查项列表（按position）
+  - POST   /tasks/{id}/checklist            body: {"text":"...", "position":0}
+  - PUT    /tasks/{id}/checklist/order      body: {"item_ids":[...]}，必须包含全部检查项
+  - PATCH  /tasks/{id}/checklist/{itemID}   body: {"checked":true} / {"text":"..."} / {"position":2}
+  - DELETE /tasks/{id}/checklist/{itemID}
+*/
+func (h *TaskHandler) HandleChecklist(w http.ResponseWriter, r *http.Request, id, itemID string) {
+	switch {
+	case itemID == "" && r.Method == http.MethodGet:
+		items, ok, err := h.svc.Checklist(r.Context(), id)
+		h.writeChecklist(w, r, http.StatusOK, items, ok, err)
+	case itemID == "" && r.Method == http.MethodPost:
+		var req addChecklistItemRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			h.writeBadRequest(w, r, "INVALID_JSON", "invalid json body")
+			return
+		}
+		it, ok, err := h.svc.AddChecklistItem(r.Context(), id, req.Text, req.Position)
+		h.writeChecklist(w, r, http.StatusCreated, it, ok, err)
+	case itemID == "order" && r.Method == http.MethodPut:
+		var req reorderChecklistRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			h.writeBadRequest(w, r, "INVALID_JSON", "invalid json body")
+			return
+		}
+		items, ok, err := h.svc.ReorderChecklist(r.Context(), id, req.ItemIDs)
+		h.writeChecklist(w, r, http.StatusOK, items, ok, err)
+	case itemID != "" && itemID != "order" && r.Method == http.MethodPatch:
+		var req updateChecklistItemRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			h.writeBadRequest(w, r, "INVALID_JSON", "invalid json body")
+			return
+		}
+		it, ok, err := h.svc.UpdateChecklistItem(r.Context(), id, itemID, service.ChecklistItemInput{
+			Text:     req.Text,
+			Checked:  req.Checked,
+			Position: req.Position,
+		})
+		h.writeChecklist(w, r, http.StatusOK, it, ok, err)
+	case itemID != "" && itemID != "order" && r.Method == http.MethodDelete:
+		ok, err := h.svc.DeleteChecklistItem(r.Context(), id, itemID)
+		if err != nil {
+			h.writeTaskError(w, r, err)
+			return
+		}
+		if !ok {
+			h.writeNotFound(w, r, "NOT_FOUND", "checklist item not found")
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *TaskHandler) writeChecklist(w http.ResponseWriter, r *http.Request, status int, v any, ok bool, err error) {
+	if err != nil {
+		h.writeTaskError(w, r, err)
+		return
+	}
+	if !ok {
+		h.writeNotFound(w, r, "NOT_FOUND", "task or checklist item not found")
+		return
+	}
+	httpx.WriteJson(w, status, v)
+}
@@ -0,0 +1,105 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/kitouo/taskhub/internal/httpx"
+	"github.com/kitouo/taskhub/internal/service"
+)
+
+type addDependencyRequest struct {
+	BlockerID string `json:"blocker_id"`
+}
+
+/*
+HandleDependencies
+  - GET    /tasks/{id}/dependencies             前置任务列表
+  - POST   /tasks/{id}/dependencies             body: {"blocker_id":"..."}
+  - DELETE /tasks/{id}/dependencies/{blockerID}
+*/
+func (h *TaskHandler) HandleDependencies(w http.ResponseWriter, r *http.Request, id, blockerID string) {
+	switch {
+	case blockerID == "" && r.Method == http.MethodGet:
+		tasks, ok, err := h.svc.Dependencies(r.Context(), id)
+		h.writeTasks(w, r, tasks, ok, err)
+	case blockerID == "" && r.Method == http.MethodPost:
+		var req addDependencyRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			h.writeBadRequest(w, r, "INVALID_JSON", "invalid json body")
+			return
+		}
+		if req.BlockerID == "" {
+			h.writeBadRequest(w, r, "INVALID_ARGUMENT", "blocker_id is required")
+			return
+		}
+		ok, err := h.svc.AddDependency(r.Context(), id, req.BlockerID)
+		if err != nil {
+			h.writeTaskError(w, r, err)
+			return
+		}
+		if !ok {
+			h.writeNotFound(w, r, "NOT_FOUND", "task not found")
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	case blockerID != "" && r.Method == http.MethodDelete:
+		ok, err := h.svc.RemoveDependency(r.Context(), id, blockerID)
+		if err != nil {
+			h.writeTaskError(w, r, err)
+			return
+		}
+		if !ok {
+			h.writeNotFound(w, r, "NOT_FOUND", "dependency not found")
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// HandleDependents GET /tasks/{id}/dependents 被该任务阻塞的任务
+func (h *TaskHandler) HandleDependents(w http.ResponseWriter, r *http.Request, id string) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	tasks, ok, err := h.svc.Dependents(r.Context(), id)
+	h.writeTasks(w, r, tasks, ok, err)
+}
+
+/*
+HandleTopological GET /tasks/topological[?filter=&sort=]
+按依赖关系排好的执行顺序，filter/sort 与 GET /tasks 相同
+*/
+func (h *TaskHandler) HandleTopological(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	tasks, err := h.svc.TopologicalOrder(r.Context(), service.ListOptions{
+		Filter: r.URL.Query().Get("filter"),
+		Sort:   r.URL.Query().Get("sort"),
+	})
+	if writeListError(w, r, err) {
+		return
+	}
+	if err != nil {
+		h.writeInternal(w, r, err)
+		return
+	}
+	httpx.WriteJson(w, http.StatusOK, tasks)
+}
+
+func (h *TaskHandler) writeTasks(w http.ResponseWriter, r *http.Request, tasks any, ok bool, err error) {
+	if err != nil {
+		h.writeTaskError(w, r, err)
+		return
+	}
+	if !ok {
+		h.writeNotFound(w, r, "NOT_FOUND", "task not found")
+		return
+	}
+	httpx.WriteJson(w, http.StatusOK, tasks)
+}
@@ -2,9 +2,11 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/kitouo/taskhub/internal/httpx"
 	"github.com/kitouo/taskhub/internal/service"
@@ -19,11 +21,28 @@ func NewTaskHandler(svc *service.TaskService) *TaskHandler {
 }
 
 type createTaskRequest struct {
-	Title string `json:"title"`
+	Title    string     `json:"title"`
+	ParentID string     `json:"parent_id"`
+	DueAt    *time.Time `json:"due_at"`   // RFC3339
+	RRule    string     `json:"rrule"`    // 例如 FREQ=WEEKLY;BYDAY=MO
+	Timezone string     `json:"timezone"` // IANA时区名，默认UTC
+	Project  string     `json:"project"`
+	// EstimateMinutes 预估工时（分钟）
+	EstimateMinutes int    `json:"estimate_minutes"`
+	Column          string `json:"column"` // 看板列，新任务排在列尾
 }
 
-type markDoneRequest struct {
-	Done bool `json:"done"`
+/*
+patchTaskRequest PATCH /tasks/{id}
+只带project/estimate_minutes时不改完成状态；为兼容旧客户端，其余情况按done处理（缺省为false）
+*/
+type patchTaskRequest struct {
+	Done  *bool `json:"done"`
+	Force bool  `json:"force"` // 忽略未完成的前置任务
+	// RequireChecklist 仍有未勾选的检查项时拒绝完成
+	RequireChecklist bool    `json:"require_checklist"`
+	Project          *string `json:"project"`
+	EstimateMinutes  *int    `json:"estimate_minutes"`
 }
 
 /*
@@ -33,9 +52,25 @@ func (h *TaskHandler) HandleTasks(w http.ResponseWriter, r *http.Request) {
 
 	switch r.Method {
 	case http.MethodGet:
-		tasks, err := h.svc.List(r.Context())
+		// assignee=me 解析为调用者自己
+		assignee := r.URL.Query().Get("assignee")
+		if assignee == "me" {
+			uid, ok := requireUser(w, r)
+			if !ok {
+				return
+			}
+			assignee = uid
+		}
+		tasks, err := h.svc.List(r.Context(), service.ListOptions{
+			Filter:   r.URL.Query().Get("filter"),
+			Sort:     r.URL.Query().Get("sort"),
+			Assignee: assignee,
+		})
+		if writeListError(w, r, err) {
+			return
+		}
 		if err != nil {
-			h.writeInternal(w, r)
+			h.writeInternal(w, r, err)
 			return
 		}
 		httpx.WriteJson(w, http.StatusOK, tasks)
@@ -46,13 +81,22 @@ func (h *TaskHandler) HandleTasks(w http.ResponseWriter, r *http.Request) {
 			h.writeBadRequest(w, r, "INVALID_JSON", "invalid json body")
 			return
 		}
-		t, err := h.svc.Create(r.Context(), req.Title)
+		t, err := h.svc.Create(r.Context(), service.CreateInput{
+			Title:           req.Title,
+			ParentID:        req.ParentID,
+			DueAt:           req.DueAt,
+			RRule:           req.RRule,
+			Timezone:        req.Timezone,
+			Project:         req.Project,
+			EstimateMinutes: req.EstimateMinutes,
+			Column:          req.Column,
+		})
 		if err == service.ErrInvalidTitle {
 			h.writeBadRequest(w, r, "INVALID_ARGUMENT", "title is required (<= 200)")
 			return
 		}
 		if err != nil {
-			h.writeInternal(w, r)
+			h.writeTaskError(w, r, err)
 			return
 		}
 		httpx.WriteJson(w, http.StatusCreated, t)
@@ -82,7 +126,7 @@ func (h *TaskHandler) HandleTaskByID(w http.ResponseWriter, r *http.Request) {
 	if r.Method == http.MethodGet && len(parts) == 1 {
 		t, ok, err := h.svc.Get(r.Context(), id)
 		if err != nil {
-			h.writeInternal(w, r)
+			h.writeInternal(w, r, err)
 			return
 		}
 		if !ok {
@@ -93,17 +137,29 @@ func (h *TaskHandler) HandleTaskByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// PATCH /tasks/{id}  (body: {"done":true})
+	// PATCH /tasks/{id}  (body: {"done":true} 或 {"project":"...","estimate_minutes":90})
 	if r.Method == http.MethodPatch && len(parts) == 1 {
-		var req markDoneRequest
+		var req patchTaskRequest
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 			h.writeBadRequest(w, r, "INVALID_JSON", "invalid json body")
 			return
 		}
 
-		t, ok, err := h.svc.MarkDone(r.Context(), id, req.Done)
+		in := service.UpdateInput{
+			Project:         req.Project,
+			EstimateMinutes: req.EstimateMinutes,
+			Done:            req.Done,
+			DoneOptions: service.MarkDoneOptions{
+				Force:            req.Force,
+				RequireChecklist: req.RequireChecklist,
+			},
+		}
+		if in.Done == nil && in.Project == nil && in.EstimateMinutes == nil {
+			in.Done = new(bool)
+		}
+		t, ok, err := h.svc.Update(r.Context(), id, in)
 		if err != nil {
-			h.writeInternal(w, r)
+			h.writeTaskError(w, r, err)
 			return
 		}
 		if !ok {
@@ -113,12 +169,101 @@ func (h *TaskHandler) HandleTaskByID(w http.ResponseWriter, r *http.Request) {
 		httpx.WriteJson(w, http.StatusOK, t)
 		return
 	}
+	// 子资源：/tasks/{id}/xxx
+	if len(parts) == 2 {
+		switch parts[1] {
+		case "children":
+			h.HandleChildren(w, r, id)
+			return
+		case "parent":
+			h.HandleParent(w, r, id)
+			return
+		case "dependencies":
+			h.HandleDependencies(w, r, id, "")
+			return
+		case "dependents":
+			h.HandleDependents(w, r, id)
+			return
+		case "assignees":
+			h.HandleAssignees(w, r, id)
+			return
+		case "checklist":
+			h.HandleChecklist(w, r, id, "")
+			return
+		case "move":
+			h.HandleMove(w, r, id)
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if len(parts) == 3 && parts[1] == "dependencies" {
+		h.HandleDependencies(w, r, id, parts[2])
+		return
+	}
+	if len(parts) == 3 && parts[1] == "checklist" {
+		h.HandleChecklist(w, r, id, parts[2])
+		return
+	}
 	w.WriteHeader(http.StatusMethodNotAllowed)
 }
 
-func (h *TaskHandler) writeInternal(w http.ResponseWriter, r *http.Request) {
-	rid := httpx.RequestIDFromContext(r.Context())
-	httpx.WriteError(w, http.StatusInternalServerError, "INTERNAL", "internal server error", rid)
+// writeTaskError 把service层的业务错误映射为HTTP错误，未知错误按500处理
+func (h *TaskHandler) writeTaskError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, service.ErrParentNotFound):
+		h.writeBadRequest(w, r, "INVALID_ARGUMENT", "parent task not found")
+	case errors.Is(err, service.ErrCycle):
+		writeError(w, r, http.StatusConflict, "CYCLE_DETECTED", "task cannot be moved under its own descendant")
+	case errors.Is(err, service.ErrOpenChildren):
+		writeError(w, r, http.StatusConflict, "OPEN_CHILDREN", "task has open children")
+	case errors.Is(err, service.ErrBlocked):
+		writeError(w, r, http.StatusConflict, "BLOCKED", "task is blocked by open dependencies, use force to override")
+	case errors.Is(err, service.ErrSelfDependency):
+		h.writeBadRequest(w, r, "INVALID_ARGUMENT", "task cannot depend on itself")
+	case errors.Is(err, service.ErrBlockerNotFound):
+		h.writeBadRequest(w, r, "INVALID_ARGUMENT", "blocker task not found")
+	case errors.Is(err, service.ErrDependencyCycle):
+		writeError(w, r, http.StatusConflict, "CYCLE_DETECTED", "dependency would create a cycle")
+	case errors.Is(err, service.ErrInvalidRRule):
+		h.writeBadRequest(w, r, "INVALID_RRULE", err.Error())
+	case errors.Is(err, service.ErrInvalidTimezone):
+		h.writeBadRequest(w, r, "INVALID_ARGUMENT", "unknown timezone")
+	case errors.Is(err, service.ErrInvalidProject):
+		h.writeBadRequest(w, r, "INVALID_ARGUMENT", "project must be <= 64 characters")
+	case errors.Is(err, service.ErrInvalidEstimate):
+		h.writeBadRequest(w, r, "INVALID_ARGUMENT", "estimate_minutes must be between 0 and 100000")
+	case errors.Is(err, service.ErrInvalidColumn):
+		h.writeBadRequest(w, r, "INVALID_ARGUMENT", "column must be <= 64 characters")
+	case errors.Is(err, service.ErrInvalidMove):
+		h.writeBadRequest(w, r, "INVALID_ARGUMENT", "task cannot be its own neighbour")
+	case errors.Is(err, service.ErrNeighbourNotFound):
+		h.writeBadRequest(w, r, "INVALID_ARGUMENT", "after/before must be tasks in the target column")
+	case errors.Is(err, service.ErrNeighboursNotAdjacent):
+		writeError(w, r, http.StatusConflict, "STALE_BOARD", "after and before are no longer adjacent, reload the column")
+	case errors.Is(err, service.ErrInvalidChecklistItem):
+		h.writeBadRequest(w, r, "INVALID_ARGUMENT", "text is required (<= 500)")
+	case errors.Is(err, service.ErrInvalidChecklistOrder):
+		h.writeBadRequest(w, r, "INVALID_ARGUMENT", "item_ids must list every checklist item exactly once")
+	case errors.Is(err, service.ErrChecklistFull):
+		writeError(w, r, http.StatusConflict, "CHECKLIST_FULL", "too many checklist items (<= 200)")
+	case errors.Is(err, service.ErrChecklistIncomplete):
+		writeError(w, r, http.StatusConflict, "CHECKLIST_INCOMPLETE", "task has unchecked checklist items")
+	case errors.Is(err, service.ErrUserNotFound):
+		h.writeBadRequest(w, r, "INVALID_ARGUMENT", "user not found")
+	case errors.Is(err, service.ErrUserInactive):
+		writeError(w, r, http.StatusConflict, "USER_INACTIVE", "cannot assign a deactivated user")
+	case errors.Is(err, service.ErrTooManyAssignees):
+		h.writeBadRequest(w, r, "INVALID_ARGUMENT", "too many assignees (<= 20)")
+	case errors.Is(err, service.ErrDueRequired):
+		h.writeBadRequest(w, r, "INVALID_ARGUMENT", "due_at is required for recurring tasks")
+	default:
+		h.writeInternal(w, r, err)
+	}
+}
+
+func (h *TaskHandler) writeInternal(w http.ResponseWriter, r *http.Request, err error) {
+	writeInternalError(w, r, err)
 }
 
 func (h *TaskHandler) writeBadRequest(w http.ResponseWriter, r *http.Request, code, msg string) {
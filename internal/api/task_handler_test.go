@@ -0,0 +1,108 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kitouo/taskhub/internal/httpx"
+	"github.com/kitouo/taskhub/internal/model"
+	"github.com/kitouo/taskhub/internal/repo"
+	"github.com/kitouo/taskhub/internal/repo/memory"
+	"github.com/kitouo/taskhub/internal/service"
+)
+
+// assignee=me 解析为X-User-ID中的调用者，未携带身份时返回401
+func TestListAssigneeMe(t *testing.T) {
+	ctx := context.Background()
+	users := memory.NewUserRepo()
+	svc := service.NewTaskService(memory.NewTaskRepo(), service.WithAssignees(memory.NewAssignmentRepo(), users))
+	userSvc := service.NewUserService(users, svc)
+	for _, id := range []string{"alice", "bob"} {
+		name := id
+		if _, err := userSvc.Create(ctx, service.UserInput{ID: id, DisplayName: &name}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	mine, err := svc.Create(ctx, service.CreateInput{Title: "mine"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.Create(ctx, service.CreateInput{Title: "unassigned"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := svc.SetAssignees(ctx, mine.ID, []string{"alice"}); err != nil {
+		t.Fatal(err)
+	}
+	h := httpx.WithPrincipal(http.HandlerFunc(NewTaskHandler(svc).HandleTasks))
+
+	list := func(user string) (int, []model.Task) {
+		req := httptest.NewRequest(http.MethodGet, "/tasks?assignee=me", nil)
+		if user != "" {
+			req.Header.Set("X-User-ID", user)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		var tasks []model.Task
+		if rec.Code == http.StatusOK {
+			if err := json.Unmarshal(rec.Body.Bytes(), &tasks); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+		}
+		return rec.Code, tasks
+	}
+
+	if code, tasks := list("alice"); code != http.StatusOK || len(tasks) != 1 || tasks[0].ID != mine.ID {
+		t.Errorf("alice: %d %+v, want only her task", code, tasks)
+	}
+	if code, tasks := list("bob"); code != http.StatusOK || len(tasks) != 0 {
+		t.Errorf("bob: %d %+v, want empty list", code, tasks)
+	}
+	if code, _ := list(""); code != http.StatusUnauthorized {
+		t.Errorf("anonymous: status %d, want 401", code)
+	}
+}
+
+// failingRepo 读操作一律返回err
+type failingRepo struct {
+	*memory.TaskRepo
+	err error
+}
+
+func (r failingRepo) Get(context.Context, string) (model.Task, bool, error) {
+	return model.Task{}, false, r.err
+}
+
+func (r failingRepo) List(context.Context, repo.ListQuery) ([]model.Task, error) {
+	return nil, r.err
+}
+
+// 存储的普通错误返回500，熔断返回503；两者都不能把进程带崩
+func TestInternalErrors(t *testing.T) {
+	cases := []struct {
+		err  error
+		want int
+	}{
+		{errors.New("driver: bad connection"), http.StatusInternalServerError},
+		{fmt.Errorf("get: %w", repo.ErrUnavailable), http.StatusServiceUnavailable},
+	}
+	for _, c := range cases {
+		h := NewTaskHandler(service.NewTaskService(failingRepo{memory.NewTaskRepo(), c.err}))
+		for _, path := range []string{"/tasks", "/tasks/t1"} {
+			req := httptest.NewRequest(http.MethodGet, path, nil)
+			rec := httptest.NewRecorder()
+			if path == "/tasks" {
+				h.HandleTasks(rec, req)
+			} else {
+				h.HandleTaskByID(rec, req)
+			}
+			if rec.Code != c.want {
+				t.Errorf("GET %s with %v: status %d, want %d", path, c.err, rec.Code, c.want)
+			}
+		}
+	}
+}
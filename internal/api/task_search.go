@@ -0,0 +1,84 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/kitouo/taskhub/internal/httpx"
+	"github.com/kitouo/taskhub/internal/model"
+	"github.com/kitouo/taskhub/internal/service"
+)
+
+type searchHit struct {
+	Task    model.Task `json:"task"`
+	Score   float64    `json:"score"`
+	Snippet string     `json:"snippet"`
+}
+
+type searchResponse struct {
+	Query   string      `json:"query"`
+	Total   int         `json:"total"`
+	Limit   int         `json:"limit"`
+	Offset  int         `json:"offset"`
+	Results []searchHit `json:"results"`
+}
+
+/*
+HandleSearch GET /tasks/search?q=&limit=&offset=
+*/
+func (h *TaskHandler) HandleSearch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	limit, ok1 := queryInt(query.Get("limit"), service.DefaultSearchLimit)
+	offset, ok2 := queryInt(query.Get("offset"), 0)
+	if !ok1 || !ok2 {
+		h.writeBadRequest(w, r, "INVALID_ARGUMENT", "limit/offset must be non-negative integers")
+		return
+	}
+
+	results, total, err := h.svc.Search(r.Context(), query.Get("q"), limit, offset)
+	if errors.Is(err, service.ErrInvalidQuery) {
+		h.writeBadRequest(w, r, "INVALID_ARGUMENT", "q must contain at least one word")
+		return
+	}
+	if err != nil {
+		h.writeInternal(w, r, err)
+		return
+	}
+
+	resp := searchResponse{
+		Query:   query.Get("q"),
+		Total:   total,
+		Limit:   effectiveLimit(limit),
+		Offset:  offset,
+		Results: make([]searchHit, len(results)),
+	}
+	for i, res := range results {
+		resp.Results[i] = searchHit{Task: res.Task, Score: res.Score, Snippet: res.Snippet}
+	}
+	httpx.WriteJson(w, http.StatusOK, resp)
+}
+
+func effectiveLimit(limit int) int {
+	if limit <= 0 {
+		return service.DefaultSearchLimit
+	}
+	return min(limit, service.MaxSearchLimit)
+}
+
+// queryInt 解析非负整数查询参数，空值返回def
+func queryInt(v string, def int) (int, bool) {
+	if v == "" {
+		return def, true
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		return 0, false
+	}
+	return n, true
+}
@@ -0,0 +1,140 @@
+package api
+
+import (
+	"errors"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/kitouo/taskhub/internal/httpx"
+	"github.com/kitouo/taskhub/internal/service"
+	"github.com/kitouo/taskhub/internal/transfer"
+)
+
+// maxImportBytes 导入请求体的大小上限
+const maxImportBytes = 64 << 20
+
+// exportFlushEvery 导出时每写出多少行主动flush一次，让客户端尽早收到数据
+const exportFlushEvery = 500
+
+/*
+HandleExport GET /tasks/export?format=csv|jsonl[&filter=]
+按创建顺序流式输出；开始输出后再出错只能中断连接，客户端会看到不完整的响应
+*/
+func (h *TaskHandler) HandleExport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	format := transfer.CSV
+	if v := r.URL.Query().Get("format"); v != "" {
+		f, err := transfer.ParseFormat(v)
+		if err != nil {
+			h.writeBadRequest(w, r, "INVALID_ARGUMENT", err.Error())
+			return
+		}
+		format = f
+	}
+
+	var (
+		tw transfer.Writer
+		n  int
+		rc = http.NewResponseController(w)
+	)
+	// 第一行数据到来时才写响应头，这样过滤表达式错误等仍能返回正常的错误响应
+	start := func() {
+		hdr := w.Header()
+		hdr.Set("Content-Type", format.ContentType())
+		hdr.Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": "tasks." + string(format)}))
+		tw = transfer.NewWriter(w, format)
+	}
+	err := h.svc.Export(r.Context(), r.URL.Query().Get("filter"), func(rec transfer.Record) error {
+		if tw == nil {
+			start()
+		}
+		if err := tw.Write(rec); err != nil {
+			return err
+		}
+		if n++; n%exportFlushEvery == 0 {
+			if err := tw.Flush(); err != nil {
+				return err
+			}
+			_ = rc.Flush()
+		}
+		return nil
+	})
+	if err != nil {
+		if tw != nil {
+			panic(http.ErrAbortHandler)
+		}
+		if !writeListError(w, r, err) {
+			h.writeInternal(w, r, err)
+		}
+		return
+	}
+	if tw == nil {
+		start()
+	}
+	_ = tw.Flush()
+}
+
+/*
+HandleImport POST /tasks/import?format=csv|jsonl&dry_run=true&preserve_ids=true
+请求体即文件内容；format省略时按Content-Type判断（text/csv或application/x-ndjson）
+逐行校验，失败的行记录在errors中（行号从1开始，CSV含表头），其余行照常导入
+*/
+func (h *TaskHandler) HandleImport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	q := r.URL.Query()
+	v := q.Get("format")
+	if v == "" {
+		switch ct, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); ct {
+		case "text/csv":
+			v = "csv"
+		case "application/x-ndjson", "application/jsonl":
+			v = "jsonl"
+		}
+	}
+	format, err := transfer.ParseFormat(v)
+	if err != nil {
+		h.writeBadRequest(w, r, "INVALID_ARGUMENT", err.Error())
+		return
+	}
+	var opts service.ImportOptions
+	for name, dst := range map[string]*bool{"dry_run": &opts.DryRun, "preserve_ids": &opts.PreserveIDs} {
+		if s := q.Get(name); s != "" {
+			b, err := strconv.ParseBool(s)
+			if err != nil {
+				h.writeBadRequest(w, r, "INVALID_ARGUMENT", name+" must be true or false")
+				return
+			}
+			*dst = b
+		}
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportBytes)
+	res, err := h.svc.Import(r.Context(), transfer.NewReader(r.Body, format), opts)
+	var readErr *service.ImportReadError
+	switch {
+	case err == nil:
+	case !errors.As(err, &readErr):
+		// 存储失败或请求被取消：已写入的行无法撤回，按服务端错误返回
+		h.writeInternal(w, r, err)
+		return
+	case res.Total == 0:
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			writeError(w, r, http.StatusRequestEntityTooLarge, "PAYLOAD_TOO_LARGE", "import body exceeds 64 MiB")
+			return
+		}
+		h.writeBadRequest(w, r, "INVALID_ARGUMENT", err.Error())
+		return
+	default:
+		// 已经处理了一部分：返回已处理部分的统计，并说明为何中止
+		res.Aborted = err.Error()
+	}
+	httpx.WriteJson(w, http.StatusOK, res)
+}
@@ -0,0 +1,79 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/kitouo/taskhub/internal/model"
+	"github.com/kitouo/taskhub/internal/repo/memory"
+	"github.com/kitouo/taskhub/internal/service"
+)
+
+// createFailingRepo 写入一律失败
+type createFailingRepo struct {
+	*memory.TaskRepo
+	err error
+}
+
+func (r createFailingRepo) Create(context.Context, model.Task) (model.Task, error) {
+	return model.Task{}, r.err
+}
+
+// 逐行错误与中途无法解析返回200；请求本身无法解析返回400；存储失败与取消走5xx，不把驱动错误带给客户端
+func TestImportStatus(t *testing.T) {
+	const rows = `{"id":"a","title":"a"}` + "\n" + `{"title":""}` + "\n"
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	cases := []struct {
+		name     string
+		body     string
+		ctx      context.Context
+		fail     error
+		want     int
+		imported int
+		aborted  bool
+	}{
+		{name: "line errors", body: rows, want: http.StatusOK, imported: 1},
+		{name: "aborted", body: rows + "{\"title\":\"b\"}\n" + strings.Repeat("x", 2<<20) + "\n", want: http.StatusOK, imported: 2, aborted: true},
+		{name: "unreadable", body: strings.Repeat("x", 2<<20) + "\n", want: http.StatusBadRequest},
+		{name: "store failure", body: rows, fail: errors.New("driver: bad connection"), want: http.StatusInternalServerError},
+		{name: "cancelled", body: rows, ctx: cancelled, want: http.StatusInternalServerError},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			svc := service.NewTaskService(memory.NewTaskRepo())
+			if c.fail != nil {
+				svc = service.NewTaskService(createFailingRepo{memory.NewTaskRepo(), c.fail})
+			}
+			req := httptest.NewRequest(http.MethodPost, "/tasks/import?format=jsonl", strings.NewReader(c.body))
+			if c.ctx != nil {
+				req = req.WithContext(c.ctx)
+			}
+			rec := httptest.NewRecorder()
+			NewTaskHandler(svc).HandleImport(rec, req)
+
+			if rec.Code != c.want {
+				t.Fatalf("status %d, want %d: %s", rec.Code, c.want, rec.Body)
+			}
+			if strings.Contains(rec.Body.String(), "driver:") {
+				t.Errorf("body leaks the store error: %s", rec.Body)
+			}
+			if c.want != http.StatusOK {
+				return
+			}
+			var res service.ImportResult
+			if err := json.Unmarshal(rec.Body.Bytes(), &res); err != nil {
+				t.Fatal(err)
+			}
+			if res.Imported != c.imported || (res.Aborted != "") != c.aborted || res.Failed != 1 {
+				t.Errorf("result = %+v", res)
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/kitouo/taskhub/internal/httpx"
+)
+
+type moveParentRequest struct {
+	ParentID string `json:"parent_id"` // 空串表示移到顶层
+}
+
+// HandleChildren GET /tasks/{id}/children
+func (h *TaskHandler) HandleChildren(w http.ResponseWriter, r *http.Request, id string) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	children, ok, err := h.svc.Children(r.Context(), id)
+	if err != nil {
+		h.writeTaskError(w, r, err)
+		return
+	}
+	if !ok {
+		h.writeNotFound(w, r, "NOT_FOUND", "task not found")
+		return
+	}
+	httpx.WriteJson(w, http.StatusOK, children)
+}
+
+// HandleParent PUT /tasks/{id}/parent  (body: {"parent_id":"..."})
+func (h *TaskHandler) HandleParent(w http.ResponseWriter, r *http.Request, id string) {
+	if r.Method != http.MethodPut {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	var req moveParentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeBadRequest(w, r, "INVALID_JSON", "invalid json body")
+		return
+	}
+
+	t, ok, err := h.svc.Move(r.Context(), id, req.ParentID)
+	if err != nil {
+		h.writeTaskError(w, r, err)
+		return
+	}
+	if !ok {
+		h.writeNotFound(w, r, "NOT_FOUND", "task not found")
+		return
+	}
+	httpx.WriteJson(w, http.StatusOK, t)
+}
@@ -0,0 +1,192 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/kitouo/taskhub/internal/httpx"
+	"github.com/kitouo/taskhub/internal/model"
+	"github.com/kitouo/taskhub/internal/service"
+)
+
+type TimeHandler struct {
+	svc *service.TimeService
+}
+
+func NewTimeHandler(svc *service.TimeService) *TimeHandler {
+	return &TimeHandler{svc: svc}
+}
+
+type timeEntryRequest struct {
+	Start           *time.Time `json:"start"` // RFC3339
+	End             *time.Time `json:"end"`
+	DurationMinutes int        `json:"duration_minutes"`
+	Note            string     `json:"note"`
+}
+
+type timerRequest struct {
+	Note string `json:"note"`
+}
+
+/*
+Handle /tasks/{id}/time: GET 记录与合计, POST 手工补录
+/tasks/{id}/time/start、/tasks/{id}/time/stop: POST 启动/停止调用者的计时器
+/tasks/{id}/time/{entry_id}: DELETE（仅本人）
+*/
+func (h *TimeHandler) Handle(w http.ResponseWriter, r *http.Request, taskID, sub string) {
+	switch {
+	case sub == "" && r.Method == http.MethodGet:
+		out, err := h.svc.List(r.Context(), taskID)
+		if err != nil {
+			h.writeTimeError(w, r, err)
+			return
+		}
+		httpx.WriteJson(w, http.StatusOK, out)
+	case sub == "" && r.Method == http.MethodPost:
+		uid, ok := requireUser(w, r)
+		if !ok {
+			return
+		}
+		var req timeEntryRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, r, http.StatusBadRequest, "INVALID_JSON", "invalid json body")
+			return
+		}
+		e, err := h.svc.Add(r.Context(), uid, taskID, service.TimeEntryInput{
+			Start:    req.Start,
+			End:      req.End,
+			Duration: time.Duration(req.DurationMinutes) * time.Minute,
+			Note:     req.Note,
+		})
+		if err != nil {
+			h.writeTimeError(w, r, err)
+			return
+		}
+		httpx.WriteJson(w, http.StatusCreated, e)
+	case (sub == "start" || sub == "stop") && r.Method == http.MethodPost:
+		uid, ok := requireUser(w, r)
+		if !ok {
+			return
+		}
+		var (
+			e   model.TimeEntry
+			err error
+		)
+		if sub == "start" {
+			var req timerRequest
+			// body可以为空
+			if r.ContentLength != 0 {
+				if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+					writeError(w, r, http.StatusBadRequest, "INVALID_JSON", "invalid json body")
+					return
+				}
+			}
+			e, err = h.svc.Start(r.Context(), uid, taskID, req.Note)
+		} else {
+			e, err = h.svc.Stop(r.Context(), uid, taskID)
+		}
+		if err != nil {
+			h.writeTimeError(w, r, err)
+			return
+		}
+		status := http.StatusOK
+		if sub == "start" {
+			status = http.StatusCreated
+		}
+		httpx.WriteJson(w, status, e)
+	case sub != "" && sub != "start" && sub != "stop" && r.Method == http.MethodDelete:
+		uid, ok := requireUser(w, r)
+		if !ok {
+			return
+		}
+		found, err := h.svc.Delete(r.Context(), uid, taskID, sub)
+		if err == nil && !found {
+			err = errTimeEntryNotFound
+		}
+		if err != nil {
+			h.writeTimeError(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+/*
+HandleReport GET /reports/time?group_by=task|project|user&from=2026-10-01&to=2026-10-31&tz=Europe/Berlin
+from/to 为日期时按tz（默认UTC）解释，to当天包含在内；也可以传RFC3339时间，此时to不包含
+*/
+func (h *TimeHandler) HandleReport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	q := r.URL.Query()
+	loc := time.UTC
+	if tz := q.Get("tz"); tz != "" {
+		l, err := time.LoadLocation(tz)
+		if err != nil {
+			writeError(w, r, http.StatusBadRequest, "INVALID_ARGUMENT", "unknown timezone")
+			return
+		}
+		loc = l
+	}
+	from, okFrom := parseReportTime(q.Get("from"), loc, false)
+	to, okTo := parseReportTime(q.Get("to"), loc, true)
+	if !okFrom || !okTo {
+		writeError(w, r, http.StatusBadRequest, "INVALID_ARGUMENT", "from and to are required (YYYY-MM-DD or RFC3339)")
+		return
+	}
+	group := model.TimeGroup(q.Get("group_by"))
+	if group == "" {
+		group = model.TimeByTask
+	}
+
+	rows, err := h.svc.Report(r.Context(), group, from, to)
+	if err != nil {
+		h.writeTimeError(w, r, err)
+		return
+	}
+	httpx.WriteJson(w, http.StatusOK, rows)
+}
+
+// parseReportTime endOfDay为true时，日期解析为次日零点（即包含当天）
+func parseReportTime(v string, loc *time.Location, endOfDay bool) (time.Time, bool) {
+	if d, err := time.ParseInLocation(time.DateOnly, v, loc); err == nil {
+		if endOfDay {
+			d = d.AddDate(0, 0, 1)
+		}
+		return d, true
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	return t, err == nil
+}
+
+var errTimeEntryNotFound = errors.New("time entry not found")
+
+func (h *TimeHandler) writeTimeError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, service.ErrTaskNotFound):
+		writeError(w, r, http.StatusNotFound, "NOT_FOUND", "task not found")
+	case errors.Is(err, errTimeEntryNotFound):
+		writeError(w, r, http.StatusNotFound, "NOT_FOUND", "time entry not found")
+	case errors.Is(err, service.ErrInvalidTimeEntry):
+		writeError(w, r, http.StatusBadRequest, "INVALID_ARGUMENT",
+			"give start+end, start+duration_minutes or duration_minutes; duration must be within 24h, note <= 1000")
+	case errors.Is(err, service.ErrTimerRunning):
+		writeError(w, r, http.StatusConflict, "TIMER_RUNNING", "a timer is already running, stop it first")
+	case errors.Is(err, service.ErrNoRunningTimer):
+		writeError(w, r, http.StatusConflict, "NO_RUNNING_TIMER", "no running timer on this task")
+	case errors.Is(err, service.ErrInvalidTimeRange):
+		writeError(w, r, http.StatusBadRequest, "INVALID_ARGUMENT", "to must be after from, range <= 366 days")
+	case errors.Is(err, service.ErrInvalidTimeGroup):
+		writeError(w, r, http.StatusBadRequest, "INVALID_ARGUMENT", "group_by must be task, project or user")
+	case errors.Is(err, service.ErrForbidden):
+		writeError(w, r, http.StatusForbidden, "FORBIDDEN", "only the owner can delete this time entry")
+	default:
+		writeInternalError(w, r, err)
+	}
+}
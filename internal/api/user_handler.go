@@ -0,0 +1,154 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/kitouo/taskhub/internal/httpx"
+	"github.com/kitouo/taskhub/internal/service"
+)
+
+type UserHandler struct {
+	svc *service.UserService
+}
+
+func NewUserHandler(svc *service.UserService) *UserHandler {
+	return &UserHandler{svc: svc}
+}
+
+type userRequest struct {
+	ID          string  `json:"id"`
+	DisplayName *string `json:"display_name"`
+	Email       *string `json:"email"`
+	Active      *bool   `json:"active"`
+}
+
+func (req userRequest) input() service.UserInput {
+	return service.UserInput{ID: req.ID, DisplayName: req.DisplayName, Email: req.Email, Active: req.Active}
+}
+
+/*
+HandleUsers /users: GET list（?active=true|false）, POST create
+*/
+func (h *UserHandler) HandleUsers(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		var active *bool
+		if v := r.URL.Query().Get("active"); v != "" {
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				writeError(w, r, http.StatusBadRequest, "INVALID_ARGUMENT", "active must be true or false")
+				return
+			}
+			active = &b
+		}
+		users, err := h.svc.List(r.Context(), active)
+		if err != nil {
+			h.writeUserError(w, r, err)
+			return
+		}
+		httpx.WriteJson(w, http.StatusOK, users)
+	case http.MethodPost:
+		var req userRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, r, http.StatusBadRequest, "INVALID_JSON", "invalid json body")
+			return
+		}
+		u, err := h.svc.Create(r.Context(), req.input())
+		if err != nil {
+			h.writeUserError(w, r, err)
+			return
+		}
+		httpx.WriteJson(w, http.StatusCreated, u)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+/*
+HandleUserByID /users/{id}: GET, PATCH
+/users/{id}/tasks: GET 分配给该用户的任务，支持filter/sort
+*/
+func (h *UserHandler) HandleUserByID(w http.ResponseWriter, r *http.Request) {
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, "/users/"), "/")
+	if path == "" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	parts := strings.Split(path, "/")
+	id := parts[0]
+
+	switch {
+	case len(parts) == 1 && r.Method == http.MethodGet:
+		u, ok, err := h.svc.Get(r.Context(), id)
+		h.writeResult(w, r, u, ok, err)
+	case len(parts) == 1 && r.Method == http.MethodPatch:
+		var req userRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, r, http.StatusBadRequest, "INVALID_JSON", "invalid json body")
+			return
+		}
+		u, ok, err := h.svc.Update(r.Context(), id, req.input())
+		h.writeResult(w, r, u, ok, err)
+	case len(parts) == 2 && parts[1] == "tasks" && r.Method == http.MethodGet:
+		tasks, ok, err := h.svc.Tasks(r.Context(), id, service.ListOptions{
+			Filter: r.URL.Query().Get("filter"),
+			Sort:   r.URL.Query().Get("sort"),
+		})
+		h.writeResult(w, r, tasks, ok || err != nil, err)
+	case len(parts) <= 2:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+/*
+HandleInactiveAssignments GET /reports/inactive-assignments
+停用用户仍负责的未完成任务
+*/
+func (h *UserHandler) HandleInactiveAssignments(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	report, err := h.svc.InactiveAssignments(r.Context())
+	if err != nil {
+		h.writeUserError(w, r, err)
+		return
+	}
+	httpx.WriteJson(w, http.StatusOK, report)
+}
+
+func (h *UserHandler) writeResult(w http.ResponseWriter, r *http.Request, v any, ok bool, err error) {
+	if err != nil {
+		h.writeUserError(w, r, err)
+		return
+	}
+	if !ok {
+		writeError(w, r, http.StatusNotFound, "NOT_FOUND", "user not found")
+		return
+	}
+	httpx.WriteJson(w, http.StatusOK, v)
+}
+
+func (h *UserHandler) writeUserError(w http.ResponseWriter, r *http.Request, err error) {
+	if writeListError(w, r, err) {
+		return
+	}
+	switch {
+	case errors.Is(err, service.ErrInvalidUserID):
+		writeError(w, r, http.StatusBadRequest, "INVALID_ARGUMENT", "id is required (<= 64, no spaces, commas or slashes)")
+	case errors.Is(err, service.ErrInvalidDisplayName):
+		writeError(w, r, http.StatusBadRequest, "INVALID_ARGUMENT", "display_name is required (<= 100)")
+	case errors.Is(err, service.ErrInvalidEmail):
+		writeError(w, r, http.StatusBadRequest, "INVALID_ARGUMENT", "invalid email")
+	case errors.Is(err, service.ErrUserExists):
+		writeError(w, r, http.StatusConflict, "ALREADY_EXISTS", "user already exists")
+	default:
+		writeInternalError(w, r, err)
+	}
+}
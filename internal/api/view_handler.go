@@ -0,0 +1,166 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/kitouo/taskhub/internal/httpx"
+	"github.com/kitouo/taskhub/internal/model"
+	"github.com/kitouo/taskhub/internal/service"
+)
+
+type ViewHandler struct {
+	svc *service.ViewService
+}
+
+func NewViewHandler(svc *service.ViewService) *ViewHandler {
+	return &ViewHandler{svc: svc}
+}
+
+type viewRequest struct {
+	Name       *string `json:"name"`
+	Filter     *string `json:"filter"`
+	Sort       *string `json:"sort"`
+	Visibility *string `json:"visibility"` // private/team
+}
+
+func (req viewRequest) input() service.ViewInput {
+	return service.ViewInput{Name: req.Name, Filter: req.Filter, Sort: req.Sort, Visibility: req.Visibility}
+}
+
+type viewWithCount struct {
+	model.SavedView
+	Count int `json:"count"`
+}
+
+/*
+HandleViews /views: GET list（?counts=true 附带命中数）, POST create
+所有视图接口都需要X-User-ID
+*/
+func (h *ViewHandler) HandleViews(w http.ResponseWriter, r *http.Request) {
+	uid, ok := requireUser(w, r)
+	if !ok {
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		withCounts, _ := strconv.ParseBool(r.URL.Query().Get("counts"))
+		if !withCounts {
+			views, err := h.svc.List(r.Context(), uid)
+			if err != nil {
+				writeInternalError(w, r, err)
+				return
+			}
+			httpx.WriteJson(w, http.StatusOK, views)
+			return
+		}
+
+		counts, err := h.svc.ListWithCounts(r.Context(), uid)
+		if err != nil {
+			writeInternalError(w, r, err)
+			return
+		}
+		out := make([]viewWithCount, len(counts))
+		for i, c := range counts {
+			out[i] = viewWithCount{SavedView: c.View, Count: c.Count}
+		}
+		httpx.WriteJson(w, http.StatusOK, out)
+	case http.MethodPost:
+		var req viewRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, r, http.StatusBadRequest, "INVALID_JSON", "invalid json body")
+			return
+		}
+		v, err := h.svc.Create(r.Context(), uid, req.input())
+		if err != nil {
+			h.writeViewError(w, r, err)
+			return
+		}
+		httpx.WriteJson(w, http.StatusCreated, v)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+/*
+HandleViewByID /views/{id}: GET, PATCH, DELETE
+/views/{id}/tasks: GET 执行视图
+/views/{id}/count: GET 视图命中数
+*/
+func (h *ViewHandler) HandleViewByID(w http.ResponseWriter, r *http.Request) {
+	uid, ok := requireUser(w, r)
+	if !ok {
+		return
+	}
+
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, "/views/"), "/")
+	if path == "" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	parts := strings.Split(path, "/")
+	id := parts[0]
+
+	switch {
+	case len(parts) == 1 && r.Method == http.MethodGet:
+		v, ok, err := h.svc.Get(r.Context(), uid, id)
+		h.writeResult(w, r, http.StatusOK, v, ok, err)
+	case len(parts) == 1 && r.Method == http.MethodPatch:
+		var req viewRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, r, http.StatusBadRequest, "INVALID_JSON", "invalid json body")
+			return
+		}
+		v, ok, err := h.svc.Update(r.Context(), uid, id, req.input())
+		h.writeResult(w, r, http.StatusOK, v, ok, err)
+	case len(parts) == 1 && r.Method == http.MethodDelete:
+		ok, err := h.svc.Delete(r.Context(), uid, id)
+		if err == nil && ok {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		h.writeResult(w, r, http.StatusNoContent, nil, ok, err)
+	case len(parts) == 2 && parts[1] == "tasks" && r.Method == http.MethodGet:
+		tasks, ok, err := h.svc.Tasks(r.Context(), uid, id)
+		h.writeResult(w, r, http.StatusOK, tasks, ok, err)
+	case len(parts) == 2 && parts[1] == "count" && r.Method == http.MethodGet:
+		n, ok, err := h.svc.Count(r.Context(), uid, id)
+		h.writeResult(w, r, http.StatusOK, map[string]int{"count": n}, ok, err)
+	case len(parts) <= 2:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (h *ViewHandler) writeResult(w http.ResponseWriter, r *http.Request, status int, v any, ok bool, err error) {
+	if err != nil {
+		h.writeViewError(w, r, err)
+		return
+	}
+	if !ok {
+		writeError(w, r, http.StatusNotFound, "NOT_FOUND", "view not found")
+		return
+	}
+	httpx.WriteJson(w, status, v)
+}
+
+func (h *ViewHandler) writeViewError(w http.ResponseWriter, r *http.Request, err error) {
+	if writeListError(w, r, err) {
+		return
+	}
+	switch {
+	case errors.Is(err, service.ErrInvalidViewName):
+		writeError(w, r, http.StatusBadRequest, "INVALID_ARGUMENT", "name is required (<= 100)")
+	case errors.Is(err, service.ErrInvalidVisibility):
+		writeError(w, r, http.StatusBadRequest, "INVALID_ARGUMENT", "visibility must be private or team")
+	case errors.Is(err, service.ErrForbidden):
+		writeError(w, r, http.StatusForbidden, "FORBIDDEN", "only the owner can modify this view")
+	default:
+		writeInternalError(w, r, err)
+	}
+}
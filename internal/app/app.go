@@ -2,6 +2,7 @@ package app
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"net/http"
 	"os"
@@ -15,9 +16,15 @@ import (
 	"github.com/kitouo/taskhub/internal/httpx"
 	"github.com/kitouo/taskhub/internal/logx"
 	"github.com/kitouo/taskhub/internal/repo"
+	breakerrepo "github.com/kitouo/taskhub/internal/repo/breaker"
+	cacherepo "github.com/kitouo/taskhub/internal/repo/cache"
 	"github.com/kitouo/taskhub/internal/repo/memory"
 	mysqlrepo "github.com/kitouo/taskhub/internal/repo/mysql"
+	pgrepo "github.com/kitouo/taskhub/internal/repo/postgres"
+	sqliterepo "github.com/kitouo/taskhub/internal/repo/sqlite"
+	"github.com/kitouo/taskhub/internal/scheduler"
 	"github.com/kitouo/taskhub/internal/service"
+	"github.com/kitouo/taskhub/internal/wal"
 )
 
 type App struct {
@@ -29,32 +36,98 @@ type App struct {
 		memory模式下可以是nil或空函数
 	*/
 	closeFunc func() error
+	// sched 随Run启动、随关闭退出的后台任务
+	sched *scheduler.Scheduler
 }
 
 func New(cfg config.Config, logger logx.Logger) (*App, error) {
 
 	// wire dependencies 线路依赖
 	var taskRepo repo.TaskRepo
+	var viewRepo repo.ViewRepo
+	var depRepo repo.DependencyRepo
+	var reminderRepo repo.ReminderRepo
+	var leaseRepo repo.LeaseRepo
+	var commentRepo repo.CommentRepo
+	var attachmentRepo repo.AttachmentRepo
+	var userRepo repo.UserRepo
+	var assignRepo repo.AssignmentRepo
+	var timeRepo repo.TimeEntryRepo
+	var checklistRepo repo.ChecklistRepo
 	/*
 		readyCheck：注入到 router，用于 /readyz
 			- memory：nil（默认 ok）
-			- mysql ：Ping MySQL
+			- mysql/postgres/sqlite：Ping 数据库
 	*/
 	var readyCheck func(context.Context) error
 	var closeFunc func() error
+	// snapshotter 持久化的内存TaskRepo，定期压缩日志
+	var snapshotter *memory.TaskRepo
+	// mysqlTasks mysql模式下的任务repo，用于暴露副本状态
+	var mysqlTasks *mysqlrepo.TaskRepo
+
+	// poolStats 数据库连接池状态，用于/debug/stats
+	var poolStats func() map[string]any
+
+	pool := db.Pool{
+		MaxOpen:     cfg.DBMaxOpenConns,
+		MaxIdle:     cfg.DBMaxIdleConns,
+		MaxLifetime: cfg.DBConnMaxLifetime,
+		MaxIdleTime: cfg.DBConnMaxIdleTime,
+	}
+	logRetry := func(attempt int, err error, wait time.Duration) {
+		logger.Warn("database not ready, retrying", fmt.Sprintf("attempt=%d wait=%s", attempt, wait), "err=", err)
+	}
+
+	// useMemoryAux 任务以外的repo全部使用内存实现（需要先设置好taskRepo）
+	useMemoryAux := func() {
+		viewRepo = memory.NewViewRepo()
+		depRepo = memory.NewDependencyRepo()
+		reminderRepo = memory.NewReminderRepo()
+		leaseRepo = memory.NewLeaseRepo()
+		commentRepo = memory.NewCommentRepo()
+		attachmentRepo = memory.NewAttachmentRepo()
+		userRepo = memory.NewUserRepo()
+		assignRepo = memory.NewAssignmentRepo()
+		timeRepo = memory.NewTimeEntryRepo(taskRepo)
+		checklistRepo = memory.NewChecklistRepo()
+	}
 
 	switch cfg.RepoMode {
 	case "memory":
 		// 内存模式：无外部依赖，启动永远 ready
 		taskRepo = memory.NewTaskRepo()
+		if cfg.MemoryDataDir != "" {
+			// 配置了数据目录时任务落盘：启动时加载快照并重放日志
+			durable, rec, err := memory.OpenTaskRepo(cfg.MemoryDataDir, wal.Options{
+				Sync:         wal.SyncPolicy(cfg.WALSync),
+				SyncInterval: cfg.WALSyncInterval,
+			})
+			if err != nil {
+				return nil, err
+			}
+			logger.Info("task journal recovered", "dir="+cfg.MemoryDataDir,
+				fmt.Sprintf("snapshot=%t records=%d", rec.Snapshot, rec.Records))
+			if rec.TruncatedBytes > 0 {
+				logger.Warn("task journal had a torn final record, truncated", fmt.Sprintf("bytes=%d", rec.TruncatedBytes))
+			}
+			taskRepo = durable
+			snapshotter = durable
+			closeFunc = durable.Close
+		}
+		useMemoryAux()
 		readyCheck = nil
-		closeFunc = nil
 	case "mysql":
 		// MySQL模式：需要DB_DSN，否则db.Open会返回error
-		dbConn, err := db.Open(cfg.DBDriver, cfg.DBDNS)
+		dbConn, err := db.Open(cfg.DBDriver, cfg.DBDNS, pool)
 		if err != nil {
 			return nil, err
 		}
+		// 数据库可能比应用晚启动：在DB_CONNECT_TIMEOUT_SEC内退避重试
+		if err := db.WaitReady(context.Background(), dbConn, cfg.DBConnectTimeout, logRetry); err != nil {
+			_ = dbConn.Close()
+			return nil, err
+		}
 
 		// 如果后续任何步骤失败，记得及时Close，避免资源泄露
 		if err := db.MigrateMySQL(dbConn); err != nil {
@@ -70,18 +143,234 @@ func New(cfg config.Config, logger logx.Logger) (*App, error) {
 		// 退出时关闭连接池
 		closeFunc = dbConn.Close
 
+		/*
+			只读副本：只分担任务的读查询，启动时不要求可连通；
+			不可用的副本在查询出错时自动跳过，回退到主库
+		*/
+		replicas := make([]*sql.DB, 0, len(cfg.DBReplicaDSNs))
+		for _, dsn := range cfg.DBReplicaDSNs {
+			rc, err := db.Open(cfg.DBDriver, dsn, pool)
+			if err != nil {
+				for _, r := range replicas {
+					_ = r.Close()
+				}
+				_ = dbConn.Close()
+				return nil, fmt.Errorf("open replica: %w", err)
+			}
+			replicas = append(replicas, rc)
+		}
+		if len(replicas) > 0 {
+			closeFunc = func() error {
+				for _, r := range replicas {
+					_ = r.Close()
+				}
+				return dbConn.Close()
+			}
+			logger.Info("mysql read replicas configured", fmt.Sprintf("count=%d", len(replicas)))
+		}
+
 		//使用MySQL repo实现
-		taskRepo = mysqlrepo.NewTaskRepo(dbConn)
+		mysqlTasks = mysqlrepo.NewTaskRepo(dbConn,
+			mysqlrepo.WithReplicas(cfg.DBReplicaCooldown, replicas...),
+			mysqlrepo.WithQueryTimeout(cfg.DBQueryTimeout),
+			mysqlrepo.WithRetries(cfg.DBRetries),
+		)
+		poolStats = func() map[string]any {
+			rs := make([]db.PoolStats, len(replicas))
+			for i, r := range replicas {
+				rs[i] = db.Stats(r)
+			}
+			return map[string]any{"primary": db.Stats(dbConn), "replicas": rs}
+		}
+		taskRepo = mysqlTasks
+		viewRepo = mysqlrepo.NewViewRepo(dbConn)
+		depRepo = mysqlrepo.NewDependencyRepo(dbConn)
+		reminderRepo = mysqlrepo.NewReminderRepo(dbConn)
+		leaseRepo = mysqlrepo.NewLeaseRepo(dbConn)
+		commentRepo = mysqlrepo.NewCommentRepo(dbConn)
+		attachmentRepo = mysqlrepo.NewAttachmentRepo(dbConn)
+		userRepo = mysqlrepo.NewUserRepo(dbConn)
+		assignRepo = mysqlrepo.NewAssignmentRepo(dbConn)
+		timeRepo = mysqlrepo.NewTimeEntryRepo(dbConn)
+		checklistRepo = mysqlrepo.NewChecklistRepo(dbConn)
+	case "postgres":
+		dbConn, err := db.Open(db.PostgresDriver, cfg.DBDNS, pool)
+		if err != nil {
+			return nil, err
+		}
+		if err := db.WaitReady(context.Background(), dbConn, cfg.DBConnectTimeout, logRetry); err != nil {
+			_ = dbConn.Close()
+			return nil, err
+		}
+		poolStats = func() map[string]any {
+			return map[string]any{"primary": db.Stats(dbConn)}
+		}
+		if err := db.MigratePostgres(dbConn); err != nil {
+			_ = dbConn.Close()
+			return nil, err
+		}
+		readyCheck = func(ctx context.Context) error {
+			return db.Ping(ctx, dbConn)
+		}
+		closeFunc = dbConn.Close
+
+		/*
+			目前只有任务本身落在PostgreSQL，其余数据仍在内存中，重启即丢失；
+			调度租约也在内存中，多副本部署时后台任务会在每个实例上各跑一份
+		*/
+		taskRepo = pgrepo.NewTaskRepo(dbConn)
+		useMemoryAux()
+		logger.Warn("postgres mode stores only tasks in PostgreSQL; views, comments, users and other data are kept in memory")
+	case "sqlite":
+		conn, err := db.OpenSQLite(cfg.SQLitePath)
+		if err != nil {
+			return nil, err
+		}
+		if err := db.MigrateSQLite(conn.Write); err != nil {
+			_ = conn.Close()
+			return nil, err
+		}
+		readyCheck = func(ctx context.Context) error {
+			return db.Ping(ctx, conn.Read)
+		}
+		closeFunc = conn.Close
+		poolStats = func() map[string]any {
+			return map[string]any{"write": db.Stats(conn.Write), "read": db.Stats(conn.Read)}
+		}
+
+		taskRepo = sqliterepo.NewTaskRepo(conn)
+		useMemoryAux()
+		logger.Warn("sqlite mode stores only tasks in SQLite; views, comments, users and other data are kept in memory")
 	default:
 		return nil, fmt.Errorf("unsupported REPO_MODE: %s", cfg.RepoMode)
 	}
 
-	taskSvc := service.NewTaskService(taskRepo)
+	/*
+		熔断器直接包住数据库实现：数据库持续出错时任务请求立即返回503，不再逐个等到超时；
+		缓存在它外面，熔断期间已缓存的任务仍可读取
+	*/
+	var taskBreaker *breakerrepo.TaskRepo
+	if cfg.RepoMode != "memory" && cfg.CircuitBreaker == "on" {
+		taskBreaker = breakerrepo.New(taskRepo, breakerrepo.Options{
+			Window:         cfg.CircuitBreakerWindow,
+			MinRequests:    cfg.CircuitBreakerMinRequests,
+			FailureRatio:   float64(cfg.CircuitBreakerFailurePercent) / 100,
+			OpenDuration:   cfg.CircuitBreakerOpen,
+			HalfOpenProbes: cfg.CircuitBreakerHalfOpenProbes,
+		}, logger)
+		taskRepo = taskBreaker
+		// 熔断期间/readyz直接返回未就绪，不再ping数据库
+		ping := readyCheck
+		readyCheck = func(ctx context.Context) error {
+			if s := taskBreaker.State(); s == breakerrepo.Open {
+				return fmt.Errorf("%w: circuit %s", repo.ErrUnavailable, s)
+			}
+			return ping(ctx)
+		}
+	}
+
+	/*
+		按id的读缓存包在最外层：之后所有service拿到的都是包装后的repo，修改都会经过它失效缓存
+		多实例部署时其它实例的修改只能靠TTL过期
+	*/
+	var taskCache *cacherepo.TaskRepo
+	if cfg.TaskCacheSize > 0 {
+		taskCache = cacherepo.New(taskRepo, cacherepo.Options{
+			Size:        cfg.TaskCacheSize,
+			TTL:         cfg.TaskCacheTTL,
+			NegativeTTL: cfg.TaskCacheNegativeTTL,
+		})
+		taskRepo = taskCache
+	}
+
+	blobs, err := newBlobStore(cfg)
+	if err != nil {
+		if closeFunc != nil {
+			_ = closeFunc()
+		}
+		return nil, err
+	}
+
+	taskSvc := service.NewTaskService(taskRepo,
+		service.WithBatchMaxSize(cfg.BatchMaxSize),
+		service.WithParentCompletion(service.ParentCompletion(cfg.ParentCompletion)),
+		service.WithDependencies(depRepo),
+		service.WithAssignees(assignRepo, userRepo),
+		service.WithChecklists(checklistRepo),
+		service.WithLogger(logger),
+	)
+
+	viewSvc := service.NewViewService(viewRepo, taskSvc)
+
+	reminderSvc := service.NewReminderService(taskRepo, reminderRepo,
+		newNotifier(cfg, logger),
+		cfg.ReminderLead,
+	)
+
+	// 后台任务：多副本时通过租约保证同一时刻只有一个实例在跑
+	sched := scheduler.New(leaseRepo, logger)
+	sched.Add(scheduler.Job{
+		Name:      "recurrence",
+		Interval:  cfg.RecurrenceScan,
+		Exclusive: true,
+		Run: func(ctx context.Context) error {
+			n, err := taskSvc.MaterializeRecurring(ctx, time.Now().UTC(), cfg.RecurrenceHorizon)
+			if n > 0 {
+				logger.Info("materialized recurring tasks", fmt.Sprintf("created=%d", n))
+			}
+			return err
+		},
+	})
+	sched.Add(scheduler.Job{
+		Name:      "reminders",
+		Interval:  cfg.ReminderScan,
+		Exclusive: true,
+		Run: func(ctx context.Context) error {
+			n, err := reminderSvc.Scan(ctx, time.Now().UTC())
+			if n > 0 {
+				logger.Info("reminders sent", fmt.Sprintf("count=%d", n))
+			}
+			return err
+		},
+	})
+
+	if snapshotter != nil {
+		sched.Add(scheduler.Job{
+			Name:     "task-snapshot",
+			Interval: cfg.SnapshotInterval,
+			Run:      snapshotter.Snapshot,
+		})
+	}
 
-	handler := api.NewRouter(taskSvc, readyCheck)
+	handler := api.NewRouter(api.Services{
+		Task:       taskSvc,
+		View:       viewSvc,
+		Comment:    service.NewCommentService(commentRepo, taskRepo),
+		Attachment: service.NewAttachmentService(attachmentRepo, taskRepo, blobs, int64(cfg.AttachmentMaxBytes)),
+		User:       service.NewUserService(userRepo, taskSvc),
+		Time:       service.NewTimeService(timeRepo, taskRepo),
+		Calendar:   service.NewCalendarService(taskSvc, cfg.CalendarSecret),
+	}, readyCheck, func() map[string]any {
+		stats := map[string]any{}
+		if taskCache != nil {
+			stats["task_cache"] = taskCache.Stats()
+		}
+		if taskBreaker != nil {
+			stats["task_breaker"] = taskBreaker.Stats()
+		}
+		if poolStats != nil {
+			stats["db_pool"] = poolStats()
+		}
+		if mysqlTasks != nil && len(cfg.DBReplicaDSNs) > 0 {
+			stats["mysql_replicas"] = mysqlTasks.ReplicaStats()
+		}
+		return stats
+	})
 
 	// middleware chain
 	h := handler
+	h = httpx.PrimaryReadsForWrites(h)
+	h = httpx.WithPrincipal(h)
 	h = httpx.AccessLogger(logger, h)
 	h = httpx.Recover(logger, h)
 	h = httpx.WithRequestID(h)
@@ -89,9 +378,9 @@ func New(cfg config.Config, logger logx.Logger) (*App, error) {
 	srv := &http.Server{
 		Addr:         ":" + cfg.HTTPPort,
 		Handler:      h,
-		ReadTimeout:  time.Duration(cfg.ReadTimeoutSec) * time.Second,
-		WriteTimeout: time.Duration(cfg.WriteTimeoutSec) * time.Second,
-		IdleTimeout:  time.Duration(cfg.IdleTimeoutSec) * time.Second,
+		ReadTimeout:  cfg.ReadTimeout,
+		WriteTimeout: cfg.WriteTimeout,
+		IdleTimeout:  cfg.IdleTimeout,
 	}
 
 	return &App{
@@ -99,10 +388,20 @@ func New(cfg config.Config, logger logx.Logger) (*App, error) {
 		logger:    logger,
 		srv:       srv,
 		closeFunc: closeFunc,
+		sched:     sched,
 	}, nil
 }
 
 func (a *App) Run(ctx context.Context) error {
+	// start scheduler
+	schedCtx, stopSched := context.WithCancel(context.Background())
+	defer stopSched()
+	schedDone := make(chan struct{})
+	go func() {
+		defer close(schedDone)
+		a.sched.Run(schedCtx)
+	}()
+
 	// start server
 	go func() {
 		a.logger.Info("http server starting", "addr="+a.srv.Addr)
@@ -124,7 +423,7 @@ func (a *App) Run(ctx context.Context) error {
 		a.logger.Info("shutdown signal received")
 	}
 
-	sdCtx, cancel := context.WithTimeout(context.Background(), time.Duration(a.cfg.ShutdownTimeoutSec)*time.Second)
+	sdCtx, cancel := context.WithTimeout(context.Background(), a.cfg.ShutdownTimeout)
 	defer cancel()
 
 	if err := a.srv.Shutdown(sdCtx); err != nil {
@@ -133,6 +432,10 @@ func (a *App) Run(ctx context.Context) error {
 	}
 	a.logger.Info("http server shutdown gracefully")
 
+	// 先停后台任务再释放资源，避免它们用到已关闭的连接池
+	stopSched()
+	<-schedDone
+
 	// 释放外部资源
 	if a.closeFunc != nil {
 		if err := a.closeFunc(); err != nil {
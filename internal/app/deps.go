@@ -0,0 +1,37 @@
+package app
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/kitouo/taskhub/internal/blob"
+	"github.com/kitouo/taskhub/internal/config"
+	"github.com/kitouo/taskhub/internal/logx"
+	"github.com/kitouo/taskhub/internal/notify"
+)
+
+// newNotifier 按NOTIFIER选择提醒渠道（取值已在config.Load中校验）
+func newNotifier(cfg config.Config, logger logx.Logger) notify.Notifier {
+	switch cfg.Notifier {
+	case "webhook":
+		return notify.NewWebhook(cfg.NotifyWebhookURL, 5*time.Second)
+	case "smtp":
+		return notify.NewSMTP(cfg.SMTPAddr, cfg.SMTPUsername, cfg.SMTPPassword, cfg.SMTPFrom, cfg.SMTPTo)
+	default:
+		return notify.NewLog(logger)
+	}
+}
+
+// newBlobStore 按BLOB_STORE选择附件存储（取值已在config.Load中校验）
+func newBlobStore(cfg config.Config) (blob.Store, error) {
+	if cfg.BlobStore == "s3" {
+		return blob.NewS3(blob.S3Config{
+			Endpoint:  cfg.S3Endpoint,
+			Bucket:    cfg.S3Bucket,
+			Region:    cfg.S3Region,
+			AccessKey: cfg.S3AccessKey,
+			SecretKey: cfg.S3SecretKey,
+		}, &http.Client{Timeout: 5 * time.Minute})
+	}
+	return blob.NewFS(cfg.BlobDir)
+}
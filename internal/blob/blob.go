@@ -0,0 +1,31 @@
+/*
+Package blob 附件内容的存储
+  - FS：本地目录，适合单机部署
+  - S3：任意S3兼容服务（AWS S3、MinIO等），手写SigV4签名，不依赖SDK
+
+元数据（文件名、大小、sha256等）存在数据库里，这里只负责按key存取字节
+*/
+package blob
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+var ErrNotFound = errors.New("blob not found")
+
+// PutMeta 写入时附带的信息，SHA256为内容的十六进制摘要
+type PutMeta struct {
+	Size        int64
+	ContentType string
+	SHA256      string
+}
+
+type Store interface {
+	Put(ctx context.Context, key string, r io.Reader, meta PutMeta) error
+	// Get 从offset开始读取length字节，length<0表示读到末尾
+	Get(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error)
+	// Delete key不存在时不报错
+	Delete(ctx context.Context, key string) error
+}
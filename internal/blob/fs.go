@@ -0,0 +1,96 @@
+package blob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// FS 以key为文件名存放在root下，按key前两位分目录避免单目录文件过多
+type FS struct {
+	root string
+}
+
+func NewFS(root string) (*FS, error) {
+	if err := os.MkdirAll(root, 0o750); err != nil {
+		return nil, fmt.Errorf("blob dir: %w", err)
+	}
+	return &FS{root: root}, nil
+}
+
+func (s *FS) path(key string) (string, error) {
+	// key由服务端生成，这里仍然拒绝路径分隔符，防止越出root
+	if len(key) < 2 || strings.ContainsAny(key, `/\`) || strings.Contains(key, "..") {
+		return "", fmt.Errorf("invalid blob key %q", key)
+	}
+	return filepath.Join(s.root, key[:2], key), nil
+}
+
+// Put 先写临时文件再rename，读者不会看到写了一半的内容
+func (s *FS) Put(ctx context.Context, key string, r io.Reader, meta PutMeta) error {
+	p, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0o750); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(p), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), p)
+}
+
+func (s *FS) Get(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error) {
+	p, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(p)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		f.Close()
+		return nil, err
+	}
+	if length < 0 {
+		return f, nil
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{io.LimitReader(f, length), f}, nil
+}
+
+func (s *FS) Delete(ctx context.Context, key string) error {
+	p, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(p); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
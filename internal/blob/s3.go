@@ -0,0 +1,246 @@
+package blob
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// emptySHA256 空请求体的sha256，GET/DELETE签名时使用
+const emptySHA256 = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+type S3Config struct {
+	Endpoint  string // 例如 https://s3.us-east-1.amazonaws.com 或 http://127.0.0.1:9000
+	Bucket    string
+	Region    string
+	AccessKey string
+	SecretKey string
+}
+
+// S3 path-style访问（endpoint/bucket/key），MinIO等兼容服务都支持
+type S3 struct {
+	cfg      S3Config
+	endpoint *url.URL
+	client   *http.Client
+	now      func() time.Time
+}
+
+func NewS3(cfg S3Config, client *http.Client) (*S3, error) {
+	u, err := url.Parse(strings.TrimRight(cfg.Endpoint, "/"))
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("invalid s3 endpoint %q", cfg.Endpoint)
+	}
+	if cfg.Bucket == "" {
+		return nil, fmt.Errorf("s3 bucket is required")
+	}
+	if cfg.Region == "" {
+		cfg.Region = "us-east-1"
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &S3{cfg: cfg, endpoint: u, client: client, now: time.Now}, nil
+}
+
+func (s *S3) objectURL(key string) string {
+	return s.endpoint.String() + "/" + uriEncode(s.cfg.Bucket, false) + "/" + uriEncode(key, false)
+}
+
+func (s *S3) newRequest(ctx context.Context, method, key string, body io.Reader, payloadHash string) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, s.objectURL(key), body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("x-amz-content-sha256", payloadHash)
+	return req, nil
+}
+
+func (s *S3) do(req *http.Request) (*http.Response, error) {
+	signV4(req, s.cfg.AccessKey, s.cfg.SecretKey, s.cfg.Region, "s3", s.now())
+	return s.client.Do(req)
+}
+
+// Put 使用内容的sha256作为x-amz-content-sha256，服务端会据此校验完整性
+func (s *S3) Put(ctx context.Context, key string, r io.Reader, meta PutMeta) error {
+	req, err := s.newRequest(ctx, http.MethodPut, key, r, meta.SHA256)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = meta.Size
+	if meta.ContentType != "" {
+		req.Header.Set("Content-Type", meta.ContentType)
+	}
+
+	resp, err := s.do(req)
+	if err != nil {
+		return fmt.Errorf("s3 put: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return s3Error("put", resp)
+	}
+	return nil
+}
+
+func (s *S3) Get(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error) {
+	req, err := s.newRequest(ctx, http.MethodGet, key, nil, emptySHA256)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case length >= 0:
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", offset, offset+length-1))
+	case offset > 0:
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+
+	resp, err := s.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("s3 get: %w", err)
+	}
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusPartialContent:
+		return resp.Body, nil
+	case http.StatusNotFound:
+		resp.Body.Close()
+		return nil, ErrNotFound
+	default:
+		defer resp.Body.Close()
+		return nil, s3Error("get", resp)
+	}
+}
+
+func (s *S3) Delete(ctx context.Context, key string) error {
+	req, err := s.newRequest(ctx, http.MethodDelete, key, nil, emptySHA256)
+	if err != nil {
+		return err
+	}
+	resp, err := s.do(req)
+	if err != nil {
+		return fmt.Errorf("s3 delete: %w", err)
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusNoContent, http.StatusNotFound:
+		return nil
+	default:
+		return s3Error("delete", resp)
+	}
+}
+
+// s3Error 带上响应体开头（S3的XML错误码）便于排查
+func s3Error(op string, resp *http.Response) error {
+	b, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	return fmt.Errorf("s3 %s: status %d: %s", op, resp.StatusCode, strings.TrimSpace(string(b)))
+}
+
+/*
+signV4 按AWS Signature Version 4给请求加Authorization头
+调用前需设置好x-amz-content-sha256；签名覆盖host、x-amz-*以及range、content-type
+*/
+func signV4(req *http.Request, accessKey, secretKey, region, service string, now time.Time) {
+	now = now.UTC()
+	amzDate := now.Format("20060102T150405Z")
+	day := now.Format("20060102")
+	req.Header.Set("x-amz-date", amzDate)
+
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	headers := map[string]string{"host": host}
+	for name, vals := range req.Header {
+		lname := strings.ToLower(name)
+		if strings.HasPrefix(lname, "x-amz-") || lname == "range" || lname == "content-type" || lname == "content-md5" {
+			headers[lname] = strings.Join(strings.Fields(strings.Join(vals, ",")), " ")
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonHeaders strings.Builder
+	for _, name := range names {
+		canonHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonical := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		canonicalQuery(req.URL.Query()),
+		canonHeaders.String(),
+		signedHeaders,
+		req.Header.Get("x-amz-content-sha256"),
+	}, "\n")
+
+	scope := day + "/" + region + "/" + service + "/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonical))
+
+	key := hmacSHA256([]byte("AWS4"+secretKey), day)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, "aws4_request")
+	sig := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		accessKey, scope, signedHeaders, sig,
+	))
+}
+
+func canonicalQuery(q url.Values) string {
+	keys := make([]string, 0, len(q))
+	for k := range q {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var parts []string
+	for _, k := range keys {
+		vals := append([]string(nil), q[k]...)
+		sort.Strings(vals)
+		for _, v := range vals {
+			parts = append(parts, uriEncode(k, true)+"="+uriEncode(v, true))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// uriEncode SigV4要求的编码：只保留unreserved字符，其余按%XX大写编码；encodeSlash为false时保留"/"
+func uriEncode(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '.', c == '_', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func sha256Hex(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	m := hmac.New(sha256.New, key)
+	m.Write([]byte(data))
+	return m.Sum(nil)
+}
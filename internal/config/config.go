@@ -2,9 +2,8 @@ package config
 
 import (
 	"fmt"
-	"os"
 	"strconv"
-	"strings"
+	"time"
 )
 
 // Config 配置结构体
@@ -22,88 +21,225 @@ type Config struct {
 	**/
 	LogLevel string
 
-	ReadTimeoutSec     int
-	WriteTimeoutSec    int
-	IdleTimeoutSec     int
-	ShutdownTimeoutSec int
+	// HTTP服务的读写/空闲超时与优雅退出的等待时长
+	ReadTimeout     time.Duration
+	WriteTimeout    time.Duration
+	IdleTimeout     time.Duration
+	ShutdownTimeout time.Duration
 
 	/*
 		RepoMode 决定使用哪种数据存储实现：
 			- "memory": 仅内存存储，适合本地开发/纯单测
 			- "mysql" : 使用MySQL持久化，适合生产/云原生部署
+			- "postgres": 任务持久化到PostgreSQL（DBDriver不生效，固定使用pgx），其余数据仍在内存
+			- "sqlite"  : 任务持久化到SQLitePath处的单个文件，适合单机部署，其余数据仍在内存
 	*/
-	RepoMode string
-	DBDriver string
-	DBDNS    string
+	RepoMode   string
+	DBDriver   string
+	DBDNS      string
+	SQLitePath string
+
+	/*
+		DBReplicaDSNs mysql模式下任务只读查询使用的副本（逗号分隔），为空时全部读主库DB_DSN
+		DBReplicaCooldown 副本查询出错后暂停使用的时长
+	*/
+	DBReplicaDSNs     []string
+	DBReplicaCooldown time.Duration
+
+	// 连接池参数，对主库与每个副本分别生效；DBConnMaxIdleTime为0表示不限制
+	DBMaxOpenConns    int
+	DBMaxIdleConns    int
+	DBConnMaxLifetime time.Duration
+	DBConnMaxIdleTime time.Duration
+
+	/*
+		DBConnectTimeout 启动时等待数据库可用的最长时间，期间按退避重试
+		DBQueryTimeout mysql模式下每次任务查询的超时，0表示不限制；DBRetries 死锁、锁等待超时、连接断开等临时性错误的重试次数
+	*/
+	DBConnectTimeout time.Duration
+	DBQueryTimeout   time.Duration
+	DBRetries        int
+
+	/*
+		MemoryDataDir memory模式下任务的持久化目录（快照+预写日志），为空时纯内存
+		WALSync 日志fsync策略：always每条写入都fsync / interval每WALSyncInterval一次 / none交给操作系统
+	*/
+	MemoryDataDir    string
+	WALSync          string
+	WALSyncInterval  time.Duration
+	SnapshotInterval time.Duration
+
+	/*
+		TaskCacheSize 按id缓存任务的条目数上限，0表示不启用缓存
+		TaskCacheTTL 缓存有效期；TaskCacheNegativeTTL 缓存not found结果的有效期
+	*/
+	TaskCacheSize        int
+	TaskCacheTTL         time.Duration
+	TaskCacheNegativeTTL time.Duration
+
+	/*
+		CircuitBreaker 数据库模式下任务存储的熔断开关：on/off
+		最近CircuitBreakerWindow内调用数不少于CircuitBreakerMinRequests且失败占比达到CircuitBreakerFailurePercent时熔断，
+		CircuitBreakerOpen内直接返回503，之后放行CircuitBreakerHalfOpenProbes个试探请求
+	*/
+	CircuitBreaker               string
+	CircuitBreakerWindow         time.Duration
+	CircuitBreakerMinRequests    int
+	CircuitBreakerFailurePercent int
+	CircuitBreakerOpen           time.Duration
+	CircuitBreakerHalfOpenProbes int
+
+	// BatchMaxSize POST /tasks:batch 单次允许的最大操作数
+	BatchMaxSize int
+
+	/*
+		ParentCompletion 完成仍有未完成子任务的父任务时的策略
+			- "reject" : 拒绝（409 OPEN_CHILDREN）
+			- "cascade": 连同所有后代一起完成
+	*/
+	ParentCompletion string
+
+	// RecurrenceScan 后台物化周期任务的扫描间隔
+	RecurrenceScan time.Duration
+	// RecurrenceHorizon 提前生成多久之内的周期任务实例
+	RecurrenceHorizon time.Duration
+
+	// ReminderScan 扫描即将到期/已过期任务的间隔
+	ReminderScan time.Duration
+	// ReminderLead 截止前多久发送“即将到期”提醒
+	ReminderLead time.Duration
+
+	/*
+		Notifier 提醒发送渠道
+			- "log"    : 只写日志
+			- "webhook": POST JSON到NotifyWebhookURL
+			- "smtp"   : 通过SMTPAddr发邮件给SMTPTo（逗号分隔）
+	*/
+	Notifier         string
+	NotifyWebhookURL string
+	SMTPAddr         string
+	SMTPUsername     string
+	SMTPPassword     string
+	SMTPFrom         string
+	SMTPTo           []string
+
+	/*
+		BlobStore 附件内容的存储
+			- "fs": 本地目录BlobDir（容器里应挂载为volume）
+			- "s3": S3兼容服务
+	*/
+	BlobStore   string
+	BlobDir     string
+	S3Endpoint  string
+	S3Bucket    string
+	S3Region    string
+	S3AccessKey string
+	S3SecretKey string
+	// AttachmentMaxBytes 单个附件的大小上限
+	AttachmentMaxBytes int
+
+	// CalendarSecret 签发日历订阅token的密钥，为空时不提供日历feed；更换后旧token全部失效
+	CalendarSecret string
 }
 
-// Load 加载器
-func Load() (Config, error) {
-	// 创建配置文件对象
-	cfg := Config{
-		AppEnv:   getenv("APP_ENV", "dev"),
-		HTTPPort: getenv("HTTP_PORT", "8080"),
-		LogLevel: strings.ToLower(getenv("LOG_LEVEL", "info")),
-
-		ReadTimeoutSec:     getenvInt("READ_TIMEOUT_SEC", 5),
-		WriteTimeoutSec:    getenvInt("WRITE_TIMEOUT_SEC", 10),
-		IdleTimeoutSec:     getenvInt("IDLE_TIMEOUT_SEC", 60),
-		ShutdownTimeoutSec: getenvInt("SHUTDOWN_TIMEOUT_SEC", 10),
-
-		// 默认memory，避免没有MySQL时启动失败
-		RepoMode: getenv("REPO_MODE", "memory"),
-		DBDriver: getenv("DB_DRIVER", "mysql"),
-		// 示例：user:pass@tcp(127.0.0.1:3306)/taskhub?parseTime=true&loc=UTC&charset=utf8mb4&collation=utf8mb4_unicode_ci
-		DBDNS: getenv("DB_DSN", ""),
+// validate 逐项检查取值之间的约束，返回全部问题而不是遇到第一个就停下
+func (c Config) validate() []string {
+	var problems []string
+	add := func(format string, args ...any) {
+		problems = append(problems, fmt.Sprintf(format, args...))
 	}
 
-	if cfg.HTTPPort == "" {
-		return Config{}, fmt.Errorf("HTTPPort must not be empty")
+	if port, err := strconv.Atoi(c.HTTPPort); err != nil || port < 1 || port > 65535 {
+		add("HTTP_PORT must be a port number between 1 and 65535: %q", c.HTTPPort)
 	}
 
 	// 选择日志等级
-	switch cfg.LogLevel {
+	switch c.LogLevel {
 	case "debug", "info", "warn", "error":
 	default:
-		return Config{}, fmt.Errorf("invalid LOG_LEVEL: %s", cfg.LogLevel)
+		add("invalid LOG_LEVEL: %s", c.LogLevel)
 	}
 
-	return cfg, nil
-}
+	switch c.RepoMode {
+	case "memory", "mysql", "postgres", "sqlite":
+	default:
+		add("invalid REPO_MODE: %s", c.RepoMode)
+	}
 
-func (c Config) SafeString() string {
-	hasDSN := "no"
-	if c.DBDNS != "" {
-		hasDSN = "yes"
+	if (c.RepoMode == "mysql" || c.RepoMode == "postgres") && c.DBDNS == "" {
+		add("DB_DSN is required when REPO_MODE=%s", c.RepoMode)
+	}
+	if len(c.DBReplicaDSNs) > 0 && c.RepoMode != "mysql" {
+		add("DB_REPLICA_DSNS is only supported when REPO_MODE=mysql")
 	}
 
-	return fmt.Sprintf(
-		"app_env: %s, http_port: %s, level: %s, repo_mode: %s, db_driver: %s, db_dsn_set: %s, rt: %ds, wt: %ds, it: %ds, st: %ds",
-		c.AppEnv, c.HTTPPort, c.LogLevel,
-		c.RepoMode, c.DBDriver, hasDSN,
-		c.ReadTimeoutSec, c.WriteTimeoutSec, c.IdleTimeoutSec, c.ShutdownTimeoutSec,
-	)
-}
+	switch c.CircuitBreaker {
+	case "on", "off":
+	default:
+		add("invalid CIRCUIT_BREAKER: %s", c.CircuitBreaker)
+	}
+	if c.CircuitBreakerFailurePercent > 100 {
+		add("CIRCUIT_BREAKER_FAILURE_PERCENT must be between 1 and 100")
+	}
 
-// 读取环境变量
-func getenv(key, def string) string {
-	if v := os.Getenv(key); v != "" {
-		return v
+	switch c.ParentCompletion {
+	case "reject", "cascade":
+	default:
+		add("invalid PARENT_COMPLETION: %s", c.ParentCompletion)
+	}
+
+	switch c.WALSync {
+	case "always", "interval", "none":
+	default:
+		add("invalid WAL_SYNC: %s", c.WALSync)
 	}
-	return def
-}
 
-func getenvInt(key string, def int) int {
-	v := os.Getenv(key)
-	if v == "" {
-		return def
+	switch c.Notifier {
+	case "log":
+	case "webhook":
+		if c.NotifyWebhookURL == "" {
+			add("NOTIFY_WEBHOOK_URL is required when NOTIFIER=webhook")
+		}
+	case "smtp":
+		if c.SMTPAddr == "" || c.SMTPFrom == "" || len(c.SMTPTo) == 0 {
+			add("SMTP_ADDR, SMTP_FROM and SMTP_TO are required when NOTIFIER=smtp")
+		}
+	default:
+		add("invalid NOTIFIER: %s", c.Notifier)
 	}
 
-	n, err := strconv.Atoi(v)
-	if err != nil || n <= 0 {
-		return def
+	switch c.BlobStore {
+	case "fs":
+	case "s3":
+		if c.S3Endpoint == "" || c.S3Bucket == "" {
+			add("S3_ENDPOINT and S3_BUCKET are required when BLOB_STORE=s3")
+		}
+	default:
+		add("invalid BLOB_STORE: %s", c.BlobStore)
 	}
 
-	return n
+	return problems
+}
 
+func (c Config) SafeString() string {
+	hasDSN := "no"
+	if c.DBDNS != "" {
+		hasDSN = "yes"
+	}
+	hasCalendar := "no"
+	if c.CalendarSecret != "" {
+		hasCalendar = "yes"
+	}
+
+	return fmt.Sprintf(
+		"app_env: %s, http_port: %s, level: %s, repo_mode: %s, db_driver: %s, db_dsn_set: %s, db_replicas: %d, db_pool: %d/%d, db_query_timeout: %s, sqlite_path: %s, memory_data_dir: %s, wal_sync: %s, task_cache: %d/%s, circuit_breaker: %s, rt: %s, wt: %s, it: %s, st: %s, batch_max: %d, parent_completion: %s, recurrence_scan: %s, recurrence_horizon: %s, reminder_scan: %s, reminder_lead: %s, notifier: %s, blob_store: %s, attachment_max: %d, calendar_secret_set: %s",
+		c.AppEnv, c.HTTPPort, c.LogLevel,
+		c.RepoMode, c.DBDriver, hasDSN, len(c.DBReplicaDSNs), c.DBMaxOpenConns, c.DBMaxIdleConns, c.DBQueryTimeout, c.SQLitePath, c.MemoryDataDir, c.WALSync,
+		c.TaskCacheSize, c.TaskCacheTTL, c.CircuitBreaker,
+		c.ReadTimeout, c.WriteTimeout, c.IdleTimeout, c.ShutdownTimeout,
+		c.BatchMaxSize, c.ParentCompletion,
+		c.RecurrenceScan, c.RecurrenceHorizon,
+		c.ReminderScan, c.ReminderLead, c.Notifier,
+		c.BlobStore, c.AttachmentMaxBytes, hasCalendar,
+	)
 }
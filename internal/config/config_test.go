@@ -0,0 +1,201 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func envOf(kv map[string]string) func(string) (string, bool) {
+	return func(k string) (string, bool) {
+		v, ok := kv[k]
+		return v, ok
+	}
+}
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	p := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(p, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func sourceOf(t *testing.T, out []Setting, key string) Setting {
+	t.Helper()
+	for _, s := range out {
+		if s.Key == key {
+			return s
+		}
+	}
+	t.Fatalf("%s missing from settings", key)
+	return Setting{}
+}
+
+func TestDefaults(t *testing.T) {
+	cfg, _, err := load(nil, envOf(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.HTTPPort != "8080" || cfg.RepoMode != "memory" || cfg.ReadTimeout != 5*time.Second ||
+		cfg.DBQueryTimeout != 5*time.Second || cfg.RecurrenceHorizon != 168*time.Hour || cfg.DBConnMaxIdleTime != 0 {
+		t.Errorf("unexpected defaults: %+v", cfg)
+	}
+}
+
+// 文件 < 环境变量 < 命令行
+func TestLayering(t *testing.T) {
+	file := writeFile(t, "taskhub.yaml", `
+http_port: 9000
+log_level: WARN
+read_timeout_sec: 1m30s
+write_timeout_sec: 20
+smtp_to: [a@example.com, b@example.com]
+`)
+	env := envOf(map[string]string{
+		"CONFIG_FILE":         file,
+		"HTTP_PORT":           "9001",
+		"WRITE_TIMEOUT_SEC":   "",
+		"DB_QUERY_TIMEOUT_MS": "250",
+	})
+	cfg, out, err := load([]string{"-http-port", "9002", "-idle-timeout-sec=2m"}, env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.HTTPPort != "9002" || cfg.LogLevel != "warn" || cfg.ReadTimeout != 90*time.Second ||
+		cfg.WriteTimeout != 20*time.Second || cfg.IdleTimeout != 2*time.Minute || cfg.DBQueryTimeout != 250*time.Millisecond {
+		t.Errorf("unexpected config: %+v", cfg)
+	}
+	if len(cfg.SMTPTo) != 2 {
+		t.Errorf("SMTPTo = %v", cfg.SMTPTo)
+	}
+
+	for key, want := range map[string]string{
+		"CONFIG_FILE":         "env",
+		"HTTP_PORT":           "flag",
+		"LOG_LEVEL":           "file " + file,
+		"WRITE_TIMEOUT_SEC":   "file " + file, // 空的环境变量不覆盖
+		"DB_QUERY_TIMEOUT_MS": "env",
+		"APP_ENV":             "default",
+	} {
+		if got := sourceOf(t, out, key).Source; got != want {
+			t.Errorf("%s source = %q, want %q", key, got, want)
+		}
+	}
+}
+
+func TestTOML(t *testing.T) {
+	file := writeFile(t, "taskhub.toml", `
+repo_mode = "mysql"
+db_dsn = "user:pass@tcp(db:3306)/taskhub"
+db_replica_dsns = ["r1", "r2"]
+db_max_open_conns = 40
+`)
+	cfg, _, err := load([]string{"-config", file}, envOf(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.RepoMode != "mysql" || len(cfg.DBReplicaDSNs) != 2 || cfg.DBMaxOpenConns != 40 {
+		t.Errorf("unexpected config: %+v", cfg)
+	}
+}
+
+func TestReportsAllProblems(t *testing.T) {
+	file := writeFile(t, "taskhub.yaml", "htp_port: 1\nblob_store: s3\n")
+	env := envOf(map[string]string{
+		"CONFIG_FILE":      file,
+		"READ_TIMEOUT_SEC": "abc",
+		"DB_RETRIES":       "-1",
+		"BATCH_MAX_SIZE":   "0",
+		"LOG_LEVEL":        "loud",
+	})
+	_, _, err := load(nil, env)
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("err = %v, want *ValidationError", err)
+	}
+	for _, want := range []string{"htp_port", "READ_TIMEOUT_SEC", "DB_RETRIES", "BATCH_MAX_SIZE", "LOG_LEVEL", "S3_ENDPOINT"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error does not mention %s:\n%v", want, err)
+		}
+	}
+	if len(verr.Problems) != 6 {
+		t.Errorf("got %d problems, want 6:\n%v", len(verr.Problems), err)
+	}
+}
+
+func TestSecretFile(t *testing.T) {
+	secret := writeFile(t, "dsn", "user:pass@tcp(db:3306)/taskhub\n")
+	cfg, out, err := load(nil, envOf(map[string]string{"DB_DSN_FILE": secret}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.DBDNS != "user:pass@tcp(db:3306)/taskhub" {
+		t.Errorf("DBDNS = %q", cfg.DBDNS)
+	}
+	s := sourceOf(t, out, "DB_DSN")
+	if s.Value != "<redacted>" || s.Source != "env via DB_DSN_FILE" {
+		t.Errorf("DB_DSN setting = %+v", s)
+	}
+
+	// 同一来源里两种写法同时出现属于配置错误；不同来源按优先级覆盖
+	if _, _, err := load(nil, envOf(map[string]string{"DB_DSN": "x", "DB_DSN_FILE": secret})); err == nil {
+		t.Error("DB_DSN and DB_DSN_FILE both set: want error")
+	}
+	cfg, _, err = load([]string{"-db-dsn", "from-flag"}, envOf(map[string]string{"DB_DSN_FILE": secret}))
+	if err != nil || cfg.DBDNS != "from-flag" {
+		t.Errorf("flag did not override DB_DSN_FILE: %q, %v", cfg.DBDNS, err)
+	}
+
+	// KEY_FILE指向的文件读不到时报错，而不是当作空值
+	_, _, err = load(nil, envOf(map[string]string{"DB_DSN_FILE": filepath.Join(t.TempDir(), "missing")}))
+	if err == nil {
+		t.Fatal("missing secret file: want error")
+	}
+}
+
+// 用作ticker间隔的配置在启动时就拒绝0和负数，而不是让time.NewTicker panic
+func TestTickerIntervalsMustBePositive(t *testing.T) {
+	for _, key := range []string{"RECURRENCE_SCAN_SEC", "REMINDER_SCAN_SEC", "SNAPSHOT_INTERVAL_SEC", "WAL_SYNC_INTERVAL_MS"} {
+		for _, v := range []string{"0", "-5", "0s"} {
+			_, _, err := load(nil, envOf(map[string]string{key: v}))
+			if err == nil || !strings.Contains(err.Error(), key) || !strings.Contains(err.Error(), "greater than 0") {
+				t.Errorf("%s=%s: err = %v, want must be greater than 0", key, v, err)
+			}
+		}
+	}
+}
+
+// 存储与监听端口的错误在校验阶段报出，而不是等到启动时打开连接或监听端口
+func TestValidateDSNAndPort(t *testing.T) {
+	cases := []struct {
+		env  map[string]string
+		want string
+	}{
+		{map[string]string{"REPO_MODE": "mysql"}, "DB_DSN is required when REPO_MODE=mysql"},
+		{map[string]string{"REPO_MODE": "postgres"}, "DB_DSN is required when REPO_MODE=postgres"},
+		{map[string]string{"HTTP_PORT": "http"}, "HTTP_PORT"},
+		{map[string]string{"HTTP_PORT": "0"}, "HTTP_PORT"},
+		{map[string]string{"HTTP_PORT": "65536"}, "HTTP_PORT"},
+	}
+	for _, c := range cases {
+		_, _, err := load(nil, envOf(c.env))
+		if err == nil || !strings.Contains(err.Error(), c.want) {
+			t.Errorf("%v: err = %v, want %q", c.env, err, c.want)
+		}
+	}
+
+	for _, env := range []map[string]string{
+		{"REPO_MODE": "mysql", "DB_DSN": "user:pass@tcp(db:3306)/taskhub"},
+		{"REPO_MODE": "sqlite"},
+		{"HTTP_PORT": "65535"},
+	} {
+		if _, _, err := load(nil, envOf(env)); err != nil {
+			t.Errorf("%v: %v", env, err)
+		}
+	}
+}
@@ -0,0 +1,354 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	sourceDefault = "default"
+	sourceEnv     = "env"
+	sourceFlag    = "flag"
+)
+
+// Setting 一个配置项的生效值及其来源（default / file 路径 / env / flag），敏感值已隐藏
+type Setting struct {
+	Key    string
+	Value  string
+	Source string
+}
+
+// ValidationError 所有无法解析或不满足约束的配置项，一次性全部列出
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid configuration:\n  - " + strings.Join(e.Problems, "\n  - ")
+}
+
+/*
+Load 依次叠加默认值、配置文件、环境变量、命令行参数，后者覆盖前者；args不含程序名
+  - 配置文件由-config或环境变量CONFIG_FILE指定，按扩展名解析YAML（.yaml/.yml）或TOML（.toml），键名为小写的环境变量名
+  - 敏感项可以用KEY_FILE给出文件路径，读取文件内容（去掉首尾空白）作为取值
+  - 环境变量为空串等同于未设置
+  - 有任何问题时返回*ValidationError
+*/
+func Load(args []string) (Config, error) {
+	cfg, _, err := load(args, os.LookupEnv)
+	return cfg, err
+}
+
+// Describe 按与Load相同的方式解析，返回每一项的生效值与来源；出错时仍返回能解析出的部分
+func Describe(args []string) ([]Setting, error) {
+	_, out, err := load(args, os.LookupEnv)
+	return out, err
+}
+
+// layer 某一来源给出的原始取值，键为大写的配置名（含KEY_FILE）
+type layer map[string]string
+
+// picked 某一项最终采用的原始取值；file非空表示取值在KEY_FILE指向的文件里
+type picked struct {
+	value, file, source string
+}
+
+func load(args []string, lookupEnv func(string) (string, bool)) (Config, []Setting, error) {
+	var problems []string
+
+	flags, configPath, err := parseFlags(args)
+	if err != nil {
+		problems = append(problems, err.Error())
+	}
+	configSource := sourceFlag
+	if configPath == "" {
+		configPath, _ = lookupEnv("CONFIG_FILE")
+		configSource = sourceEnv
+	}
+
+	chosen := make(map[string]picked, len(settings))
+	apply := func(l layer, source string) {
+		for _, s := range settings {
+			v, ok := l[s.key]
+			f, fok := l[s.key+"_FILE"]
+			switch {
+			case ok && fok:
+				problems = append(problems, fmt.Sprintf("%s and %s_FILE are both set (%s)", s.key, s.key, source))
+			case ok:
+				chosen[s.key] = picked{value: v, source: source}
+			case fok:
+				chosen[s.key] = picked{file: f, source: source}
+			}
+		}
+	}
+
+	if configPath != "" {
+		l, errs := readFile(configPath)
+		problems = append(problems, errs...)
+		apply(l, "file "+configPath)
+	}
+	apply(envLayer(lookupEnv), sourceEnv)
+	apply(flags, sourceFlag)
+
+	var cfg Config
+	out := make([]Setting, 0, len(settings)+1)
+	if configPath != "" {
+		out = append(out, Setting{Key: "CONFIG_FILE", Value: configPath, Source: configSource})
+	}
+	for _, s := range settings {
+		p, ok := chosen[s.key]
+		if !ok {
+			p = picked{value: s.def, source: sourceDefault}
+		}
+		if p.file != "" {
+			b, err := os.ReadFile(p.file)
+			if err != nil {
+				problems = append(problems, fmt.Sprintf("%s_FILE (%s): %v", s.key, p.source, err))
+				continue
+			}
+			p.value = strings.TrimSpace(string(b))
+			p.source += " via " + s.key + "_FILE"
+		}
+		field := s.field(&cfg)
+		if msg := s.parse(p.value, field); msg != "" {
+			v := strconv.Quote(p.value)
+			if s.secret {
+				v = "<redacted>"
+			}
+			problems = append(problems, fmt.Sprintf("%s=%s (%s): %s", s.key, v, p.source, msg))
+			continue
+		}
+		out = append(out, Setting{Key: s.key, Value: s.format(field), Source: p.source})
+	}
+
+	problems = append(problems, cfg.validate()...)
+	if len(problems) > 0 {
+		return Config{}, out, &ValidationError{Problems: problems}
+	}
+	return cfg, out, nil
+}
+
+// parse 把原始字符串写入field，不合法时返回原因
+func (s setting) parse(v string, field any) string {
+	switch p := field.(type) {
+	case *string:
+		if s.lower {
+			v = strings.ToLower(v)
+		}
+		*p = v
+	case *[]string:
+		*p = splitList(v)
+	case *int:
+		n, err := strconv.Atoi(strings.TrimSpace(v))
+		if err != nil {
+			return "not an integer"
+		}
+		if msg := s.checkRange(n < 0, n == 0); msg != "" {
+			return msg
+		}
+		*p = n
+	case *time.Duration:
+		d, err := parseDuration(strings.TrimSpace(v), s.unit)
+		if err != nil {
+			return fmt.Sprintf("not a duration (use a number of %s or a value like 10s)", unitName(s.unit))
+		}
+		if msg := s.checkRange(d < 0, d == 0); msg != "" {
+			return msg
+		}
+		*p = d
+	default:
+		panic("config: unsupported field type for " + s.key)
+	}
+	return ""
+}
+
+func (s setting) checkRange(negative, zero bool) string {
+	switch {
+	case negative && s.zero:
+		return "must not be negative"
+	case negative || zero && !s.zero:
+		return "must be greater than 0"
+	}
+	return ""
+}
+
+// format 生效值的展示形式，敏感项只显示是否设置
+func (s setting) format(field any) string {
+	var v string
+	switch p := field.(type) {
+	case *string:
+		v = *p
+	case *[]string:
+		v = strings.Join(*p, ",")
+	case *int:
+		v = strconv.Itoa(*p)
+	case *time.Duration:
+		v = p.String()
+	}
+	if s.secret && v != "" {
+		return "<redacted>"
+	}
+	return v
+}
+
+// parseDuration 纯数字按unit解释（兼容旧配置），否则按time.ParseDuration解析
+func parseDuration(v string, unit time.Duration) (time.Duration, error) {
+	if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+		return time.Duration(n) * unit, nil
+	}
+	return time.ParseDuration(v)
+}
+
+func unitName(unit time.Duration) string {
+	switch unit {
+	case time.Millisecond:
+		return "milliseconds"
+	case time.Minute:
+		return "minutes"
+	case time.Hour:
+		return "hours"
+	}
+	return "seconds"
+}
+
+// keys 该项在各来源中可以使用的名字
+func (s setting) keys() []string {
+	if s.secret {
+		return []string{s.key, s.key + "_FILE"}
+	}
+	return []string{s.key}
+}
+
+func flagName(key string) string {
+	return strings.ReplaceAll(strings.ToLower(key), "_", "-")
+}
+
+// parseFlags 每个配置项对应一个-小写连字符形式的flag，另有-config指定配置文件
+func parseFlags(args []string) (layer, string, error) {
+	fs := flag.NewFlagSet("taskhub", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	var configPath string
+	fs.StringVar(&configPath, "config", "", "")
+	l := layer{}
+	for _, s := range settings {
+		for _, key := range s.keys() {
+			fs.Func(flagName(key), "", func(v string) error {
+				l[key] = v
+				return nil
+			})
+		}
+	}
+	if err := fs.Parse(args); err != nil {
+		return l, configPath, err
+	}
+	if fs.NArg() > 0 {
+		return l, configPath, fmt.Errorf("unexpected argument %q", fs.Arg(0))
+	}
+	return l, configPath, nil
+}
+
+func envLayer(lookupEnv func(string) (string, bool)) layer {
+	l := layer{}
+	for _, s := range settings {
+		for _, key := range s.keys() {
+			if v, ok := lookupEnv(key); ok && v != "" {
+				l[key] = v
+			}
+		}
+	}
+	return l
+}
+
+/*
+readFile 读取扁平的YAML/TOML配置文件，键名不区分大小写（通常写成小写的环境变量名）
+取值可以是标量或标量列表（列表等同于逗号分隔）；未知的键、嵌套结构都算作错误
+*/
+func readFile(path string) (layer, []string) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, []string{fmt.Sprintf("config file: %v", err)}
+	}
+	var doc map[string]any
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(b, &doc)
+	case ".toml":
+		_, err = toml.Decode(string(b), &doc)
+	default:
+		return nil, []string{fmt.Sprintf("config file %s: unsupported format, use .yaml, .yml or .toml", path)}
+	}
+	if err != nil {
+		return nil, []string{fmt.Sprintf("config file %s: %v", path, err)}
+	}
+
+	known := map[string]bool{}
+	for _, s := range settings {
+		for _, key := range s.keys() {
+			known[key] = true
+		}
+	}
+	names := make([]string, 0, len(doc))
+	for name := range doc {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var problems []string
+	l := layer{}
+	for _, name := range names {
+		key := strings.ToUpper(name)
+		if !known[key] {
+			problems = append(problems, fmt.Sprintf("unknown key %q in %s", name, path))
+			continue
+		}
+		if doc[name] == nil {
+			continue
+		}
+		v, ok := fileValue(doc[name])
+		if !ok {
+			problems = append(problems, fmt.Sprintf("%q in %s: must be a scalar or a list of scalars", name, path))
+			continue
+		}
+		l[key] = v
+	}
+	return l, problems
+}
+
+func fileValue(v any) (string, bool) {
+	switch x := v.(type) {
+	case string, bool, int, int64, uint64, float64:
+		return fmt.Sprint(x), true
+	case []any:
+		parts := make([]string, len(x))
+		for i, item := range x {
+			s, ok := fileValue(item)
+			if !ok {
+				return "", false
+			}
+			parts[i] = s
+		}
+		return strings.Join(parts, ","), true
+	}
+	return "", false
+}
+
+// splitList 逗号分隔的列表，忽略空项
+func splitList(s string) []string {
+	var out []string
+	for _, p := range strings.Split(s, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
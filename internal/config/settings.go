@@ -0,0 +1,101 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+)
+
+/*
+setting 一个配置项
+  - key为环境变量名；配置文件中写成小写（http_port），命令行写成小写并用连字符（-http-port）
+  - field返回Config中对应字段的指针：*string / *int / *time.Duration / *[]string
+*/
+type setting struct {
+	key   string
+	def   string
+	field func(c *Config) any
+
+	// secret 打印时隐藏取值，并且可以用KEY_FILE指定从文件读取（例如Docker secrets）
+	secret bool
+	// zero 数值/时长允许为0，否则必须大于0
+	zero bool
+	// lower 取值不区分大小写，统一转为小写
+	lower bool
+	// unit 时长写成纯数字时的单位，兼容*_SEC、*_MS等旧写法；也可以直接写10s、1m30s
+	unit time.Duration
+}
+
+// settings 全部配置项，顺序即config print的输出顺序
+var settings = []setting{
+	{key: "APP_ENV", def: "dev", field: func(c *Config) any { return &c.AppEnv }},
+	{key: "HTTP_PORT", def: "8080", field: func(c *Config) any { return &c.HTTPPort }},
+	{key: "LOG_LEVEL", def: "info", lower: true, field: func(c *Config) any { return &c.LogLevel }},
+
+	{key: "READ_TIMEOUT_SEC", def: "5", unit: time.Second, field: func(c *Config) any { return &c.ReadTimeout }},
+	{key: "WRITE_TIMEOUT_SEC", def: "10", unit: time.Second, field: func(c *Config) any { return &c.WriteTimeout }},
+	{key: "IDLE_TIMEOUT_SEC", def: "60", unit: time.Second, field: func(c *Config) any { return &c.IdleTimeout }},
+	{key: "SHUTDOWN_TIMEOUT_SEC", def: "10", unit: time.Second, field: func(c *Config) any { return &c.ShutdownTimeout }},
+
+	// 默认memory，避免没有MySQL时启动失败
+	{key: "REPO_MODE", def: "memory", lower: true, field: func(c *Config) any { return &c.RepoMode }},
+	{key: "DB_DRIVER", def: "mysql", field: func(c *Config) any { return &c.DBDriver }},
+	// 示例：user:pass@tcp(127.0.0.1:3306)/taskhub?parseTime=true&loc=UTC&charset=utf8mb4&collation=utf8mb4_unicode_ci
+	{key: "DB_DSN", secret: true, field: func(c *Config) any { return &c.DBDNS }},
+	{key: "SQLITE_PATH", def: filepath.Join("data", "taskhub.db"), field: func(c *Config) any { return &c.SQLitePath }},
+
+	{key: "DB_REPLICA_DSNS", secret: true, field: func(c *Config) any { return &c.DBReplicaDSNs }},
+	{key: "DB_REPLICA_COOLDOWN_SEC", def: "10", unit: time.Second, field: func(c *Config) any { return &c.DBReplicaCooldown }},
+
+	{key: "DB_MAX_OPEN_CONNS", def: "20", field: func(c *Config) any { return &c.DBMaxOpenConns }},
+	{key: "DB_MAX_IDLE_CONNS", def: "20", field: func(c *Config) any { return &c.DBMaxIdleConns }},
+	{key: "DB_CONN_MAX_LIFETIME_SEC", def: "1800", unit: time.Second, field: func(c *Config) any { return &c.DBConnMaxLifetime }},
+	{key: "DB_CONN_MAX_IDLE_TIME_SEC", def: "0", zero: true, unit: time.Second, field: func(c *Config) any { return &c.DBConnMaxIdleTime }},
+	{key: "DB_CONNECT_TIMEOUT_SEC", def: "30", unit: time.Second, field: func(c *Config) any { return &c.DBConnectTimeout }},
+	{key: "DB_QUERY_TIMEOUT_MS", def: "5000", zero: true, unit: time.Millisecond, field: func(c *Config) any { return &c.DBQueryTimeout }},
+	{key: "DB_RETRIES", def: "2", zero: true, field: func(c *Config) any { return &c.DBRetries }},
+
+	{key: "MEMORY_DATA_DIR", field: func(c *Config) any { return &c.MemoryDataDir }},
+	{key: "WAL_SYNC", def: "always", lower: true, field: func(c *Config) any { return &c.WALSync }},
+	{key: "WAL_SYNC_INTERVAL_MS", def: "1000", unit: time.Millisecond, field: func(c *Config) any { return &c.WALSyncInterval }},
+	{key: "SNAPSHOT_INTERVAL_SEC", def: "300", unit: time.Second, field: func(c *Config) any { return &c.SnapshotInterval }},
+
+	{key: "TASK_CACHE_SIZE", def: "0", zero: true, field: func(c *Config) any { return &c.TaskCacheSize }},
+	{key: "TASK_CACHE_TTL_SEC", def: "30", unit: time.Second, field: func(c *Config) any { return &c.TaskCacheTTL }},
+	{key: "TASK_CACHE_NEGATIVE_TTL_SEC", def: "5", unit: time.Second, field: func(c *Config) any { return &c.TaskCacheNegativeTTL }},
+
+	{key: "CIRCUIT_BREAKER", def: "on", lower: true, field: func(c *Config) any { return &c.CircuitBreaker }},
+	{key: "CIRCUIT_BREAKER_WINDOW_SEC", def: "10", unit: time.Second, field: func(c *Config) any { return &c.CircuitBreakerWindow }},
+	{key: "CIRCUIT_BREAKER_MIN_REQUESTS", def: "20", field: func(c *Config) any { return &c.CircuitBreakerMinRequests }},
+	{key: "CIRCUIT_BREAKER_FAILURE_PERCENT", def: "50", field: func(c *Config) any { return &c.CircuitBreakerFailurePercent }},
+	{key: "CIRCUIT_BREAKER_OPEN_SEC", def: "5", unit: time.Second, field: func(c *Config) any { return &c.CircuitBreakerOpen }},
+	{key: "CIRCUIT_BREAKER_HALF_OPEN_PROBES", def: "3", field: func(c *Config) any { return &c.CircuitBreakerHalfOpenProbes }},
+
+	{key: "BATCH_MAX_SIZE", def: "100", field: func(c *Config) any { return &c.BatchMaxSize }},
+	{key: "PARENT_COMPLETION", def: "reject", lower: true, field: func(c *Config) any { return &c.ParentCompletion }},
+
+	// 本项与REMINDER_SCAN_SEC、SNAPSHOT_INTERVAL_SEC直接用作time.NewTicker的间隔，必须大于0（NewTicker在<=0时panic）
+	{key: "RECURRENCE_SCAN_SEC", def: "60", unit: time.Second, field: func(c *Config) any { return &c.RecurrenceScan }},
+	{key: "RECURRENCE_HORIZON_HOURS", def: "168", unit: time.Hour, field: func(c *Config) any { return &c.RecurrenceHorizon }},
+
+	{key: "REMINDER_SCAN_SEC", def: "60", unit: time.Second, field: func(c *Config) any { return &c.ReminderScan }},
+	{key: "REMINDER_LEAD_MIN", def: "60", unit: time.Minute, field: func(c *Config) any { return &c.ReminderLead }},
+	{key: "NOTIFIER", def: "log", lower: true, field: func(c *Config) any { return &c.Notifier }},
+	{key: "NOTIFY_WEBHOOK_URL", secret: true, field: func(c *Config) any { return &c.NotifyWebhookURL }},
+	{key: "SMTP_ADDR", field: func(c *Config) any { return &c.SMTPAddr }},
+	{key: "SMTP_USERNAME", field: func(c *Config) any { return &c.SMTPUsername }},
+	{key: "SMTP_PASSWORD", secret: true, field: func(c *Config) any { return &c.SMTPPassword }},
+	{key: "SMTP_FROM", field: func(c *Config) any { return &c.SMTPFrom }},
+	{key: "SMTP_TO", field: func(c *Config) any { return &c.SMTPTo }},
+
+	{key: "BLOB_STORE", def: "fs", lower: true, field: func(c *Config) any { return &c.BlobStore }},
+	{key: "BLOB_DIR", def: filepath.Join(os.TempDir(), "taskhub-blobs"), field: func(c *Config) any { return &c.BlobDir }},
+	{key: "S3_ENDPOINT", field: func(c *Config) any { return &c.S3Endpoint }},
+	{key: "S3_BUCKET", field: func(c *Config) any { return &c.S3Bucket }},
+	{key: "S3_REGION", def: "us-east-1", field: func(c *Config) any { return &c.S3Region }},
+	{key: "S3_ACCESS_KEY", secret: true, field: func(c *Config) any { return &c.S3AccessKey }},
+	{key: "S3_SECRET_KEY", secret: true, field: func(c *Config) any { return &c.S3SecretKey }},
+	{key: "ATTACHMENT_MAX_BYTES", def: "10485760", field: func(c *Config) any { return &c.AttachmentMaxBytes }},
+
+	{key: "CALENDAR_SECRET", secret: true, field: func(c *Config) any { return &c.CalendarSecret }},
+}
@@ -4,11 +4,26 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
-	_ "github.com/go-sql-driver/mysql"
 	"time"
+
+	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/jackc/pgx/v5/stdlib"
 )
 
-func Open(driver, dsn string) (*sql.DB, error) {
+// PostgresDriver REPO_MODE=postgres 使用的database/sql驱动名（pgx）
+const PostgresDriver = "pgx"
+
+// Pool 连接池参数，零值字段取默认值（见Open）
+type Pool struct {
+	MaxOpen     int
+	MaxIdle     int
+	MaxLifetime time.Duration
+	// MaxIdleTime 空闲连接最多保留多久，0表示不限制
+	MaxIdleTime time.Duration
+}
+
+// Open 只创建连接池，不会建立连接；启动时用WaitReady确认数据库可用
+func Open(driver, dsn string, pool Pool) (*sql.DB, error) {
 	if dsn == "" {
 		return nil, fmt.Errorf("empty DB_DSN")
 	}
@@ -19,9 +34,19 @@ func Open(driver, dsn string) (*sql.DB, error) {
 	}
 
 	// 连接池参数
-	db.SetMaxOpenConns(20)
-	db.SetMaxIdleConns(20)
-	db.SetConnMaxLifetime(30 * time.Minute)
+	if pool.MaxOpen <= 0 {
+		pool.MaxOpen = 20
+	}
+	if pool.MaxIdle <= 0 {
+		pool.MaxIdle = pool.MaxOpen
+	}
+	if pool.MaxLifetime <= 0 {
+		pool.MaxLifetime = 30 * time.Minute
+	}
+	db.SetMaxOpenConns(pool.MaxOpen)
+	db.SetMaxIdleConns(pool.MaxIdle)
+	db.SetConnMaxLifetime(pool.MaxLifetime)
+	db.SetConnMaxIdleTime(pool.MaxIdleTime)
 
 	return db, nil
 }
@@ -30,3 +55,59 @@ func Open(driver, dsn string) (*sql.DB, error) {
 func Ping(ctx context.Context, db *sql.DB) error {
 	return db.PingContext(ctx)
 }
+
+/*
+WaitReady 启动时等待数据库可用：Ping失败后按指数退避（200ms起，最长5s）重试，直到成功或超过timeout
+容器编排下应用常常比数据库先启动，不应因此直接退出；onRetry用于记录每次失败，可以为nil
+*/
+func WaitReady(ctx context.Context, db *sql.DB, timeout time.Duration, onRetry func(attempt int, err error, wait time.Duration)) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	wait := 200 * time.Millisecond
+	for attempt := 1; ; attempt++ {
+		pingCtx, pingCancel := context.WithTimeout(ctx, 5*time.Second)
+		err := db.PingContext(pingCtx)
+		pingCancel()
+		if err == nil {
+			return nil
+		}
+		if onRetry != nil {
+			onRetry(attempt, err, wait)
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("database not ready after %s (%d attempts): %w", timeout, attempt, err)
+		case <-time.After(wait):
+		}
+		wait = min(wait*2, 5*time.Second)
+	}
+}
+
+// PoolStats 连接池状态，用于/debug/stats
+type PoolStats struct {
+	MaxOpen           int   `json:"max_open"`
+	Open              int   `json:"open"`
+	InUse             int   `json:"in_use"`
+	Idle              int   `json:"idle"`
+	WaitCount         int64 `json:"wait_count"`
+	WaitDurationMs    int64 `json:"wait_duration_ms"`
+	MaxIdleClosed     int64 `json:"max_idle_closed"`
+	MaxIdleTimeClosed int64 `json:"max_idle_time_closed"`
+	MaxLifetimeClosed int64 `json:"max_lifetime_closed"`
+}
+
+func Stats(db *sql.DB) PoolStats {
+	s := db.Stats()
+	return PoolStats{
+		MaxOpen:           s.MaxOpenConnections,
+		Open:              s.OpenConnections,
+		InUse:             s.InUse,
+		Idle:              s.Idle,
+		WaitCount:         s.WaitCount,
+		WaitDurationMs:    s.WaitDuration.Milliseconds(),
+		MaxIdleClosed:     s.MaxIdleClosed,
+		MaxIdleTimeClosed: s.MaxIdleTimeClosed,
+		MaxLifetimeClosed: s.MaxLifetimeClosed,
+	}
+}
@@ -1,21 +1,249 @@
 package db
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 )
 
-func MigrateMySQL(db *sql.DB) error {
-	const ddl = `
+/*
+migration 一次不可变的schema变更
+  - version 单调递增，已发布的migration不要再修改，新增变更追加到末尾
+  - MySQL的DDL不支持事务，一个migration内的多条语句不是原子的，所以尽量一条语句一个migration
+*/
+type migration struct {
+	version int
+	name    string
+	stmt    string
+}
+
+var mysqlMigrations = []migration{
+	{1, "create tasks", `
 CREATE TABLE IF NOT EXISTS tasks (
   id         VARCHAR(64)  PRIMARY KEY,
   title      VARCHAR(200) NOT NULL,
   done       TINYINT(1)   NOT NULL DEFAULT 0,
   created_at DATETIME(6)  NOT NULL DEFAULT CURRENT_TIMESTAMP(6)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
-`
-	if _, err := db.Exec(ddl); err != nil {
-		return fmt.Errorf(`migrate mysql ddl: %w`, err)
+`},
+	{2, "fulltext index on tasks.title", `
+CREATE FULLTEXT INDEX ft_tasks_title ON tasks (title);
+`},
+	{3, "create saved_views", `
+CREATE TABLE IF NOT EXISTS saved_views (
+  id          VARCHAR(64)   PRIMARY KEY,
+  name        VARCHAR(100)  NOT NULL,
+  owner_id    VARCHAR(64)   NOT NULL,
+  filter_expr VARCHAR(1000) NOT NULL DEFAULT '',
+  sort_spec   VARCHAR(64)   NOT NULL DEFAULT '',
+  visibility  VARCHAR(16)   NOT NULL,
+  created_at  DATETIME(6)   NOT NULL,
+  updated_at  DATETIME(6)   NOT NULL,
+  KEY idx_saved_views_owner (owner_id),
+  KEY idx_saved_views_visibility (visibility)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+`},
+	{4, "add tasks.parent_id", `
+ALTER TABLE tasks
+  ADD COLUMN parent_id VARCHAR(64) NULL,
+  ADD KEY idx_tasks_parent (parent_id);
+`},
+	{5, "create task_dependencies", `
+CREATE TABLE IF NOT EXISTS task_dependencies (
+  task_id    VARCHAR(64) NOT NULL,
+  blocker_id VARCHAR(64) NOT NULL,
+  created_at DATETIME(6) NOT NULL,
+  PRIMARY KEY (task_id, blocker_id),
+  KEY idx_task_dependencies_blocker (blocker_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+`},
+	{6, "add tasks due_at and recurrence", `
+ALTER TABLE tasks
+  ADD COLUMN due_at      DATETIME(6)  NULL,
+  ADD COLUMN rrule       VARCHAR(255) NULL,
+  ADD COLUMN rrule_tz    VARCHAR(64)  NULL,
+  ADD COLUMN rrule_start DATETIME(6)  NULL,
+  ADD COLUMN series_id   VARCHAR(64)  NULL,
+  ADD KEY idx_tasks_due (due_at),
+  ADD KEY idx_tasks_series (series_id);
+`},
+	{7, "create task_reminders", `
+CREATE TABLE IF NOT EXISTS task_reminders (
+  task_id VARCHAR(64) NOT NULL,
+  kind    VARCHAR(16) NOT NULL,
+  due_at  DATETIME(6) NOT NULL,
+  sent_at DATETIME(6) NOT NULL,
+  PRIMARY KEY (task_id, kind, due_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+`},
+	{8, "create scheduler_leases", `
+CREATE TABLE IF NOT EXISTS scheduler_leases (
+  name       VARCHAR(64) PRIMARY KEY,
+  holder     VARCHAR(128) NOT NULL,
+  expires_at DATETIME(6) NOT NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+`},
+	{9, "create comments", `
+CREATE TABLE IF NOT EXISTS comments (
+  id         VARCHAR(64)   PRIMARY KEY,
+  task_id    VARCHAR(64)   NOT NULL,
+  author_id  VARCHAR(64)   NOT NULL,
+  body       TEXT          NOT NULL,
+  mentions   TEXT          NOT NULL,
+  created_at DATETIME(6)   NOT NULL,
+  edited_at  DATETIME(6)   NULL,
+  deleted_at DATETIME(6)   NULL,
+  KEY idx_comments_task (task_id, created_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+`},
+	{10, "create attachments", `
+CREATE TABLE IF NOT EXISTS attachments (
+  id           VARCHAR(64)  PRIMARY KEY,
+  task_id      VARCHAR(64)  NOT NULL,
+  name         VARCHAR(255) NOT NULL,
+  size         BIGINT       NOT NULL,
+  content_type VARCHAR(255) NOT NULL,
+  sha256       CHAR(64)     NOT NULL,
+  uploader_id  VARCHAR(64)  NOT NULL,
+  blob_key     VARCHAR(128) NOT NULL,
+  created_at   DATETIME(6)  NOT NULL,
+  KEY idx_attachments_task (task_id, created_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+`},
+	{11, "create users", `
+CREATE TABLE IF NOT EXISTS users (
+  id           VARCHAR(64)  PRIMARY KEY,
+  display_name VARCHAR(100) NOT NULL,
+  email        VARCHAR(254) NOT NULL DEFAULT '',
+  active       TINYINT(1)   NOT NULL DEFAULT 1,
+  created_at   DATETIME(6)  NOT NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+`},
+	{12, "create task_assignees", `
+CREATE TABLE IF NOT EXISTS task_assignees (
+  task_id VARCHAR(64) NOT NULL,
+  user_id VARCHAR(64) NOT NULL,
+  PRIMARY KEY (task_id, user_id),
+  KEY idx_task_assignees_user (user_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+`},
+	{13, "add tasks project and estimate", `
+ALTER TABLE tasks
+  ADD COLUMN project      VARCHAR(64) NOT NULL DEFAULT '',
+  ADD COLUMN estimate_min INT         NOT NULL DEFAULT 0,
+  ADD KEY idx_tasks_project (project);
+`},
+	{14, "create time_entries", `
+CREATE TABLE IF NOT EXISTS time_entries (
+  id           VARCHAR(64)   PRIMARY KEY,
+  task_id      VARCHAR(64)   NOT NULL,
+  user_id      VARCHAR(64)   NOT NULL,
+  started_at   DATETIME(6)   NOT NULL,
+  ended_at     DATETIME(6)   NULL,
+  note         VARCHAR(1000) NOT NULL DEFAULT '',
+  created_at   DATETIME(6)   NOT NULL,
+  running_user VARCHAR(64)   AS (IF(ended_at IS NULL, user_id, NULL)) STORED,
+  UNIQUE KEY uk_time_entries_running (running_user),
+  KEY idx_time_entries_task (task_id, started_at),
+  KEY idx_time_entries_user (user_id, started_at),
+  KEY idx_time_entries_started (started_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+`},
+	{15, "create checklist_items", `
+CREATE TABLE IF NOT EXISTS checklist_items (
+  id         VARCHAR(64)  PRIMARY KEY,
+  task_id    VARCHAR(64)  NOT NULL,
+  text       VARCHAR(500) NOT NULL,
+  checked    TINYINT(1)   NOT NULL DEFAULT 0,
+  position   INT          NOT NULL,
+  created_at DATETIME(6)  NOT NULL,
+  KEY idx_checklist_items_task (task_id, position)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+`},
+	{16, "add tasks board column and rank", `
+ALTER TABLE tasks
+  ADD COLUMN board_column VARCHAR(64) NOT NULL DEFAULT '',
+  ADD COLUMN rank_key     VARCHAR(64) CHARACTER SET ascii COLLATE ascii_bin NOT NULL DEFAULT '',
+  ADD KEY idx_tasks_board (board_column, rank_key);
+`},
+	{17, "index tasks by creation order", `
+CREATE INDEX idx_tasks_created ON tasks (created_at, id);
+`},
+	// 项目与看板列按原样区分大小写，与其它后端一致；默认的utf8mb4_unicode_ci会把"Foo"与"foo"视为相等
+	{18, "compare tasks project and board column case-sensitively", `
+ALTER TABLE tasks
+  MODIFY COLUMN project      VARCHAR(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_bin NOT NULL DEFAULT '',
+  MODIFY COLUMN board_column VARCHAR(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_bin NOT NULL DEFAULT '';
+`},
+}
+
+/*
+MigrateMySQL 按version顺序执行尚未应用的migration
+  - schema_migrations 记录已应用的版本
+  - GET_LOCK 保证多副本同时启动时只有一个实例在做迁移
+*/
+func MigrateMySQL(db *sql.DB) error {
+	ctx := context.Background()
+
+	// GET_LOCK是会话级的，必须固定在同一个连接上
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("migrate mysql conn: %w", err)
+	}
+	defer conn.Close()
+
+	var locked int
+	if err := conn.QueryRowContext(ctx, `SELECT GET_LOCK('taskhub_migrate', 60)`).Scan(&locked); err != nil {
+		return fmt.Errorf("migrate mysql lock: %w", err)
+	}
+	if locked != 1 {
+		return fmt.Errorf("migrate mysql lock: timeout")
+	}
+	defer func() {
+		_, _ = conn.ExecContext(ctx, `SELECT RELEASE_LOCK('taskhub_migrate')`)
+	}()
+
+	if _, err := conn.ExecContext(ctx, `
+CREATE TABLE IF NOT EXISTS schema_migrations (
+  version    INT          PRIMARY KEY,
+  name       VARCHAR(200) NOT NULL,
+  applied_at DATETIME(6)  NOT NULL DEFAULT CURRENT_TIMESTAMP(6)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+`); err != nil {
+		return fmt.Errorf("migrate mysql schema_migrations: %w", err)
+	}
+
+	applied := make(map[int]bool)
+	rows, err := conn.QueryContext(ctx, `SELECT version FROM schema_migrations`)
+	if err != nil {
+		return fmt.Errorf("migrate mysql query versions: %w", err)
+	}
+	for rows.Next() {
+		var v int
+		if err := rows.Scan(&v); err != nil {
+			rows.Close()
+			return fmt.Errorf("migrate mysql scan version: %w", err)
+		}
+		applied[v] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("migrate mysql versions: %w", err)
+	}
+
+	for _, m := range mysqlMigrations {
+		if applied[m.version] {
+			continue
+		}
+		if _, err := conn.ExecContext(ctx, m.stmt); err != nil {
+			return fmt.Errorf(`migrate mysql ddl %d (%s): %w`, m.version, m.name, err)
+		}
+		if _, err := conn.ExecContext(ctx,
+			`INSERT INTO schema_migrations(version, name) VALUES (?, ?)`,
+			m.version, m.name,
+		); err != nil {
+			return fmt.Errorf("migrate mysql record %d: %w", m.version, err)
+		}
 	}
 	return nil
 }
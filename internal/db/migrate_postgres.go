@@ -0,0 +1,132 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+/*
+postgresMigrations PostgreSQL的schema，与mysqlMigrations各自独立编号
+PostgreSQL的DDL支持事务，每个migration与它的版本记录在同一个事务里提交
+*/
+var postgresMigrations = []migration{
+	{1, "create tasks", `
+CREATE TABLE IF NOT EXISTS tasks (
+  id           VARCHAR(64)  PRIMARY KEY,
+  title        VARCHAR(200) NOT NULL,
+  done         BOOLEAN      NOT NULL DEFAULT FALSE,
+  created_at   TIMESTAMPTZ  NOT NULL DEFAULT now(),
+  parent_id    VARCHAR(64)  NULL,
+  due_at       TIMESTAMPTZ  NULL,
+  rrule        VARCHAR(255) NULL,
+  rrule_tz     VARCHAR(64)  NULL,
+  rrule_start  TIMESTAMPTZ  NULL,
+  series_id    VARCHAR(64)  NULL,
+  project      VARCHAR(64)  NOT NULL DEFAULT '',
+  estimate_min INTEGER      NOT NULL DEFAULT 0,
+  board_column VARCHAR(64)  NOT NULL DEFAULT '',
+  rank_key     VARCHAR(64)  COLLATE "C" NOT NULL DEFAULT ''
+);
+`},
+	{2, "index tasks by creation order", `
+CREATE INDEX IF NOT EXISTS idx_tasks_created ON tasks (created_at, id);
+`},
+	{3, "index tasks.parent_id", `
+CREATE INDEX IF NOT EXISTS idx_tasks_parent ON tasks (parent_id);
+`},
+	{4, "index tasks.due_at", `
+CREATE INDEX IF NOT EXISTS idx_tasks_due ON tasks (due_at);
+`},
+	{5, "index tasks.series_id", `
+CREATE INDEX IF NOT EXISTS idx_tasks_series ON tasks (series_id);
+`},
+	{6, "index tasks.project", `
+CREATE INDEX IF NOT EXISTS idx_tasks_project ON tasks (project);
+`},
+	{7, "index tasks board column and rank", `
+CREATE INDEX IF NOT EXISTS idx_tasks_board ON tasks (board_column, rank_key);
+`},
+	{8, "full text index on tasks.title", `
+CREATE INDEX IF NOT EXISTS idx_tasks_title_fts ON tasks USING GIN (to_tsvector('simple', title));
+`},
+}
+
+// postgresMigrateLock pg_advisory_lock的键，任意固定值即可
+const postgresMigrateLock = 7283461
+
+/*
+MigratePostgres 按version顺序执行尚未应用的migration
+  - schema_migrations 记录已应用的版本
+  - pg_advisory_lock 保证多副本同时启动时只有一个实例在做迁移
+*/
+func MigratePostgres(db *sql.DB) error {
+	ctx := context.Background()
+
+	// advisory lock是会话级的，必须固定在同一个连接上
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("migrate postgres conn: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, postgresMigrateLock); err != nil {
+		return fmt.Errorf("migrate postgres lock: %w", err)
+	}
+	defer func() {
+		_, _ = conn.ExecContext(ctx, `SELECT pg_advisory_unlock($1)`, postgresMigrateLock)
+	}()
+
+	if _, err := conn.ExecContext(ctx, `
+CREATE TABLE IF NOT EXISTS schema_migrations (
+  version    INTEGER      PRIMARY KEY,
+  name       VARCHAR(200) NOT NULL,
+  applied_at TIMESTAMPTZ  NOT NULL DEFAULT now()
+);
+`); err != nil {
+		return fmt.Errorf("migrate postgres schema_migrations: %w", err)
+	}
+
+	applied := make(map[int]bool)
+	rows, err := conn.QueryContext(ctx, `SELECT version FROM schema_migrations`)
+	if err != nil {
+		return fmt.Errorf("migrate postgres query versions: %w", err)
+	}
+	for rows.Next() {
+		var v int
+		if err := rows.Scan(&v); err != nil {
+			rows.Close()
+			return fmt.Errorf("migrate postgres scan version: %w", err)
+		}
+		applied[v] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("migrate postgres versions: %w", err)
+	}
+
+	for _, m := range postgresMigrations {
+		if applied[m.version] {
+			continue
+		}
+		tx, err := conn.BeginTx(ctx, nil)
+		if err != nil {
+			return fmt.Errorf("migrate postgres begin %d: %w", m.version, err)
+		}
+		if _, err := tx.ExecContext(ctx, m.stmt); err != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("migrate postgres ddl %d (%s): %w", m.version, m.name, err)
+		}
+		if _, err := tx.ExecContext(ctx,
+			`INSERT INTO schema_migrations(version, name) VALUES ($1, $2)`,
+			m.version, m.name,
+		); err != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("migrate postgres record %d: %w", m.version, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("migrate postgres commit %d: %w", m.version, err)
+		}
+	}
+	return nil
+}
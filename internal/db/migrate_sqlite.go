@@ -0,0 +1,135 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+/*
+sqliteMigrations SQLite的schema，与其它库的migration各自独立编号
+  - 时间以固定宽度的UTC文本保存（见sqliterepo），字典序即时间顺序
+  - rid作为rowid供FTS5外部内容表引用；没有INTEGER PRIMARY KEY时VACUUM可能改写rowid
+  - SQLite的DDL支持事务，每个migration与它的版本记录在同一个事务里提交
+*/
+var sqliteMigrations = []migration{
+	{1, "create tasks", `
+CREATE TABLE IF NOT EXISTS tasks (
+  rid          INTEGER PRIMARY KEY,
+  id           TEXT    NOT NULL UNIQUE,
+  title        TEXT    NOT NULL,
+  done         INTEGER NOT NULL DEFAULT 0,
+  created_at   TEXT    NOT NULL,
+  parent_id    TEXT    NULL,
+  due_at       TEXT    NULL,
+  rrule        TEXT    NULL,
+  rrule_tz     TEXT    NULL,
+  rrule_start  TEXT    NULL,
+  series_id    TEXT    NULL,
+  project      TEXT    NOT NULL DEFAULT '',
+  estimate_min INTEGER NOT NULL DEFAULT 0,
+  board_column TEXT    NOT NULL DEFAULT '',
+  rank_key     TEXT    NOT NULL DEFAULT ''
+) STRICT;
+`},
+	{2, "index tasks by creation order", `
+CREATE INDEX IF NOT EXISTS idx_tasks_created ON tasks (created_at, id);
+`},
+	{3, "index tasks.parent_id", `
+CREATE INDEX IF NOT EXISTS idx_tasks_parent ON tasks (parent_id);
+`},
+	{4, "index tasks.due_at", `
+CREATE INDEX IF NOT EXISTS idx_tasks_due ON tasks (due_at);
+`},
+	{5, "index tasks.series_id", `
+CREATE INDEX IF NOT EXISTS idx_tasks_series ON tasks (series_id);
+`},
+	{6, "index tasks.project", `
+CREATE INDEX IF NOT EXISTS idx_tasks_project ON tasks (project);
+`},
+	{7, "index tasks board column and rank", `
+CREATE INDEX IF NOT EXISTS idx_tasks_board ON tasks (board_column, rank_key);
+`},
+	{8, "create tasks_fts", `
+CREATE VIRTUAL TABLE IF NOT EXISTS tasks_fts USING fts5(
+  title, content='tasks', content_rowid='rid', tokenize='unicode61'
+);
+`},
+	{9, "sync tasks_fts on insert", `
+CREATE TRIGGER IF NOT EXISTS tasks_fts_ai AFTER INSERT ON tasks BEGIN
+  INSERT INTO tasks_fts(rowid, title) VALUES (new.rid, new.title);
+END;
+`},
+	{10, "sync tasks_fts on delete", `
+CREATE TRIGGER IF NOT EXISTS tasks_fts_ad AFTER DELETE ON tasks BEGIN
+  INSERT INTO tasks_fts(tasks_fts, rowid, title) VALUES ('delete', old.rid, old.title);
+END;
+`},
+	{11, "sync tasks_fts on title update", `
+CREATE TRIGGER IF NOT EXISTS tasks_fts_au AFTER UPDATE OF title ON tasks BEGIN
+  INSERT INTO tasks_fts(tasks_fts, rowid, title) VALUES ('delete', old.rid, old.title);
+  INSERT INTO tasks_fts(rowid, title) VALUES (new.rid, new.title);
+END;
+`},
+}
+
+/*
+MigrateSQLite 按version顺序执行尚未应用的migration
+传入写连接池：它只有一个连接，本身就保证了同一时刻只有一个迁移在执行
+*/
+func MigrateSQLite(db *sql.DB) error {
+	ctx := context.Background()
+
+	if _, err := db.ExecContext(ctx, `
+CREATE TABLE IF NOT EXISTS schema_migrations (
+  version    INTEGER PRIMARY KEY,
+  name       TEXT    NOT NULL,
+  applied_at TEXT    NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%fZ', 'now'))
+) STRICT;
+`); err != nil {
+		return fmt.Errorf("migrate sqlite schema_migrations: %w", err)
+	}
+
+	applied := make(map[int]bool)
+	rows, err := db.QueryContext(ctx, `SELECT version FROM schema_migrations`)
+	if err != nil {
+		return fmt.Errorf("migrate sqlite query versions: %w", err)
+	}
+	for rows.Next() {
+		var v int
+		if err := rows.Scan(&v); err != nil {
+			rows.Close()
+			return fmt.Errorf("migrate sqlite scan version: %w", err)
+		}
+		applied[v] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("migrate sqlite versions: %w", err)
+	}
+
+	for _, m := range sqliteMigrations {
+		if applied[m.version] {
+			continue
+		}
+		tx, err := db.BeginTx(ctx, nil)
+		if err != nil {
+			return fmt.Errorf("migrate sqlite begin %d: %w", m.version, err)
+		}
+		if _, err := tx.ExecContext(ctx, m.stmt); err != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("migrate sqlite ddl %d (%s): %w", m.version, m.name, err)
+		}
+		if _, err := tx.ExecContext(ctx,
+			`INSERT INTO schema_migrations(version, name) VALUES (?, ?)`,
+			m.version, m.name,
+		); err != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("migrate sqlite record %d: %w", m.version, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("migrate sqlite commit %d: %w", m.version, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	_ "modernc.org/sqlite"
+)
+
+// sqliteBusyTimeoutMs 拿不到锁时SQLite内部重试等待的时长
+const sqliteBusyTimeoutMs = 5000
+
+/*
+SQLite 单机部署用的SQLite连接（纯Go驱动modernc.org/sqlite，CGO_ENABLED=0也能编译）
+SQLite同一时刻只允许一个写者，多个连接抢写锁只会得到SQLITE_BUSY，所以拆成两个连接池：
+  - Write 只有一个连接，所有写操作在Go侧排队；事务以BEGIN IMMEDIATE开始，避免读锁升级写锁时死锁
+  - Read  多个只读连接，WAL模式下读不阻塞写、写也不阻塞读
+*/
+type SQLite struct {
+	Write *sql.DB
+	Read  *sql.DB
+}
+
+// OpenSQLite 打开（必要时创建）path处的数据库文件；path不能是":memory:"，两个连接池需要看到同一个库
+func OpenSQLite(path string) (*SQLite, error) {
+	if path == "" {
+		return nil, fmt.Errorf("empty SQLITE_PATH")
+	}
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, fmt.Errorf("sqlite dir: %w", err)
+		}
+	}
+
+	w, err := sql.Open("sqlite", sqliteDSN(path, false))
+	if err != nil {
+		return nil, fmt.Errorf("sql.Open: %w", err)
+	}
+	w.SetMaxOpenConns(1)
+	w.SetMaxIdleConns(1)
+	w.SetConnMaxLifetime(0)
+
+	r, err := sql.Open("sqlite", sqliteDSN(path, true))
+	if err != nil {
+		_ = w.Close()
+		return nil, fmt.Errorf("sql.Open: %w", err)
+	}
+	n := max(4, runtime.NumCPU())
+	r.SetMaxOpenConns(n)
+	r.SetMaxIdleConns(n)
+
+	return &SQLite{Write: w, Read: r}, nil
+}
+
+/*
+sqliteDSN 每个新连接都会执行的pragma：
+  - journal_mode=WAL：持久化在库文件里，设置一次即可，重复设置无害
+  - synchronous=NORMAL：WAL模式下只在checkpoint时fsync，掉电最多丢最后几个事务，不会损坏库
+*/
+func sqliteDSN(path string, readOnly bool) string {
+	q := url.Values{}
+	q.Add("_pragma", fmt.Sprintf("busy_timeout(%d)", sqliteBusyTimeoutMs))
+	q.Add("_pragma", "journal_mode(WAL)")
+	q.Add("_pragma", "synchronous(NORMAL)")
+	q.Add("_pragma", "foreign_keys(1)")
+	if readOnly {
+		q.Add("_pragma", "query_only(1)")
+	} else {
+		q.Set("_txlock", "immediate")
+	}
+	return path + "?" + q.Encode()
+}
+
+// Close 先关读连接池再关写连接池，最后一个连接关闭时SQLite会做checkpoint
+func (s *SQLite) Close() error {
+	return errors.Join(s.Read.Close(), s.Write.Close())
+}
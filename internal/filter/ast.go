@@ -0,0 +1,144 @@
+/*
+Package filter 实现任务过滤表达式，例如：
+
+	open AND title ~ "deploy" AND created in last 7d
+	(done = true OR created_at < 2024-01-01) AND NOT title = "tmp"
+
+Parse 产出AST；ToSQL 把AST编译成参数化SQL（值一律走占位符）；Match 在内存中求值
+*/
+package filter
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/kitouo/taskhub/internal/model"
+)
+
+// Node 过滤表达式AST节点
+type Node interface {
+	node()
+}
+
+type And struct{ Left, Right Node }
+
+type Or struct{ Left, Right Node }
+
+type Not struct{ X Node }
+
+// Compare 字段与常量的比较；Value的类型由字段决定：string/bool/time.Time
+type Compare struct {
+	Field string
+	Op    Op
+	Value any
+}
+
+func (And) node()     {}
+func (Or) node()      {}
+func (Not) node()     {}
+func (Compare) node() {}
+
+type Op string
+
+const (
+	OpEq       Op = "="
+	OpNe       Op = "!="
+	OpLt       Op = "<"
+	OpLe       Op = "<="
+	OpGt       Op = ">"
+	OpGe       Op = ">="
+	OpContains Op = "~"
+)
+
+type kind int
+
+const (
+	kindString kind = iota
+	kindBool
+	kindTime
+)
+
+// field 描述一个可过滤字段：对应的列名以及如何从model.Task上取值
+type field struct {
+	column string
+	kind   kind
+	fold   bool // 字符串比较是否大小写不敏感
+	// 库里用NULL表示空串：直接比较列（能用上索引），= ''编译成IS NULL
+	nullEmpty bool
+	get       func(model.Task) any
+}
+
+var fields = map[string]field{
+	"id":         {column: "id", kind: kindString, get: func(t model.Task) any { return t.ID }},
+	"title":      {column: "title", kind: kindString, fold: true, get: func(t model.Task) any { return t.Title }},
+	"done":       {column: "done", kind: kindBool, get: func(t model.Task) any { return t.Done }},
+	"created_at": {column: "created_at", kind: kindTime, get: func(t model.Task) any { return t.CreatedAt }},
+	// 顶层任务在库里是NULL，统一按空串比较
+	"parent_id": {column: "parent_id", kind: kindString, nullEmpty: true, get: func(t model.Task) any { return t.ParentID }},
+	// 没有截止时间的任务取nil，与库里的NULL一样比较结果为未知（NOT之后也不匹配）
+	"due_at": {column: "due_at", kind: kindTime, get: func(t model.Task) any {
+		if t.DueAt == nil {
+			return nil
+		}
+		return *t.DueAt
+	}},
+	"series_id": {column: "series_id", kind: kindString, nullEmpty: true, get: func(t model.Task) any {
+		if t.Recurrence == nil {
+			return ""
+		}
+		return t.Recurrence.SeriesID
+	}},
+	"project": {column: "project", kind: kindString, get: func(t model.Task) any { return t.Project }},
+	"column":  {column: "board_column", kind: kindString, get: func(t model.Task) any { return t.Column }},
+}
+
+// fieldAliases 字段别名
+var fieldAliases = map[string]string{
+	"created": "created_at",
+	"parent":  "parent_id",
+	"due":     "due_at",
+	"series":  "series_id",
+}
+
+// bareAliases 可以单独出现的布尔简写
+var bareAliases = map[string]Compare{
+	"open": {Field: "done", Op: OpEq, Value: false},
+	"done": {Field: "done", Op: OpEq, Value: true},
+}
+
+// opsByKind 各类型字段允许的比较运算符
+var opsByKind = map[kind][]Op{
+	kindString: {OpEq, OpNe, OpContains},
+	kindBool:   {OpEq, OpNe},
+	kindTime:   {OpEq, OpNe, OpLt, OpLe, OpGt, OpGe},
+}
+
+// Error 带位置信息的解析错误，Pos为从1开始的字符位置
+type Error struct {
+	Pos int
+	Msg string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("position %d: %s", e.Pos, e.Msg)
+}
+
+// String 便于调试与测试的表达式还原（不保证与原始输入一致）
+func String(n Node) string {
+	switch n := n.(type) {
+	case And:
+		return "(" + String(n.Left) + " AND " + String(n.Right) + ")"
+	case Or:
+		return "(" + String(n.Left) + " OR " + String(n.Right) + ")"
+	case Not:
+		return "NOT " + String(n.X)
+	case Compare:
+		v := n.Value
+		if tm, ok := v.(time.Time); ok {
+			v = tm.UTC().Format(time.RFC3339)
+		}
+		return fmt.Sprintf("%s %s %v", n.Field, n.Op, v)
+	default:
+		return ""
+	}
+}
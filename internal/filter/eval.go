@@ -0,0 +1,89 @@
+package filter
+
+import (
+	"strings"
+	"time"
+
+	"github.com/kitouo/taskhub/internal/model"
+)
+
+/*
+Match 在内存中对单个任务求值；n为nil时视为匹配全部
+与SQL一样按三值逻辑求值：字段为空（没有due_at）时比较结果为未知，NOT未知仍是未知，最终只有为真才匹配
+*/
+func Match(n Node, t model.Task) bool {
+	return eval(n, t) == truth
+}
+
+type tristate int
+
+const (
+	falsity tristate = iota
+	unknown
+	truth
+)
+
+func eval(n Node, t model.Task) tristate {
+	switch n := n.(type) {
+	case nil:
+		return truth
+	case And:
+		return min(eval(n.Left, t), eval(n.Right, t))
+	case Or:
+		return max(eval(n.Left, t), eval(n.Right, t))
+	case Not:
+		return truth - eval(n.X, t)
+	case Compare:
+		f := fields[n.Field]
+		got := f.get(t)
+		if got == nil {
+			return unknown
+		}
+		if compare(got, n.Op, n.Value, f.fold) {
+			return truth
+		}
+		return falsity
+	default:
+		return falsity
+	}
+}
+
+func compare(got any, op Op, want any, fold bool) bool {
+	switch g := got.(type) {
+	case string:
+		w := want.(string)
+		switch op {
+		case OpContains:
+			return strings.Contains(strings.ToLower(g), strings.ToLower(w))
+		case OpEq:
+			return g == w || (fold && strings.EqualFold(g, w))
+		case OpNe:
+			return !(g == w || (fold && strings.EqualFold(g, w)))
+		}
+	case bool:
+		w := want.(bool)
+		switch op {
+		case OpEq:
+			return g == w
+		case OpNe:
+			return g != w
+		}
+	case time.Time:
+		c := g.Compare(want.(time.Time))
+		switch op {
+		case OpEq:
+			return c == 0
+		case OpNe:
+			return c != 0
+		case OpLt:
+			return c < 0
+		case OpLe:
+			return c <= 0
+		case OpGt:
+			return c > 0
+		case OpGe:
+			return c >= 0
+		}
+	}
+	return false
+}
@@ -0,0 +1,147 @@
+package filter
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/kitouo/taskhub/internal/model"
+)
+
+var now = time.Date(2024, 1, 20, 12, 0, 0, 0, time.UTC)
+
+func TestParse(t *testing.T) {
+	cases := []struct {
+		src  string
+		want string
+	}{
+		{"open", "done = false"},
+		{"done = true", "done = true"},
+		{`title ~ "deploy api"`, "title ~ deploy api"},
+		{"open AND created in last 7d", "(done = false AND created_at >= 2024-01-13T12:00:00Z)"},
+		{"open or done and title=x", "(done = false OR (done = true AND title = x))"},
+		{"NOT (open OR id != abc)", "NOT (done = false OR id != abc)"},
+		{"created_at < 2024-01-01", "created_at < 2024-01-01T00:00:00Z"},
+	}
+	for _, c := range cases {
+		n, err := Parse(c.src, now)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", c.src, err)
+		}
+		if got := String(n); got != c.want {
+			t.Errorf("Parse(%q) = %s, want %s", c.src, got, c.want)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	cases := []struct {
+		src string
+		pos int
+	}{
+		{"", 1},
+		{"open AND", 9},
+		{"open AND priority>=high", 10},
+		{"done > true", 6},
+		{"title = ", 9},
+		{`title = "abc`, 9},
+		{"(open", 6},
+		{"open)", 5},
+		{"created in last 7x", 17},
+		{"created_at < yesterday", 14},
+		{"title in last 7d", 7},
+	}
+	for _, c := range cases {
+		_, err := Parse(c.src, now)
+		var ferr *Error
+		if !errors.As(err, &ferr) {
+			t.Fatalf("Parse(%q): want *Error, got %v", c.src, err)
+		}
+		if ferr.Pos != c.pos {
+			t.Errorf("Parse(%q): pos = %d (%s), want %d", c.src, ferr.Pos, ferr.Msg, c.pos)
+		}
+	}
+}
+
+func TestToSQL(t *testing.T) {
+	n, err := Parse(`open AND (title ~ "50%_off" OR id = abc)`, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sql, args := ToSQL(n, Postgres, 1)
+	wantSQL := "(done = $2 AND (LOWER(title) LIKE $3 ESCAPE '!' OR id = $4))"
+	if sql != wantSQL {
+		t.Errorf("sql = %s, want %s", sql, wantSQL)
+	}
+	wantArgs := []any{false, "%50!%!_off%", "abc"}
+	if !reflect.DeepEqual(args, wantArgs) {
+		t.Errorf("args = %v, want %v", args, wantArgs)
+	}
+}
+
+// parent_id/series_id直接比较列以便走索引，空串对应IS NULL，NOT下结果仍与内存一致
+func TestToSQLNullEmpty(t *testing.T) {
+	cases := map[string]string{
+		"parent = ''":     "parent_id IS NULL",
+		"NOT parent = ''": "(NOT parent_id IS NULL)",
+		"parent != ''":    "parent_id IS NOT NULL",
+		"parent = abc":    "(parent_id IS NOT NULL AND parent_id = ?)",
+		"series != abc":   "(series_id IS NULL OR series_id <> ?)",
+		"series ~ ab":     "LOWER(COALESCE(series_id, '')) LIKE ? ESCAPE '!'",
+	}
+	for src, want := range cases {
+		n, err := Parse(src, now)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", src, err)
+		}
+		if sql, _ := ToSQL(n, MySQL, 0); sql != want {
+			t.Errorf("ToSQL(%q) = %s, want %s", src, sql, want)
+		}
+	}
+}
+
+func TestMatch(t *testing.T) {
+	task := model.Task{ID: "abc", Title: "Deploy API", Done: false, CreatedAt: now.Add(-48 * time.Hour)}
+	cases := map[string]bool{
+		"open":                               true,
+		"done":                               false,
+		"title = 'deploy api'":               true,
+		"title ~ API AND created in last 3d": true,
+		"created in last 1d":                 false,
+		"NOT id = abc":                       false,
+		"id = ABC":                           false,
+	}
+	for src, want := range cases {
+		n, err := Parse(src, now)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", src, err)
+		}
+		if got := Match(n, task); got != want {
+			t.Errorf("Match(%q) = %v, want %v", src, got, want)
+		}
+	}
+}
+
+// 没有due_at的任务与SQL中的NULL一致：任何比较及其NOT都不匹配
+func TestMatchNullDue(t *testing.T) {
+	task := model.Task{ID: "abc", Title: "no due"}
+	cases := map[string]bool{
+		"due < 2024-01-01":                false,
+		"NOT due < 2024-01-01":            false,
+		"NOT (due < 2024-01-01 AND open)": false,
+		"NOT (due < 2024-01-01 AND done)": true,
+		"NOT due < 2024-01-01 OR open":    true,
+		"NOT (due < 2024-01-01 OR open)":  false,
+		"due >= 2024-01-01 OR id = abc":   true,
+	}
+	for src, want := range cases {
+		n, err := Parse(src, now)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", src, err)
+		}
+		if got := Match(n, task); got != want {
+			t.Errorf("Match(%q) = %v, want %v", src, got, want)
+		}
+	}
+}
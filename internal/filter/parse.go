@@ -0,0 +1,320 @@
+package filter
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokWord
+	tokString
+	tokOp
+	tokLParen
+	tokRParen
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int // 从1开始的字符位置
+}
+
+// MaxLength 表达式最大长度，避免超长输入占用解析资源
+const MaxLength = 1000
+
+func lex(src string) ([]token, error) {
+	runes := []rune(src)
+	var out []token
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		pos := i + 1
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			out = append(out, token{tokLParen, "(", pos})
+			i++
+		case r == ')':
+			out = append(out, token{tokRParen, ")", pos})
+			i++
+		case r == '"' || r == '\'':
+			var b strings.Builder
+			j := i + 1
+			for ; j < len(runes) && runes[j] != r; j++ {
+				if runes[j] == '\\' && j+1 < len(runes) {
+					j++
+				}
+				b.WriteRune(runes[j])
+			}
+			if j >= len(runes) {
+				return nil, &Error{Pos: pos, Msg: "unterminated string"}
+			}
+			out = append(out, token{tokString, b.String(), pos})
+			i = j + 1
+		case strings.ContainsRune("=!<>~", r):
+			op := string(r)
+			if i+1 < len(runes) && runes[i+1] == '=' && r != '=' && r != '~' {
+				op += "="
+			}
+			if op == "!" {
+				return nil, &Error{Pos: pos, Msg: `unexpected "!", did you mean "!="?`}
+			}
+			out = append(out, token{tokOp, op, pos})
+			i += len(op)
+		default:
+			j := i
+			for j < len(runes) && !unicode.IsSpace(runes[j]) && !strings.ContainsRune(`()"'=!<>~`, runes[j]) {
+				j++
+			}
+			out = append(out, token{tokWord, string(runes[i:j]), pos})
+			i = j
+		}
+	}
+	out = append(out, token{tokEOF, "", len(runes) + 1})
+	return out, nil
+}
+
+type parser struct {
+	toks []token
+	i    int
+	now  time.Time
+}
+
+/*
+Parse 解析过滤表达式
+  - 优先级：NOT > AND > OR，可用括号改变
+  - 关键字（AND/OR/NOT/IN/LAST）不区分大小写
+  - 相对时间（created in last 7d）以now为基准在解析时换算成绝对时间
+*/
+func Parse(src string, now time.Time) (Node, error) {
+	if len([]rune(src)) > MaxLength {
+		return nil, &Error{Pos: MaxLength + 1, Msg: fmt.Sprintf("filter longer than %d characters", MaxLength)}
+	}
+	toks, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+	if len(toks) == 1 {
+		return nil, &Error{Pos: 1, Msg: "empty filter"}
+	}
+
+	p := &parser{toks: toks, now: now}
+	n, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokEOF {
+		return nil, p.unexpected(t)
+	}
+	return n, nil
+}
+
+func (p *parser) peek() token { return p.toks[p.i] }
+
+func (p *parser) next() token {
+	t := p.toks[p.i]
+	if t.kind != tokEOF {
+		p.i++
+	}
+	return t
+}
+
+func (p *parser) isKeyword(t token, kw string) bool {
+	return t.kind == tokWord && strings.EqualFold(t.text, kw)
+}
+
+func (p *parser) unexpected(t token) error {
+	if t.kind == tokEOF {
+		return &Error{Pos: t.pos, Msg: "unexpected end of filter"}
+	}
+	return &Error{Pos: t.pos, Msg: fmt.Sprintf("unexpected %q", t.text)}
+}
+
+func (p *parser) parseOr() (Node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.isKeyword(p.peek(), "or") {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = Or{Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (Node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.isKeyword(p.peek(), "and") {
+		p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = And{Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseUnary() (Node, error) {
+	if p.isKeyword(p.peek(), "not") {
+		p.next()
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return Not{X: x}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (Node, error) {
+	t := p.next()
+	switch t.kind {
+	case tokLParen:
+		n, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if c := p.next(); c.kind != tokRParen {
+			if c.kind == tokEOF {
+				return nil, &Error{Pos: c.pos, Msg: "missing closing parenthesis"}
+			}
+			return nil, p.unexpected(c)
+		}
+		return n, nil
+	case tokWord:
+		return p.parseTerm(t)
+	default:
+		return nil, p.unexpected(t)
+	}
+}
+
+func (p *parser) parseTerm(name token) (Node, error) {
+	key := strings.ToLower(name.text)
+	nt := p.peek()
+
+	// 单独出现的简写：open / done
+	if nt.kind != tokOp && !p.isKeyword(nt, "in") {
+		if c, ok := bareAliases[key]; ok {
+			return c, nil
+		}
+	}
+
+	if alias, ok := fieldAliases[key]; ok {
+		key = alias
+	}
+	f, ok := fields[key]
+	if !ok {
+		return nil, &Error{Pos: name.pos, Msg: fmt.Sprintf("unknown field %q", name.text)}
+	}
+
+	// created in last 7d
+	if p.isKeyword(nt, "in") {
+		p.next()
+		if f.kind != kindTime {
+			return nil, &Error{Pos: nt.pos, Msg: fmt.Sprintf("%q is only supported on time fields", "in last")}
+		}
+		if lt := p.next(); !p.isKeyword(lt, "last") {
+			if lt.kind == tokEOF {
+				return nil, &Error{Pos: lt.pos, Msg: `expected "last"`}
+			}
+			return nil, &Error{Pos: lt.pos, Msg: fmt.Sprintf(`expected "last", got %q`, lt.text)}
+		}
+		dt := p.next()
+		d, err := parseDuration(dt)
+		if err != nil {
+			return nil, err
+		}
+		return Compare{Field: key, Op: OpGe, Value: p.now.Add(-d).UTC()}, nil
+	}
+
+	opTok := p.next()
+	if opTok.kind != tokOp {
+		if opTok.kind == tokEOF {
+			return nil, &Error{Pos: opTok.pos, Msg: fmt.Sprintf("expected operator after %q", name.text)}
+		}
+		return nil, &Error{Pos: opTok.pos, Msg: fmt.Sprintf("expected operator after %q, got %q", name.text, opTok.text)}
+	}
+	op := Op(opTok.text)
+	if !slices.Contains(opsByKind[f.kind], op) {
+		return nil, &Error{Pos: opTok.pos, Msg: fmt.Sprintf("operator %q is not supported on %q", op, key)}
+	}
+
+	vt := p.next()
+	if vt.kind != tokWord && vt.kind != tokString {
+		if vt.kind == tokEOF {
+			return nil, &Error{Pos: vt.pos, Msg: "expected value"}
+		}
+		return nil, &Error{Pos: vt.pos, Msg: fmt.Sprintf("expected value, got %q", vt.text)}
+	}
+	v, err := parseValue(f.kind, vt)
+	if err != nil {
+		return nil, err
+	}
+	return Compare{Field: key, Op: op, Value: v}, nil
+}
+
+func parseValue(k kind, t token) (any, error) {
+	switch k {
+	case kindBool:
+		switch strings.ToLower(t.text) {
+		case "true", "yes", "1":
+			return true, nil
+		case "false", "no", "0":
+			return false, nil
+		}
+		return nil, &Error{Pos: t.pos, Msg: fmt.Sprintf("invalid boolean %q", t.text)}
+	case kindTime:
+		if tm, err := time.Parse(time.RFC3339, t.text); err == nil {
+			return tm.UTC(), nil
+		}
+		if tm, err := time.Parse(time.DateOnly, t.text); err == nil {
+			return tm.UTC(), nil
+		}
+		return nil, &Error{Pos: t.pos, Msg: fmt.Sprintf("invalid time %q (want RFC3339 or YYYY-MM-DD)", t.text)}
+	default:
+		return t.text, nil
+	}
+}
+
+// parseDuration 解析 30m / 12h / 7d / 2w
+func parseDuration(t token) (time.Duration, error) {
+	bad := &Error{Pos: t.pos, Msg: fmt.Sprintf("invalid duration %q (want e.g. 30m, 12h, 7d, 2w)", t.text)}
+	if t.kind != tokWord || len(t.text) < 2 {
+		if t.kind == tokEOF {
+			return 0, &Error{Pos: t.pos, Msg: "expected duration"}
+		}
+		return 0, bad
+	}
+
+	n, err := strconv.Atoi(t.text[:len(t.text)-1])
+	if err != nil || n <= 0 {
+		return 0, bad
+	}
+	unit := map[byte]time.Duration{
+		'm': time.Minute,
+		'h': time.Hour,
+		'd': 24 * time.Hour,
+		'w': 7 * 24 * time.Hour,
+	}[t.text[len(t.text)-1]]
+	if unit == 0 || time.Duration(n) > math.MaxInt64/unit {
+		return 0, bad
+	}
+	return time.Duration(n) * unit, nil
+}
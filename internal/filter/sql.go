@@ -0,0 +1,104 @@
+package filter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+/*
+Dialect 描述不同数据库在占位符等细节上的差异
+Placeholder 的参数n从1开始（MySQL忽略它返回"?"，PostgreSQL返回"$n"）
+*/
+type Dialect struct {
+	Placeholder func(n int) string
+}
+
+var MySQL = Dialect{Placeholder: func(int) string { return "?" }}
+
+var Postgres = Dialect{Placeholder: func(n int) string { return "$" + strconv.Itoa(n) }}
+
+/*
+ToSQL 把AST编译为WHERE子句（不含WHERE关键字）与参数
+  - 列名来自字段白名单，值全部通过占位符传递，绝不拼接进SQL
+  - argOffset 为之前已占用的参数个数，便于与其它条件拼接
+  - 大小写不敏感的字段用LOWER()比较，与内存实现语义一致，不依赖具体库的collation
+  - 其余字符串字段直接比较，大小写敏感；MySQL上对应的列使用_bin collation保证这一点
+*/
+func ToSQL(n Node, d Dialect, argOffset int) (string, []any) {
+	c := &compiler{d: d, n: argOffset}
+	sql := c.compile(n)
+	return sql, c.args
+}
+
+type compiler struct {
+	d    Dialect
+	n    int
+	args []any
+}
+
+func (c *compiler) arg(v any) string {
+	c.n++
+	c.args = append(c.args, v)
+	return c.d.Placeholder(c.n)
+}
+
+func (c *compiler) compile(n Node) string {
+	switch n := n.(type) {
+	case And:
+		return "(" + c.compile(n.Left) + " AND " + c.compile(n.Right) + ")"
+	case Or:
+		return "(" + c.compile(n.Left) + " OR " + c.compile(n.Right) + ")"
+	case Not:
+		return "(NOT " + c.compile(n.X) + ")"
+	case Compare:
+		f := fields[n.Field]
+		col := f.column
+		switch {
+		case f.nullEmpty:
+			return c.compileNullEmpty(col, n)
+		case n.Op == OpContains:
+			return fmt.Sprintf("LOWER(%s) LIKE %s ESCAPE '!'", col, c.arg("%"+escapeLike(strings.ToLower(n.Value.(string)))+"%"))
+		case f.fold:
+			return fmt.Sprintf("LOWER(%s) %s LOWER(%s)", col, sqlOp(n.Op), c.arg(n.Value))
+		default:
+			return fmt.Sprintf("%s %s %s", col, sqlOp(n.Op), c.arg(n.Value))
+		}
+	default:
+		// 不会出现：Parse只产出以上节点
+		return "1=0"
+	}
+}
+
+/*
+compileNullEmpty 编译NULL表示空串的列，保证结果与按空串比较一致且不会出现UNKNOWN（NOT下也成立）
+等值比较不包一层函数，以便走索引
+*/
+func (c *compiler) compileNullEmpty(col string, n Compare) string {
+	v := n.Value.(string)
+	switch {
+	case n.Op == OpContains:
+		return fmt.Sprintf("LOWER(COALESCE(%s, '')) LIKE %s ESCAPE '!'", col, c.arg("%"+escapeLike(strings.ToLower(v))+"%"))
+	case n.Op == OpEq && v == "":
+		return col + " IS NULL"
+	case n.Op == OpNe && v == "":
+		return col + " IS NOT NULL"
+	case n.Op == OpEq:
+		return fmt.Sprintf("(%s IS NOT NULL AND %s = %s)", col, col, c.arg(v))
+	default:
+		return fmt.Sprintf("(%s IS NULL OR %s <> %s)", col, col, c.arg(v))
+	}
+}
+
+func sqlOp(op Op) string {
+	if op == OpNe {
+		return "<>"
+	}
+	return string(op)
+}
+
+// escapeLike 转义LIKE通配符，转义字符为'!'（各数据库都不默认使用它，ESCAPE子句可移植）
+func escapeLike(s string) string {
+	r := strings.NewReplacer("!", "!!", "%", "!%", "_", "!_")
+	return r.Replace(s)
+}
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/kitouo/taskhub/internal/logx"
+	"github.com/kitouo/taskhub/internal/repo"
 )
 
 // 避免字符串撞名
@@ -88,9 +89,50 @@ func (w *wrapWriter) WriteHeader(statusCode int) {
 	w.ResponseWriter.WriteHeader(statusCode)
 }
 
+// Unwrap 让http.ResponseController能拿到底层writer（Flush等）
+func (w *wrapWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
 // 生成新的request_id
 func newRequestID() string {
 	b := make([]byte, 16)
 	_, _ = rand.Read(b)
 	return hex.EncodeToString(b)
 }
+
+const userIDKey ctxKey = "user_id"
+
+// UserIDFromContext 取出当前请求的调用者id，未携带时为空串
+func UserIDFromContext(ctx context.Context) string {
+	if v, ok := ctx.Value(userIDKey).(string); ok {
+		return v
+	}
+	return ""
+}
+
+/*
+WithPrincipal 从X-User-ID请求头识别调用者
+服务本身不做认证，约定由前置网关完成认证后注入该请求头
+*/
+func WithPrincipal(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if uid := r.Header.Get("X-User-ID"); uid != "" {
+			r = r.WithContext(context.WithValue(r.Context(), userIDKey, uid))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+/*
+PrimaryReadsForWrites GET/HEAD以外的请求中，所有读操作都走主库（见repo.WithPrimaryReads）
+同一请求里写完再读（例如完成任务后回读、检查父任务与依赖）不会读到副本上复制延迟前的旧数据
+*/
+func PrimaryReadsForWrites(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			r = r.WithContext(repo.WithPrimaryReads(r.Context()))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -15,6 +15,10 @@ func Recover(logger logx.Logger, next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		defer func() {
 			if rec := recover(); rec != nil {
+				// 流式响应中途失败时handler用ErrAbortHandler主动断开连接，不当作异常处理
+				if rec == http.ErrAbortHandler {
+					panic(rec)
+				}
 				rid := RequestIDFromContext(r.Context())
 
 				logger.Error("panic",
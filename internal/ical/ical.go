@@ -0,0 +1,103 @@
+/*
+Package ical 生成RFC 5545 iCalendar文本
+  - 每行以CRLF结尾，超过75个八位字节的行按规范折行（续行以一个空格开头），不会切断UTF-8字符
+  - TEXT类型的值通过Text写入，会转义反斜杠、分号、逗号与换行
+  - 时间统一以UTC写出（形如 20240102T150405Z）
+*/
+package ical
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// maxLineOctets 不含CRLF的单行最大长度
+const maxLineOctets = 75
+
+const timeLayout = "20060102T150405Z"
+
+// Writer 逐行写出iCalendar内容，第一次出错后的写入都会被忽略，错误由Flush返回
+type Writer struct {
+	w   *bufio.Writer
+	err error
+}
+
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: bufio.NewWriter(w)}
+}
+
+// Begin 写出 BEGIN:name
+func (w *Writer) Begin(name string) { w.line("BEGIN:" + name) }
+
+// End 写出 END:name
+func (w *Writer) End(name string) { w.line("END:" + name) }
+
+// Raw 原样写出属性值，调用方负责保证value合法（例如STATUS、VERSION）
+func (w *Writer) Raw(name, value string) { w.line(name + ":" + value) }
+
+// Text 写出TEXT类型的属性，值会被转义
+func (w *Writer) Text(name, value string) { w.line(name + ":" + Escape(value)) }
+
+// Time 写出UTC形式的DATE-TIME属性
+func (w *Writer) Time(name string, t time.Time) { w.line(name + ":" + FormatTime(t)) }
+
+// Flush 把缓冲写到底层io.Writer，并返回之前的第一个错误
+func (w *Writer) Flush() error {
+	if w.err != nil {
+		return w.err
+	}
+	return w.w.Flush()
+}
+
+func (w *Writer) line(s string) {
+	if w.err != nil {
+		return
+	}
+	_, w.err = w.w.WriteString(Fold(s))
+}
+
+// FormatTime 格式化为UTC的DATE-TIME
+func FormatTime(t time.Time) string {
+	return t.UTC().Format(timeLayout)
+}
+
+var escaper = strings.NewReplacer(
+	`\`, `\\`,
+	";", `\;`,
+	",", `\,`,
+	"\r\n", `\n`,
+	"\n", `\n`,
+	"\r", `\n`,
+)
+
+// Escape 按RFC 5545 3.3.11转义TEXT值
+func Escape(s string) string {
+	return escaper.Replace(s)
+}
+
+/*
+Fold 把一条内容行折成不超过75个八位字节的物理行，每行以CRLF结尾
+续行以一个空格开头，空格计入该行长度；折行位置总在UTF-8字符边界上
+*/
+func Fold(line string) string {
+	var b strings.Builder
+	b.Grow(len(line) + len(line)/maxLineOctets*3 + 2)
+	limit := maxLineOctets
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		b.WriteString(line[:cut])
+		b.WriteString("\r\n ")
+		line = line[cut:]
+		// 续行开头的空格占用一个八位字节
+		limit = maxLineOctets - 1
+	}
+	b.WriteString(line)
+	b.WriteString("\r\n")
+	return b.String()
+}
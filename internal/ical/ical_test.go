@@ -0,0 +1,83 @@
+package ical
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestEscape(t *testing.T) {
+	cases := map[string]string{
+		"plain":           "plain",
+		`a\b`:             `a\\b`,
+		"a;b,c":           `a\;b\,c`,
+		"line1\nline2":    `line1\nline2`,
+		"line1\r\nline2":  `line1\nline2`,
+		`x\;`:             `x\\\;`,
+		"中文，标点;semicolon": `中文，标点\;semicolon`,
+	}
+	for in, want := range cases {
+		if got := Escape(in); got != want {
+			t.Errorf("Escape(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestFold(t *testing.T) {
+	inputs := []string{
+		"SUMMARY:short",
+		"SUMMARY:" + strings.Repeat("a", 67),  // 正好75
+		"SUMMARY:" + strings.Repeat("a", 68),  // 76
+		"SUMMARY:" + strings.Repeat("a", 300), // 多次折行
+		"SUMMARY:" + strings.Repeat("任务", 60), // 多字节字符
+		"SUMMARY:" + strings.Repeat("é", 100),
+	}
+	for _, in := range inputs {
+		out := Fold(in)
+		if !strings.HasSuffix(out, "\r\n") {
+			t.Fatalf("Fold(%q) does not end with CRLF", in)
+		}
+		lines := strings.Split(strings.TrimSuffix(out, "\r\n"), "\r\n")
+		var joined strings.Builder
+		for i, l := range lines {
+			if len(l) > maxLineOctets {
+				t.Errorf("line %d has %d octets: %q", i, len(l), l)
+			}
+			if !utf8.ValidString(l) {
+				t.Errorf("line %d splits a UTF-8 sequence: %q", i, l)
+			}
+			if i > 0 {
+				if !strings.HasPrefix(l, " ") {
+					t.Fatalf("continuation line %d does not start with a space: %q", i, l)
+				}
+				l = l[1:]
+			}
+			joined.WriteString(l)
+		}
+		if joined.String() != in {
+			t.Errorf("unfolded %q, want %q", joined.String(), in)
+		}
+	}
+	if got := Fold("SUMMARY:" + strings.Repeat("a", 67)); strings.Count(got, "\r\n") != 1 {
+		t.Errorf("75-octet line should not be folded: %q", got)
+	}
+}
+
+func TestWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	w.Begin("VCALENDAR")
+	w.Raw("VERSION", "2.0")
+	w.Text("SUMMARY", "a,b")
+	w.Time("DTSTAMP", time.Date(2024, 1, 2, 23, 4, 5, 0, time.FixedZone("x", 8*3600)))
+	w.End("VCALENDAR")
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	want := "BEGIN:VCALENDAR\r\nVERSION:2.0\r\nSUMMARY:a\\,b\r\nDTSTAMP:20240102T150405Z\r\nEND:VCALENDAR\r\n"
+	if buf.String() != want {
+		t.Errorf("got %q, want %q", buf.String(), want)
+	}
+}
@@ -0,0 +1,73 @@
+/*
+Package mention 从Markdown正文中提取@提及
+  - @后跟字母、数字、_ . -，末尾的 . - 视为标点
+  - @前是字母数字（例如邮箱 a@b.com）时不算提及；超过MaxNameLength的名字忽略
+  - 行内代码 `...` 与围栏代码块 ``` 中的内容忽略
+*/
+package mention
+
+import (
+	"strings"
+	"unicode"
+)
+
+const (
+	MaxMentions   = 50 // 单条正文最多提取的用户数
+	MaxNameLength = 64 // 与用户id长度上限一致
+)
+
+// Parse 按首次出现顺序返回去重后的用户名，没有提及时返回空切片（非nil）
+func Parse(body string) []string {
+	var (
+		out     = []string{}
+		seen    = make(map[string]bool)
+		inFence bool
+	)
+	for _, line := range strings.Split(body, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inFence = !inFence
+			continue
+		}
+		if inFence {
+			continue
+		}
+		for _, name := range parseLine(line) {
+			if !seen[name] && len(out) < MaxMentions {
+				seen[name] = true
+				out = append(out, name)
+			}
+		}
+	}
+	return out
+}
+
+func parseLine(line string) []string {
+	var (
+		out    []string
+		rs     = []rune(line)
+		inCode bool
+	)
+	for i := 0; i < len(rs); i++ {
+		switch {
+		case rs[i] == '`':
+			inCode = !inCode
+		case inCode || rs[i] != '@':
+		case i > 0 && (isWord(rs[i-1]) || rs[i-1] == '@'):
+		default:
+			j := i + 1
+			for j < len(rs) && (isWord(rs[j]) || rs[j] == '.' || rs[j] == '-') {
+				j++
+			}
+			name := strings.TrimRight(string(rs[i+1:j]), ".-")
+			if name != "" && len(name) <= MaxNameLength {
+				out = append(out, name)
+			}
+			i = j - 1
+		}
+	}
+	return out
+}
+
+func isWord(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
@@ -0,0 +1,29 @@
+package mention
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	cases := []struct {
+		in   string
+		want []string
+	}{
+		{"@alice please review", []string{"alice"}},
+		{"cc @bob, @carol.", []string{"bob", "carol"}},
+		{"@alice and @alice again", []string{"alice"}},
+		{"mail ops@example.com", nil},
+		{"`@notme` but @me", []string{"me"}},
+		{"```\n@inside\n```\n@outside", []string{"outside"}},
+		{"@first.last-name!", []string{"first.last-name"}},
+		{"@@double @", nil},
+		{"你好@张三", nil},
+		{"(@王五)", []string{"王五"}},
+	}
+	for _, c := range cases {
+		if got := Parse(c.in); !slices.Equal(got, c.want) {
+			t.Errorf("Parse(%q) = %v, want %v", c.in, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,19 @@
+package model
+
+import "time"
+
+/*
+Attachment 任务附件的元数据，内容保存在blob.Store中
+ContentType 由服务端根据内容嗅探得到，不信任客户端声明
+*/
+type Attachment struct {
+	ID          string    `json:"id"`
+	TaskID      string    `json:"task_id"`
+	Name        string    `json:"name"`
+	Size        int64     `json:"size"`
+	ContentType string    `json:"content_type"`
+	SHA256      string    `json:"sha256"`
+	UploaderID  string    `json:"uploader_id"`
+	CreatedAt   time.Time `json:"created_at"`
+	BlobKey     string    `json:"-"`
+}
@@ -0,0 +1,13 @@
+package model
+
+import "time"
+
+// ChecklistItem 任务内的检查项，Position从0开始连续编号
+type ChecklistItem struct {
+	ID        string    `json:"id"`
+	TaskID    string    `json:"task_id"`
+	Text      string    `json:"text"`
+	Checked   bool      `json:"checked"`
+	Position  int       `json:"position"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,18 @@
+package model
+
+import "time"
+
+/*
+Comment 任务下的评论，Body为Markdown原文（不在服务端渲染）
+Mentions 由Body解析得到的@用户名；DeletedAt非nil表示已软删除
+*/
+type Comment struct {
+	ID        string     `json:"id"`
+	TaskID    string     `json:"task_id"`
+	AuthorID  string     `json:"author_id"`
+	Body      string     `json:"body"`
+	Mentions  []string   `json:"mentions"`
+	CreatedAt time.Time  `json:"created_at"`
+	EditedAt  *time.Time `json:"edited_at,omitempty"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+}
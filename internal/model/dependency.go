@@ -0,0 +1,10 @@
+package model
+
+import "time"
+
+// Dependency 任务依赖：TaskID 被 BlockerID 阻塞
+type Dependency struct {
+	TaskID    string    `json:"task_id"`
+	BlockerID string    `json:"blocker_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,22 @@
+package model
+
+import "time"
+
+// ReminderKind 提醒类型
+type ReminderKind string
+
+const (
+	ReminderDueSoon ReminderKind = "due_soon" // 即将到期
+	ReminderOverdue ReminderKind = "overdue"  // 已过期
+)
+
+/*
+Reminder 一条已发送的提醒
+(TaskID, Kind, DueAt) 唯一：截止时间被修改后会重新提醒
+*/
+type Reminder struct {
+	TaskID string       `json:"task_id"`
+	Kind   ReminderKind `json:"kind"`
+	DueAt  time.Time    `json:"due_at"`
+	SentAt time.Time    `json:"sent_at"`
+}
@@ -3,8 +3,46 @@ package model
 import "time"
 
 type Task struct {
-	ID        string    `json:"id"`
-	Title     string    `json:"title"`
-	Done      bool      `json:"done"`
-	CreatedAt time.Time `json:"created_at"`
+	ID        string     `json:"id"`
+	Title     string     `json:"title"`
+	Done      bool       `json:"done"`
+	CreatedAt time.Time  `json:"created_at"`
+	ParentID  string     `json:"parent_id,omitempty"` // 空串表示顶层任务
+	DueAt     *time.Time `json:"due_at,omitempty"`
+	// Project 所属项目（自由文本），用于按项目汇总工时
+	Project string `json:"project,omitempty"`
+	// EstimateMinutes 预估工时（分钟），0表示未预估
+	EstimateMinutes int `json:"estimate_minutes,omitempty"`
+	// Column 看板列，空串为默认列；Rank 列内排序键（见internal/rank），按字节序升序排列
+	Column string `json:"column,omitempty"`
+	Rank   string `json:"rank,omitempty"`
+
+	// Recurrence 周期任务的规则；同一系列的每个实例都携带一份
+	Recurrence *Recurrence `json:"recurrence,omitempty"`
+
+	// Progress 子任务完成进度，由service计算，不落库；没有子任务时为nil
+	Progress *Progress `json:"progress,omitempty"`
+	// Checklist 检查项完成进度，由service计算；没有检查项时为nil
+	Checklist *Progress `json:"checklist,omitempty"`
+	// Blocked 存在未完成的前置任务，由service计算，不落库
+	Blocked bool `json:"blocked"`
+	// Assignees 负责人id，保存在单独的关联表中，由service填充
+	Assignees []string `json:"assignees,omitempty"`
+}
+
+// Progress 子任务完成情况：Done of Total
+type Progress struct {
+	Done  int `json:"done"`
+	Total int `json:"total"`
+}
+
+/*
+Recurrence 周期规则，RRule为RFC 5545 RRULE子集（见internal/rrule）
+Start即DTSTART，在Timezone下展开；SeriesID为系列中第一个任务的id
+*/
+type Recurrence struct {
+	RRule    string    `json:"rrule"`
+	Timezone string    `json:"timezone"`
+	Start    time.Time `json:"start"`
+	SeriesID string    `json:"series_id"`
 }
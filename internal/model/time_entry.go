@@ -0,0 +1,40 @@
+package model
+
+import "time"
+
+/*
+TimeEntry 一条工时记录
+End为nil表示计时器仍在运行；Seconds由service计算（运行中的记录按当前时间计）
+*/
+type TimeEntry struct {
+	ID        string     `json:"id"`
+	TaskID    string     `json:"task_id"`
+	UserID    string     `json:"user_id"`
+	Start     time.Time  `json:"start"`
+	End       *time.Time `json:"end,omitempty"`
+	Seconds   int64      `json:"seconds"`
+	Running   bool       `json:"running"`
+	Note      string     `json:"note,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// TimeGroup 工时报表的汇总维度
+type TimeGroup string
+
+const (
+	TimeByTask    TimeGroup = "task"
+	TimeByProject TimeGroup = "project"
+	TimeByUser    TimeGroup = "user"
+)
+
+/*
+TimeReportRow 报表中的一行，Key为任务id、项目名或用户id
+Title与EstimateMinutes只在按任务汇总时填充
+*/
+type TimeReportRow struct {
+	Key             string `json:"key"`
+	Title           string `json:"title,omitempty"`
+	EstimateMinutes int    `json:"estimate_minutes,omitempty"`
+	Seconds         int64  `json:"seconds"`
+	Entries         int    `json:"entries"`
+}
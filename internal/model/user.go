@@ -0,0 +1,18 @@
+package model
+
+import "time"
+
+// User 用户目录中的一项；停用（Active=false）的用户不能再被分配任务
+type User struct {
+	ID          string    `json:"id"`
+	DisplayName string    `json:"display_name"`
+	Email       string    `json:"email"`
+	Active      bool      `json:"active"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// Assignment 任务与负责人的关联
+type Assignment struct {
+	TaskID string `json:"task_id"`
+	UserID string `json:"user_id"`
+}
@@ -0,0 +1,20 @@
+package model
+
+import "time"
+
+const (
+	VisibilityPrivate = "private" // 仅创建者可见
+	VisibilityTeam    = "team"    // 团队内所有人可见，仅创建者可修改
+)
+
+// SavedView 保存的任务视图：一组过滤与排序条件
+type SavedView struct {
+	ID         string    `json:"id"`
+	Name       string    `json:"name"`
+	OwnerID    string    `json:"owner_id"`
+	Filter     string    `json:"filter"`
+	Sort       string    `json:"sort"`
+	Visibility string    `json:"visibility"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
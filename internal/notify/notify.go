@@ -0,0 +1,125 @@
+/*
+Package notify 提醒的发送渠道
+  - Log    ：只写日志，默认
+  - Webhook：POST JSON到指定URL
+  - SMTP   ：发邮件，本地可以用mailhog之类的替身
+*/
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"github.com/kitouo/taskhub/internal/logx"
+	"github.com/kitouo/taskhub/internal/model"
+)
+
+type Notifier interface {
+	Notify(ctx context.Context, t model.Task, r model.Reminder) error
+}
+
+// subject 各渠道共用的一行摘要
+func subject(t model.Task, r model.Reminder) string {
+	switch r.Kind {
+	case model.ReminderOverdue:
+		return fmt.Sprintf("[taskhub] overdue: %s", t.Title)
+	default:
+		return fmt.Sprintf("[taskhub] due soon: %s", t.Title)
+	}
+}
+
+type Log struct {
+	logger logx.Logger
+}
+
+func NewLog(logger logx.Logger) *Log {
+	return &Log{logger: logger}
+}
+
+func (n *Log) Notify(ctx context.Context, t model.Task, r model.Reminder) error {
+	n.logger.Info(subject(t, r), "task_id="+t.ID, "due_at="+r.DueAt.Format(time.RFC3339))
+	return nil
+}
+
+type Webhook struct {
+	url    string
+	client *http.Client
+}
+
+func NewWebhook(url string, timeout time.Duration) *Webhook {
+	return &Webhook{url: url, client: &http.Client{Timeout: timeout}}
+}
+
+// webhookPayload Webhook请求体
+type webhookPayload struct {
+	Subject  string         `json:"subject"`
+	Reminder model.Reminder `json:"reminder"`
+	Task     model.Task     `json:"task"`
+}
+
+func (n *Webhook) Notify(ctx context.Context, t model.Task, r model.Reminder) error {
+	body, err := json.Marshal(webhookPayload{Subject: subject(t, r), Reminder: r, Task: t})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook: unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+/*
+SMTP 通过net/smtp发送纯文本邮件
+没有配置用户名时不做认证，适合本地替身；注意net/smtp只允许在TLS或localhost上使用PLAIN认证
+*/
+type SMTP struct {
+	addr string
+	auth smtp.Auth
+	from string
+	to   []string
+}
+
+func NewSMTP(addr, username, password, from string, to []string) *SMTP {
+	var auth smtp.Auth
+	if username != "" {
+		host, _, _ := strings.Cut(addr, ":")
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+	return &SMTP{addr: addr, auth: auth, from: from, to: to}
+}
+
+func (n *SMTP) Notify(ctx context.Context, t model.Task, r model.Reminder) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", n.from)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(n.to, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", headerSafe(subject(t, r)))
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	fmt.Fprintf(&b, "Task: %s\r\nID: %s\r\nDue: %s\r\n", t.Title, t.ID, r.DueAt.Format(time.RFC3339))
+
+	if err := smtp.SendMail(n.addr, n.auth, n.from, n.to, []byte(b.String())); err != nil {
+		return fmt.Errorf("smtp: %w", err)
+	}
+	return nil
+}
+
+// headerSafe 去掉换行，避免标题注入额外的邮件头
+func headerSafe(s string) string {
+	return strings.NewReplacer("\r", " ", "\n", " ").Replace(s)
+}
@@ -0,0 +1,122 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kitouo/taskhub/internal/model"
+)
+
+var (
+	due  = time.Date(2024, 1, 22, 9, 0, 0, 0, time.UTC)
+	task = model.Task{ID: "t1", Title: "rotate keys", DueAt: &due}
+	rem  = model.Reminder{TaskID: "t1", Kind: model.ReminderOverdue, DueAt: due}
+)
+
+func TestWebhook(t *testing.T) {
+	var got webhookPayload
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ct := r.Header.Get("Content-Type"); ct != "application/json" {
+			t.Errorf("content-type = %q", ct)
+		}
+		_ = json.NewDecoder(r.Body).Decode(&got)
+	}))
+	defer srv.Close()
+
+	if err := NewWebhook(srv.URL, time.Second).Notify(context.Background(), task, rem); err != nil {
+		t.Fatal(err)
+	}
+	if got.Task.ID != "t1" || got.Reminder.Kind != model.ReminderOverdue || got.Subject != "[taskhub] overdue: rotate keys" {
+		t.Fatalf("payload = %+v", got)
+	}
+}
+
+func TestWebhookRejectsNon2xx(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	if err := NewWebhook(srv.URL, time.Second).Notify(context.Background(), task, rem); err == nil {
+		t.Fatal("expected error for 502")
+	}
+}
+
+// fakeSMTP 只实现SendMail用到的最小命令集，返回收到的DATA内容
+func fakeSMTP(t *testing.T) (addr string, data <-chan string) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	ch := make(chan string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		rw := bufio.NewReadWriter(bufio.NewReader(conn), bufio.NewWriter(conn))
+		reply := func(s string) { rw.WriteString(s + "\r\n"); rw.Flush() }
+
+		reply("220 localhost ready")
+		for {
+			line, err := rw.ReadString('\n')
+			if err != nil {
+				return
+			}
+			cmd := strings.ToUpper(strings.TrimSpace(line))
+			switch {
+			case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+				reply("250 localhost")
+			case strings.HasPrefix(cmd, "DATA"):
+				reply("354 go ahead")
+				var b strings.Builder
+				for {
+					l, err := rw.ReadString('\n')
+					if err != nil || l == ".\r\n" {
+						break
+					}
+					b.WriteString(l)
+				}
+				ch <- b.String()
+				reply("250 ok")
+			case strings.HasPrefix(cmd, "QUIT"):
+				reply("221 bye")
+				return
+			default:
+				reply("250 ok")
+			}
+		}
+	}()
+	return ln.Addr().String(), ch
+}
+
+func TestSMTP(t *testing.T) {
+	addr, data := fakeSMTP(t)
+
+	bad := task
+	bad.Title = "evil\r\nBcc: someone@example.com"
+	n := NewSMTP(addr, "", "", "taskhub@example.com", []string{"ops@example.com"})
+	if err := n.Notify(context.Background(), bad, rem); err != nil {
+		t.Fatal(err)
+	}
+
+	msg := <-data
+	if !strings.Contains(msg, "To: ops@example.com\r\n") || !strings.Contains(msg, "ID: t1\r\n") {
+		t.Fatalf("unexpected message:\n%s", msg)
+	}
+	header, _, _ := strings.Cut(msg, "\r\n\r\n")
+	if strings.Contains(header, "\r\nBcc:") {
+		t.Fatalf("header injection in message:\n%s", msg)
+	}
+}
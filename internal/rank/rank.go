@@ -0,0 +1,132 @@
+/*
+Package rank 看板排序用的字典序分数索引（fractional indexing）
+  - 键由base62字符 0-9A-Za-z 组成，按字节序比较（MySQL列需使用ascii_bin）
+  - 键不以'0'结尾，因此任意两个键之间、以及任意键之前总能再插入一个键
+  - 在两张卡片之间插入只需要为被移动的卡片生成一个新键，不改动其它行
+  - 反复在同一位置插入会让键变长，超过MaxLength时由调用方用Spread重新均匀分配
+*/
+package rank
+
+import (
+	"errors"
+	"strings"
+)
+
+const digits = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+const base = len(digits)
+
+// MaxLength 键超过该长度时应当重新分配整列
+const MaxLength = 32
+
+var ErrInvalid = errors.New("invalid rank")
+
+// Valid 非空、只含base62字符且不以'0'结尾
+func Valid(s string) bool {
+	if s == "" || s[len(s)-1] == '0' {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if strings.IndexByte(digits, s[i]) < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+/*
+Between 返回严格介于a与b之间的键
+a为空表示没有下界，b为空表示没有上界；两者都非空时要求a < b
+*/
+func Between(a, b string) (string, error) {
+	if (a != "" && !Valid(a)) || (b != "" && !Valid(b)) || (a != "" && b != "" && a >= b) {
+		return "", ErrInvalid
+	}
+	return midpoint(a, b), nil
+}
+
+func midpoint(a, b string) string {
+	if b != "" {
+		// 去掉公共前缀（a在末尾之后视为补'0'）
+		n := 0
+		for n < len(b) && digitAt(a, n) == b[n] {
+			n++
+		}
+		if n > 0 {
+			rest := ""
+			if n < len(a) {
+				rest = a[n:]
+			}
+			return b[:n] + midpoint(rest, b[n:])
+		}
+	}
+
+	da, db := 0, base
+	if a != "" {
+		da = strings.IndexByte(digits, a[0])
+	}
+	if b != "" {
+		db = strings.IndexByte(digits, b[0])
+	}
+	if db-da > 1 {
+		return string(digits[(da+db)/2])
+	}
+	// 首位相邻：b更长时取b的首位即可（它比b短所以更小，又大于a）
+	if b != "" && len(b) > 1 {
+		return b[:1]
+	}
+	rest := ""
+	if len(a) > 1 {
+		rest = a[1:]
+	}
+	return string(digits[da]) + midpoint(rest, "")
+}
+
+func digitAt(s string, i int) byte {
+	if i < len(s) {
+		return s[i]
+	}
+	return digits[0]
+}
+
+/*
+After 返回比a大的一个较短的键，用于追加到列尾
+把最右边不是'z'的位加一并截断其后的部分；全是'z'时在末尾追加中间值
+*/
+func After(a string) string {
+	if a == "" {
+		return string(digits[base/2])
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		if d := strings.IndexByte(digits, a[i]); d < base-1 {
+			return a[:i] + string(digits[d+1])
+		}
+	}
+	return a + string(digits[base/2])
+}
+
+// Spread 生成n个等距、等宽（去掉末尾的'0'）的递增键，用于整列重新分配
+func Spread(n int) []string {
+	if n <= 0 {
+		return nil
+	}
+	// 选最小的宽度w，使相邻键之间至少留出一段空隙
+	width, space := 1, uint64(base)
+	for space/uint64(n+1) < 2 {
+		width++
+		space *= uint64(base)
+	}
+	step := space / uint64(n+1)
+
+	out := make([]string, n)
+	buf := make([]byte, width)
+	for i := range out {
+		v := uint64(i+1) * step
+		for j := width - 1; j >= 0; j-- {
+			buf[j] = digits[v%uint64(base)]
+			v /= uint64(base)
+		}
+		out[i] = strings.TrimRight(string(buf), "0")
+	}
+	return out
+}
@@ -0,0 +1,94 @@
+package rank
+
+import (
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+func TestBetween(t *testing.T) {
+	cases := []struct{ a, b string }{
+		{"", ""},
+		{"", "V"},
+		{"V", ""},
+		{"V", "W"},
+		{"A", "A5"},
+		{"A", "A01"},
+		{"Az", "B"},
+		{"", "01"},
+		{"zz", ""},
+		{"a1", "a2"},
+	}
+	for _, c := range cases {
+		got, err := Between(c.a, c.b)
+		if err != nil {
+			t.Fatalf("Between(%q, %q): %v", c.a, c.b, err)
+		}
+		if !Valid(got) || (c.a != "" && got <= c.a) || (c.b != "" && got >= c.b) {
+			t.Errorf("Between(%q, %q) = %q, not strictly between", c.a, c.b, got)
+		}
+	}
+}
+
+func TestBetweenInvalid(t *testing.T) {
+	for _, c := range []struct{ a, b string }{{"B", "A"}, {"A", "A"}, {"A0", ""}, {"", "a-b"}} {
+		if _, err := Between(c.a, c.b); err == nil {
+			t.Errorf("Between(%q, %q) should fail", c.a, c.b)
+		}
+	}
+}
+
+// 在随机位置反复插入，顺序始终与插入意图一致
+func TestRandomInserts(t *testing.T) {
+	r := rand.New(rand.NewPCG(1, 2))
+	keys := []string{}
+	for range 2000 {
+		i := r.IntN(len(keys) + 1)
+		lo, hi := "", ""
+		if i > 0 {
+			lo = keys[i-1]
+		}
+		if i < len(keys) {
+			hi = keys[i]
+		}
+		k, err := Between(lo, hi)
+		if err != nil {
+			t.Fatalf("Between(%q, %q): %v", lo, hi, err)
+		}
+		keys = slices.Insert(keys, i, k)
+	}
+	if !slices.IsSorted(keys) {
+		t.Fatal("keys not sorted")
+	}
+	if len(slices.Compact(slices.Clone(keys))) != len(keys) {
+		t.Fatal("duplicate keys")
+	}
+}
+
+func TestAfter(t *testing.T) {
+	k := ""
+	for range 5000 {
+		next := After(k)
+		if !Valid(next) || next <= k {
+			t.Fatalf("After(%q) = %q", k, next)
+		}
+		k = next
+	}
+	if len(k) > 200 {
+		t.Errorf("After grows too fast: len %d", len(k))
+	}
+}
+
+func TestSpread(t *testing.T) {
+	for _, n := range []int{1, 2, 61, 62, 1000, 100000} {
+		keys := Spread(n)
+		if len(keys) != n {
+			t.Fatalf("Spread(%d) returned %d keys", n, len(keys))
+		}
+		for i, k := range keys {
+			if !Valid(k) || len(k) > MaxLength || (i > 0 && keys[i-1] >= k) {
+				t.Fatalf("Spread(%d)[%d] = %q", n, i, k)
+			}
+		}
+	}
+}
@@ -0,0 +1,15 @@
+package repo
+
+import (
+	"context"
+
+	"github.com/kitouo/taskhub/internal/model"
+)
+
+type AttachmentRepo interface {
+	Create(ctx context.Context, a model.Attachment) (model.Attachment, error)
+	Get(ctx context.Context, id string) (model.Attachment, bool, error)
+	// List 按created_at、id升序
+	List(ctx context.Context, taskID string) ([]model.Attachment, error)
+	Delete(ctx context.Context, id string) (bool, error)
+}
@@ -0,0 +1,249 @@
+package breakerrepo
+
+import (
+	"sync"
+	"time"
+)
+
+type State int
+
+const (
+	// Closed 正常放行，按滑动窗口统计失败率
+	Closed State = iota
+	// Open 熔断中，所有调用直接失败，不再访问后端
+	Open
+	// HalfOpen 熔断期已过，放行少量试探调用决定恢复还是再次熔断
+	HalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case Closed:
+		return "closed"
+	case Open:
+		return "open"
+	case HalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+type Options struct {
+	// Window 统计失败率的滑动窗口（分成10个桶滚动），<=0时取10秒
+	Window time.Duration
+	// MinRequests 窗口内的调用数达到该值才判断失败率，避免个别失败就熔断，<=0时取20
+	MinRequests int
+	// FailureRatio 窗口内失败占比达到该值时熔断，不在(0,1]内时取0.5
+	FailureRatio float64
+	// OpenDuration 熔断后直接拒绝的时长，之后进入半开状态，<=0时取5秒
+	OpenDuration time.Duration
+	// HalfOpenProbes 半开状态下放行的试探调用数，全部成功才恢复，<=0时取3
+	HalfOpenProbes int
+}
+
+func (o Options) withDefaults() Options {
+	if o.Window <= 0 {
+		o.Window = 10 * time.Second
+	}
+	if o.MinRequests <= 0 {
+		o.MinRequests = 20
+	}
+	if o.FailureRatio <= 0 || o.FailureRatio > 1 {
+		o.FailureRatio = 0.5
+	}
+	if o.OpenDuration <= 0 {
+		o.OpenDuration = 5 * time.Second
+	}
+	if o.HalfOpenProbes <= 0 {
+		o.HalfOpenProbes = 3
+	}
+	return o
+}
+
+// Stats 当前状态、窗口内的计数，以及自创建以来的累计拒绝/熔断次数
+type Stats struct {
+	State    string `json:"state"`
+	Requests int    `json:"requests"`
+	Failures int    `json:"failures"`
+	Rejected int64  `json:"rejected"`
+	Opens    int64  `json:"opens"`
+}
+
+type outcome int
+
+const (
+	// ignored 不能说明后端好坏的结果（例如调用方自己取消），不计入统计
+	ignored outcome = iota
+	success
+	failure
+)
+
+const buckets = 10
+
+// bucket 窗口中的一段；slot为所属时间段的序号，过期的桶在复用时清零
+type bucket struct {
+	slot            int64
+	total, failures int
+}
+
+// transition 一次状态变化，在释放锁之后通知
+type transition struct {
+	from, to           State
+	requests, failures int
+}
+
+type breaker struct {
+	opts     Options
+	now      func() time.Time
+	onChange func(transition)
+
+	mu       sync.Mutex
+	state    State
+	gen      uint64 // 每次状态变化加一，旧状态下放行的调用结果不再计入
+	openedAt time.Time
+	window   [buckets]bucket
+	probes   int // 半开状态下已放行、尚未返回的试探调用
+	probeOK  int // 半开状态下成功返回的试探调用
+
+	rejected, opens int64
+}
+
+func newBreaker(opts Options, onChange func(transition)) *breaker {
+	return &breaker{opts: opts.withDefaults(), now: time.Now, onChange: onChange}
+}
+
+// allow 判断能否放行一次调用；放行时返回当前的gen，调用结束后交给record
+func (b *breaker) allow() (uint64, bool) {
+	b.mu.Lock()
+	t := b.expireOpen()
+	gen, ok := b.gen, true
+	switch b.state {
+	case Open:
+		ok = false
+	case HalfOpen:
+		if b.probes < b.opts.HalfOpenProbes {
+			b.probes++
+		} else {
+			ok = false
+		}
+	}
+	if !ok {
+		b.rejected++
+	}
+	b.mu.Unlock()
+	b.notify(t)
+	return gen, ok
+}
+
+func (b *breaker) record(gen uint64, o outcome) {
+	b.mu.Lock()
+	var t *transition
+	if gen == b.gen {
+		switch b.state {
+		case Closed:
+			if o != ignored {
+				t = b.count(o == failure)
+			}
+		case HalfOpen:
+			b.probes--
+			switch o {
+			case failure:
+				t = b.moveTo(Open)
+			case success:
+				if b.probeOK++; b.probeOK >= b.opts.HalfOpenProbes {
+					t = b.moveTo(Closed)
+				}
+			}
+		}
+	}
+	b.mu.Unlock()
+	b.notify(t)
+}
+
+func (b *breaker) State() State {
+	b.mu.Lock()
+	t := b.expireOpen()
+	s := b.state
+	b.mu.Unlock()
+	b.notify(t)
+	return s
+}
+
+func (b *breaker) Stats() Stats {
+	b.mu.Lock()
+	t := b.expireOpen()
+	total, failures := b.sum()
+	s := Stats{State: b.state.String(), Requests: total, Failures: failures, Rejected: b.rejected, Opens: b.opens}
+	b.mu.Unlock()
+	b.notify(t)
+	return s
+}
+
+func (b *breaker) notify(t *transition) {
+	if t != nil && b.onChange != nil {
+		b.onChange(*t)
+	}
+}
+
+// 以下helper要求调用方持有b.mu
+
+// expireOpen 熔断期已过时转为半开
+func (b *breaker) expireOpen() *transition {
+	if b.state == Open && !b.now().Before(b.openedAt.Add(b.opts.OpenDuration)) {
+		return b.moveTo(HalfOpen)
+	}
+	return nil
+}
+
+// count 把一次调用记入当前桶，失败率达到阈值时熔断
+func (b *breaker) count(failed bool) *transition {
+	slot := b.slot()
+	bk := &b.window[slot%buckets]
+	if bk.slot != slot {
+		*bk = bucket{slot: slot}
+	}
+	bk.total++
+	if !failed {
+		return nil
+	}
+	bk.failures++
+	total, failures := b.sum()
+	if total >= b.opts.MinRequests && float64(failures) >= b.opts.FailureRatio*float64(total) {
+		return b.moveTo(Open)
+	}
+	return nil
+}
+
+// sum 窗口内（最近buckets个时间段）的调用数与失败数
+func (b *breaker) sum() (total, failures int) {
+	slot := b.slot()
+	for _, bk := range b.window {
+		if bk.slot > slot-buckets {
+			total += bk.total
+			failures += bk.failures
+		}
+	}
+	return total, failures
+}
+
+// slot 当前时刻所在时间段的序号，每段为Window的1/buckets
+func (b *breaker) slot() int64 {
+	width := max(int64(b.opts.Window/buckets), 1)
+	return b.now().UnixNano() / width
+}
+
+func (b *breaker) moveTo(s State) *transition {
+	t := &transition{from: b.state, to: s}
+	t.requests, t.failures = b.sum()
+	b.state = s
+	b.gen++
+	b.probes, b.probeOK = 0, 0
+	switch s {
+	case Open:
+		b.openedAt = b.now()
+		b.opens++
+	case Closed:
+		b.window = [buckets]bucket{}
+	}
+	return t
+}
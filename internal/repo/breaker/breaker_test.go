@@ -0,0 +1,133 @@
+package breakerrepo
+
+import (
+	"testing"
+	"time"
+)
+
+// fakeBreaker 使用可控时钟，并按顺序记录状态变化
+func fakeBreaker(opts Options) (*breaker, *time.Time, *[]State) {
+	now := time.Unix(1_700_000_000, 0)
+	var changes []State
+	b := newBreaker(opts, func(t transition) { changes = append(changes, t.to) })
+	b.now = func() time.Time { return now }
+	return b, &now, &changes
+}
+
+func call(t *testing.T, b *breaker, o outcome) {
+	t.Helper()
+	gen, ok := b.allow()
+	if !ok {
+		t.Fatalf("call rejected in state %s", b.State())
+	}
+	b.record(gen, o)
+}
+
+func TestOpensOnFailureRatio(t *testing.T) {
+	b, _, _ := fakeBreaker(Options{MinRequests: 10, FailureRatio: 0.5})
+
+	// 调用数不足MinRequests时不熔断
+	for range 4 {
+		call(t, b, failure)
+	}
+	if b.State() != Closed {
+		t.Fatalf("opened after %d calls", 4)
+	}
+	for range 5 {
+		call(t, b, success)
+	}
+	call(t, b, failure) // 5/10
+	if b.State() != Open {
+		t.Fatalf("state = %s, want open", b.State())
+	}
+	if _, ok := b.allow(); ok {
+		t.Error("open breaker allowed a call")
+	}
+	if s := b.Stats(); s.Rejected != 1 || s.Opens != 1 {
+		t.Errorf("Stats = %+v", s)
+	}
+}
+
+func TestIgnoredNotCounted(t *testing.T) {
+	b, _, _ := fakeBreaker(Options{MinRequests: 2})
+	for range 10 {
+		call(t, b, ignored)
+	}
+	call(t, b, failure)
+	if b.State() != Closed {
+		t.Error("ignored calls counted towards MinRequests")
+	}
+}
+
+func TestWindowSlides(t *testing.T) {
+	b, now, _ := fakeBreaker(Options{Window: 10 * time.Second, MinRequests: 4})
+	for range 3 {
+		call(t, b, failure)
+	}
+	*now = now.Add(11 * time.Second)
+	call(t, b, failure)
+	if b.State() != Closed {
+		t.Error("failures outside the window were counted")
+	}
+	if s := b.Stats(); s.Requests != 1 {
+		t.Errorf("window requests = %d, want 1", s.Requests)
+	}
+}
+
+func TestHalfOpen(t *testing.T) {
+	b, now, changes := fakeBreaker(Options{MinRequests: 1, OpenDuration: time.Second, HalfOpenProbes: 2})
+	call(t, b, failure)
+
+	*now = now.Add(time.Second)
+	g1, ok1 := b.allow()
+	g2, ok2 := b.allow()
+	if _, ok := b.allow(); !ok1 || !ok2 || ok {
+		t.Fatalf("half-open allowed %v %v %v, want 2 probes", ok1, ok2, ok)
+	}
+	// 试探失败：重新熔断
+	b.record(g1, failure)
+	b.record(g2, success) // 属于上一个状态，忽略
+	if b.State() != Open {
+		t.Fatalf("state = %s, want open", b.State())
+	}
+
+	*now = now.Add(time.Second)
+	call(t, b, success)
+	call(t, b, ignored) // 不占用名额，也不算成功
+	if b.State() != HalfOpen {
+		t.Fatalf("state = %s, want half-open", b.State())
+	}
+	call(t, b, success)
+	if b.State() != Closed {
+		t.Fatalf("state = %s, want closed", b.State())
+	}
+	// 恢复后从空窗口重新统计
+	if s := b.Stats(); s.Requests != 0 {
+		t.Errorf("window requests after close = %d", s.Requests)
+	}
+
+	want := []State{Open, HalfOpen, Open, HalfOpen, Closed}
+	if len(*changes) != len(want) {
+		t.Fatalf("transitions = %v, want %v", *changes, want)
+	}
+	for i := range want {
+		if (*changes)[i] != want[i] {
+			t.Fatalf("transitions = %v, want %v", *changes, want)
+		}
+	}
+}
+
+// 熔断前放行的慢调用在熔断后才返回，不能影响新状态
+func TestStaleResultIgnored(t *testing.T) {
+	b, now, _ := fakeBreaker(Options{MinRequests: 1, OpenDuration: time.Second, HalfOpenProbes: 1})
+	slow, _ := b.allow()
+	call(t, b, failure)
+	*now = now.Add(time.Second)
+	if b.State() != HalfOpen {
+		t.Fatalf("state = %s, want half-open", b.State())
+	}
+	b.record(slow, success)
+	if b.State() != HalfOpen {
+		t.Errorf("stale success closed the breaker")
+	}
+}
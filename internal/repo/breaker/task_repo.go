@@ -0,0 +1,197 @@
+/*
+Package breakerrepo 给repo.TaskRepo加一层熔断：后端持续出错时直接返回repo.ErrUnavailable，
+不再让每个请求都等到超时
+  - closed：正常放行，滑动窗口内失败占比达到阈值（且调用数足够）时转为open
+  - open：直接拒绝，OpenDuration之后转为half-open
+  - half-open：放行少量试探调用，全部成功则恢复closed，任一失败则重新open
+  - 只统计基础设施层面的失败：id冲突、原子批量中被回滚的条目、非法过滤条件等说明后端工作正常；
+    调用方自己取消或超时的调用不计入
+*/
+package breakerrepo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/kitouo/taskhub/internal/filter"
+	"github.com/kitouo/taskhub/internal/logx"
+	"github.com/kitouo/taskhub/internal/model"
+	"github.com/kitouo/taskhub/internal/repo"
+)
+
+type TaskRepo struct {
+	next repo.TaskRepo
+	b    *breaker
+}
+
+// New 返回包装next的熔断器，状态变化通过logger记录
+func New(next repo.TaskRepo, opts Options, logger logx.Logger) *TaskRepo {
+	return &TaskRepo{
+		next: next,
+		b: newBreaker(opts, func(t transition) {
+			kv := fmt.Sprintf("from=%s to=%s requests=%d failures=%d", t.from, t.to, t.requests, t.failures)
+			if t.to == Open {
+				logger.Warn("task store circuit opened", kv)
+				return
+			}
+			logger.Info("task store circuit state changed", kv)
+		}),
+	}
+}
+
+func (r *TaskRepo) State() State { return r.b.State() }
+
+func (r *TaskRepo) Stats() Stats { return r.b.Stats() }
+
+// do 在熔断器允许时执行fn并记录结果；被拒绝时返回repo.ErrUnavailable
+func (r *TaskRepo) do(ctx context.Context, fn func() error) error {
+	gen, ok := r.b.allow()
+	if !ok {
+		return repo.ErrUnavailable
+	}
+	err := fn()
+	r.b.record(gen, classify(ctx, err))
+	return err
+}
+
+func classify(ctx context.Context, err error) outcome {
+	var ferr *filter.Error
+	switch {
+	case err == nil, errors.Is(err, repo.ErrConflict), errors.Is(err, repo.ErrBatchAborted), errors.As(err, &ferr):
+		return success
+	case errors.Is(err, repo.ErrUnavailable):
+		// 下游还有一层熔断，已经由它处理
+		return ignored
+	case ctx.Err() != nil:
+		// 调用方取消或超时；查询自身的超时（ctx仍有效）算作失败
+		return ignored
+	}
+	return failure
+}
+
+func (r *TaskRepo) Create(ctx context.Context, t model.Task) (out model.Task, err error) {
+	err = r.do(ctx, func() error {
+		out, err = r.next.Create(ctx, t)
+		return err
+	})
+	return out, err
+}
+
+func (r *TaskRepo) List(ctx context.Context, q repo.ListQuery) (out []model.Task, err error) {
+	err = r.do(ctx, func() error {
+		out, err = r.next.List(ctx, q)
+		return err
+	})
+	return out, err
+}
+
+func (r *TaskRepo) Count(ctx context.Context, q repo.ListQuery) (n int, err error) {
+	err = r.do(ctx, func() error {
+		n, err = r.next.Count(ctx, q)
+		return err
+	})
+	return n, err
+}
+
+// Each fn返回的错误（例如客户端断开）与后端无关，不计为失败
+func (r *TaskRepo) Each(ctx context.Context, q repo.ListQuery, fn func(model.Task) error) error {
+	var fnErr error
+	gen, ok := r.b.allow()
+	if !ok {
+		return repo.ErrUnavailable
+	}
+	err := r.next.Each(ctx, q, func(t model.Task) error {
+		fnErr = fn(t)
+		return fnErr
+	})
+	if err != nil && fnErr != nil && errors.Is(err, fnErr) {
+		r.b.record(gen, success)
+	} else {
+		r.b.record(gen, classify(ctx, err))
+	}
+	return err
+}
+
+func (r *TaskRepo) Get(ctx context.Context, id string) (t model.Task, found bool, err error) {
+	err = r.do(ctx, func() error {
+		t, found, err = r.next.Get(ctx, id)
+		return err
+	})
+	return t, found, err
+}
+
+func (r *TaskRepo) MarkDone(ctx context.Context, id string, done bool) (t model.Task, found bool, err error) {
+	err = r.do(ctx, func() error {
+		t, found, err = r.next.MarkDone(ctx, id, done)
+		return err
+	})
+	return t, found, err
+}
+
+func (r *TaskRepo) Update(ctx context.Context, id string, p repo.TaskPatch) (t model.Task, found bool, err error) {
+	err = r.do(ctx, func() error {
+		t, found, err = r.next.Update(ctx, id, p)
+		return err
+	})
+	return t, found, err
+}
+
+// SetParent check拒绝（例如会形成环）是正常结果，不计为失败
+func (r *TaskRepo) SetParent(ctx context.Context, id, parentID string, check func([]string) error) (t model.Task, found bool, err error) {
+	var rejected error
+	if derr := r.do(ctx, func() error {
+		t, found, err = r.next.SetParent(ctx, id, parentID, func(ancestors []string) error {
+			if check != nil {
+				rejected = check(ancestors)
+			}
+			return rejected
+		})
+		if rejected != nil {
+			return nil
+		}
+		return err
+	}); derr != nil {
+		return model.Task{}, false, derr
+	}
+	return t, found, err
+}
+
+func (r *TaskRepo) Delete(ctx context.Context, id string) (found bool, err error) {
+	err = r.do(ctx, func() error {
+		found, err = r.next.Delete(ctx, id)
+		return err
+	})
+	return found, err
+}
+
+// Batch 条目级的not found、冲突等属于正常结果；逐条执行时条目上的基础设施错误同样计为失败
+func (r *TaskRepo) Batch(ctx context.Context, ops []repo.BatchOp, atomic bool) ([]repo.BatchResult, error) {
+	gen, ok := r.b.allow()
+	if !ok {
+		return nil, repo.ErrUnavailable
+	}
+	out, err := r.next.Batch(ctx, ops, atomic)
+	o := classify(ctx, err)
+	for i := 0; err == nil && i < len(out) && o == success; i++ {
+		o = classify(ctx, out[i].Err)
+	}
+	r.b.record(gen, o)
+	return out, err
+}
+
+func (r *TaskRepo) Search(ctx context.Context, q repo.SearchQuery) (hits []repo.SearchHit, total int, err error) {
+	err = r.do(ctx, func() error {
+		hits, total, err = r.next.Search(ctx, q)
+		return err
+	})
+	return hits, total, err
+}
+
+func (r *TaskRepo) LastRank(ctx context.Context, column string) (last string, err error) {
+	err = r.do(ctx, func() error {
+		last, err = r.next.LastRank(ctx, column)
+		return err
+	})
+	return last, err
+}
@@ -0,0 +1,92 @@
+package breakerrepo
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kitouo/taskhub/internal/logx"
+	"github.com/kitouo/taskhub/internal/model"
+	"github.com/kitouo/taskhub/internal/repo"
+	"github.com/kitouo/taskhub/internal/repo/memory"
+	"github.com/kitouo/taskhub/internal/repo/repotest"
+)
+
+var quiet = logx.New("", logx.Error)
+
+func TestTaskRepo(t *testing.T) {
+	repotest.Run(t, func(t *testing.T) repo.TaskRepo {
+		return New(memory.NewTaskRepo(), Options{}, quiet)
+	})
+}
+
+// flakyRepo err非nil时Get返回该错误
+type flakyRepo struct {
+	repo.TaskRepo
+	err error
+}
+
+func (r *flakyRepo) Get(ctx context.Context, id string) (model.Task, bool, error) {
+	if r.err != nil {
+		return model.Task{}, false, r.err
+	}
+	return r.TaskRepo.Get(ctx, id)
+}
+
+func TestFailFast(t *testing.T) {
+	ctx := context.Background()
+	back := &flakyRepo{TaskRepo: memory.NewTaskRepo(), err: errors.New("connection refused")}
+	r := New(back, Options{MinRequests: 3, OpenDuration: time.Minute}, quiet)
+
+	for range 3 {
+		if _, _, err := r.Get(ctx, "a"); err == nil || errors.Is(err, repo.ErrUnavailable) {
+			t.Fatalf("Get = %v, want the backend error", err)
+		}
+	}
+	back.err = nil
+	if _, _, err := r.Get(ctx, "a"); !errors.Is(err, repo.ErrUnavailable) {
+		t.Fatalf("Get after opening = %v, want ErrUnavailable", err)
+	}
+	if _, err := r.Create(ctx, model.Task{ID: "a", Title: "a", CreatedAt: time.Now()}); !errors.Is(err, repo.ErrUnavailable) {
+		t.Errorf("Create after opening = %v, want ErrUnavailable", err)
+	}
+	if r.State() != Open {
+		t.Errorf("state = %s, want open", r.State())
+	}
+}
+
+// 业务层面的错误和调用方取消都不应触发熔断
+func TestNonFailures(t *testing.T) {
+	ctx := context.Background()
+	r := New(memory.NewTaskRepo(), Options{MinRequests: 1}, quiet)
+
+	task := model.Task{ID: "a", Title: "a", CreatedAt: time.Now()}
+	if _, err := r.Create(ctx, task); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Create(ctx, task); !errors.Is(err, repo.ErrConflict) {
+		t.Fatalf("duplicate Create = %v", err)
+	}
+	if _, err := r.Batch(ctx, []repo.BatchOp{
+		{Kind: repo.BatchDelete, ID: "missing"},
+		{Kind: repo.BatchDelete, ID: "a"},
+	}, true); err != nil {
+		t.Fatal(err)
+	}
+
+	stop := errors.New("client went away")
+	if err := r.Each(ctx, repo.ListQuery{}, func(model.Task) error { return stop }); !errors.Is(err, stop) {
+		t.Fatalf("Each = %v", err)
+	}
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	back := &flakyRepo{TaskRepo: r.next, err: context.Canceled}
+	r.next = back
+	r.Get(canceled, "a")
+
+	if r.State() != Closed {
+		t.Errorf("state = %s, want closed", r.State())
+	}
+}
@@ -0,0 +1,281 @@
+/*
+Package cacherepo 给任意repo.TaskRepo加一层按id的读穿透缓存
+  - 只缓存Get；List/Count/Each/Search等范围查询直接透传
+  - 容量有上限（LRU淘汰），每条带TTL；not found也会缓存（NegativeTTL），挡住对不存在id的反复查询
+  - 同一id并发未命中时只有一个请求回源，其余等待它的结果
+  - 经本装饰器的每次修改都会在返回前失效相关id；其它实例的修改无法感知，只能靠TTL兜底
+*/
+package cacherepo
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/kitouo/taskhub/internal/model"
+	"github.com/kitouo/taskhub/internal/repo"
+)
+
+type Options struct {
+	// Size 最多缓存的条目数（含not found），<=0时取1024
+	Size int
+	// TTL 命中结果的有效期，<=0时取30秒
+	TTL time.Duration
+	// NegativeTTL not found结果的有效期，<=0时不缓存not found
+	NegativeTTL time.Duration
+}
+
+/*
+Stats 自创建以来的累计计数与当前条目数
+Misses为没有命中缓存的Get次数，Loads为实际回源次数，两者之差即被合并的并发未命中
+*/
+type Stats struct {
+	Hits      int64 `json:"hits"`
+	Misses    int64 `json:"misses"`
+	Loads     int64 `json:"loads"`
+	Evictions int64 `json:"evictions"`
+	Entries   int   `json:"entries"`
+}
+
+type entry struct {
+	id      string
+	task    model.Task
+	found   bool
+	expires time.Time
+}
+
+// flight 一次正在进行的回源；stale表示期间该id被失效过，结果只返回给等待者，不写入缓存
+type flight struct {
+	done  chan struct{}
+	task  model.Task
+	found bool
+	err   error
+	stale bool
+}
+
+type TaskRepo struct {
+	next repo.TaskRepo
+	opts Options
+	now  func() time.Time
+
+	mu      sync.Mutex
+	lru     *list.List // 表头为最近使用，元素为*entry
+	items   map[string]*list.Element
+	flights map[string]*flight
+
+	hits, misses, loads, evictions atomic.Int64
+}
+
+// New 返回包装next的缓存；所有对next的修改都必须经过返回值，否则缓存无法失效
+func New(next repo.TaskRepo, opts Options) *TaskRepo {
+	if opts.Size <= 0 {
+		opts.Size = 1024
+	}
+	if opts.TTL <= 0 {
+		opts.TTL = 30 * time.Second
+	}
+	return &TaskRepo{
+		next:    next,
+		opts:    opts,
+		now:     time.Now,
+		lru:     list.New(),
+		items:   make(map[string]*list.Element),
+		flights: make(map[string]*flight),
+	}
+}
+
+func (r *TaskRepo) Stats() Stats {
+	r.mu.Lock()
+	n := r.lru.Len()
+	r.mu.Unlock()
+	return Stats{
+		Hits:      r.hits.Load(),
+		Misses:    r.misses.Load(),
+		Loads:     r.loads.Load(),
+		Evictions: r.evictions.Load(),
+		Entries:   n,
+	}
+}
+
+/*
+Get 命中则直接返回；未命中时同一id只有一个调用回源
+回源的调用被取消时，仍在等待的其它调用会自己重新回源，而不是收到别人的context错误
+*/
+func (r *TaskRepo) Get(ctx context.Context, id string) (model.Task, bool, error) {
+	missed := false
+	for {
+		r.mu.Lock()
+		if e, ok := r.lookup(id); ok {
+			r.mu.Unlock()
+			if !missed {
+				r.hits.Add(1)
+			}
+			return e.task, e.found, nil
+		}
+		if !missed {
+			r.misses.Add(1)
+			missed = true
+		}
+		if f, ok := r.flights[id]; ok {
+			r.mu.Unlock()
+			select {
+			case <-f.done:
+			case <-ctx.Done():
+				return model.Task{}, false, ctx.Err()
+			}
+			if isContextErr(f.err) && ctx.Err() == nil {
+				continue
+			}
+			return f.task, f.found, f.err
+		}
+		f := &flight{done: make(chan struct{})}
+		r.flights[id] = f
+		r.mu.Unlock()
+
+		r.loads.Add(1)
+		// 回源读主库：从有复制延迟的副本读到的旧值会在缓存里留一整个TTL
+		f.task, f.found, f.err = r.next.Get(repo.WithPrimaryReads(ctx), id)
+
+		r.mu.Lock()
+		if r.flights[id] == f {
+			delete(r.flights, id)
+		}
+		if f.err == nil && !f.stale {
+			r.store(id, f.task, f.found)
+		}
+		r.mu.Unlock()
+		close(f.done)
+		return f.task, f.found, f.err
+	}
+}
+
+func isContextErr(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
+
+// 以下helper要求调用方持有r.mu
+
+// lookup 取出未过期的条目并移到表头；过期的顺手删除
+func (r *TaskRepo) lookup(id string) (*entry, bool) {
+	el, ok := r.items[id]
+	if !ok {
+		return nil, false
+	}
+	e := el.Value.(*entry)
+	if !r.now().Before(e.expires) {
+		r.lru.Remove(el)
+		delete(r.items, id)
+		return nil, false
+	}
+	r.lru.MoveToFront(el)
+	return e, true
+}
+
+func (r *TaskRepo) store(id string, t model.Task, found bool) {
+	ttl := r.opts.TTL
+	if !found {
+		if r.opts.NegativeTTL <= 0 {
+			return
+		}
+		ttl = r.opts.NegativeTTL
+	}
+	e := &entry{id: id, task: t, found: found, expires: r.now().Add(ttl)}
+	if el, ok := r.items[id]; ok {
+		el.Value = e
+		r.lru.MoveToFront(el)
+		return
+	}
+	r.items[id] = r.lru.PushFront(e)
+	for r.lru.Len() > r.opts.Size {
+		oldest := r.lru.Back()
+		r.lru.Remove(oldest)
+		delete(r.items, oldest.Value.(*entry).id)
+		r.evictions.Add(1)
+	}
+}
+
+/*
+invalidate 删除这些id的条目，并让正在进行的回源结果不再写入缓存
+（回源可能在修改提交之前读到旧值）；之后的Get会发起新的回源
+*/
+func (r *TaskRepo) invalidate(ids ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, id := range ids {
+		if el, ok := r.items[id]; ok {
+			r.lru.Remove(el)
+			delete(r.items, id)
+		}
+		if f, ok := r.flights[id]; ok {
+			f.stale = true
+			delete(r.flights, id)
+		}
+	}
+}
+
+/*
+修改操作：先写next再失效缓存，出错时同样失效（写入可能已经部分生效）
+失效发生在返回之前，调用方随后的Get一定能读到自己的写入
+*/
+
+func (r *TaskRepo) Create(ctx context.Context, t model.Task) (model.Task, error) {
+	defer r.invalidate(t.ID)
+	return r.next.Create(ctx, t)
+}
+
+func (r *TaskRepo) MarkDone(ctx context.Context, id string, done bool) (model.Task, bool, error) {
+	defer r.invalidate(id)
+	return r.next.MarkDone(ctx, id, done)
+}
+
+func (r *TaskRepo) Update(ctx context.Context, id string, p repo.TaskPatch) (model.Task, bool, error) {
+	defer r.invalidate(id)
+	return r.next.Update(ctx, id, p)
+}
+
+func (r *TaskRepo) SetParent(ctx context.Context, id, parentID string, check func([]string) error) (model.Task, bool, error) {
+	defer r.invalidate(id)
+	return r.next.SetParent(ctx, id, parentID, check)
+}
+
+func (r *TaskRepo) Delete(ctx context.Context, id string) (bool, error) {
+	defer r.invalidate(id)
+	return r.next.Delete(ctx, id)
+}
+
+func (r *TaskRepo) Batch(ctx context.Context, ops []repo.BatchOp, atomic bool) ([]repo.BatchResult, error) {
+	ids := make([]string, len(ops))
+	for i, op := range ops {
+		ids[i] = op.ID
+		if op.Kind == repo.BatchCreate {
+			ids[i] = op.Task.ID
+		}
+	}
+	defer r.invalidate(ids...)
+	return r.next.Batch(ctx, ops, atomic)
+}
+
+// 范围查询不经过缓存
+
+func (r *TaskRepo) List(ctx context.Context, q repo.ListQuery) ([]model.Task, error) {
+	return r.next.List(ctx, q)
+}
+
+func (r *TaskRepo) Count(ctx context.Context, q repo.ListQuery) (int, error) {
+	return r.next.Count(ctx, q)
+}
+
+func (r *TaskRepo) Each(ctx context.Context, q repo.ListQuery, fn func(model.Task) error) error {
+	return r.next.Each(ctx, q, fn)
+}
+
+func (r *TaskRepo) Search(ctx context.Context, q repo.SearchQuery) ([]repo.SearchHit, int, error) {
+	return r.next.Search(ctx, q)
+}
+
+func (r *TaskRepo) LastRank(ctx context.Context, column string) (string, error) {
+	return r.next.LastRank(ctx, column)
+}
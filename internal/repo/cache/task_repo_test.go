@@ -0,0 +1,281 @@
+package cacherepo
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kitouo/taskhub/internal/model"
+	"github.com/kitouo/taskhub/internal/repo"
+	"github.com/kitouo/taskhub/internal/repo/memory"
+	"github.com/kitouo/taskhub/internal/repo/repotest"
+)
+
+func TestTaskRepo(t *testing.T) {
+	repotest.Run(t, func(t *testing.T) repo.TaskRepo {
+		return New(memory.NewTaskRepo(), Options{Size: 4, NegativeTTL: time.Minute})
+	})
+}
+
+// slowRepo 统计Get调用次数；设置了gate时Get会阻塞到gate关闭
+type slowRepo struct {
+	repo.TaskRepo
+	mu    sync.Mutex
+	gets  int
+	gate  chan struct{}
+	enter chan struct{}
+}
+
+func (r *slowRepo) Get(ctx context.Context, id string) (model.Task, bool, error) {
+	r.mu.Lock()
+	r.gets++
+	gate, enter := r.gate, r.enter
+	r.mu.Unlock()
+	if gate != nil {
+		if enter != nil {
+			enter <- struct{}{}
+		}
+		select {
+		case <-gate:
+		case <-ctx.Done():
+			return model.Task{}, false, ctx.Err()
+		}
+	}
+	return r.TaskRepo.Get(ctx, id)
+}
+
+func (r *slowRepo) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.gets
+}
+
+func newSlow(t *testing.T, tasks ...string) *slowRepo {
+	t.Helper()
+	mem := memory.NewTaskRepo()
+	for _, id := range tasks {
+		if _, err := mem.Create(context.Background(), model.Task{ID: id, Title: id, CreatedAt: time.Now()}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return &slowRepo{TaskRepo: mem}
+}
+
+func mustGet(t *testing.T, r repo.TaskRepo, id string) (model.Task, bool) {
+	t.Helper()
+	task, ok, err := r.Get(context.Background(), id)
+	if err != nil {
+		t.Fatalf("Get(%s): %v", id, err)
+	}
+	return task, ok
+}
+
+func TestHitMissAndInvalidation(t *testing.T) {
+	ctx := context.Background()
+	back := newSlow(t, "a")
+	c := New(back, Options{})
+
+	mustGet(t, c, "a")
+	mustGet(t, c, "a")
+	if back.count() != 1 {
+		t.Fatalf("backend Gets = %d, want 1", back.count())
+	}
+
+	title := "renamed"
+	if _, _, err := c.Update(ctx, "a", repo.TaskPatch{Title: &title}); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := mustGet(t, c, "a"); got.Title != title {
+		t.Errorf("Get after Update = %q, want %q", got.Title, title)
+	}
+	if back.count() != 2 {
+		t.Errorf("backend Gets = %d, want 2", back.count())
+	}
+
+	// 未缓存not found：每次都回源
+	mustGet(t, c, "missing")
+	mustGet(t, c, "missing")
+	if want := (Stats{Hits: 1, Misses: 4, Loads: 4, Entries: 1}); c.Stats() != want {
+		t.Errorf("Stats = %+v, want %+v", c.Stats(), want)
+	}
+}
+
+func TestNegativeCache(t *testing.T) {
+	back := newSlow(t)
+	c := New(back, Options{NegativeTTL: time.Minute})
+
+	for range 3 {
+		if _, ok := mustGet(t, c, "x"); ok {
+			t.Fatal("found a task that does not exist")
+		}
+	}
+	if back.count() != 1 {
+		t.Fatalf("backend Gets = %d, want 1", back.count())
+	}
+
+	// 创建后不能再返回缓存的not found
+	if _, err := c.Create(context.Background(), model.Task{ID: "x", Title: "x", CreatedAt: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := mustGet(t, c, "x"); !ok {
+		t.Error("Get after Create returned cached not found")
+	}
+}
+
+func TestTTL(t *testing.T) {
+	back := newSlow(t, "a")
+	c := New(back, Options{TTL: time.Minute, NegativeTTL: time.Second})
+	now := time.Now()
+	c.now = func() time.Time { return now }
+
+	mustGet(t, c, "a")
+	mustGet(t, c, "b")
+	now = now.Add(2 * time.Second)
+	mustGet(t, c, "a") // 仍在TTL内
+	mustGet(t, c, "b") // not found已过期
+	if back.count() != 3 {
+		t.Fatalf("backend Gets = %d, want 3", back.count())
+	}
+	now = now.Add(time.Minute)
+	mustGet(t, c, "a")
+	if back.count() != 4 {
+		t.Fatalf("backend Gets = %d, want 4", back.count())
+	}
+}
+
+func TestLRUEviction(t *testing.T) {
+	back := newSlow(t, "a", "b", "c")
+	c := New(back, Options{Size: 2})
+
+	mustGet(t, c, "a")
+	mustGet(t, c, "b")
+	mustGet(t, c, "a") // a变为最近使用
+	mustGet(t, c, "c") // 淘汰b
+	n := back.count()
+	mustGet(t, c, "a")
+	if back.count() != n {
+		t.Error("a was evicted, want b")
+	}
+	mustGet(t, c, "b")
+	if back.count() != n+1 {
+		t.Error("b is still cached")
+	}
+	if s := c.Stats(); s.Evictions != 2 || s.Entries != 2 {
+		t.Errorf("Stats = %+v, want 2 evictions and 2 entries", s)
+	}
+}
+
+func TestSingleFlight(t *testing.T) {
+	back := newSlow(t, "a")
+	back.gate = make(chan struct{})
+	back.enter = make(chan struct{}, 1)
+	c := New(back, Options{})
+
+	const n = 20
+	var wg sync.WaitGroup
+	for range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, ok, err := c.Get(context.Background(), "a"); err != nil || !ok {
+				t.Errorf("Get = %v, %v", ok, err)
+			}
+		}()
+	}
+	<-back.enter
+	// 等其余调用都挂到同一次回源上
+	for c.Stats().Misses < n {
+		time.Sleep(time.Millisecond)
+	}
+	close(back.gate)
+	wg.Wait()
+	if back.count() != 1 {
+		t.Errorf("backend Gets = %d, want 1", back.count())
+	}
+	if s := c.Stats(); s.Misses != n || s.Loads != 1 {
+		t.Errorf("Stats = %+v", s)
+	}
+}
+
+// 回源期间发生的修改：回源结果可能是旧值，不能写入缓存
+func TestInvalidateDuringLoad(t *testing.T) {
+	ctx := context.Background()
+	back := newSlow(t, "a")
+	back.gate = make(chan struct{})
+	back.enter = make(chan struct{}, 1)
+	c := New(back, Options{})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		c.Get(ctx, "a")
+	}()
+	<-back.enter
+	if _, _, err := c.MarkDone(ctx, "a", true); err != nil {
+		t.Fatal(err)
+	}
+	back.mu.Lock()
+	back.enter = nil
+	back.mu.Unlock()
+	close(back.gate)
+	<-done
+
+	if got, _ := mustGet(t, c, "a"); !got.Done {
+		t.Error("Get returned the value loaded before MarkDone")
+	}
+}
+
+// 回源的调用被取消，等待同一结果的其它调用应自己重新回源
+func TestLeaderCanceled(t *testing.T) {
+	back := newSlow(t, "a")
+	back.gate = make(chan struct{})
+	back.enter = make(chan struct{}, 2)
+	c := New(back, Options{})
+
+	leaderCtx, cancel := context.WithCancel(context.Background())
+	leaderDone := make(chan error, 1)
+	go func() {
+		_, _, err := c.Get(leaderCtx, "a")
+		leaderDone <- err
+	}()
+	<-back.enter
+
+	followerDone := make(chan bool, 1)
+	go func() {
+		_, ok, err := c.Get(context.Background(), "a")
+		followerDone <- ok && err == nil
+	}()
+	for c.Stats().Misses < 1 {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+	if err := <-leaderDone; err == nil {
+		t.Fatal("canceled leader returned no error")
+	}
+	<-back.enter
+	close(back.gate)
+	if !<-followerDone {
+		t.Error("follower did not retry after the leader was canceled")
+	}
+}
+
+// primaryRepo 记录Get是否带有读主库标记
+type primaryRepo struct {
+	repo.TaskRepo
+	primary bool
+}
+
+func (r *primaryRepo) Get(ctx context.Context, id string) (model.Task, bool, error) {
+	r.primary = repo.PrimaryReads(ctx)
+	return r.TaskRepo.Get(ctx, id)
+}
+
+func TestLoadReadsPrimary(t *testing.T) {
+	back := &primaryRepo{TaskRepo: memory.NewTaskRepo()}
+	mustGet(t, New(back, Options{}), "a")
+	if !back.primary {
+		t.Error("cache miss was not loaded from the primary")
+	}
+}
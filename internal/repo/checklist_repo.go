@@ -0,0 +1,19 @@
+package repo
+
+import (
+	"context"
+
+	"github.com/kitouo/taskhub/internal/model"
+)
+
+type ChecklistRepo interface {
+	Create(ctx context.Context, it model.ChecklistItem) (model.ChecklistItem, error)
+	Get(ctx context.Context, id string) (model.ChecklistItem, bool, error)
+	// List 任务下的全部检查项，按position、id升序
+	List(ctx context.Context, taskID string) ([]model.ChecklistItem, error)
+	// Update 修改文本与勾选状态
+	Update(ctx context.Context, it model.ChecklistItem) (model.ChecklistItem, bool, error)
+	Delete(ctx context.Context, id string) (bool, error)
+	// Reorder 把ids中各项的position依次设为0..n-1，不属于taskID的id被忽略
+	Reorder(ctx context.Context, taskID string, ids []string) error
+}
@@ -0,0 +1,19 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"github.com/kitouo/taskhub/internal/model"
+)
+
+type CommentRepo interface {
+	Create(ctx context.Context, c model.Comment) (model.Comment, error)
+	// Get 软删除的评论也会返回，由调用方判断DeletedAt
+	Get(ctx context.Context, id string) (model.Comment, bool, error)
+	// List 返回任务下未删除的评论（按created_at、id升序）及其总数
+	List(ctx context.Context, taskID string, limit, offset int) ([]model.Comment, int, error)
+	// Update 修改正文、提及与编辑时间，已删除的评论视为不存在
+	Update(ctx context.Context, c model.Comment) (model.Comment, bool, error)
+	SoftDelete(ctx context.Context, id string, at time.Time) (bool, error)
+}
@@ -0,0 +1,19 @@
+package repo
+
+import "context"
+
+type primaryReadsKey struct{}
+
+/*
+WithPrimaryReads 标记这个ctx上的读操作必须读主库（读己之写）
+配置了只读副本的实现据此跳过副本；没有副本的实现忽略该标记
+*/
+func WithPrimaryReads(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryReadsKey{}, true)
+}
+
+// PrimaryReads ctx是否带有WithPrimaryReads标记
+func PrimaryReads(ctx context.Context) bool {
+	v, _ := ctx.Value(primaryReadsKey{}).(bool)
+	return v
+}
@@ -0,0 +1,22 @@
+package repo
+
+import (
+	"context"
+
+	"github.com/kitouo/taskhub/internal/model"
+)
+
+type DependencyRepo interface {
+	/*
+		Add 插入一条边，已存在相同的边时忽略
+		check非nil时，与插入在同一把锁（SQL实现为事务内的SELECT ... FOR UPDATE）下拿全部已有边调用，
+		返回错误则不插入并原样返回；并发的Add因此不会绕过成环检查
+	*/
+	Add(ctx context.Context, d model.Dependency, check func(existing []model.Dependency) error) error
+	Remove(ctx context.Context, taskID, blockerID string) (bool, error)
+	// Blockers 返回taskIDs中各任务的所有阻塞边
+	Blockers(ctx context.Context, taskIDs []string) ([]model.Dependency, error)
+	// Dependents 返回被blockerID阻塞的所有边
+	Dependents(ctx context.Context, blockerID string) ([]model.Dependency, error)
+	All(ctx context.Context) ([]model.Dependency, error)
+}
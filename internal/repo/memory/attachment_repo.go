@@ -0,0 +1,61 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/kitouo/taskhub/internal/model"
+)
+
+type AttachmentRepo struct {
+	mu   sync.RWMutex
+	byID map[string]model.Attachment
+}
+
+func NewAttachmentRepo() *AttachmentRepo {
+	return &AttachmentRepo{byID: make(map[string]model.Attachment)}
+}
+
+func (r *AttachmentRepo) Create(ctx context.Context, a model.Attachment) (model.Attachment, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.byID[a.ID] = a
+	return a, nil
+}
+
+func (r *AttachmentRepo) Get(ctx context.Context, id string) (model.Attachment, bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	a, ok := r.byID[id]
+	return a, ok, nil
+}
+
+func (r *AttachmentRepo) List(ctx context.Context, taskID string) ([]model.Attachment, error) {
+	r.mu.RLock()
+	out := make([]model.Attachment, 0)
+	for _, a := range r.byID {
+		if a.TaskID == taskID {
+			out = append(out, a)
+		}
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(out, func(i, j int) bool {
+		if !out[i].CreatedAt.Equal(out[j].CreatedAt) {
+			return out[i].CreatedAt.Before(out[j].CreatedAt)
+		}
+		return out[i].ID < out[j].ID
+	})
+	return out, nil
+}
+
+func (r *AttachmentRepo) Delete(ctx context.Context, id string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.byID[id]; !ok {
+		return false, nil
+	}
+	delete(r.byID, id)
+	return true, nil
+}
@@ -0,0 +1,85 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/kitouo/taskhub/internal/model"
+)
+
+type ChecklistRepo struct {
+	mu   sync.RWMutex
+	byID map[string]model.ChecklistItem
+}
+
+func NewChecklistRepo() *ChecklistRepo {
+	return &ChecklistRepo{byID: make(map[string]model.ChecklistItem)}
+}
+
+func (r *ChecklistRepo) Create(ctx context.Context, it model.ChecklistItem) (model.ChecklistItem, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.byID[it.ID] = it
+	return it, nil
+}
+
+func (r *ChecklistRepo) Get(ctx context.Context, id string) (model.ChecklistItem, bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	it, ok := r.byID[id]
+	return it, ok, nil
+}
+
+func (r *ChecklistRepo) List(ctx context.Context, taskID string) ([]model.ChecklistItem, error) {
+	r.mu.RLock()
+	out := make([]model.ChecklistItem, 0)
+	for _, it := range r.byID {
+		if it.TaskID == taskID {
+			out = append(out, it)
+		}
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Position != out[j].Position {
+			return out[i].Position < out[j].Position
+		}
+		return out[i].ID < out[j].ID
+	})
+	return out, nil
+}
+
+func (r *ChecklistRepo) Update(ctx context.Context, it model.ChecklistItem) (model.ChecklistItem, bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	cur, ok := r.byID[it.ID]
+	if !ok {
+		return model.ChecklistItem{}, false, nil
+	}
+	cur.Text, cur.Checked = it.Text, it.Checked
+	r.byID[it.ID] = cur
+	return cur, true, nil
+}
+
+func (r *ChecklistRepo) Delete(ctx context.Context, id string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.byID[id]; !ok {
+		return false, nil
+	}
+	delete(r.byID, id)
+	return true, nil
+}
+
+func (r *ChecklistRepo) Reorder(ctx context.Context, taskID string, ids []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, id := range ids {
+		if it, ok := r.byID[id]; ok && it.TaskID == taskID {
+			it.Position = i
+			r.byID[id] = it
+		}
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/kitouo/taskhub/internal/model"
+)
+
+type CommentRepo struct {
+	mu   sync.RWMutex
+	byID map[string]model.Comment
+}
+
+func NewCommentRepo() *CommentRepo {
+	return &CommentRepo{byID: make(map[string]model.Comment)}
+}
+
+// cloneComment 复制切片，避免调用方修改仓库内部状态
+func cloneComment(c model.Comment) model.Comment {
+	c.Mentions = slices.Clone(c.Mentions)
+	return c
+}
+
+func (r *CommentRepo) Create(ctx context.Context, c model.Comment) (model.Comment, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.byID[c.ID] = cloneComment(c)
+	return c, nil
+}
+
+func (r *CommentRepo) Get(ctx context.Context, id string) (model.Comment, bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	c, ok := r.byID[id]
+	return cloneComment(c), ok, nil
+}
+
+func (r *CommentRepo) List(ctx context.Context, taskID string, limit, offset int) ([]model.Comment, int, error) {
+	r.mu.RLock()
+	all := make([]model.Comment, 0)
+	for _, c := range r.byID {
+		if c.TaskID == taskID && c.DeletedAt == nil {
+			all = append(all, cloneComment(c))
+		}
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(all, func(i, j int) bool {
+		if !all[i].CreatedAt.Equal(all[j].CreatedAt) {
+			return all[i].CreatedAt.Before(all[j].CreatedAt)
+		}
+		return all[i].ID < all[j].ID
+	})
+
+	total := len(all)
+	if offset >= total {
+		return []model.Comment{}, total, nil
+	}
+	return all[offset:min(offset+limit, total)], total, nil
+}
+
+func (r *CommentRepo) Update(ctx context.Context, c model.Comment) (model.Comment, bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	cur, ok := r.byID[c.ID]
+	if !ok || cur.DeletedAt != nil {
+		return model.Comment{}, false, nil
+	}
+	cur.Body = c.Body
+	cur.Mentions = slices.Clone(c.Mentions)
+	cur.EditedAt = c.EditedAt
+	r.byID[c.ID] = cur
+	return cloneComment(cur), true, nil
+}
+
+func (r *CommentRepo) SoftDelete(ctx context.Context, id string, at time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	cur, ok := r.byID[id]
+	if !ok || cur.DeletedAt != nil {
+		return false, nil
+	}
+	cur.DeletedAt = &at
+	r.byID[id] = cur
+	return true, nil
+}
@@ -0,0 +1,85 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/kitouo/taskhub/internal/model"
+)
+
+type depKey struct{ taskID, blockerID string }
+
+type DependencyRepo struct {
+	mu    sync.RWMutex
+	edges map[depKey]model.Dependency
+}
+
+func NewDependencyRepo() *DependencyRepo {
+	return &DependencyRepo{edges: make(map[depKey]model.Dependency)}
+}
+
+func (r *DependencyRepo) Add(ctx context.Context, d model.Dependency, check func([]model.Dependency) error) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if check != nil {
+		if err := check(r.collectLocked(func(model.Dependency) bool { return true })); err != nil {
+			return err
+		}
+	}
+	k := depKey{d.TaskID, d.BlockerID}
+	if _, ok := r.edges[k]; !ok {
+		r.edges[k] = d
+	}
+	return nil
+}
+
+func (r *DependencyRepo) Remove(ctx context.Context, taskID, blockerID string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	k := depKey{taskID, blockerID}
+	if _, ok := r.edges[k]; !ok {
+		return false, nil
+	}
+	delete(r.edges, k)
+	return true, nil
+}
+
+func (r *DependencyRepo) Blockers(ctx context.Context, taskIDs []string) ([]model.Dependency, error) {
+	ids := idSet(taskIDs)
+	return r.collect(func(d model.Dependency) bool { return ids[d.TaskID] }), nil
+}
+
+func (r *DependencyRepo) Dependents(ctx context.Context, blockerID string) ([]model.Dependency, error) {
+	return r.collect(func(d model.Dependency) bool { return d.BlockerID == blockerID }), nil
+}
+
+func (r *DependencyRepo) All(ctx context.Context) ([]model.Dependency, error) {
+	return r.collect(func(model.Dependency) bool { return true }), nil
+}
+
+// collect 返回满足条件的边，按创建时间排序保证输出稳定
+func (r *DependencyRepo) collect(match func(model.Dependency) bool) []model.Dependency {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.collectLocked(match)
+}
+
+func (r *DependencyRepo) collectLocked(match func(model.Dependency) bool) []model.Dependency {
+	out := make([]model.Dependency, 0)
+	for _, d := range r.edges {
+		if match(d) {
+			out = append(out, d)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if !out[i].CreatedAt.Equal(out[j].CreatedAt) {
+			return out[i].CreatedAt.Before(out[j].CreatedAt)
+		}
+		if out[i].TaskID != out[j].TaskID {
+			return out[i].TaskID < out[j].TaskID
+		}
+		return out[i].BlockerID < out[j].BlockerID
+	})
+	return out
+}
@@ -0,0 +1,72 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/kitouo/taskhub/internal/model"
+)
+
+type reminderKey struct {
+	taskID string
+	kind   model.ReminderKind
+	dueAt  int64
+}
+
+func reminderKeyOf(r model.Reminder) reminderKey {
+	return reminderKey{r.TaskID, r.Kind, r.DueAt.UnixNano()}
+}
+
+type ReminderRepo struct {
+	mu   sync.Mutex
+	sent map[reminderKey]model.Reminder
+}
+
+func NewReminderRepo() *ReminderRepo {
+	return &ReminderRepo{sent: make(map[reminderKey]model.Reminder)}
+}
+
+func (r *ReminderRepo) Claim(ctx context.Context, rem model.Reminder) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	k := reminderKeyOf(rem)
+	if _, ok := r.sent[k]; ok {
+		return false, nil
+	}
+	r.sent[k] = rem
+	return true, nil
+}
+
+func (r *ReminderRepo) Release(ctx context.Context, rem model.Reminder) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.sent, reminderKeyOf(rem))
+	return nil
+}
+
+type lease struct {
+	holder    string
+	expiresAt time.Time
+}
+
+// LeaseRepo 单进程内的租约，memory模式只有一个实例，主要用于保持接口一致
+type LeaseRepo struct {
+	mu     sync.Mutex
+	leases map[string]lease
+}
+
+func NewLeaseRepo() *LeaseRepo {
+	return &LeaseRepo{leases: make(map[string]lease)}
+}
+
+func (r *LeaseRepo) Acquire(ctx context.Context, name, holder string, ttl time.Duration) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	if l, ok := r.leases[name]; ok && l.holder != holder && now.Before(l.expiresAt) {
+		return false, nil
+	}
+	r.leases[name] = lease{holder: holder, expiresAt: now.Add(ttl)}
+	return true, nil
+}
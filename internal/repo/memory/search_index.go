@@ -0,0 +1,98 @@
+package memory
+
+import (
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/kitouo/taskhub/internal/search"
+)
+
+/*
+invertedIndex 内存倒排索引：词元 -> 任务id -> 词频
+vocab 保持有序，前缀匹配时用二分找到起点后顺序扫描
+并发安全由TaskRepo的锁保证
+*/
+type invertedIndex struct {
+	postings map[string]map[string]int
+	vocab    []string
+	docs     int
+}
+
+func newInvertedIndex() *invertedIndex {
+	return &invertedIndex{postings: make(map[string]map[string]int)}
+}
+
+func (ix *invertedIndex) add(id, text string) {
+	ix.docs++
+	for _, tok := range search.Tokenize(text) {
+		p, ok := ix.postings[tok]
+		if !ok {
+			p = make(map[string]int)
+			ix.postings[tok] = p
+			i := sort.SearchStrings(ix.vocab, tok)
+			ix.vocab = append(ix.vocab, "")
+			copy(ix.vocab[i+1:], ix.vocab[i:])
+			ix.vocab[i] = tok
+		}
+		p[id]++
+	}
+}
+
+func (ix *invertedIndex) remove(id, text string) {
+	ix.docs--
+	for _, tok := range search.Tokenize(text) {
+		p, ok := ix.postings[tok]
+		if !ok {
+			continue
+		}
+		delete(p, id)
+		if len(p) == 0 {
+			delete(ix.postings, tok)
+			i := sort.SearchStrings(ix.vocab, tok)
+			if i < len(ix.vocab) && ix.vocab[i] == tok {
+				ix.vocab = append(ix.vocab[:i], ix.vocab[i+1:]...)
+			}
+		}
+	}
+}
+
+// prefixWeight 前缀命中相对完整命中的权重
+const prefixWeight = 0.5
+
+/*
+search 返回命中全部terms的任务id及其相关度
+相关度为各词元 tf * idf 之和，前缀命中打折
+*/
+func (ix *invertedIndex) search(terms []string) map[string]float64 {
+	var scores map[string]float64
+	for _, term := range terms {
+		termScores := make(map[string]float64)
+		for i := sort.SearchStrings(ix.vocab, term); i < len(ix.vocab) && strings.HasPrefix(ix.vocab[i], term); i++ {
+			tok := ix.vocab[i]
+			p := ix.postings[tok]
+			idf := math.Log(1 + float64(ix.docs)/float64(len(p)))
+			w := 1.0
+			if tok != term {
+				w = prefixWeight
+			}
+			for id, tf := range p {
+				termScores[id] += float64(tf) * idf * w
+			}
+		}
+
+		// 所有词元都必须命中：与已有结果取交集
+		if scores == nil {
+			scores = termScores
+			continue
+		}
+		for id := range scores {
+			if s, ok := termScores[id]; ok {
+				scores[id] += s
+			} else {
+				delete(scores, id)
+			}
+		}
+	}
+	return scores
+}
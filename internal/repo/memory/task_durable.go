@@ -0,0 +1,117 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/kitouo/taskhub/internal/model"
+	"github.com/kitouo/taskhub/internal/wal"
+)
+
+/*
+walOp 日志里记录的是修改后的结果而不是操作本身：
+Put为写入后的完整任务（新建或覆盖），Del为被删除的id；重放时按顺序套用即可，与业务逻辑无关
+*/
+type walOp struct {
+	Put *model.Task `json:"put,omitempty"`
+	Del string      `json:"del,omitempty"`
+}
+
+/*
+OpenTaskRepo 打开dir下持久化的TaskRepo：加载快照并重放日志后返回
+之后每次修改都会在返回前写入日志（fsync时机由opts.Sync决定）；写日志失败时撤销这次修改并返回错误
+调用方应定期调用Snapshot压缩日志，退出时调用Close
+*/
+func OpenTaskRepo(dir string, opts wal.Options) (*TaskRepo, wal.Recovery, error) {
+	r := NewTaskRepo()
+	log, rec, err := wal.Open(dir, opts, r.loadSnapshot, r.replay)
+	if err != nil {
+		return nil, rec, err
+	}
+	r.log = log
+	return r, rec, nil
+}
+
+/*
+Snapshot 写出当前全部任务并删除已被覆盖的日志段
+只在切换日志段、复制数据时持有写锁，编码和写文件都在锁外进行
+*/
+func (r *TaskRepo) Snapshot(ctx context.Context) error {
+	if r.log == nil {
+		return nil
+	}
+	r.mu.Lock()
+	seq, err := r.log.Rotate()
+	tasks := make([]model.Task, len(r.order))
+	for i, id := range r.order {
+		tasks[i] = r.byID[id]
+	}
+	r.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	return r.log.WriteSnapshot(seq, func(w io.Writer) error {
+		enc := json.NewEncoder(w)
+		for _, t := range tasks {
+			if err := enc.Encode(t); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Close 关闭日志；纯内存的TaskRepo上调用是no-op
+func (r *TaskRepo) Close() error {
+	if r.log == nil {
+		return nil
+	}
+	return r.log.Close()
+}
+
+// persist 把一次修改写入日志；失败时按undos撤销内存中的修改。调用方持有写锁
+func (r *TaskRepo) persist(ops []walOp, undos ...undo) error {
+	if r.log == nil || len(ops) == 0 {
+		return nil
+	}
+	payload, err := json.Marshal(ops)
+	if err == nil {
+		err = r.log.Append(payload)
+	}
+	if err != nil {
+		r.revert(undos)
+		return fmt.Errorf("persist task change: %w", err)
+	}
+	return nil
+}
+
+// loadSnapshot 快照为按创建顺序排列的JSON Lines
+func (r *TaskRepo) loadSnapshot(rd io.Reader) error {
+	dec := json.NewDecoder(rd)
+	for dec.More() {
+		var t model.Task
+		if err := dec.Decode(&t); err != nil {
+			return err
+		}
+		r.create(t)
+	}
+	return nil
+}
+
+func (r *TaskRepo) replay(payload []byte) error {
+	var ops []walOp
+	if err := json.Unmarshal(payload, &ops); err != nil {
+		return err
+	}
+	for _, op := range ops {
+		if op.Put != nil {
+			r.create(*op.Put)
+		} else {
+			r.delete(op.Del)
+		}
+	}
+	return nil
+}
@@ -2,43 +2,152 @@ package memory
 
 import (
 	"context"
+	"sort"
+	"strings"
 	"sync"
 
+	"github.com/kitouo/taskhub/internal/filter"
 	"github.com/kitouo/taskhub/internal/model"
+	"github.com/kitouo/taskhub/internal/repo"
+	"github.com/kitouo/taskhub/internal/wal"
 )
 
 type TaskRepo struct {
 	mu    sync.RWMutex
 	byID  map[string]model.Task
 	order []string
+	index *invertedIndex // 标题全文索引
+	// log 非nil时每次修改都先写入日志再返回（见OpenTaskRepo）
+	log *wal.Log
 }
 
 func NewTaskRepo() *TaskRepo {
 	return &TaskRepo{
-		byID: make(map[string]model.Task),
+		byID:  make(map[string]model.Task),
+		index: newInvertedIndex(),
 	}
 }
 
 func (r *TaskRepo) Create(ctx context.Context, task model.Task) (model.Task, error) {
+	task = repo.Normalize(task)
 	r.mu.Lock()
 	defer r.mu.Unlock()
-	r.byID[task.ID] = task
-	r.order = append(r.order, task.ID)
+	if _, exists := r.byID[task.ID]; exists {
+		return model.Task{}, repo.ErrConflict
+	}
+	u := r.undoFor(task.ID, r.log != nil)
+	r.create(task)
+	if err := r.persist([]walOp{{Put: &task}}, u); err != nil {
+		return model.Task{}, err
+	}
 	return task, nil
 }
 
-func (r *TaskRepo) List(ctx context.Context) ([]model.Task, error) {
+func (r *TaskRepo) List(ctx context.Context, q repo.ListQuery) ([]model.Task, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
+	ids := idSet(q.IDs)
 	out := make([]model.Task, 0, len(r.order))
 	for _, id := range r.order {
-		out = append(out, r.byID[id])
+		if ids != nil && !ids[id] {
+			continue
+		}
+		if t := r.byID[id]; filter.Match(q.Filter, t) {
+			out = append(out, t)
+		}
 	}
+	sortTasks(out, q.Sort)
 
 	return out, nil
 }
 
+// Each 先在锁内取出快照，回调时不持有锁
+func (r *TaskRepo) Each(ctx context.Context, q repo.ListQuery, fn func(model.Task) error) error {
+	q.Sort = repo.Sort{Field: repo.SortCreatedAt}
+	tasks, err := r.List(ctx, q)
+	if err != nil {
+		return err
+	}
+	for _, t := range tasks {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := fn(t); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *TaskRepo) LastRank(ctx context.Context, column string) (string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	last := ""
+	for _, t := range r.byID {
+		if t.Column == column && t.Rank > last {
+			last = t.Rank
+		}
+	}
+	return last, nil
+}
+
+func (r *TaskRepo) Count(ctx context.Context, q repo.ListQuery) (int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	ids := idSet(q.IDs)
+	n := 0
+	for id, t := range r.byID {
+		if ids != nil && !ids[id] {
+			continue
+		}
+		if filter.Match(q.Filter, t) {
+			n++
+		}
+	}
+	return n, nil
+}
+
+// idSet ids为nil时返回nil（不限制）
+func idSet(ids []string) map[string]bool {
+	if ids == nil {
+		return nil
+	}
+	set := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		set[id] = true
+	}
+	return set
+}
+
+// sortTasks 按Sort排序；Field为空时按created_at、id升序，与SQL实现的默认顺序一致
+func sortTasks(tasks []model.Task, s repo.Sort) {
+	sort.SliceStable(tasks, func(i, j int) bool {
+		a, b := tasks[i], tasks[j]
+		c := 0
+		switch s.Field {
+		case repo.SortTitle:
+			c = strings.Compare(strings.ToLower(a.Title), strings.ToLower(b.Title))
+		case repo.SortCreatedAt:
+			c = a.CreatedAt.Compare(b.CreatedAt)
+		case repo.SortRank:
+			c = strings.Compare(a.Rank, b.Rank)
+		}
+		if s.Desc {
+			c = -c
+		}
+		if c != 0 {
+			return c < 0
+		}
+		if c = a.CreatedAt.Compare(b.CreatedAt); c != 0 {
+			return c < 0
+		}
+		return a.ID < b.ID
+	})
+}
+
 func (r *TaskRepo) Get(ctx context.Context, id string) (model.Task, bool, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
@@ -50,13 +159,249 @@ func (r *TaskRepo) MarkDone(ctx context.Context, id string, done bool) (model.Ta
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	task, ok := r.byID[id]
+	u := r.undoFor(id, r.log != nil)
+	task, ok := r.update(id, repo.TaskPatch{Done: &done})
+	if !ok {
+		return model.Task{}, false, nil
+	}
+	if err := r.persist([]walOp{{Put: &task}}, u); err != nil {
+		return model.Task{}, false, err
+	}
+	return task, true, nil
+}
 
+func (r *TaskRepo) Update(ctx context.Context, id string, p repo.TaskPatch) (model.Task, bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	u := r.undoFor(id, r.log != nil)
+	task, ok := r.update(id, p)
 	if !ok {
 		return model.Task{}, false, nil
 	}
+	if err := r.persist([]walOp{{Put: &task}}, u); err != nil {
+		return model.Task{}, false, err
+	}
+	return task, true, nil
+}
 
-	task.Done = done
-	r.byID[id] = task
+// SetParent 检查与修改都在写锁内完成
+func (r *TaskRepo) SetParent(ctx context.Context, id, parentID string, check func([]string) error) (model.Task, bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.byID[id]; !ok {
+		return model.Task{}, false, nil
+	}
+	if check != nil {
+		ancestors, _ := repo.Ancestors(id, parentID, func(cur string) (string, bool, error) {
+			t, ok := r.byID[cur]
+			return t.ParentID, ok, nil
+		})
+		if err := check(ancestors); err != nil {
+			return model.Task{}, true, err
+		}
+	}
+	u := r.undoFor(id, r.log != nil)
+	task, _ := r.update(id, repo.TaskPatch{ParentID: &parentID})
+	if err := r.persist([]walOp{{Put: &task}}, u); err != nil {
+		return model.Task{}, false, err
+	}
 	return task, true, nil
 }
+
+func (r *TaskRepo) Delete(ctx context.Context, id string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	u := r.undoFor(id, r.log != nil)
+	if !r.delete(id) {
+		return false, nil
+	}
+	if err := r.persist([]walOp{{Del: id}}, u); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+/*
+Batch 在同一把锁内执行全部操作
+atomic模式下记录每一步之前的状态，失败时按相反顺序撤销
+整批的实际变更作为一条日志记录写入，重放时不会只应用一半
+*/
+func (r *TaskRepo) Batch(ctx context.Context, ops []repo.BatchOp, atomic bool) ([]repo.BatchResult, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	results := make([]repo.BatchResult, len(ops))
+	undos := make([]undo, 0, len(ops))
+	changes := make([]walOp, 0, len(ops))
+	failed := -1
+
+	for i, op := range ops {
+		id := op.ID
+		if op.Kind == repo.BatchCreate {
+			id = op.Task.ID
+		}
+		undos = append(undos, r.undoFor(id, atomic || r.log != nil))
+
+		switch op.Kind {
+		case repo.BatchCreate:
+			if _, exists := r.byID[id]; exists {
+				results[i] = repo.BatchResult{Found: true, Err: repo.ErrConflict}
+				break
+			}
+			t := repo.Normalize(op.Task)
+			r.create(t)
+			results[i] = repo.BatchResult{Task: t, Found: true}
+			changes = append(changes, walOp{Put: &results[i].Task})
+		case repo.BatchUpdate:
+			t, ok := r.update(op.ID, op.Patch)
+			results[i] = repo.BatchResult{Task: t, Found: ok}
+			if ok {
+				changes = append(changes, walOp{Put: &results[i].Task})
+			}
+		case repo.BatchDelete:
+			results[i] = repo.BatchResult{Found: r.delete(op.ID)}
+			if results[i].Found {
+				changes = append(changes, walOp{Del: op.ID})
+			}
+		}
+
+		if atomic && results[i].Failed() {
+			failed = i
+			break
+		}
+	}
+
+	if failed < 0 {
+		if err := r.persist(changes, undos...); err != nil {
+			return nil, err
+		}
+		return results, nil
+	}
+
+	r.revert(undos)
+	for i := range results {
+		if i != failed {
+			results[i] = repo.BatchResult{Found: true, Err: repo.ErrBatchAborted}
+		}
+	}
+	return results, nil
+}
+
+func (r *TaskRepo) Search(ctx context.Context, q repo.SearchQuery) ([]repo.SearchHit, int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	scores := r.index.search(q.Terms)
+	hits := make([]repo.SearchHit, 0, len(scores))
+	for id, score := range scores {
+		hits = append(hits, repo.SearchHit{Task: r.byID[id], Score: score})
+	}
+
+	// 相关度降序；同分时按创建时间，保证分页稳定
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		if !hits[i].Task.CreatedAt.Equal(hits[j].Task.CreatedAt) {
+			return hits[i].Task.CreatedAt.Before(hits[j].Task.CreatedAt)
+		}
+		return hits[i].Task.ID < hits[j].Task.ID
+	})
+
+	total := len(hits)
+	start := min(q.Offset, total)
+	end := min(start+q.Limit, total)
+	return hits[start:end], total, nil
+}
+
+// 以下helper均要求调用方已持有写锁
+
+// undo 修改前某个id的状态，用于回滚
+type undo struct {
+	id      string
+	prev    model.Task
+	existed bool
+	pos     int // 在order中的位置
+}
+
+/*
+undoFor 记录id修改前的状态；查找在order中的位置是O(n)的，
+只在可能撤销（withPos）且任务已存在时才计算，其它情况pos为-1
+*/
+func (r *TaskRepo) undoFor(id string, withPos bool) undo {
+	prev, existed := r.byID[id]
+	u := undo{id: id, prev: prev, existed: existed, pos: -1}
+	if withPos && existed {
+		u.pos = r.indexOf(id)
+	}
+	return u
+}
+
+// revert 逆序恢复到每一步之前的状态
+func (r *TaskRepo) revert(undos []undo) {
+	for i := len(undos) - 1; i >= 0; i-- {
+		u := undos[i]
+		r.delete(u.id)
+		if u.existed {
+			r.byID[u.id] = u.prev
+			r.index.add(u.id, u.prev.Title)
+			r.insertAt(u.id, u.pos)
+		}
+	}
+}
+
+func (r *TaskRepo) create(task model.Task) {
+	if old, exists := r.byID[task.ID]; exists {
+		r.index.remove(old.ID, old.Title)
+	} else {
+		r.order = append(r.order, task.ID)
+	}
+	r.byID[task.ID] = task
+	r.index.add(task.ID, task.Title)
+}
+
+func (r *TaskRepo) update(id string, p repo.TaskPatch) (model.Task, bool) {
+	old, ok := r.byID[id]
+	if !ok {
+		return model.Task{}, false
+	}
+	task := p.Apply(old)
+	r.byID[id] = task
+	if task.Title != old.Title {
+		r.index.remove(id, old.Title)
+		r.index.add(id, task.Title)
+	}
+	return task, true
+}
+
+func (r *TaskRepo) delete(id string) bool {
+	old, ok := r.byID[id]
+	if !ok {
+		return false
+	}
+	delete(r.byID, id)
+	r.index.remove(id, old.Title)
+	if i := r.indexOf(id); i >= 0 {
+		r.order = append(r.order[:i], r.order[i+1:]...)
+	}
+	return true
+}
+
+func (r *TaskRepo) indexOf(id string) int {
+	for i, v := range r.order {
+		if v == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func (r *TaskRepo) insertAt(id string, pos int) {
+	if pos < 0 || pos > len(r.order) {
+		r.order = append(r.order, id)
+		return
+	}
+	r.order = append(r.order, "")
+	copy(r.order[pos+1:], r.order[pos:])
+	r.order[pos] = id
+}
@@ -0,0 +1,31 @@
+package memory
+
+import (
+	"testing"
+
+	"github.com/kitouo/taskhub/internal/repo"
+	"github.com/kitouo/taskhub/internal/repo/repotest"
+	"github.com/kitouo/taskhub/internal/wal"
+)
+
+func TestTaskRepo(t *testing.T) {
+	repotest.Run(t, func(t *testing.T) repo.TaskRepo { return NewTaskRepo() })
+}
+
+func TestDurableTaskRepo(t *testing.T) {
+	repotest.Run(t, func(t *testing.T) repo.TaskRepo {
+		r, _, err := OpenTaskRepo(t.TempDir(), wal.Options{Sync: wal.SyncNone})
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { r.Close() })
+		return r
+	})
+}
+
+func TestTimeEntryRepo(t *testing.T) {
+	repotest.RunTimeEntries(t, func(t *testing.T) (repo.TimeEntryRepo, repo.TaskRepo) {
+		tasks := NewTaskRepo()
+		return NewTimeEntryRepo(tasks), tasks
+	})
+}
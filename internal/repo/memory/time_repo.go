@@ -0,0 +1,160 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/kitouo/taskhub/internal/model"
+	"github.com/kitouo/taskhub/internal/repo"
+)
+
+/*
+TimeEntryRepo 内存实现
+按项目汇总时需要任务的project，因此持有TaskRepo（任意实现均可）
+*/
+type TimeEntryRepo struct {
+	mu    sync.RWMutex
+	byID  map[string]model.TimeEntry
+	tasks repo.TaskRepo
+}
+
+func NewTimeEntryRepo(tasks repo.TaskRepo) *TimeEntryRepo {
+	return &TimeEntryRepo{byID: make(map[string]model.TimeEntry), tasks: tasks}
+}
+
+func (r *TimeEntryRepo) Create(ctx context.Context, e model.TimeEntry) (model.TimeEntry, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if e.End == nil {
+		if _, ok := r.running(e.UserID); ok {
+			return model.TimeEntry{}, repo.ErrTimerRunning
+		}
+	}
+	r.byID[e.ID] = e
+	return e, nil
+}
+
+func (r *TimeEntryRepo) Get(ctx context.Context, id string) (model.TimeEntry, bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	e, ok := r.byID[id]
+	return e, ok, nil
+}
+
+func (r *TimeEntryRepo) List(ctx context.Context, taskID string) ([]model.TimeEntry, error) {
+	r.mu.RLock()
+	out := make([]model.TimeEntry, 0)
+	for _, e := range r.byID {
+		if e.TaskID == taskID {
+			out = append(out, e)
+		}
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(out, func(i, j int) bool {
+		if !out[i].Start.Equal(out[j].Start) {
+			return out[i].Start.Before(out[j].Start)
+		}
+		return out[i].ID < out[j].ID
+	})
+	return out, nil
+}
+
+func (r *TimeEntryRepo) Running(ctx context.Context, userID string) (model.TimeEntry, bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	e, ok := r.running(userID)
+	return e, ok, nil
+}
+
+// running 调用方需持有锁
+func (r *TimeEntryRepo) running(userID string) (model.TimeEntry, bool) {
+	for _, e := range r.byID {
+		if e.UserID == userID && e.End == nil {
+			return e, true
+		}
+	}
+	return model.TimeEntry{}, false
+}
+
+func (r *TimeEntryRepo) Stop(ctx context.Context, id string, end time.Time) (model.TimeEntry, bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	e, ok := r.byID[id]
+	if !ok || e.End != nil {
+		return model.TimeEntry{}, false, nil
+	}
+	end = end.UTC()
+	e.End = &end
+	r.byID[id] = e
+	return e, true, nil
+}
+
+func (r *TimeEntryRepo) Delete(ctx context.Context, id string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.byID[id]; !ok {
+		return false, nil
+	}
+	delete(r.byID, id)
+	return true, nil
+}
+
+func (r *TimeEntryRepo) Report(ctx context.Context, q repo.TimeReportQuery) ([]model.TimeReportRow, error) {
+	type part struct {
+		taskID, userID string
+		seconds        int64
+	}
+	r.mu.RLock()
+	parts := make([]part, 0)
+	for _, e := range r.byID {
+		if e.End == nil || !e.Start.Before(q.To) || !e.End.After(q.From) {
+			continue
+		}
+		start, end := e.Start, *e.End
+		if start.Before(q.From) {
+			start = q.From
+		}
+		if end.After(q.To) {
+			end = q.To
+		}
+		parts = append(parts, part{e.TaskID, e.UserID, int64(end.Sub(start) / time.Second)})
+	}
+	r.mu.RUnlock()
+
+	rows := make(map[string]*model.TimeReportRow)
+	for _, p := range parts {
+		var key string
+		switch q.GroupBy {
+		case model.TimeByTask:
+			key = p.taskID
+		case model.TimeByUser:
+			key = p.userID
+		default:
+			// 任务已被删除时归入空项目，与MySQL的LEFT JOIN一致
+			t, _, _ := r.tasks.Get(ctx, p.taskID)
+			key = t.Project
+		}
+		row, ok := rows[key]
+		if !ok {
+			row = &model.TimeReportRow{Key: key}
+			rows[key] = row
+		}
+		row.Seconds += p.seconds
+		row.Entries++
+	}
+
+	out := make([]model.TimeReportRow, 0, len(rows))
+	for _, row := range rows {
+		out = append(out, *row)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Seconds != out[j].Seconds {
+			return out[i].Seconds > out[j].Seconds
+		}
+		return out[i].Key < out[j].Key
+	})
+	return out, nil
+}
@@ -0,0 +1,115 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/kitouo/taskhub/internal/model"
+	"github.com/kitouo/taskhub/internal/repo"
+)
+
+type UserRepo struct {
+	mu   sync.RWMutex
+	byID map[string]model.User
+}
+
+func NewUserRepo() *UserRepo {
+	return &UserRepo{byID: make(map[string]model.User)}
+}
+
+func (r *UserRepo) Create(ctx context.Context, u model.User) (model.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.byID[u.ID]; ok {
+		return model.User{}, repo.ErrConflict
+	}
+	r.byID[u.ID] = u
+	return u, nil
+}
+
+func (r *UserRepo) Get(ctx context.Context, id string) (model.User, bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	u, ok := r.byID[id]
+	return u, ok, nil
+}
+
+func (r *UserRepo) List(ctx context.Context, active *bool) ([]model.User, error) {
+	r.mu.RLock()
+	out := make([]model.User, 0, len(r.byID))
+	for _, u := range r.byID {
+		if active == nil || u.Active == *active {
+			out = append(out, u)
+		}
+	}
+	r.mu.RUnlock()
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out, nil
+}
+
+func (r *UserRepo) Update(ctx context.Context, u model.User) (model.User, bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	cur, ok := r.byID[u.ID]
+	if !ok {
+		return model.User{}, false, nil
+	}
+	cur.DisplayName, cur.Email, cur.Active = u.DisplayName, u.Email, u.Active
+	r.byID[u.ID] = cur
+	return cur, true, nil
+}
+
+// AssignmentRepo 以任务为主键保存负责人集合
+type AssignmentRepo struct {
+	mu     sync.RWMutex
+	byTask map[string][]string
+}
+
+func NewAssignmentRepo() *AssignmentRepo {
+	return &AssignmentRepo{byTask: make(map[string][]string)}
+}
+
+func (r *AssignmentRepo) Replace(ctx context.Context, taskID string, userIDs []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(userIDs) == 0 {
+		delete(r.byTask, taskID)
+		return nil
+	}
+	r.byTask[taskID] = append([]string(nil), userIDs...)
+	return nil
+}
+
+func (r *AssignmentRepo) ByTasks(ctx context.Context, taskIDs []string) ([]model.Assignment, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := make([]model.Assignment, 0)
+	for _, tid := range taskIDs {
+		for _, uid := range r.byTask[tid] {
+			out = append(out, model.Assignment{TaskID: tid, UserID: uid})
+		}
+	}
+	return out, nil
+}
+
+func (r *AssignmentRepo) ByUsers(ctx context.Context, userIDs []string) ([]model.Assignment, error) {
+	users := idSet(userIDs)
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := make([]model.Assignment, 0)
+	for tid, uids := range r.byTask {
+		for _, uid := range uids {
+			if users[uid] {
+				out = append(out, model.Assignment{TaskID: tid, UserID: uid})
+			}
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].UserID != out[j].UserID {
+			return out[i].UserID < out[j].UserID
+		}
+		return out[i].TaskID < out[j].TaskID
+	})
+	return out, nil
+}
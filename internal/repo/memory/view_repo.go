@@ -0,0 +1,82 @@
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"github.com/kitouo/taskhub/internal/model"
+)
+
+type ViewRepo struct {
+	mu    sync.RWMutex
+	byID  map[string]model.SavedView
+	order []string
+}
+
+func NewViewRepo() *ViewRepo {
+	return &ViewRepo{
+		byID: make(map[string]model.SavedView),
+	}
+}
+
+func (r *ViewRepo) Create(ctx context.Context, v model.SavedView) (model.SavedView, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.byID[v.ID]; !exists {
+		r.order = append(r.order, v.ID)
+	}
+	r.byID[v.ID] = v
+	return v, nil
+}
+
+func (r *ViewRepo) Get(ctx context.Context, id string) (model.SavedView, bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	v, ok := r.byID[id]
+	return v, ok, nil
+}
+
+func (r *ViewRepo) ListVisible(ctx context.Context, ownerID string) ([]model.SavedView, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	out := make([]model.SavedView, 0)
+	for _, id := range r.order {
+		v := r.byID[id]
+		if v.OwnerID == ownerID || v.Visibility == model.VisibilityTeam {
+			out = append(out, v)
+		}
+	}
+	return out, nil
+}
+
+func (r *ViewRepo) Update(ctx context.Context, v model.SavedView) (model.SavedView, bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	old, ok := r.byID[v.ID]
+	if !ok {
+		return model.SavedView{}, false, nil
+	}
+	v.OwnerID = old.OwnerID
+	v.CreatedAt = old.CreatedAt
+	r.byID[v.ID] = v
+	return v, true, nil
+}
+
+func (r *ViewRepo) Delete(ctx context.Context, id string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.byID[id]; !ok {
+		return false, nil
+	}
+	delete(r.byID, id)
+	for i, v := range r.order {
+		if v == id {
+			r.order = append(r.order[:i], r.order[i+1:]...)
+			break
+		}
+	}
+	return true, nil
+}
@@ -0,0 +1,88 @@
+package mysqlrepo
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/kitouo/taskhub/internal/model"
+)
+
+type AttachmentRepo struct {
+	db *sql.DB
+}
+
+func NewAttachmentRepo(db *sql.DB) *AttachmentRepo {
+	return &AttachmentRepo{db: db}
+}
+
+const attachmentColumns = `id, task_id, name, size, content_type, sha256, uploader_id, blob_key, created_at`
+
+func scanAttachment(s scanner) (model.Attachment, error) {
+	var a model.Attachment
+	if err := s.Scan(&a.ID, &a.TaskID, &a.Name, &a.Size, &a.ContentType, &a.SHA256, &a.UploaderID, &a.BlobKey, &a.CreatedAt); err != nil {
+		return model.Attachment{}, err
+	}
+	a.CreatedAt = a.CreatedAt.UTC()
+	return a, nil
+}
+
+func (r *AttachmentRepo) Create(ctx context.Context, a model.Attachment) (model.Attachment, error) {
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO attachments(`+attachmentColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		a.ID, a.TaskID, a.Name, a.Size, a.ContentType, a.SHA256, a.UploaderID, a.BlobKey, a.CreatedAt.UTC(),
+	)
+	if err != nil {
+		return model.Attachment{}, fmt.Errorf("insert attachment: %w", err)
+	}
+	return a, nil
+}
+
+func (r *AttachmentRepo) Get(ctx context.Context, id string) (model.Attachment, bool, error) {
+	a, err := scanAttachment(r.db.QueryRowContext(ctx,
+		`SELECT `+attachmentColumns+` FROM attachments WHERE id = ?`, id,
+	))
+	if err == sql.ErrNoRows {
+		return model.Attachment{}, false, nil
+	}
+	if err != nil {
+		return model.Attachment{}, false, fmt.Errorf("get attachment: %w", err)
+	}
+	return a, true, nil
+}
+
+func (r *AttachmentRepo) List(ctx context.Context, taskID string) ([]model.Attachment, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT `+attachmentColumns+` FROM attachments WHERE task_id = ? ORDER BY created_at ASC, id ASC`,
+		taskID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query attachments: %w", err)
+	}
+	defer rows.Close()
+
+	out := make([]model.Attachment, 0)
+	for rows.Next() {
+		a, err := scanAttachment(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan: %w", err)
+		}
+		out = append(out, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows err: %w", err)
+	}
+	return out, nil
+}
+
+func (r *AttachmentRepo) Delete(ctx context.Context, id string) (bool, error) {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM attachments WHERE id = ?`, id)
+	if err != nil {
+		return false, fmt.Errorf("delete attachment: %w", err)
+	}
+	aff, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("rows affected: %w", err)
+	}
+	return aff > 0, nil
+}
@@ -0,0 +1,124 @@
+package mysqlrepo
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/kitouo/taskhub/internal/model"
+)
+
+type ChecklistRepo struct {
+	db *sql.DB
+}
+
+func NewChecklistRepo(db *sql.DB) *ChecklistRepo {
+	return &ChecklistRepo{db: db}
+}
+
+const checklistColumns = `id, task_id, text, checked, position, created_at`
+
+func scanChecklistItem(s scanner) (model.ChecklistItem, error) {
+	var (
+		it      model.ChecklistItem
+		checked int
+	)
+	if err := s.Scan(&it.ID, &it.TaskID, &it.Text, &checked, &it.Position, &it.CreatedAt); err != nil {
+		return model.ChecklistItem{}, err
+	}
+	it.Checked = checked == 1
+	it.CreatedAt = it.CreatedAt.UTC()
+	return it, nil
+}
+
+func (r *ChecklistRepo) Create(ctx context.Context, it model.ChecklistItem) (model.ChecklistItem, error) {
+	if _, err := r.db.ExecContext(ctx,
+		`INSERT INTO checklist_items(`+checklistColumns+`) VALUES (?, ?, ?, ?, ?, ?)`,
+		it.ID, it.TaskID, it.Text, it.Checked, it.Position, it.CreatedAt.UTC(),
+	); err != nil {
+		return model.ChecklistItem{}, fmt.Errorf("insert checklist item: %w", err)
+	}
+	return it, nil
+}
+
+func (r *ChecklistRepo) Get(ctx context.Context, id string) (model.ChecklistItem, bool, error) {
+	it, err := scanChecklistItem(r.db.QueryRowContext(ctx,
+		`SELECT `+checklistColumns+` FROM checklist_items WHERE id = ?`, id,
+	))
+	if err == sql.ErrNoRows {
+		return model.ChecklistItem{}, false, nil
+	}
+	if err != nil {
+		return model.ChecklistItem{}, false, fmt.Errorf("get checklist item: %w", err)
+	}
+	return it, true, nil
+}
+
+func (r *ChecklistRepo) List(ctx context.Context, taskID string) ([]model.ChecklistItem, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT `+checklistColumns+` FROM checklist_items WHERE task_id = ? ORDER BY position ASC, id ASC`,
+		taskID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query checklist items: %w", err)
+	}
+	defer rows.Close()
+
+	out := make([]model.ChecklistItem, 0)
+	for rows.Next() {
+		it, err := scanChecklistItem(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan: %w", err)
+		}
+		out = append(out, it)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows err: %w", err)
+	}
+	return out, nil
+}
+
+func (r *ChecklistRepo) Update(ctx context.Context, it model.ChecklistItem) (model.ChecklistItem, bool, error) {
+	if _, err := r.db.ExecContext(ctx,
+		`UPDATE checklist_items SET text = ?, checked = ? WHERE id = ?`,
+		it.Text, it.Checked, it.ID,
+	); err != nil {
+		return model.ChecklistItem{}, false, fmt.Errorf("update checklist item: %w", err)
+	}
+	// 与TaskRepo.Update相同，RowsAffected无法区分not found与值未变化，回读判断
+	return r.Get(ctx, it.ID)
+}
+
+func (r *ChecklistRepo) Delete(ctx context.Context, id string) (bool, error) {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM checklist_items WHERE id = ?`, id)
+	if err != nil {
+		return false, fmt.Errorf("delete checklist item: %w", err)
+	}
+	aff, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("rows affected: %w", err)
+	}
+	return aff > 0, nil
+}
+
+// Reorder 在一个事务里逐条更新position
+func (r *ChecklistRepo) Reorder(ctx context.Context, taskID string, ids []string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	for i, id := range ids {
+		if _, err := tx.ExecContext(ctx,
+			`UPDATE checklist_items SET position = ? WHERE id = ? AND task_id = ?`,
+			i, id, taskID,
+		); err != nil {
+			return fmt.Errorf("reorder checklist: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+package mysqlrepo
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/kitouo/taskhub/internal/model"
+)
+
+type CommentRepo struct {
+	db *sql.DB
+}
+
+func NewCommentRepo(db *sql.DB) *CommentRepo {
+	return &CommentRepo{db: db}
+}
+
+const commentColumns = `id, task_id, author_id, body, mentions, created_at, edited_at, deleted_at`
+
+// mentions以逗号分隔存储（用户名中不会出现逗号）
+func joinMentions(m []string) string { return strings.Join(m, ",") }
+
+func splitMentions(s string) []string {
+	if s == "" {
+		return []string{}
+	}
+	return strings.Split(s, ",")
+}
+
+func scanComment(s scanner) (model.Comment, error) {
+	var (
+		c        model.Comment
+		mentions string
+		edited   sql.NullTime
+		deleted  sql.NullTime
+	)
+	if err := s.Scan(&c.ID, &c.TaskID, &c.AuthorID, &c.Body, &mentions, &c.CreatedAt, &edited, &deleted); err != nil {
+		return model.Comment{}, err
+	}
+	c.CreatedAt = c.CreatedAt.UTC()
+	c.Mentions = splitMentions(mentions)
+	if edited.Valid {
+		t := edited.Time.UTC()
+		c.EditedAt = &t
+	}
+	if deleted.Valid {
+		t := deleted.Time.UTC()
+		c.DeletedAt = &t
+	}
+	return c, nil
+}
+
+func (r *CommentRepo) Create(ctx context.Context, c model.Comment) (model.Comment, error) {
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO comments(`+commentColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		c.ID, c.TaskID, c.AuthorID, c.Body, joinMentions(c.Mentions),
+		c.CreatedAt.UTC(), nullTime(c.EditedAt), nullTime(c.DeletedAt),
+	)
+	if err != nil {
+		return model.Comment{}, fmt.Errorf("insert comment: %w", err)
+	}
+	return c, nil
+}
+
+func (r *CommentRepo) Get(ctx context.Context, id string) (model.Comment, bool, error) {
+	c, err := scanComment(r.db.QueryRowContext(ctx,
+		`SELECT `+commentColumns+` FROM comments WHERE id = ?`, id,
+	))
+	if err == sql.ErrNoRows {
+		return model.Comment{}, false, nil
+	}
+	if err != nil {
+		return model.Comment{}, false, fmt.Errorf("get comment: %w", err)
+	}
+	return c, true, nil
+}
+
+func (r *CommentRepo) List(ctx context.Context, taskID string, limit, offset int) ([]model.Comment, int, error) {
+	var total int
+	if err := r.db.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM comments WHERE task_id = ? AND deleted_at IS NULL`, taskID,
+	).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("count comments: %w", err)
+	}
+
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT `+commentColumns+` FROM comments
+		 WHERE task_id = ? AND deleted_at IS NULL
+		 ORDER BY created_at ASC, id ASC LIMIT ? OFFSET ?`,
+		taskID, limit, offset,
+	)
+	if err != nil {
+		return nil, 0, fmt.Errorf("query comments: %w", err)
+	}
+	defer rows.Close()
+
+	out := make([]model.Comment, 0)
+	for rows.Next() {
+		c, err := scanComment(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("scan: %w", err)
+		}
+		out = append(out, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("rows err: %w", err)
+	}
+	return out, total, nil
+}
+
+func (r *CommentRepo) Update(ctx context.Context, c model.Comment) (model.Comment, bool, error) {
+	if _, err := r.db.ExecContext(ctx,
+		`UPDATE comments SET body = ?, mentions = ?, edited_at = ? WHERE id = ? AND deleted_at IS NULL`,
+		c.Body, joinMentions(c.Mentions), nullTime(c.EditedAt), c.ID,
+	); err != nil {
+		return model.Comment{}, false, fmt.Errorf("update comment: %w", err)
+	}
+
+	// 与TaskRepo.update相同：RowsAffected不可靠，回读判断
+	cur, ok, err := r.Get(ctx, c.ID)
+	if err != nil || !ok || cur.DeletedAt != nil {
+		return model.Comment{}, false, err
+	}
+	return cur, true, nil
+}
+
+func (r *CommentRepo) SoftDelete(ctx context.Context, id string, at time.Time) (bool, error) {
+	res, err := r.db.ExecContext(ctx,
+		`UPDATE comments SET deleted_at = ? WHERE id = ? AND deleted_at IS NULL`,
+		at.UTC(), id,
+	)
+	if err != nil {
+		return false, fmt.Errorf("delete comment: %w", err)
+	}
+	aff, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("rows affected: %w", err)
+	}
+	return aff > 0, nil
+}
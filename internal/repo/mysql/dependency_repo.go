@@ -0,0 +1,172 @@
+package mysqlrepo
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"time"
+
+	"github.com/kitouo/taskhub/internal/model"
+)
+
+type DependencyRepo struct {
+	db *sql.DB
+}
+
+func NewDependencyRepo(db *sql.DB) *DependencyRepo {
+	return &DependencyRepo{db: db}
+}
+
+// addAttempts 插入仍可能与Remove等不持有命名锁的写入死锁；被回滚的一方重做整个事务
+const addAttempts = 3
+
+// depLockName 串行化带检查的Add的命名锁，depLockWaitSec 为等待它的秒数
+const (
+	depLockName    = "taskhub_task_deps"
+	depLockWaitSec = 5
+)
+
+func (r *DependencyRepo) Add(ctx context.Context, d model.Dependency, check func([]model.Dependency) error) error {
+	var err error
+	for range addAttempts {
+		if err = r.add(ctx, d, check); classify(err) != rolledBack {
+			return err
+		}
+	}
+	return err
+}
+
+/*
+add 在一个事务里完成检查与插入
+  - 带检查时先取命名锁depLockName，检查与插入因此在各Add之间串行，且不对表加行锁或间隙锁
+  - 事务在取锁之后才开始，读到的快照包含此前所有Add已提交的边
+*/
+func (r *DependencyRepo) add(ctx context.Context, d model.Dependency, check func([]model.Dependency) error) error {
+	// GET_LOCK是会话级的，事务必须开在同一个连接上
+	conn, err := r.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("dependency conn: %w", err)
+	}
+	defer conn.Close()
+
+	if check != nil {
+		if err := lockDependencies(ctx, conn); err != nil {
+			return err
+		}
+		defer unlockDependencies(ctx, conn)
+	}
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if check != nil {
+		existing, err := queryDependencies(ctx, tx, ``)
+		if err != nil {
+			return err
+		}
+		if err := check(existing); err != nil {
+			return err
+		}
+	}
+	// INSERT IGNORE：重复添加同一条边时保持幂等
+	if _, err := tx.ExecContext(ctx,
+		`INSERT IGNORE INTO task_dependencies(task_id, blocker_id, created_at) VALUES (?, ?, ?)`,
+		d.TaskID, d.BlockerID, d.CreatedAt.UTC(),
+	); err != nil {
+		return fmt.Errorf("insert dependency: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
+	}
+	return nil
+}
+
+func lockDependencies(ctx context.Context, conn *sql.Conn) error {
+	var locked sql.NullInt64
+	if err := conn.QueryRowContext(ctx, `SELECT GET_LOCK(?, ?)`, depLockName, depLockWaitSec).Scan(&locked); err != nil {
+		return fmt.Errorf("lock dependencies: %w", err)
+	}
+	if locked.Int64 != 1 {
+		return fmt.Errorf("lock dependencies: timeout")
+	}
+	return nil
+}
+
+// unlockDependencies 释放失败时丢弃该连接，断开会话即释放锁，避免带着锁回到连接池
+func unlockDependencies(ctx context.Context, conn *sql.Conn) {
+	if _, err := conn.ExecContext(context.WithoutCancel(ctx), `SELECT RELEASE_LOCK(?)`, depLockName); err != nil {
+		_ = conn.Raw(func(any) error { return driver.ErrBadConn })
+	}
+}
+
+func (r *DependencyRepo) Remove(ctx context.Context, taskID, blockerID string) (bool, error) {
+	res, err := r.db.ExecContext(ctx,
+		`DELETE FROM task_dependencies WHERE task_id = ? AND blocker_id = ?`,
+		taskID, blockerID,
+	)
+	if err != nil {
+		return false, fmt.Errorf("delete dependency: %w", err)
+	}
+	aff, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("rows affected: %w", err)
+	}
+	return aff > 0, nil
+}
+
+func (r *DependencyRepo) Blockers(ctx context.Context, taskIDs []string) ([]model.Dependency, error) {
+	if len(taskIDs) == 0 {
+		return []model.Dependency{}, nil
+	}
+	args := make([]any, len(taskIDs))
+	for i, id := range taskIDs {
+		args[i] = id
+	}
+	return r.query(ctx, `WHERE task_id IN (`+placeholders(len(taskIDs))+`)`, args...)
+}
+
+func (r *DependencyRepo) Dependents(ctx context.Context, blockerID string) ([]model.Dependency, error) {
+	return r.query(ctx, `WHERE blocker_id = ?`, blockerID)
+}
+
+func (r *DependencyRepo) All(ctx context.Context) ([]model.Dependency, error) {
+	return r.query(ctx, ``)
+}
+
+func (r *DependencyRepo) query(ctx context.Context, where string, args ...any) ([]model.Dependency, error) {
+	return queryDependencies(ctx, r.db, where, args...)
+}
+
+// queryDependencies 在q上查询依赖，q可以是连接池或事务
+func queryDependencies(ctx context.Context, q querier, where string, args ...any) ([]model.Dependency, error) {
+	rows, err := q.QueryContext(ctx,
+		`SELECT task_id, blocker_id, created_at FROM task_dependencies `+where+
+			` ORDER BY created_at ASC, task_id ASC, blocker_id ASC`,
+		args...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query dependencies: %w", err)
+	}
+	defer rows.Close()
+
+	out := make([]model.Dependency, 0)
+	for rows.Next() {
+		var (
+			d  model.Dependency
+			ct time.Time
+		)
+		if err := rows.Scan(&d.TaskID, &d.BlockerID, &ct); err != nil {
+			return nil, fmt.Errorf("scan: %w", err)
+		}
+		d.CreatedAt = ct.UTC()
+		out = append(out, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows err: %w", err)
+	}
+	return out, nil
+}
@@ -0,0 +1,87 @@
+package mysqlrepo
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/kitouo/taskhub/internal/model"
+)
+
+type ReminderRepo struct {
+	db *sql.DB
+}
+
+func NewReminderRepo(db *sql.DB) *ReminderRepo {
+	return &ReminderRepo{db: db}
+}
+
+func (r *ReminderRepo) Claim(ctx context.Context, rem model.Reminder) (bool, error) {
+	// 主键(task_id, kind, due_at)；INSERT IGNORE的RowsAffected为0即已登记
+	res, err := r.db.ExecContext(ctx,
+		`INSERT IGNORE INTO task_reminders(task_id, kind, due_at, sent_at) VALUES (?, ?, ?, ?)`,
+		rem.TaskID, string(rem.Kind), rem.DueAt.UTC(), rem.SentAt.UTC(),
+	)
+	if err != nil {
+		return false, fmt.Errorf("insert reminder: %w", err)
+	}
+	aff, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("rows affected: %w", err)
+	}
+	return aff == 1, nil
+}
+
+func (r *ReminderRepo) Release(ctx context.Context, rem model.Reminder) error {
+	_, err := r.db.ExecContext(ctx,
+		`DELETE FROM task_reminders WHERE task_id = ? AND kind = ? AND due_at = ?`,
+		rem.TaskID, string(rem.Kind), rem.DueAt.UTC(),
+	)
+	if err != nil {
+		return fmt.Errorf("delete reminder: %w", err)
+	}
+	return nil
+}
+
+type LeaseRepo struct {
+	db *sql.DB
+}
+
+func NewLeaseRepo(db *sql.DB) *LeaseRepo {
+	return &LeaseRepo{db: db}
+}
+
+/*
+Acquire 基于scheduler_leases表的租约
+  - 过期时间用数据库的NOW(6)计算，避免各副本之间的时钟偏差
+  - 条件UPDATE只会在“自己持有”或“已过期”时成功，最后回读holder判断结果
+*/
+func (r *LeaseRepo) Acquire(ctx context.Context, name, holder string, ttl time.Duration) (bool, error) {
+	us := ttl.Microseconds()
+
+	if _, err := r.db.ExecContext(ctx,
+		`INSERT IGNORE INTO scheduler_leases(name, holder, expires_at)
+		 VALUES (?, ?, DATE_ADD(NOW(6), INTERVAL ? MICROSECOND))`,
+		name, holder, us,
+	); err != nil {
+		return false, fmt.Errorf("insert lease: %w", err)
+	}
+
+	if _, err := r.db.ExecContext(ctx,
+		`UPDATE scheduler_leases
+		 SET holder = ?, expires_at = DATE_ADD(NOW(6), INTERVAL ? MICROSECOND)
+		 WHERE name = ? AND (holder = ? OR expires_at < NOW(6))`,
+		holder, us, name, holder,
+	); err != nil {
+		return false, fmt.Errorf("renew lease: %w", err)
+	}
+
+	var cur string
+	if err := r.db.QueryRowContext(ctx,
+		`SELECT holder FROM scheduler_leases WHERE name = ?`, name,
+	).Scan(&cur); err != nil {
+		return false, fmt.Errorf("read lease: %w", err)
+	}
+	return cur == holder, nil
+}
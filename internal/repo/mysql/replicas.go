@@ -0,0 +1,98 @@
+package mysqlrepo
+
+import (
+	"context"
+	"database/sql"
+	"sync/atomic"
+	"time"
+
+	"github.com/kitouo/taskhub/internal/repo"
+)
+
+// TaskRepoOption NewTaskRepo的可选配置
+type TaskRepoOption func(*TaskRepo)
+
+/*
+WithReplicas 把只读查询（Get/List/Count/Each/Search）按轮询分给这些只读副本
+  - 副本查询出错时改到主库重试，并在cooldown内不再选它；全部副本都不可用时读主库
+  - ctx带有repo.WithPrimaryReads标记时（例如刚做过修改的请求）始终读主库，避免读到复制延迟前的旧数据
+  - 写操作与事务总是走主库
+*/
+func WithReplicas(cooldown time.Duration, dbs ...*sql.DB) TaskRepoOption {
+	return func(r *TaskRepo) {
+		r.cooldown = cooldown
+		for _, db := range dbs {
+			r.replicas = append(r.replicas, &replica{db: db})
+		}
+	}
+}
+
+type replica struct {
+	db *sql.DB
+	// downUntil 最近一次出错后暂停使用到的时刻（UnixNano），0表示健康
+	downUntil atomic.Int64
+
+	reads, errors atomic.Int64
+}
+
+// ReplicaStats 单个副本的累计计数，不含DSN等敏感信息
+type ReplicaStats struct {
+	Healthy bool  `json:"healthy"`
+	Reads   int64 `json:"reads"`
+	Errors  int64 `json:"errors"`
+}
+
+// ReplicaStats 按配置顺序返回各副本的状态；没有配置副本时为nil
+func (r *TaskRepo) ReplicaStats() []ReplicaStats {
+	if len(r.replicas) == 0 {
+		return nil
+	}
+	now := time.Now().UnixNano()
+	out := make([]ReplicaStats, len(r.replicas))
+	for i, rep := range r.replicas {
+		out[i] = ReplicaStats{
+			Healthy: rep.downUntil.Load() <= now,
+			Reads:   rep.reads.Load(),
+			Errors:  rep.errors.Load(),
+		}
+	}
+	return out
+}
+
+// pickReplica 从上次的位置起轮询，跳过仍在cooldown中的副本
+func (r *TaskRepo) pickReplica() *replica {
+	n := len(r.replicas)
+	if n == 0 {
+		return nil
+	}
+	now := time.Now().UnixNano()
+	start := r.rr.Add(1)
+	for i := range n {
+		rep := r.replicas[(start+uint64(i))%uint64(n)]
+		if rep.downUntil.Load() <= now {
+			return rep
+		}
+	}
+	return nil
+}
+
+/*
+read 执行一次只读查询：优先交给副本，副本出错则在主库上重试；主库上的临时性错误按retry处理
+副本与主库各自套用queryTimeout：副本卡住超时只算副本故障，改读主库时重新计时
+fn可能被调用多次，不能在其中产生对外可见的副作用
+*/
+func (r *TaskRepo) read(ctx context.Context, fn func(ctx context.Context, q querier) error) error {
+	if !repo.PrimaryReads(ctx) {
+		if rep := r.pickReplica(); rep != nil {
+			rep.reads.Add(1)
+			err := r.withTimeout(ctx, func(ctx context.Context) error { return fn(ctx, rep.db) })
+			// 只有调用方自己的ctx结束才算取消，这时不是副本的问题，也没有必要再试主库
+			if err == nil || ctx.Err() != nil {
+				return err
+			}
+			rep.errors.Add(1)
+			rep.downUntil.Store(time.Now().Add(r.cooldown).UnixNano())
+		}
+	}
+	return r.retry(ctx, true, func(ctx context.Context) error { return fn(ctx, r.db) })
+}
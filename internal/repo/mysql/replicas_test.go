@@ -0,0 +1,117 @@
+package mysqlrepo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kitouo/taskhub/internal/repo"
+)
+
+// lazyDB sql.Open不会建立连接，这里只用指针区分各个库
+func lazyDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("mysql", "u:p@tcp(127.0.0.1:1)/x")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestReadRouting(t *testing.T) {
+	primary, a, b := lazyDB(t), lazyDB(t), lazyDB(t)
+	r := NewTaskRepo(primary, WithReplicas(time.Minute, a, b))
+	ctx := context.Background()
+
+	// route 记录一次read依次交给了哪些库，fail中的库返回错误
+	route := func(ctx context.Context, fail ...*sql.DB) ([]*sql.DB, error) {
+		var seen []*sql.DB
+		err := r.read(ctx, func(_ context.Context, q querier) error {
+			db := q.(*sql.DB)
+			seen = append(seen, db)
+			for _, f := range fail {
+				if f == db {
+					return errors.New("boom")
+				}
+			}
+			return nil
+		})
+		return seen, err
+	}
+
+	counts := map[*sql.DB]int{}
+	for range 4 {
+		seen, err := route(ctx)
+		if err != nil || len(seen) != 1 {
+			t.Fatalf("read = %v, %v", seen, err)
+		}
+		counts[seen[0]]++
+	}
+	if counts[a] != 2 || counts[b] != 2 {
+		t.Errorf("round robin counts a=%d b=%d primary=%d", counts[a], counts[b], counts[primary])
+	}
+
+	// 带读己之写标记时只读主库
+	if seen, _ := route(repo.WithPrimaryReads(ctx)); len(seen) != 1 || seen[0] != primary {
+		t.Errorf("primary reads went to %v", seen)
+	}
+
+	// 调用方取消不算副本故障
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	r.read(canceled, func(context.Context, querier) error { return context.Canceled })
+	if s := r.ReplicaStats(); !s[0].Healthy || !s[1].Healthy {
+		t.Errorf("context cancellation marked a replica down: %+v", s)
+	}
+
+	// 副本出错：本次改读主库，之后cooldown内不再选它
+	seen, err := route(ctx, a, b)
+	if err != nil || len(seen) != 2 || seen[1] != primary {
+		t.Fatalf("fallback read = %v, %v", seen, err)
+	}
+	failed := seen[0]
+	for range 3 {
+		if seen, _ := route(ctx); seen[0] == failed {
+			t.Fatal("replica in cooldown was picked")
+		}
+	}
+
+	// 全部副本不可用时直接读主库；主库的错误原样返回
+	route(ctx, a, b)
+	seen, err = route(ctx, primary)
+	if len(seen) != 1 || seen[0] != primary || err == nil {
+		t.Errorf("all replicas down: read = %v, %v", seen, err)
+	}
+	if s := r.ReplicaStats(); s[0].Healthy || s[1].Healthy || s[0].Errors != 1 || s[1].Errors != 1 {
+		t.Errorf("ReplicaStats = %+v", s)
+	}
+}
+
+// 副本卡住直到单次超时：记为副本故障，并在主库上用新的超时重试
+func TestReadHangingReplica(t *testing.T) {
+	primary, hung := lazyDB(t), lazyDB(t)
+	r := NewTaskRepo(primary, WithReplicas(time.Minute, hung), WithQueryTimeout(20*time.Millisecond))
+
+	var seen []*sql.DB
+	err := r.read(context.Background(), func(ctx context.Context, q querier) error {
+		seen = append(seen, q.(*sql.DB))
+		if q == hung {
+			<-ctx.Done()
+			return ctx.Err()
+		}
+		// 主库拿到的是新的超时，而不是副本用剩下的
+		if dl, ok := ctx.Deadline(); !ok || time.Until(dl) < 10*time.Millisecond {
+			t.Errorf("primary deadline = %v, %v", dl, ok)
+		}
+		return nil
+	})
+	if err != nil || len(seen) != 2 || seen[1] != primary {
+		t.Fatalf("read = %v, %v; want fallback to primary", seen, err)
+	}
+	if s := r.ReplicaStats(); s[0].Healthy || s[0].Errors != 1 {
+		t.Errorf("hung replica not marked down: %+v", s)
+	}
+}
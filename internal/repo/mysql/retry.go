@@ -0,0 +1,93 @@
+package mysqlrepo
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"syscall"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// WithQueryTimeout 每次执行（含每次重试、Each的每一页）的超时，0表示只受调用方ctx约束
+func WithQueryTimeout(d time.Duration) TaskRepoOption {
+	return func(r *TaskRepo) { r.queryTimeout = d }
+}
+
+// WithRetries 遇到临时性错误时最多重试的次数（不含第一次执行）
+func WithRetries(n int) TaskRepoOption {
+	return func(r *TaskRepo) { r.retries = n }
+}
+
+const (
+	erLockWaitTimeout = 1205
+	erLockDeadlock    = 1213
+)
+
+type transience int
+
+const (
+	permanent transience = iota
+	// rolledBack 死锁/锁等待超时：服务端已回滚这条语句（死锁时是整个事务），任何操作都可以重试
+	rolledBack
+	// connLost 连接在执行中途断开：不确定语句是否已生效，只能重试幂等操作
+	connLost
+)
+
+func classify(err error) transience {
+	var myErr *mysql.MySQLError
+	if errors.As(err, &myErr) {
+		if myErr.Number == erLockDeadlock || myErr.Number == erLockWaitTimeout {
+			return rolledBack
+		}
+		return permanent
+	}
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, mysql.ErrInvalidConn) ||
+		errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.EPIPE) {
+		return connLost
+	}
+	return permanent
+}
+
+func retryable(err error, idempotent bool) bool {
+	switch classify(err) {
+	case rolledBack:
+		return true
+	case connLost:
+		return idempotent
+	}
+	return false
+}
+
+/*
+retry 执行fn，遇到临时性错误时退避后重试（20ms起翻倍，带随机抖动）
+  - idempotent表示fn重复执行结果不变（读、按值覆盖的更新）；否则只在服务端确认已回滚时重试
+  - 每次执行都套上queryTimeout；调用方ctx结束后不再重试
+*/
+func (r *TaskRepo) retry(ctx context.Context, idempotent bool, fn func(ctx context.Context) error) error {
+	wait := 20 * time.Millisecond
+	for attempt := 0; ; attempt++ {
+		err := r.withTimeout(ctx, fn)
+		if err == nil || attempt >= r.retries || !retryable(err, idempotent) {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(wait/2 + rand.N(wait)):
+		}
+		wait *= 2
+	}
+}
+
+func (r *TaskRepo) withTimeout(ctx context.Context, fn func(ctx context.Context) error) error {
+	if r.queryTimeout <= 0 {
+		return fn(ctx)
+	}
+	ctx, cancel := context.WithTimeout(ctx, r.queryTimeout)
+	defer cancel()
+	return fn(ctx)
+}
@@ -0,0 +1,95 @@
+package mysqlrepo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+func TestClassify(t *testing.T) {
+	reset := &net.OpError{Op: "read", Net: "tcp", Err: os.NewSyscallError("read", syscall.ECONNRESET)}
+	cases := []struct {
+		err  error
+		want transience
+	}{
+		{&mysql.MySQLError{Number: 1213, Message: "Deadlock found"}, rolledBack},
+		{fmt.Errorf("update task: %w", &mysql.MySQLError{Number: 1205}), rolledBack},
+		{&mysql.MySQLError{Number: 1062}, permanent},
+		{mysql.ErrInvalidConn, connLost},
+		{io.ErrUnexpectedEOF, connLost},
+		{fmt.Errorf("query tasks: %w", reset), connLost},
+		{context.DeadlineExceeded, permanent},
+		{errors.New("boom"), permanent},
+	}
+	for _, c := range cases {
+		if got := classify(c.err); got != c.want {
+			t.Errorf("classify(%v) = %d, want %d", c.err, got, c.want)
+		}
+	}
+}
+
+func TestRetry(t *testing.T) {
+	deadlock := &mysql.MySQLError{Number: 1213}
+	cases := []struct {
+		name       string
+		idempotent bool
+		errs       []error // 依次返回的错误，用完后返回nil
+		calls      int
+		ok         bool
+	}{
+		{"deadlock then success", false, []error{deadlock, deadlock}, 3, true},
+		{"deadlock exhausts retries", false, []error{deadlock, deadlock, deadlock}, 3, false},
+		{"conn lost idempotent", true, []error{mysql.ErrInvalidConn}, 2, true},
+		{"conn lost not idempotent", false, []error{mysql.ErrInvalidConn}, 1, false},
+		{"permanent", true, []error{errors.New("syntax")}, 1, false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			r := NewTaskRepo(nil, WithRetries(2))
+			calls := 0
+			err := r.retry(context.Background(), c.idempotent, func(context.Context) error {
+				calls++
+				if calls <= len(c.errs) {
+					return c.errs[calls-1]
+				}
+				return nil
+			})
+			if calls != c.calls || (err == nil) != c.ok {
+				t.Errorf("calls = %d, err = %v; want %d calls, ok %v", calls, err, c.calls, c.ok)
+			}
+		})
+	}
+}
+
+func TestRetryStopsWhenCanceled(t *testing.T) {
+	r := NewTaskRepo(nil, WithRetries(5))
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := 0
+	err := r.retry(ctx, true, func(context.Context) error {
+		calls++
+		cancel()
+		return mysql.ErrInvalidConn
+	})
+	if calls != 1 || !errors.Is(err, mysql.ErrInvalidConn) {
+		t.Errorf("calls = %d, err = %v", calls, err)
+	}
+}
+
+func TestQueryTimeout(t *testing.T) {
+	r := NewTaskRepo(nil, WithQueryTimeout(10*time.Millisecond))
+	err := r.retry(context.Background(), true, func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v, want DeadlineExceeded", err)
+	}
+}
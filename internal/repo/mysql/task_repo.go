@@ -3,21 +3,114 @@ package mysqlrepo
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"strings"
+	"sync/atomic"
 	"time"
 
+	"github.com/go-sql-driver/mysql"
+	"github.com/kitouo/taskhub/internal/filter"
 	"github.com/kitouo/taskhub/internal/model"
+	"github.com/kitouo/taskhub/internal/repo"
 )
 
 type TaskRepo struct {
-	db *sql.DB
+	db *sql.DB // 主库
+
+	// replicas 只读副本（见WithReplicas），rr为轮询位置
+	replicas []*replica
+	rr       atomic.Uint64
+	cooldown time.Duration
+
+	// queryTimeout、retries 见WithQueryTimeout、WithRetries
+	queryTimeout time.Duration
+	retries      int
+}
+
+func NewTaskRepo(db *sql.DB, opts ...TaskRepoOption) *TaskRepo {
+	r := &TaskRepo{db: db}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// querier 抽象*sql.DB与*sql.Tx，使同一套SQL既能单独执行也能放进事务
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+const taskColumns = `id, title, done, created_at, parent_id, due_at, rrule, rrule_tz, rrule_start, series_id, project, estimate_min, board_column, rank_key`
+
+// scanner 抽象*sql.Row与*sql.Rows
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+// scanTask 按taskColumns的顺序扫描一行，extra用于接收追加在其后的列
+func scanTask(s scanner, extra ...any) (model.Task, error) {
+	var (
+		t        model.Task
+		doneInt  int
+		ct       time.Time
+		parentID sql.NullString
+		dueAt    sql.NullTime
+		rule     sql.NullString
+		tz       sql.NullString
+		start    sql.NullTime
+		seriesID sql.NullString
+	)
+	dest := append([]any{&t.ID, &t.Title, &doneInt, &ct, &parentID, &dueAt, &rule, &tz, &start, &seriesID, &t.Project, &t.EstimateMinutes, &t.Column, &t.Rank}, extra...)
+	if err := s.Scan(dest...); err != nil {
+		return model.Task{}, err
+	}
+	t.Done = doneInt == 1
+	t.CreatedAt = ct.UTC()
+	t.ParentID = parentID.String
+	if dueAt.Valid {
+		d := dueAt.Time.UTC()
+		t.DueAt = &d
+	}
+	if rule.Valid {
+		t.Recurrence = &model.Recurrence{
+			RRule:    rule.String,
+			Timezone: tz.String,
+			Start:    start.Time.UTC(),
+			SeriesID: seriesID.String,
+		}
+	}
+	return t, nil
+}
+
+// nullTime nil落库为NULL
+func nullTime(t *time.Time) sql.NullTime {
+	if t == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: t.UTC(), Valid: true}
 }
 
-func NewTaskRepo(db *sql.DB) *TaskRepo {
-	return &TaskRepo{db: db}
+// nullString 空串落库为NULL
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
 }
 
+// Create 连接中途断开时不重试：无法确定是否已经插入，重试可能得到ErrConflict
 func (r *TaskRepo) Create(ctx context.Context, t model.Task) (model.Task, error) {
+	var out model.Task
+	err := r.retry(ctx, false, func(ctx context.Context) (err error) {
+		out, err = create(ctx, r.db, t)
+		return err
+	})
+	return out, err
+}
+
+func create(ctx context.Context, q querier, t model.Task) (model.Task, error) {
+	t = repo.Normalize(t)
+
 	// MySQL使用TINYINT(1)表示布尔
 	doneInt := 0
 	if t.Done {
@@ -26,20 +119,47 @@ func (r *TaskRepo) Create(ctx context.Context, t model.Task) (model.Task, error)
 
 	createdAt := t.CreatedAt.UTC()
 
-	_, err := r.db.ExecContext(ctx,
-		`INSERT INTO tasks(id, title, done, created_at) VALUES (?, ?, ?, ?)`,
-		t.ID, t.Title, doneInt, createdAt,
+	var (
+		rule, tz, seriesID sql.NullString
+		start              sql.NullTime
 	)
+	if rec := t.Recurrence; rec != nil {
+		rule, tz, seriesID = nullString(rec.RRule), nullString(rec.Timezone), nullString(rec.SeriesID)
+		start = nullTime(&rec.Start)
+	}
+
+	_, err := q.ExecContext(ctx,
+		`INSERT INTO tasks(`+taskColumns+`)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		t.ID, t.Title, doneInt, createdAt, nullString(t.ParentID),
+		nullTime(t.DueAt), rule, tz, start, seriesID, t.Project, t.EstimateMinutes,
+		t.Column, t.Rank,
+	)
+	var myErr *mysql.MySQLError
+	if errors.As(err, &myErr) && myErr.Number == erDupEntry {
+		return model.Task{}, repo.ErrConflict
+	}
 	if err != nil {
 		return model.Task{}, fmt.Errorf("insert task: %w", err)
 	}
 	return t, nil
 }
 
-func (r *TaskRepo) List(ctx context.Context) ([]model.Task, error) {
-	// ORDER BY created_at 使列表稳定
-	rows, err := r.db.QueryContext(ctx,
-		`SELECT id, title, done, created_at FROM tasks ORDER BY created_at ASC`,
+func (r *TaskRepo) List(ctx context.Context, q repo.ListQuery) ([]model.Task, error) {
+	var out []model.Task
+	err := r.read(ctx, func(ctx context.Context, db querier) (err error) {
+		out, err = list(ctx, db, q)
+		return err
+	})
+	return out, err
+}
+
+func list(ctx context.Context, db querier, q repo.ListQuery) ([]model.Task, error) {
+	where, args := whereClause(q)
+
+	rows, err := db.QueryContext(ctx,
+		`SELECT `+taskColumns+` FROM tasks`+where+orderBy(q.Sort),
+		args...,
 	)
 
 	if err != nil {
@@ -49,16 +169,10 @@ func (r *TaskRepo) List(ctx context.Context) ([]model.Task, error) {
 
 	out := make([]model.Task, 0)
 	for rows.Next() {
-		var (
-			t       model.Task
-			doneInt int
-			ct      time.Time
-		)
-		if err := rows.Scan(&t.ID, &t.Title, &doneInt, &ct); err != nil {
+		t, err := scanTask(rows)
+		if err != nil {
 			return nil, fmt.Errorf("scan: %w", err)
 		}
-		t.Done = doneInt == 1
-		t.CreatedAt = ct.UTC()
 		out = append(out, t)
 	}
 	if err := rows.Err(); err != nil {
@@ -67,52 +181,469 @@ func (r *TaskRepo) List(ctx context.Context) ([]model.Task, error) {
 	return out, nil
 }
 
-func (r *TaskRepo) Get(ctx context.Context, id string) (model.Task, bool, error) {
+// whereClause 把ListQuery编译成 " WHERE ..."（无条件时为空串）
+func whereClause(q repo.ListQuery) (string, []any) {
+	var (
+		conds []string
+		args  []any
+	)
+	if q.IDs != nil {
+		if len(q.IDs) == 0 {
+			return " WHERE 1 = 0", nil
+		}
+		conds = append(conds, "id IN ("+placeholders(len(q.IDs))+")")
+		for _, id := range q.IDs {
+			args = append(args, id)
+		}
+	}
+	if q.Filter != nil {
+		sql, fargs := filter.ToSQL(q.Filter, filter.MySQL, len(args))
+		conds = append(conds, sql)
+		args = append(args, fargs...)
+	}
+	if len(conds) == 0 {
+		return "", nil
+	}
+	return " WHERE " + strings.Join(conds, " AND "), args
+}
 
-	row := r.db.QueryRowContext(ctx,
-		`SELECT id, title, done, created_at FROM tasks WHERE id = ?`,
-		id,
+// placeholders 生成n个以逗号分隔的"?"
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
+
+// eachPageSize Each每次从库里取出的行数
+const eachPageSize = 500
+
+/*
+Each 基于(created_at, id)的键集游标分页：每页读完并关闭结果集后再回调，
+不会在回调期间占着连接，也不会随偏移量增大而变慢
+*/
+func (r *TaskRepo) Each(ctx context.Context, q repo.ListQuery, fn func(model.Task) error) error {
+	where, args := whereClause(q)
+	var (
+		lastAt time.Time
+		lastID string
 	)
+	for page := 0; ; page++ {
+		cond, pageArgs := where, args
+		if page > 0 {
+			keyset := `(created_at > ? OR (created_at = ? AND id > ?))`
+			if cond == "" {
+				cond = " WHERE " + keyset
+			} else {
+				cond += " AND " + keyset
+			}
+			pageArgs = append(append([]any(nil), args...), lastAt, lastAt, lastID)
+		}
+
+		// 每页单独选择副本；一页读完之后才回调，换到主库重试不会重复回调
+		var batch []model.Task
+		err := r.read(ctx, func(ctx context.Context, db querier) (err error) {
+			batch, err = eachPage(ctx, db, cond, pageArgs)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, t := range batch {
+			if err := fn(t); err != nil {
+				return err
+			}
+		}
+		if len(batch) < eachPageSize {
+			return nil
+		}
+		last := batch[len(batch)-1]
+		lastAt, lastID = last.CreatedAt, last.ID
+	}
+}
 
+func eachPage(ctx context.Context, db querier, cond string, args []any) ([]model.Task, error) {
+	rows, err := db.QueryContext(ctx,
+		`SELECT `+taskColumns+` FROM tasks`+cond+` ORDER BY created_at ASC, id ASC LIMIT ?`,
+		append(args, eachPageSize)...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query tasks: %w", err)
+	}
+	defer rows.Close()
+	batch := make([]model.Task, 0, eachPageSize)
+	for rows.Next() {
+		t, err := scanTask(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan: %w", err)
+		}
+		batch = append(batch, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows err: %w", err)
+	}
+	return batch, nil
+}
+
+func (r *TaskRepo) Count(ctx context.Context, q repo.ListQuery) (int, error) {
+	where, args := whereClause(q)
+
+	var n int
+	err := r.read(ctx, func(ctx context.Context, db querier) error {
+		if err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM tasks`+where, args...).Scan(&n); err != nil {
+			return fmt.Errorf("count tasks: %w", err)
+		}
+		return nil
+	})
+	return n, err
+}
+
+// LastRank 由idx_tasks_board(board_column, rank_key)直接给出，不扫描整列
+func (r *TaskRepo) LastRank(ctx context.Context, column string) (string, error) {
+	var last string
+	err := r.read(ctx, func(ctx context.Context, db querier) error {
+		if err := db.QueryRowContext(ctx,
+			`SELECT COALESCE(MAX(rank_key), '') FROM tasks WHERE board_column = ?`, column,
+		).Scan(&last); err != nil {
+			return fmt.Errorf("last rank: %w", err)
+		}
+		return nil
+	})
+	return last, err
+}
+
+// sortColumns 排序字段到列名的白名单映射
+var sortColumns = map[repo.SortField]string{
+	repo.SortCreatedAt: "created_at",
+	repo.SortTitle:     "title",
+	repo.SortRank:      "rank_key",
+}
+
+// orderBy 生成ORDER BY子句；末尾追加created_at、id 使列表稳定
+func orderBy(s repo.Sort) string {
+	col, ok := sortColumns[s.Field]
+	if !ok {
+		return ` ORDER BY created_at ASC, id ASC`
+	}
+	dir := "ASC"
+	if s.Desc {
+		dir = "DESC"
+	}
+	return ` ORDER BY ` + col + ` ` + dir + `, created_at ASC, id ASC`
+}
+
+func (r *TaskRepo) Get(ctx context.Context, id string) (model.Task, bool, error) {
 	var (
-		t       model.Task
-		doneInt int
-		ct      time.Time
+		t  model.Task
+		ok bool
 	)
-	if err := row.Scan(&t.ID, &t.Title, &doneInt, &ct); err != nil {
+	err := r.read(ctx, func(ctx context.Context, db querier) (err error) {
+		t, ok, err = get(ctx, db, id)
+		return err
+	})
+	return t, ok, err
+}
+
+func get(ctx context.Context, q querier, id string) (model.Task, bool, error) {
+	row := q.QueryRowContext(ctx,
+		`SELECT `+taskColumns+` FROM tasks WHERE id = ?`,
+		id,
+	)
+
+	t, err := scanTask(row)
+	if err != nil {
 		if err == sql.ErrNoRows {
 			return model.Task{}, false, nil
 		}
 		return model.Task{}, false, fmt.Errorf("get task: %w", err)
 	}
-
-	t.Done = doneInt == 1
-	t.CreatedAt = ct.UTC()
 	return t, true, nil
 }
 
+// MarkDone 与Update共用实现：重复标记时RowsAffected为0，不能据此判断not found
 func (r *TaskRepo) MarkDone(ctx context.Context, id string, done bool) (model.Task, bool, error) {
-	doneInt := 0
-	if done {
-		doneInt = 1
+	return r.Update(ctx, id, repo.TaskPatch{Done: &done})
+}
+
+// Update 按值覆盖字段，重复执行结果相同，连接断开时也可以重试
+func (r *TaskRepo) Update(ctx context.Context, id string, p repo.TaskPatch) (model.Task, bool, error) {
+	var (
+		t  model.Task
+		ok bool
+	)
+	err := r.retry(ctx, true, func(ctx context.Context) (err error) {
+		t, ok, err = update(ctx, r.db, id, p)
+		return err
+	})
+	return t, ok, err
+}
+
+func update(ctx context.Context, q querier, id string, p repo.TaskPatch) (model.Task, bool, error) {
+	var (
+		sets []string
+		args []any
+	)
+	if p.Title != nil {
+		sets = append(sets, "title = ?")
+		args = append(args, *p.Title)
+	}
+	if p.Done != nil {
+		sets = append(sets, "done = ?")
+		args = append(args, *p.Done)
+	}
+	if p.ParentID != nil {
+		sets = append(sets, "parent_id = ?")
+		args = append(args, nullString(*p.ParentID))
+	}
+	if p.Project != nil {
+		sets = append(sets, "project = ?")
+		args = append(args, *p.Project)
+	}
+	if p.EstimateMinutes != nil {
+		sets = append(sets, "estimate_min = ?")
+		args = append(args, *p.EstimateMinutes)
+	}
+	if p.Column != nil {
+		sets = append(sets, "board_column = ?")
+		args = append(args, *p.Column)
 	}
+	if p.Rank != nil {
+		sets = append(sets, "rank_key = ?")
+		args = append(args, *p.Rank)
+	}
+
+	if len(sets) > 0 {
+		args = append(args, id)
+		if _, err := q.ExecContext(ctx,
+			`UPDATE tasks SET `+strings.Join(sets, ", ")+` WHERE id = ?`,
+			args...,
+		); err != nil {
+			return model.Task{}, false, fmt.Errorf("update task: %w", err)
+		}
+	}
+
+	/*
+		MySQL的RowsAffected默认只统计“值真正变化”的行，
+		无法区分not found与“值未变化”，所以这里用回读判断是否存在
+	*/
+	return get(ctx, q, id)
+}
 
-	res, err := r.db.ExecContext(ctx,
-		`UPDATE tasks SET done = ? WHERE id = ?`,
-		doneInt, id,
+/*
+SetParent 在一个事务里用SELECT ... FOR UPDATE依次锁住任务本身与新的祖先链，再检查并写入
+两个相向的移动（A挂到B下、B挂到A下）会互相等待对方锁住的行而死锁，被回滚的一方重做整个事务时就能看到对方的修改
+*/
+func (r *TaskRepo) SetParent(ctx context.Context, id, parentID string, check func([]string) error) (model.Task, bool, error) {
+	var (
+		t  model.Task
+		ok bool
 	)
+	err := r.retry(ctx, false, func(ctx context.Context) (err error) {
+		t, ok, err = setParent(ctx, r.db, id, parentID, check)
+		return err
+	})
+	return t, ok, err
+}
+
+func setParent(ctx context.Context, db *sql.DB, id, parentID string, check func([]string) error) (model.Task, bool, error) {
+	tx, err := db.BeginTx(ctx, nil)
 	if err != nil {
-		return model.Task{}, false, fmt.Errorf("update task: %w", err)
+		return model.Task{}, false, fmt.Errorf("begin tx: %w", err)
 	}
+	defer func() { _ = tx.Rollback() }()
+
+	parentOf := func(cur string) (string, bool, error) {
+		var parent sql.NullString
+		err := tx.QueryRowContext(ctx, `SELECT parent_id FROM tasks WHERE id = ? FOR UPDATE`, cur).Scan(&parent)
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", false, nil
+		}
+		if err != nil {
+			return "", false, fmt.Errorf("lock task: %w", err)
+		}
+		return parent.String, true, nil
+	}
+	if _, ok, err := parentOf(id); err != nil || !ok {
+		return model.Task{}, ok, err
+	}
+	if check != nil {
+		ancestors, err := repo.Ancestors(id, parentID, parentOf)
+		if err != nil {
+			return model.Task{}, false, err
+		}
+		if err := check(ancestors); err != nil {
+			return model.Task{}, true, err
+		}
+	}
+	t, ok, err := update(ctx, tx, id, repo.TaskPatch{ParentID: &parentID})
+	if err != nil || !ok {
+		return model.Task{}, ok, err
+	}
+	if err := tx.Commit(); err != nil {
+		return model.Task{}, false, fmt.Errorf("commit tx: %w", err)
+	}
+	return t, true, nil
+}
 
+// Delete 连接断开后重试可能因为已删除而返回false，所以只在确认回滚时重试
+func (r *TaskRepo) Delete(ctx context.Context, id string) (bool, error) {
+	var ok bool
+	err := r.retry(ctx, false, func(ctx context.Context) (err error) {
+		ok, err = deleteTask(ctx, r.db, id)
+		return err
+	})
+	return ok, err
+}
+
+func deleteTask(ctx context.Context, q querier, id string) (bool, error) {
+	res, err := q.ExecContext(ctx, `DELETE FROM tasks WHERE id = ?`, id)
+	if err != nil {
+		return false, fmt.Errorf("delete task: %w", err)
+	}
 	aff, err := res.RowsAffected()
 	if err != nil {
-		return model.Task{}, false, fmt.Errorf("rows affected: %w", err)
+		return false, fmt.Errorf("rows affected: %w", err)
+	}
+	return aff > 0, nil
+}
+
+/*
+Batch
+  - 非原子：每条操作直接在连接池上执行，各自按retry处理临时性错误（只有update视为幂等）
+  - 原子：整批放进一个事务，任一条失败则Rollback；失败原因是死锁/锁等待超时时整个事务重做
+*/
+func (r *TaskRepo) Batch(ctx context.Context, ops []repo.BatchOp, atomic bool) ([]repo.BatchResult, error) {
+	if !atomic {
+		results := make([]repo.BatchResult, len(ops))
+		for i, op := range ops {
+			// 单条的错误记录在结果里，这里的返回值只用来决定是否重试
+			_ = r.retry(ctx, op.Kind == repo.BatchUpdate, func(ctx context.Context) error {
+				results[i] = applyOp(ctx, r.db, op)
+				return results[i].Err
+			})
+		}
+		return results, nil
+	}
+
+	var results []repo.BatchResult
+	err := r.retry(ctx, false, func(ctx context.Context) (err error) {
+		results, err = batchTx(ctx, r.db, ops)
+		if err != nil {
+			return err
+		}
+		for _, res := range results {
+			if res.Err != nil && !errors.Is(res.Err, repo.ErrBatchAborted) {
+				return res.Err
+			}
+		}
+		return nil
+	})
+	if results != nil {
+		return results, nil
+	}
+	return nil, err
+}
+
+// batchTx 在一个事务里执行整批操作；返回的error只表示开启或提交事务失败
+func batchTx(ctx context.Context, db *sql.DB, ops []repo.BatchOp) ([]repo.BatchResult, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("begin tx: %w", err)
 	}
-	if aff == 0 {
-		return model.Task{}, false, nil
+
+	results := make([]repo.BatchResult, len(ops))
+	for i, op := range ops {
+		results[i] = applyOp(ctx, tx, op)
+		if results[i].Failed() {
+			_ = tx.Rollback()
+			for j := range results {
+				if j != i {
+					results[j] = repo.BatchResult{Found: true, Err: repo.ErrBatchAborted}
+				}
+			}
+			return results, nil
+		}
 	}
 
-	// 更新后再读一次，保持返回结构与Get一致
-	return r.Get(ctx, id)
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit tx: %w", err)
+	}
+	return results, nil
+}
+
+func applyOp(ctx context.Context, q querier, op repo.BatchOp) repo.BatchResult {
+	switch op.Kind {
+	case repo.BatchCreate:
+		t, err := create(ctx, q, op.Task)
+		return repo.BatchResult{Task: t, Found: true, Err: err}
+	case repo.BatchUpdate:
+		t, ok, err := update(ctx, q, op.ID, op.Patch)
+		return repo.BatchResult{Task: t, Found: ok, Err: err}
+	case repo.BatchDelete:
+		ok, err := deleteTask(ctx, q, op.ID)
+		return repo.BatchResult{Found: ok, Err: err}
+	default:
+		return repo.BatchResult{Found: true, Err: fmt.Errorf("unknown batch op: %s", op.Kind)}
+	}
+}
+
+/*
+Search 基于FULLTEXT索引（ft_tasks_title）的布尔模式检索
+每个词元写成 +term* ：必须命中且按前缀匹配
+注意：InnoDB默认解析器不切分中文，且忽略短于innodb_ft_min_token_size的词
+*/
+func (r *TaskRepo) Search(ctx context.Context, q repo.SearchQuery) ([]repo.SearchHit, int, error) {
+	var (
+		hits  []repo.SearchHit
+		total int
+	)
+	err := r.read(ctx, func(ctx context.Context, db querier) (err error) {
+		hits, total, err = search(ctx, db, q)
+		return err
+	})
+	return hits, total, err
+}
+
+func search(ctx context.Context, db querier, q repo.SearchQuery) ([]repo.SearchHit, int, error) {
+	terms := make([]string, len(q.Terms))
+	for i, t := range q.Terms {
+		terms[i] = "+" + t + "*"
+	}
+	against := strings.Join(terms, " ")
+
+	var total int
+	if err := db.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM tasks WHERE MATCH(title) AGAINST(? IN BOOLEAN MODE)`,
+		against,
+	).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("count search: %w", err)
+	}
+	if total == 0 {
+		return []repo.SearchHit{}, 0, nil
+	}
+
+	rows, err := db.QueryContext(ctx,
+		`SELECT `+taskColumns+`, MATCH(title) AGAINST(? IN BOOLEAN MODE) AS score
+		   FROM tasks
+		  WHERE MATCH(title) AGAINST(? IN BOOLEAN MODE)
+		  ORDER BY score DESC, created_at ASC, id ASC
+		  LIMIT ? OFFSET ?`,
+		against, against, q.Limit, q.Offset,
+	)
+	if err != nil {
+		return nil, 0, fmt.Errorf("search tasks: %w", err)
+	}
+	defer rows.Close()
+
+	hits := make([]repo.SearchHit, 0, q.Limit)
+	for rows.Next() {
+		var score float64
+		t, err := scanTask(rows, &score)
+		if err != nil {
+			return nil, 0, fmt.Errorf("scan: %w", err)
+		}
+		hits = append(hits, repo.SearchHit{Task: t, Score: score})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("rows err: %w", err)
+	}
+	return hits, total, nil
 }
@@ -0,0 +1,50 @@
+package mysqlrepo
+
+import (
+	"database/sql"
+	"os"
+	"testing"
+
+	"github.com/kitouo/taskhub/internal/db"
+	"github.com/kitouo/taskhub/internal/repo"
+	"github.com/kitouo/taskhub/internal/repo/repotest"
+)
+
+// 需要一个可随意清空的库，例如 TASKHUB_TEST_MYSQL_DSN="root:pass@tcp(127.0.0.1:3306)/taskhub_test?parseTime=true&loc=UTC"
+func testDB(t *testing.T) *sql.DB {
+	dsn := os.Getenv("TASKHUB_TEST_MYSQL_DSN")
+	if dsn == "" {
+		t.Skip("TASKHUB_TEST_MYSQL_DSN not set")
+	}
+	conn, err := db.Open("mysql", dsn, db.Pool{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	if err := db.MigrateMySQL(conn); err != nil {
+		t.Fatal(err)
+	}
+	return conn
+}
+
+func TestTaskRepo(t *testing.T) {
+	conn := testDB(t)
+	repotest.Run(t, func(t *testing.T) repo.TaskRepo {
+		if _, err := conn.Exec(`DELETE FROM tasks`); err != nil {
+			t.Fatal(err)
+		}
+		return NewTaskRepo(conn)
+	})
+}
+
+func TestTimeEntryRepo(t *testing.T) {
+	conn := testDB(t)
+	repotest.RunTimeEntries(t, func(t *testing.T) (repo.TimeEntryRepo, repo.TaskRepo) {
+		for _, table := range []string{"time_entries", "tasks"} {
+			if _, err := conn.Exec(`DELETE FROM ` + table); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return NewTimeEntryRepo(conn), NewTaskRepo(conn)
+	})
+}
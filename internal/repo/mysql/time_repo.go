@@ -0,0 +1,182 @@
+package mysqlrepo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+
+	"github.com/kitouo/taskhub/internal/model"
+	"github.com/kitouo/taskhub/internal/repo"
+)
+
+type TimeEntryRepo struct {
+	db *sql.DB
+}
+
+func NewTimeEntryRepo(db *sql.DB) *TimeEntryRepo {
+	return &TimeEntryRepo{db: db}
+}
+
+const timeEntryColumns = `id, task_id, user_id, started_at, ended_at, note, created_at`
+
+// erDupEntry MySQL唯一键冲突的错误码
+const erDupEntry = 1062
+
+func scanTimeEntry(s scanner) (model.TimeEntry, error) {
+	var (
+		e   model.TimeEntry
+		end sql.NullTime
+	)
+	if err := s.Scan(&e.ID, &e.TaskID, &e.UserID, &e.Start, &end, &e.Note, &e.CreatedAt); err != nil {
+		return model.TimeEntry{}, err
+	}
+	e.Start = e.Start.UTC()
+	e.CreatedAt = e.CreatedAt.UTC()
+	if end.Valid {
+		t := end.Time.UTC()
+		e.End = &t
+	}
+	return e, nil
+}
+
+/*
+Create 运行中的计时器由生成列running_user上的唯一索引保证每个用户至多一个
+（ended_at为NULL时running_user=user_id，否则为NULL，NULL不参与唯一性检查）
+*/
+func (r *TimeEntryRepo) Create(ctx context.Context, e model.TimeEntry) (model.TimeEntry, error) {
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO time_entries(`+timeEntryColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		e.ID, e.TaskID, e.UserID, e.Start.UTC(), nullTime(e.End), e.Note, e.CreatedAt.UTC(),
+	)
+	var myErr *mysql.MySQLError
+	if errors.As(err, &myErr) && myErr.Number == erDupEntry && e.End == nil {
+		return model.TimeEntry{}, repo.ErrTimerRunning
+	}
+	if err != nil {
+		return model.TimeEntry{}, fmt.Errorf("insert time entry: %w", err)
+	}
+	return e, nil
+}
+
+func (r *TimeEntryRepo) Get(ctx context.Context, id string) (model.TimeEntry, bool, error) {
+	return r.getOne(ctx, `SELECT `+timeEntryColumns+` FROM time_entries WHERE id = ?`, id)
+}
+
+func (r *TimeEntryRepo) Running(ctx context.Context, userID string) (model.TimeEntry, bool, error) {
+	return r.getOne(ctx, `SELECT `+timeEntryColumns+` FROM time_entries WHERE running_user = ?`, userID)
+}
+
+func (r *TimeEntryRepo) getOne(ctx context.Context, query string, arg any) (model.TimeEntry, bool, error) {
+	e, err := scanTimeEntry(r.db.QueryRowContext(ctx, query, arg))
+	if err == sql.ErrNoRows {
+		return model.TimeEntry{}, false, nil
+	}
+	if err != nil {
+		return model.TimeEntry{}, false, fmt.Errorf("get time entry: %w", err)
+	}
+	return e, true, nil
+}
+
+func (r *TimeEntryRepo) List(ctx context.Context, taskID string) ([]model.TimeEntry, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT `+timeEntryColumns+` FROM time_entries WHERE task_id = ? ORDER BY started_at ASC, id ASC`,
+		taskID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query time entries: %w", err)
+	}
+	defer rows.Close()
+
+	out := make([]model.TimeEntry, 0)
+	for rows.Next() {
+		e, err := scanTimeEntry(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan: %w", err)
+		}
+		out = append(out, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows err: %w", err)
+	}
+	return out, nil
+}
+
+func (r *TimeEntryRepo) Stop(ctx context.Context, id string, end time.Time) (model.TimeEntry, bool, error) {
+	res, err := r.db.ExecContext(ctx,
+		`UPDATE time_entries SET ended_at = ? WHERE id = ? AND ended_at IS NULL`,
+		end.UTC(), id,
+	)
+	if err != nil {
+		return model.TimeEntry{}, false, fmt.Errorf("stop timer: %w", err)
+	}
+	if aff, err := res.RowsAffected(); err != nil {
+		return model.TimeEntry{}, false, fmt.Errorf("rows affected: %w", err)
+	} else if aff == 0 {
+		return model.TimeEntry{}, false, nil
+	}
+	return r.Get(ctx, id)
+}
+
+func (r *TimeEntryRepo) Delete(ctx context.Context, id string) (bool, error) {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM time_entries WHERE id = ?`, id)
+	if err != nil {
+		return false, fmt.Errorf("delete time entry: %w", err)
+	}
+	aff, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("rows affected: %w", err)
+	}
+	return aff > 0, nil
+}
+
+// reportKeys 汇总维度到分组表达式的白名单映射
+var reportKeys = map[model.TimeGroup]string{
+	model.TimeByTask:    "e.task_id",
+	model.TimeByProject: "COALESCE(t.project, '')",
+	model.TimeByUser:    "e.user_id",
+}
+
+/*
+Report 与[from, to)求交后累加秒数
+任务已被删除时LEFT JOIN得到NULL，归入空项目
+*/
+func (r *TimeEntryRepo) Report(ctx context.Context, q repo.TimeReportQuery) ([]model.TimeReportRow, error) {
+	key, ok := reportKeys[q.GroupBy]
+	if !ok {
+		return nil, fmt.Errorf("unknown time group: %s", q.GroupBy)
+	}
+	from, to := q.From.UTC(), q.To.UTC()
+
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT `+key+` AS k,
+		        SUM(TIMESTAMPDIFF(SECOND, GREATEST(e.started_at, ?), LEAST(e.ended_at, ?))) AS secs,
+		        COUNT(*) AS n
+		   FROM time_entries e
+		   LEFT JOIN tasks t ON t.id = e.task_id
+		  WHERE e.ended_at IS NOT NULL AND e.started_at < ? AND e.ended_at > ?
+		  GROUP BY k
+		  ORDER BY secs DESC, k ASC`,
+		from, to, to, from,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("time report: %w", err)
+	}
+	defer rows.Close()
+
+	out := make([]model.TimeReportRow, 0)
+	for rows.Next() {
+		var row model.TimeReportRow
+		if err := rows.Scan(&row.Key, &row.Seconds, &row.Entries); err != nil {
+			return nil, fmt.Errorf("scan: %w", err)
+		}
+		out = append(out, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows err: %w", err)
+	}
+	return out, nil
+}
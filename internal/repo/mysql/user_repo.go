@@ -0,0 +1,178 @@
+package mysqlrepo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+
+	"github.com/kitouo/taskhub/internal/model"
+	"github.com/kitouo/taskhub/internal/repo"
+)
+
+type UserRepo struct {
+	db *sql.DB
+}
+
+func NewUserRepo(db *sql.DB) *UserRepo {
+	return &UserRepo{db: db}
+}
+
+const userColumns = `id, display_name, email, active, created_at`
+
+func scanUser(s scanner) (model.User, error) {
+	var (
+		u      model.User
+		active int
+	)
+	if err := s.Scan(&u.ID, &u.DisplayName, &u.Email, &active, &u.CreatedAt); err != nil {
+		return model.User{}, err
+	}
+	u.Active = active == 1
+	u.CreatedAt = u.CreatedAt.UTC()
+	return u, nil
+}
+
+func (r *UserRepo) Create(ctx context.Context, u model.User) (model.User, error) {
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO users(`+userColumns+`) VALUES (?, ?, ?, ?, ?)`,
+		u.ID, u.DisplayName, u.Email, u.Active, u.CreatedAt.UTC(),
+	)
+	var myErr *mysql.MySQLError
+	if errors.As(err, &myErr) && myErr.Number == erDupEntry {
+		return model.User{}, repo.ErrConflict
+	}
+	if err != nil {
+		return model.User{}, fmt.Errorf("insert user: %w", err)
+	}
+	return u, nil
+}
+
+func (r *UserRepo) Get(ctx context.Context, id string) (model.User, bool, error) {
+	u, err := scanUser(r.db.QueryRowContext(ctx, `SELECT `+userColumns+` FROM users WHERE id = ?`, id))
+	if err == sql.ErrNoRows {
+		return model.User{}, false, nil
+	}
+	if err != nil {
+		return model.User{}, false, fmt.Errorf("get user: %w", err)
+	}
+	return u, true, nil
+}
+
+func (r *UserRepo) List(ctx context.Context, active *bool) ([]model.User, error) {
+	query := `SELECT ` + userColumns + ` FROM users`
+	var args []any
+	if active != nil {
+		query += ` WHERE active = ?`
+		args = append(args, *active)
+	}
+	rows, err := r.db.QueryContext(ctx, query+` ORDER BY id ASC`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query users: %w", err)
+	}
+	defer rows.Close()
+
+	out := make([]model.User, 0)
+	for rows.Next() {
+		u, err := scanUser(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan: %w", err)
+		}
+		out = append(out, u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows err: %w", err)
+	}
+	return out, nil
+}
+
+func (r *UserRepo) Update(ctx context.Context, u model.User) (model.User, bool, error) {
+	if _, err := r.db.ExecContext(ctx,
+		`UPDATE users SET display_name = ?, email = ?, active = ? WHERE id = ?`,
+		u.DisplayName, u.Email, u.Active, u.ID,
+	); err != nil {
+		return model.User{}, false, fmt.Errorf("update user: %w", err)
+	}
+	return r.Get(ctx, u.ID)
+}
+
+type AssignmentRepo struct {
+	db *sql.DB
+}
+
+func NewAssignmentRepo(db *sql.DB) *AssignmentRepo {
+	return &AssignmentRepo{db: db}
+}
+
+// Replace 在一个事务里先删后插，读者不会看到只替换了一半的负责人
+func (r *AssignmentRepo) Replace(ctx context.Context, taskID string, userIDs []string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM task_assignees WHERE task_id = ?`, taskID); err != nil {
+		return fmt.Errorf("delete assignees: %w", err)
+	}
+	if len(userIDs) > 0 {
+		args := make([]any, 0, 2*len(userIDs))
+		for _, uid := range userIDs {
+			args = append(args, taskID, uid)
+		}
+		values := strings.TrimSuffix(strings.Repeat("(?, ?), ", len(userIDs)), ", ")
+		if _, err := tx.ExecContext(ctx,
+			`INSERT INTO task_assignees(task_id, user_id) VALUES `+values, args...,
+		); err != nil {
+			return fmt.Errorf("insert assignees: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
+	}
+	return nil
+}
+
+func (r *AssignmentRepo) ByTasks(ctx context.Context, taskIDs []string) ([]model.Assignment, error) {
+	return r.query(ctx, "task_id", taskIDs)
+}
+
+func (r *AssignmentRepo) ByUsers(ctx context.Context, userIDs []string) ([]model.Assignment, error) {
+	return r.query(ctx, "user_id", userIDs)
+}
+
+// query col只会是上面两个固定列名之一
+func (r *AssignmentRepo) query(ctx context.Context, col string, ids []string) ([]model.Assignment, error) {
+	if len(ids) == 0 {
+		return []model.Assignment{}, nil
+	}
+	args := make([]any, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT task_id, user_id FROM task_assignees WHERE `+col+` IN (`+placeholders(len(ids))+`)
+		 ORDER BY user_id ASC, task_id ASC`,
+		args...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query assignees: %w", err)
+	}
+	defer rows.Close()
+
+	out := make([]model.Assignment, 0)
+	for rows.Next() {
+		var a model.Assignment
+		if err := rows.Scan(&a.TaskID, &a.UserID); err != nil {
+			return nil, fmt.Errorf("scan: %w", err)
+		}
+		out = append(out, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows err: %w", err)
+	}
+	return out, nil
+}
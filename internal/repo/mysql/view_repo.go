@@ -0,0 +1,108 @@
+package mysqlrepo
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/kitouo/taskhub/internal/model"
+)
+
+type ViewRepo struct {
+	db *sql.DB
+}
+
+func NewViewRepo(db *sql.DB) *ViewRepo {
+	return &ViewRepo{db: db}
+}
+
+const viewColumns = `id, name, owner_id, filter_expr, sort_spec, visibility, created_at, updated_at`
+
+func scanView(s scanner) (model.SavedView, error) {
+	var (
+		v      model.SavedView
+		ct, ut time.Time
+	)
+	if err := s.Scan(&v.ID, &v.Name, &v.OwnerID, &v.Filter, &v.Sort, &v.Visibility, &ct, &ut); err != nil {
+		return model.SavedView{}, err
+	}
+	v.CreatedAt = ct.UTC()
+	v.UpdatedAt = ut.UTC()
+	return v, nil
+}
+
+func (r *ViewRepo) Create(ctx context.Context, v model.SavedView) (model.SavedView, error) {
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO saved_views(`+viewColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		v.ID, v.Name, v.OwnerID, v.Filter, v.Sort, v.Visibility, v.CreatedAt.UTC(), v.UpdatedAt.UTC(),
+	)
+	if err != nil {
+		return model.SavedView{}, fmt.Errorf("insert view: %w", err)
+	}
+	return v, nil
+}
+
+func (r *ViewRepo) Get(ctx context.Context, id string) (model.SavedView, bool, error) {
+	row := r.db.QueryRowContext(ctx,
+		`SELECT `+viewColumns+` FROM saved_views WHERE id = ?`,
+		id,
+	)
+	v, err := scanView(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return model.SavedView{}, false, nil
+		}
+		return model.SavedView{}, false, fmt.Errorf("get view: %w", err)
+	}
+	return v, true, nil
+}
+
+func (r *ViewRepo) ListVisible(ctx context.Context, ownerID string) ([]model.SavedView, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT `+viewColumns+` FROM saved_views
+		  WHERE owner_id = ? OR visibility = ?
+		  ORDER BY created_at ASC, id ASC`,
+		ownerID, model.VisibilityTeam,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query views: %w", err)
+	}
+	defer rows.Close()
+
+	out := make([]model.SavedView, 0)
+	for rows.Next() {
+		v, err := scanView(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan: %w", err)
+		}
+		out = append(out, v)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows err: %w", err)
+	}
+	return out, nil
+}
+
+func (r *ViewRepo) Update(ctx context.Context, v model.SavedView) (model.SavedView, bool, error) {
+	if _, err := r.db.ExecContext(ctx,
+		`UPDATE saved_views SET name = ?, filter_expr = ?, sort_spec = ?, visibility = ?, updated_at = ? WHERE id = ?`,
+		v.Name, v.Filter, v.Sort, v.Visibility, v.UpdatedAt.UTC(), v.ID,
+	); err != nil {
+		return model.SavedView{}, false, fmt.Errorf("update view: %w", err)
+	}
+	// 与TaskRepo.Update相同：用回读判断是否存在
+	return r.Get(ctx, v.ID)
+}
+
+func (r *ViewRepo) Delete(ctx context.Context, id string) (bool, error) {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM saved_views WHERE id = ?`, id)
+	if err != nil {
+		return false, fmt.Errorf("delete view: %w", err)
+	}
+	aff, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("rows affected: %w", err)
+	}
+	return aff > 0, nil
+}
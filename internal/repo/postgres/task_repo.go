@@ -0,0 +1,538 @@
+/*
+Package pgrepo 基于PostgreSQL的repo实现
+与mysqlrepo的SQL基本一致，差异在于：$n占位符、原生BOOLEAN/TIMESTAMPTZ、
+UPDATE ... RETURNING 一次往返拿到更新后的行，以及用tsvector实现全文检索
+*/
+package pgrepo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/kitouo/taskhub/internal/filter"
+	"github.com/kitouo/taskhub/internal/model"
+	"github.com/kitouo/taskhub/internal/repo"
+)
+
+type TaskRepo struct {
+	db *sql.DB
+}
+
+func NewTaskRepo(db *sql.DB) *TaskRepo {
+	return &TaskRepo{db: db}
+}
+
+// pgUniqueViolation 唯一约束冲突的SQLSTATE
+const pgUniqueViolation = "23505"
+
+// querier 抽象*sql.DB与*sql.Tx，使同一套SQL既能单独执行也能放进事务
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+const taskColumns = `id, title, done, created_at, parent_id, due_at, rrule, rrule_tz, rrule_start, series_id, project, estimate_min, board_column, rank_key`
+
+// scanner 抽象*sql.Row与*sql.Rows
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+// scanTask 按taskColumns的顺序扫描一行，extra用于接收追加在其后的列
+func scanTask(s scanner, extra ...any) (model.Task, error) {
+	var (
+		t        model.Task
+		parentID sql.NullString
+		dueAt    sql.NullTime
+		rule     sql.NullString
+		tz       sql.NullString
+		start    sql.NullTime
+		seriesID sql.NullString
+	)
+	dest := append([]any{&t.ID, &t.Title, &t.Done, &t.CreatedAt, &parentID, &dueAt, &rule, &tz, &start, &seriesID, &t.Project, &t.EstimateMinutes, &t.Column, &t.Rank}, extra...)
+	if err := s.Scan(dest...); err != nil {
+		return model.Task{}, err
+	}
+	// TIMESTAMPTZ读出来带会话时区，统一转回UTC
+	t.CreatedAt = t.CreatedAt.UTC()
+	t.ParentID = parentID.String
+	if dueAt.Valid {
+		d := dueAt.Time.UTC()
+		t.DueAt = &d
+	}
+	if rule.Valid {
+		t.Recurrence = &model.Recurrence{
+			RRule:    rule.String,
+			Timezone: tz.String,
+			Start:    start.Time.UTC(),
+			SeriesID: seriesID.String,
+		}
+	}
+	return t, nil
+}
+
+// nullTime nil落库为NULL
+func nullTime(t *time.Time) sql.NullTime {
+	if t == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: t.UTC(), Valid: true}
+}
+
+// nullString 空串落库为NULL
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+// placeholders 生成从$from开始的n个以逗号分隔的占位符
+func placeholders(from, n int) string {
+	ps := make([]string, n)
+	for i := range ps {
+		ps[i] = "$" + strconv.Itoa(from+i)
+	}
+	return strings.Join(ps, ", ")
+}
+
+func (r *TaskRepo) Create(ctx context.Context, t model.Task) (model.Task, error) {
+	return create(ctx, r.db, t)
+}
+
+func create(ctx context.Context, q querier, t model.Task) (model.Task, error) {
+	t = repo.Normalize(t)
+	var (
+		rule, tz, seriesID sql.NullString
+		start              sql.NullTime
+	)
+	if rec := t.Recurrence; rec != nil {
+		rule, tz, seriesID = nullString(rec.RRule), nullString(rec.Timezone), nullString(rec.SeriesID)
+		start = nullTime(&rec.Start)
+	}
+
+	_, err := q.ExecContext(ctx,
+		`INSERT INTO tasks(`+taskColumns+`)
+		 VALUES (`+placeholders(1, 14)+`)`,
+		t.ID, t.Title, t.Done, t.CreatedAt.UTC(), nullString(t.ParentID),
+		nullTime(t.DueAt), rule, tz, start, seriesID, t.Project, t.EstimateMinutes,
+		t.Column, t.Rank,
+	)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == pgUniqueViolation {
+		return model.Task{}, repo.ErrConflict
+	}
+	if err != nil {
+		return model.Task{}, fmt.Errorf("insert task: %w", err)
+	}
+	return t, nil
+}
+
+func (r *TaskRepo) List(ctx context.Context, q repo.ListQuery) ([]model.Task, error) {
+	where, args := whereClause(q)
+
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT `+taskColumns+` FROM tasks`+where+orderBy(q.Sort),
+		args...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query tasks: %w", err)
+	}
+	defer rows.Close()
+
+	out := make([]model.Task, 0)
+	for rows.Next() {
+		t, err := scanTask(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan: %w", err)
+		}
+		out = append(out, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows err: %w", err)
+	}
+	return out, nil
+}
+
+/*
+whereClause 把ListQuery编译成 " WHERE ..."（无条件时为空串）
+id集合用 = ANY($1) 传一个数组参数，不随id个数改变SQL文本
+*/
+func whereClause(q repo.ListQuery) (string, []any) {
+	var (
+		conds []string
+		args  []any
+	)
+	if q.IDs != nil {
+		if len(q.IDs) == 0 {
+			return " WHERE 1 = 0", nil
+		}
+		conds = append(conds, "id = ANY($1)")
+		args = append(args, q.IDs)
+	}
+	if q.Filter != nil {
+		sql, fargs := filter.ToSQL(q.Filter, filter.Postgres, len(args))
+		conds = append(conds, sql)
+		args = append(args, fargs...)
+	}
+	if len(conds) == 0 {
+		return "", nil
+	}
+	return " WHERE " + strings.Join(conds, " AND "), args
+}
+
+// eachPageSize Each每次从库里取出的行数
+const eachPageSize = 500
+
+/*
+Each 基于(created_at, id)的键集游标分页：每页读完并关闭结果集后再回调，
+不会在回调期间占着连接，也不会随偏移量增大而变慢
+*/
+func (r *TaskRepo) Each(ctx context.Context, q repo.ListQuery, fn func(model.Task) error) error {
+	where, args := whereClause(q)
+	var (
+		lastAt time.Time
+		lastID string
+	)
+	for page := 0; ; page++ {
+		cond, pageArgs := where, append([]any(nil), args...)
+		if page > 0 {
+			n := len(args)
+			keyset := fmt.Sprintf(`(created_at, id) > ($%d, $%d)`, n+1, n+2)
+			if cond == "" {
+				cond = " WHERE " + keyset
+			} else {
+				cond += " AND " + keyset
+			}
+			pageArgs = append(pageArgs, lastAt, lastID)
+		}
+		pageArgs = append(pageArgs, eachPageSize)
+
+		rows, err := r.db.QueryContext(ctx,
+			`SELECT `+taskColumns+` FROM tasks`+cond+` ORDER BY created_at ASC, id ASC LIMIT $`+strconv.Itoa(len(pageArgs)),
+			pageArgs...,
+		)
+		if err != nil {
+			return fmt.Errorf("query tasks: %w", err)
+		}
+		batch := make([]model.Task, 0, eachPageSize)
+		for rows.Next() {
+			t, err := scanTask(rows)
+			if err != nil {
+				rows.Close()
+				return fmt.Errorf("scan: %w", err)
+			}
+			batch = append(batch, t)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("rows err: %w", err)
+		}
+
+		for _, t := range batch {
+			if err := fn(t); err != nil {
+				return err
+			}
+		}
+		if len(batch) < eachPageSize {
+			return nil
+		}
+		last := batch[len(batch)-1]
+		lastAt, lastID = last.CreatedAt, last.ID
+	}
+}
+
+func (r *TaskRepo) Count(ctx context.Context, q repo.ListQuery) (int, error) {
+	where, args := whereClause(q)
+
+	var n int
+	if err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM tasks`+where, args...).Scan(&n); err != nil {
+		return 0, fmt.Errorf("count tasks: %w", err)
+	}
+	return n, nil
+}
+
+// LastRank 由idx_tasks_board(board_column, rank_key)直接给出，不扫描整列
+func (r *TaskRepo) LastRank(ctx context.Context, column string) (string, error) {
+	var last string
+	if err := r.db.QueryRowContext(ctx,
+		`SELECT COALESCE(MAX(rank_key), '') FROM tasks WHERE board_column = $1`, column,
+	).Scan(&last); err != nil {
+		return "", fmt.Errorf("last rank: %w", err)
+	}
+	return last, nil
+}
+
+// sortColumns 排序字段到列名的白名单映射
+var sortColumns = map[repo.SortField]string{
+	repo.SortCreatedAt: "created_at",
+	repo.SortTitle:     "LOWER(title)", // 与memory、MySQL的_ci排序规则一致，不区分大小写
+	repo.SortRank:      "rank_key",
+}
+
+// orderBy 生成ORDER BY子句；末尾追加created_at、id 使列表稳定
+func orderBy(s repo.Sort) string {
+	col, ok := sortColumns[s.Field]
+	if !ok {
+		return ` ORDER BY created_at ASC, id ASC`
+	}
+	dir := "ASC"
+	if s.Desc {
+		dir = "DESC"
+	}
+	return ` ORDER BY ` + col + ` ` + dir + `, created_at ASC, id ASC`
+}
+
+func (r *TaskRepo) Get(ctx context.Context, id string) (model.Task, bool, error) {
+	return get(ctx, r.db, id)
+}
+
+func get(ctx context.Context, q querier, id string) (model.Task, bool, error) {
+	return scanOne(q.QueryRowContext(ctx, `SELECT `+taskColumns+` FROM tasks WHERE id = $1`, id))
+}
+
+// scanOne 扫描单行结果，没有行时返回found=false
+func scanOne(row *sql.Row) (model.Task, bool, error) {
+	t, err := scanTask(row)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.Task{}, false, nil
+		}
+		return model.Task{}, false, fmt.Errorf("get task: %w", err)
+	}
+	return t, true, nil
+}
+
+func (r *TaskRepo) MarkDone(ctx context.Context, id string, done bool) (model.Task, bool, error) {
+	return scanOne(r.db.QueryRowContext(ctx,
+		`UPDATE tasks SET done = $1 WHERE id = $2 RETURNING `+taskColumns,
+		done, id,
+	))
+}
+
+func (r *TaskRepo) Update(ctx context.Context, id string, p repo.TaskPatch) (model.Task, bool, error) {
+	return update(ctx, r.db, id, p)
+}
+
+func update(ctx context.Context, q querier, id string, p repo.TaskPatch) (model.Task, bool, error) {
+	var (
+		sets []string
+		args []any
+	)
+	set := func(col string, v any) {
+		args = append(args, v)
+		sets = append(sets, col+" = $"+strconv.Itoa(len(args)))
+	}
+	if p.Title != nil {
+		set("title", *p.Title)
+	}
+	if p.Done != nil {
+		set("done", *p.Done)
+	}
+	if p.ParentID != nil {
+		set("parent_id", nullString(*p.ParentID))
+	}
+	if p.Project != nil {
+		set("project", *p.Project)
+	}
+	if p.EstimateMinutes != nil {
+		set("estimate_min", *p.EstimateMinutes)
+	}
+	if p.Column != nil {
+		set("board_column", *p.Column)
+	}
+	if p.Rank != nil {
+		set("rank_key", *p.Rank)
+	}
+	if len(sets) == 0 {
+		return get(ctx, q, id)
+	}
+
+	// PostgreSQL按匹配行计数，RETURNING没有行即not found
+	args = append(args, id)
+	return scanOne(q.QueryRowContext(ctx,
+		`UPDATE tasks SET `+strings.Join(sets, ", ")+` WHERE id = $`+strconv.Itoa(len(args))+` RETURNING `+taskColumns,
+		args...,
+	))
+}
+
+// treeLockKey SetParent的事务级advisory lock，所有修改父任务的事务依次执行
+const treeLockKey = 0x7461736b74726565 // "tasktree"
+
+/*
+SetParent 先取得treeLockKey再读取祖先链、检查并写入
+READ COMMITTED下拿到锁之后的每条语句都能看到上一个持锁事务提交的修改
+*/
+func (r *TaskRepo) SetParent(ctx context.Context, id, parentID string, check func([]string) error) (model.Task, bool, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return model.Task{}, false, fmt.Errorf("begin tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1)`, int64(treeLockKey)); err != nil {
+		return model.Task{}, false, fmt.Errorf("lock tree: %w", err)
+	}
+	parentOf := func(cur string) (string, bool, error) {
+		var parent sql.NullString
+		err := tx.QueryRowContext(ctx, `SELECT parent_id FROM tasks WHERE id = $1`, cur).Scan(&parent)
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", false, nil
+		}
+		if err != nil {
+			return "", false, fmt.Errorf("get parent: %w", err)
+		}
+		return parent.String, true, nil
+	}
+	if _, ok, err := parentOf(id); err != nil || !ok {
+		return model.Task{}, ok, err
+	}
+	if check != nil {
+		ancestors, err := repo.Ancestors(id, parentID, parentOf)
+		if err != nil {
+			return model.Task{}, false, err
+		}
+		if err := check(ancestors); err != nil {
+			return model.Task{}, true, err
+		}
+	}
+	t, ok, err := update(ctx, tx, id, repo.TaskPatch{ParentID: &parentID})
+	if err != nil || !ok {
+		return model.Task{}, ok, err
+	}
+	if err := tx.Commit(); err != nil {
+		return model.Task{}, false, fmt.Errorf("commit tx: %w", err)
+	}
+	return t, true, nil
+}
+
+func (r *TaskRepo) Delete(ctx context.Context, id string) (bool, error) {
+	return deleteTask(ctx, r.db, id)
+}
+
+func deleteTask(ctx context.Context, q querier, id string) (bool, error) {
+	res, err := q.ExecContext(ctx, `DELETE FROM tasks WHERE id = $1`, id)
+	if err != nil {
+		return false, fmt.Errorf("delete task: %w", err)
+	}
+	aff, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("rows affected: %w", err)
+	}
+	return aff > 0, nil
+}
+
+/*
+Batch
+  - 非原子：每条操作直接在连接池上执行
+  - 原子：整批放进一个事务，任一条失败则Rollback
+    （PostgreSQL中事务内一条语句出错后整个事务都不可再用，所以失败后立即回滚）
+*/
+func (r *TaskRepo) Batch(ctx context.Context, ops []repo.BatchOp, atomic bool) ([]repo.BatchResult, error) {
+	if !atomic {
+		results := make([]repo.BatchResult, len(ops))
+		for i, op := range ops {
+			results[i] = applyOp(ctx, r.db, op)
+		}
+		return results, nil
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("begin tx: %w", err)
+	}
+
+	results := make([]repo.BatchResult, len(ops))
+	for i, op := range ops {
+		results[i] = applyOp(ctx, tx, op)
+		if results[i].Failed() {
+			_ = tx.Rollback()
+			for j := range results {
+				if j != i {
+					results[j] = repo.BatchResult{Found: true, Err: repo.ErrBatchAborted}
+				}
+			}
+			return results, nil
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit tx: %w", err)
+	}
+	return results, nil
+}
+
+func applyOp(ctx context.Context, q querier, op repo.BatchOp) repo.BatchResult {
+	switch op.Kind {
+	case repo.BatchCreate:
+		t, err := create(ctx, q, op.Task)
+		return repo.BatchResult{Task: t, Found: true, Err: err}
+	case repo.BatchUpdate:
+		t, ok, err := update(ctx, q, op.ID, op.Patch)
+		return repo.BatchResult{Task: t, Found: ok, Err: err}
+	case repo.BatchDelete:
+		ok, err := deleteTask(ctx, q, op.ID)
+		return repo.BatchResult{Found: ok, Err: err}
+	default:
+		return repo.BatchResult{Found: true, Err: fmt.Errorf("unknown batch op: %s", op.Kind)}
+	}
+}
+
+// searchVector 与idx_tasks_title_fts的表达式保持一致，索引才会被使用
+const searchVector = `to_tsvector('simple', title)`
+
+/*
+Search 基于GIN表达式索引（idx_tasks_title_fts）的全文检索
+每个词元写成 term:* ：必须命中且按前缀匹配，相关度用ts_rank
+词元只含字母和数字（见search.Tokenize），不会带入tsquery的运算符
+注意：simple解析器按空白和标点切词，连续的中文会被当作一个词，只能从词首做前缀匹配
+*/
+func (r *TaskRepo) Search(ctx context.Context, q repo.SearchQuery) ([]repo.SearchHit, int, error) {
+	terms := make([]string, len(q.Terms))
+	for i, t := range q.Terms {
+		terms[i] = t + ":*"
+	}
+	query := strings.Join(terms, " & ")
+
+	var total int
+	if err := r.db.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM tasks WHERE `+searchVector+` @@ to_tsquery('simple', $1)`,
+		query,
+	).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("count search: %w", err)
+	}
+	if total == 0 {
+		return []repo.SearchHit{}, 0, nil
+	}
+
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT `+taskColumns+`, ts_rank(`+searchVector+`, to_tsquery('simple', $1)) AS score
+		   FROM tasks
+		  WHERE `+searchVector+` @@ to_tsquery('simple', $1)
+		  ORDER BY score DESC, created_at ASC, id ASC
+		  LIMIT $2 OFFSET $3`,
+		query, q.Limit, q.Offset,
+	)
+	if err != nil {
+		return nil, 0, fmt.Errorf("search tasks: %w", err)
+	}
+	defer rows.Close()
+
+	hits := make([]repo.SearchHit, 0, q.Limit)
+	for rows.Next() {
+		var score float64
+		t, err := scanTask(rows, &score)
+		if err != nil {
+			return nil, 0, fmt.Errorf("scan: %w", err)
+		}
+		hits = append(hits, repo.SearchHit{Task: t, Score: score})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("rows err: %w", err)
+	}
+	return hits, total, nil
+}
@@ -0,0 +1,23 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"github.com/kitouo/taskhub/internal/model"
+)
+
+type ReminderRepo interface {
+	/*
+		Claim 登记一条提醒，返回false表示已被登记过（已发送或其它实例正在发送）
+		先登记再发送，发送失败时Release以便下次重试
+	*/
+	Claim(ctx context.Context, r model.Reminder) (bool, error)
+	Release(ctx context.Context, r model.Reminder) error
+}
+
+// LeaseRepo 多副本之间的租约，同一时刻只有一个holder持有某个name
+type LeaseRepo interface {
+	// Acquire 获取或续期租约；租约被其它holder持有且未过期时返回false
+	Acquire(ctx context.Context, name, holder string, ttl time.Duration) (bool, error)
+}
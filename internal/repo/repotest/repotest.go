@@ -0,0 +1,682 @@
+/*
+Package repotest 与实现无关的repo.TaskRepo行为测试
+每个后端在自己的_test.go里调用Run，传入一个返回空仓库的工厂，即可验证它与其它实现行为一致：
+
+	func TestTaskRepo(t *testing.T) {
+		repotest.Run(t, func(t *testing.T) repo.TaskRepo { return NewTaskRepo() })
+	}
+
+repo.TimeEntryRepo的实现用RunTimeEntries验证
+*/
+package repotest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kitouo/taskhub/internal/filter"
+	"github.com/kitouo/taskhub/internal/model"
+	"github.com/kitouo/taskhub/internal/repo"
+)
+
+// Factory 返回一个空的TaskRepo；需要清理的资源用t.Cleanup注册
+type Factory func(t *testing.T) repo.TaskRepo
+
+var cases = []struct {
+	name string
+	fn   func(t *testing.T, r repo.TaskRepo)
+}{
+	{"CreateGet", testCreateGet},
+	{"NotFound", testNotFound},
+	{"DuplicateID", testDuplicateID},
+	{"DefaultOrder", testDefaultOrder},
+	{"Sort", testSort},
+	{"FilterAndIDs", testFilterAndIDs},
+	{"FilterCaseSensitive", testFilterCaseSensitive},
+	{"Each", testEach},
+	{"Update", testUpdate},
+	{"ConcurrentMarkDone", testConcurrentMarkDone},
+	{"TimezoneRoundTrip", testTimezoneRoundTrip},
+	{"BatchAtomic", testBatchAtomic},
+	{"BatchNonAtomic", testBatchNonAtomic},
+	{"Search", testSearch},
+	{"LastRank", testLastRank},
+	{"SetParent", testSetParent},
+	{"ConcurrentSetParent", testConcurrentSetParent},
+}
+
+// Run 对newRepo创建的仓库逐个运行全部用例，每个用例使用一个新仓库
+func Run(t *testing.T, newRepo Factory) {
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			c.fn(t, newRepo(t))
+		})
+	}
+}
+
+// base 用例中的基准时间，带纳秒与非UTC时区，用来暴露精度与时区处理上的差异
+var base = time.Date(2024, 3, 10, 9, 30, 0, 123456789, time.FixedZone("UTC+8", 8*3600))
+
+func task(id, title string, created time.Time) model.Task {
+	return model.Task{ID: id, Title: title, CreatedAt: created}
+}
+
+func mustCreate(t *testing.T, r repo.TaskRepo, tasks ...model.Task) {
+	t.Helper()
+	for _, tk := range tasks {
+		if _, err := r.Create(context.Background(), tk); err != nil {
+			t.Fatalf("Create(%s): %v", tk.ID, err)
+		}
+	}
+}
+
+func mustGet(t *testing.T, r repo.TaskRepo, id string) model.Task {
+	t.Helper()
+	got, ok, err := r.Get(context.Background(), id)
+	if err != nil || !ok {
+		t.Fatalf("Get(%s) = ok %v, err %v", id, ok, err)
+	}
+	return got
+}
+
+func ids(tasks []model.Task) []string {
+	out := make([]string, len(tasks))
+	for i, tk := range tasks {
+		out[i] = tk.ID
+	}
+	return out
+}
+
+func mustList(t *testing.T, r repo.TaskRepo, q repo.ListQuery) []string {
+	t.Helper()
+	got, err := r.List(context.Background(), q)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	return ids(got)
+}
+
+func mustParse(t *testing.T, src string) filter.Node {
+	t.Helper()
+	n, err := filter.Parse(src, base)
+	if err != nil {
+		t.Fatalf("filter.Parse(%q): %v", src, err)
+	}
+	return n
+}
+
+func testCreateGet(t *testing.T, r repo.TaskRepo) {
+	want := model.Task{
+		ID: "t1", Title: "write report", CreatedAt: base, ParentID: "p1",
+		Project: "ops", EstimateMinutes: 45, Column: "doing", Rank: "m",
+	}
+	created, err := r.Create(context.Background(), want)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	want = repo.Normalize(want)
+	if !equal(created, want) {
+		t.Errorf("Create returned %+v, want %+v", created, want)
+	}
+	if got := mustGet(t, r, "t1"); !equal(got, want) {
+		t.Errorf("Get = %+v, want %+v", got, want)
+	}
+}
+
+// 不存在的id一律以ok=false表示，不是错误
+func testNotFound(t *testing.T, r repo.TaskRepo) {
+	ctx := context.Background()
+	title := "x"
+	if _, ok, err := r.Get(ctx, "missing"); ok || err != nil {
+		t.Errorf("Get = ok %v, err %v", ok, err)
+	}
+	if _, ok, err := r.MarkDone(ctx, "missing", true); ok || err != nil {
+		t.Errorf("MarkDone = ok %v, err %v", ok, err)
+	}
+	if _, ok, err := r.Update(ctx, "missing", repo.TaskPatch{Title: &title}); ok || err != nil {
+		t.Errorf("Update = ok %v, err %v", ok, err)
+	}
+	if _, ok, err := r.Update(ctx, "missing", repo.TaskPatch{}); ok || err != nil {
+		t.Errorf("empty Update = ok %v, err %v", ok, err)
+	}
+	if ok, err := r.Delete(ctx, "missing"); ok || err != nil {
+		t.Errorf("Delete = ok %v, err %v", ok, err)
+	}
+
+	mustCreate(t, r, task("t1", "a", base))
+	if ok, err := r.Delete(ctx, "t1"); !ok || err != nil {
+		t.Fatalf("Delete = ok %v, err %v", ok, err)
+	}
+	if ok, err := r.Delete(ctx, "t1"); ok || err != nil {
+		t.Errorf("second Delete = ok %v, err %v", ok, err)
+	}
+	if _, ok, err := r.Get(ctx, "t1"); ok || err != nil {
+		t.Errorf("Get after Delete = ok %v, err %v", ok, err)
+	}
+}
+
+func testDuplicateID(t *testing.T, r repo.TaskRepo) {
+	ctx := context.Background()
+	mustCreate(t, r, task("t1", "original", base))
+
+	if _, err := r.Create(ctx, task("t1", "copy", base.Add(time.Hour))); !errors.Is(err, repo.ErrConflict) {
+		t.Fatalf("Create duplicate err = %v, want ErrConflict", err)
+	}
+	if got := mustGet(t, r, "t1"); got.Title != "original" {
+		t.Errorf("duplicate Create overwrote the task: %+v", got)
+	}
+
+	res, err := r.Batch(ctx, []repo.BatchOp{
+		{Kind: repo.BatchCreate, Task: task("t2", "new", base)},
+		{Kind: repo.BatchCreate, Task: task("t1", "copy", base)},
+	}, false)
+	if err != nil {
+		t.Fatalf("Batch: %v", err)
+	}
+	if res[0].Failed() || !errors.Is(res[1].Err, repo.ErrConflict) {
+		t.Errorf("Batch results = %+v, want ok then ErrConflict", res)
+	}
+	if n, _ := r.Count(ctx, repo.ListQuery{}); n != 2 {
+		t.Errorf("Count = %d, want 2", n)
+	}
+}
+
+/*
+默认顺序为created_at、id升序，与写入顺序无关；
+t2、t3的created_at相同，靠id区分
+*/
+func testDefaultOrder(t *testing.T, r repo.TaskRepo) {
+	mustCreate(t, r,
+		task("t3", "c", base.Add(time.Minute)),
+		task("t1", "a", base.Add(2*time.Minute)),
+		task("t2", "b", base.Add(time.Minute)),
+		task("t0", "d", base),
+	)
+	if got, want := mustList(t, r, repo.ListQuery{}), []string{"t0", "t2", "t3", "t1"}; !slices.Equal(got, want) {
+		t.Errorf("List = %v, want %v", got, want)
+	}
+}
+
+func testSort(t *testing.T, r repo.TaskRepo) {
+	mustCreate(t, r,
+		model.Task{ID: "a", Title: "beta", CreatedAt: base, Rank: "c"},
+		model.Task{ID: "b", Title: "Alpha", CreatedAt: base.Add(time.Second), Rank: "a"},
+		model.Task{ID: "c", Title: "beta", CreatedAt: base.Add(2 * time.Second), Rank: "b"},
+		model.Task{ID: "d", Title: "Gamma", CreatedAt: base.Add(-time.Second), Rank: "B"},
+	)
+	cases := []struct {
+		sort repo.Sort
+		want []string
+	}{
+		// 标题不区分大小写；同值按created_at、id升序兜底，降序时兜底顺序不变
+		{repo.Sort{Field: repo.SortTitle}, []string{"b", "a", "c", "d"}},
+		{repo.Sort{Field: repo.SortTitle, Desc: true}, []string{"d", "a", "c", "b"}},
+		{repo.Sort{Field: repo.SortCreatedAt, Desc: true}, []string{"c", "b", "a", "d"}},
+		// rank按字节序，大写字母排在小写之前
+		{repo.Sort{Field: repo.SortRank}, []string{"d", "b", "c", "a"}},
+	}
+	for _, c := range cases {
+		if got := mustList(t, r, repo.ListQuery{Sort: c.sort}); !slices.Equal(got, c.want) {
+			t.Errorf("List(%+v) = %v, want %v", c.sort, got, c.want)
+		}
+	}
+}
+
+func testFilterAndIDs(t *testing.T, r repo.TaskRepo) {
+	ctx := context.Background()
+	due := base.Add(24 * time.Hour)
+	mustCreate(t, r,
+		model.Task{ID: "t1", Title: "a", CreatedAt: base, Project: "ops", DueAt: &due},
+		model.Task{ID: "t2", Title: "b", CreatedAt: base.Add(time.Second), Done: true, Project: "ops"},
+		model.Task{ID: "t3", Title: "c", CreatedAt: base.Add(2 * time.Second)},
+	)
+	cases := []struct {
+		q    repo.ListQuery
+		want []string
+	}{
+		{repo.ListQuery{Filter: mustParse(t, "open")}, []string{"t1", "t3"}},
+		{repo.ListQuery{Filter: mustParse(t, `project = "ops"`)}, []string{"t1", "t2"}},
+		{repo.ListQuery{IDs: []string{"t3", "t2", "missing"}}, []string{"t2", "t3"}},
+		{repo.ListQuery{IDs: []string{"t2", "t3"}, Filter: mustParse(t, "open")}, []string{"t3"}},
+		{repo.ListQuery{IDs: []string{}}, []string{}},
+		// 没有due_at的任务按SQL的NULL语义处理：比较及其NOT都不匹配
+		{repo.ListQuery{Filter: mustParse(t, "NOT due < 2020-01-01")}, []string{"t1"}},
+		{repo.ListQuery{Filter: mustParse(t, "NOT (due < 2020-01-01 AND open)")}, []string{"t1", "t2"}},
+	}
+	for _, c := range cases {
+		if got := mustList(t, r, c.q); !slices.Equal(got, c.want) {
+			t.Errorf("List(%+v) = %v, want %v", c.q, got, c.want)
+		}
+		n, err := r.Count(ctx, c.q)
+		if err != nil || n != len(c.want) {
+			t.Errorf("Count(%+v) = %d, %v, want %d", c.q, n, err, len(c.want))
+		}
+	}
+}
+
+// Each 忽略Sort，按created_at、id升序遍历；回调返回的错误原样传出
+// testFilterCaseSensitive 项目与看板列只差大小写时是不同的值，各后端的过滤结果一致
+func testFilterCaseSensitive(t *testing.T, r repo.TaskRepo) {
+	ctx := context.Background()
+	mustCreate(t, r,
+		model.Task{ID: "t1", Title: "a", CreatedAt: base, Project: "Foo", Column: "Doing"},
+		model.Task{ID: "t2", Title: "b", CreatedAt: base.Add(time.Second), Project: "foo", Column: "doing"},
+	)
+	cases := []struct {
+		src  string
+		want []string
+	}{
+		{`project = "Foo"`, []string{"t1"}},
+		{`project = "foo"`, []string{"t2"}},
+		{`project != "Foo"`, []string{"t2"}},
+		{`column = "doing"`, []string{"t2"}},
+		{`column = "DOING"`, []string{}},
+	}
+	for _, c := range cases {
+		q := repo.ListQuery{Filter: mustParse(t, c.src)}
+		if got := mustList(t, r, q); !slices.Equal(got, c.want) {
+			t.Errorf("List(%s) = %v, want %v", c.src, got, c.want)
+		}
+		n, err := r.Count(ctx, q)
+		if err != nil || n != len(c.want) {
+			t.Errorf("Count(%s) = %d, %v, want %d", c.src, n, err, len(c.want))
+		}
+	}
+}
+
+func testEach(t *testing.T, r repo.TaskRepo) {
+	ctx := context.Background()
+	var want []string
+	for i := range 25 {
+		id := fmt.Sprintf("t%02d", i)
+		// 写入顺序与创建时间顺序相反
+		mustCreate(t, r, task(id, "x", base.Add(-time.Duration(i)*time.Second)))
+		want = append([]string{id}, want...)
+	}
+
+	var got []string
+	q := repo.ListQuery{Sort: repo.Sort{Field: repo.SortTitle, Desc: true}}
+	if err := r.Each(ctx, q, func(tk model.Task) error {
+		got = append(got, tk.ID)
+		return nil
+	}); err != nil {
+		t.Fatalf("Each: %v", err)
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("Each = %v, want %v", got, want)
+	}
+
+	stop := errors.New("stop")
+	n := 0
+	err := r.Each(ctx, repo.ListQuery{}, func(model.Task) error {
+		n++
+		if n == 3 {
+			return stop
+		}
+		return nil
+	})
+	if !errors.Is(err, stop) || n != 3 {
+		t.Errorf("Each with failing callback = %v after %d calls, want stop after 3", err, n)
+	}
+}
+
+func testUpdate(t *testing.T, r repo.TaskRepo) {
+	ctx := context.Background()
+	mustCreate(t, r, model.Task{ID: "t1", Title: "old", CreatedAt: base, ParentID: "p", Project: "ops", EstimateMinutes: 30})
+
+	title, parent, project, est, col, rank := "new", "", "", 0, "done", "n"
+	got, ok, err := r.Update(ctx, "t1", repo.TaskPatch{
+		Title: &title, ParentID: &parent, Project: &project, EstimateMinutes: &est, Column: &col, Rank: &rank,
+	})
+	if err != nil || !ok {
+		t.Fatalf("Update = ok %v, err %v", ok, err)
+	}
+	want := repo.Normalize(model.Task{ID: "t1", Title: "new", CreatedAt: base, Column: "done", Rank: "n"})
+	if !equal(got, want) {
+		t.Errorf("Update returned %+v, want %+v", got, want)
+	}
+	if got := mustGet(t, r, "t1"); !equal(got, want) {
+		t.Errorf("Get after Update = %+v, want %+v", got, want)
+	}
+
+	// 空patch等价于Get
+	if got, ok, err := r.Update(ctx, "t1", repo.TaskPatch{}); err != nil || !ok || !equal(got, want) {
+		t.Errorf("empty Update = %+v, %v, %v", got, ok, err)
+	}
+}
+
+/*
+并发地重复标记同一个任务：每次调用都应当找到任务并返回完成状态，
+“值没有变化”不能被当成not found
+*/
+func testConcurrentMarkDone(t *testing.T, r repo.TaskRepo) {
+	ctx := context.Background()
+	mustCreate(t, r, task("t1", "a", base), task("t2", "b", base))
+
+	const workers = 16
+	var wg sync.WaitGroup
+	errs := make(chan error, 2*workers)
+	for i := range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			id := []string{"t1", "t2"}[i%2]
+			for range 2 {
+				got, ok, err := r.MarkDone(ctx, id, true)
+				switch {
+				case err != nil:
+					errs <- err
+				case !ok:
+					errs <- fmt.Errorf("MarkDone(%s) not found", id)
+				case !got.Done || got.ID != id:
+					errs <- fmt.Errorf("MarkDone(%s) returned %+v", id, got)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	for _, id := range []string{"t1", "t2"} {
+		if got := mustGet(t, r, id); !got.Done {
+			t.Errorf("%s not done", id)
+		}
+	}
+	got, ok, err := r.MarkDone(ctx, "t1", false)
+	if err != nil || !ok || got.Done {
+		t.Errorf("MarkDone(false) = %+v, %v, %v", got, ok, err)
+	}
+}
+
+// 时间以UTC、微秒精度读回，并且与写入的时刻相等
+func testTimezoneRoundTrip(t *testing.T, r repo.TaskRepo) {
+	ny := time.FixedZone("UTC-5", -5*3600)
+	due := time.Date(2024, 12, 31, 23, 59, 59, 999999999, ny)
+	start := time.Date(2024, 1, 1, 0, 0, 0, 500, ny)
+	in := model.Task{
+		ID: "t1", Title: "a", CreatedAt: base, DueAt: &due,
+		Recurrence: &model.Recurrence{RRule: "FREQ=DAILY", Timezone: "America/New_York", Start: start, SeriesID: "s1"},
+	}
+	created, err := r.Create(context.Background(), in)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if in.DueAt != &due || due.Location() != ny {
+		t.Fatalf("Create modified its argument")
+	}
+
+	check := func(what string, got model.Task) {
+		t.Helper()
+		times := []struct {
+			name      string
+			got, want time.Time
+		}{
+			{"created_at", got.CreatedAt, base},
+			{"due_at", deref(got.DueAt), due},
+			{"rrule_start", recStart(got), start},
+		}
+		for _, tt := range times {
+			want := tt.want.Truncate(time.Microsecond)
+			if !tt.got.Equal(want) || tt.got.Location() != time.UTC {
+				t.Errorf("%s %s = %v, want %v in UTC", what, tt.name, tt.got, want.UTC())
+			}
+		}
+	}
+	check("Create", created)
+	check("Get", mustGet(t, r, "t1"))
+	list, err := r.List(context.Background(), repo.ListQuery{})
+	if err != nil || len(list) != 1 {
+		t.Fatalf("List = %v, %v", list, err)
+	}
+	check("List", list[0])
+
+	// 时间过滤条件与保存的值在同一时间轴上比较
+	if got := mustList(t, r, repo.ListQuery{Filter: mustParse(t, "due_at > 2025-01-01")}); !slices.Equal(got, []string{"t1"}) {
+		t.Errorf("due_at filter = %v, want [t1]", got)
+	}
+	if got := mustList(t, r, repo.ListQuery{Filter: mustParse(t, "due_at > 2025-01-02")}); len(got) != 0 {
+		t.Errorf("due_at filter = %v, want none", got)
+	}
+}
+
+func deref(t *time.Time) time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+	return *t
+}
+
+func recStart(t model.Task) time.Time {
+	if t.Recurrence == nil {
+		return time.Time{}
+	}
+	return t.Recurrence.Start
+}
+
+func testBatchAtomic(t *testing.T, r repo.TaskRepo) {
+	ctx := context.Background()
+	mustCreate(t, r, task("t1", "a", base), task("t2", "b", base))
+	title := "changed"
+
+	res, err := r.Batch(ctx, []repo.BatchOp{
+		{Kind: repo.BatchCreate, Task: task("t3", "c", base)},
+		{Kind: repo.BatchUpdate, ID: "t1", Patch: repo.TaskPatch{Title: &title}},
+		{Kind: repo.BatchDelete, ID: "t2"},
+		{Kind: repo.BatchUpdate, ID: "missing", Patch: repo.TaskPatch{Title: &title}},
+	}, true)
+	if err != nil {
+		t.Fatalf("Batch: %v", err)
+	}
+	for i := range 3 {
+		if !errors.Is(res[i].Err, repo.ErrBatchAborted) {
+			t.Errorf("result %d err = %v, want ErrBatchAborted", i, res[i].Err)
+		}
+	}
+	if res[3].Found || res[3].Err != nil {
+		t.Errorf("failing op result = %+v, want not found", res[3])
+	}
+	if got := mustList(t, r, repo.ListQuery{}); !slices.Equal(got, []string{"t1", "t2"}) {
+		t.Errorf("after rollback List = %v, want [t1 t2]", got)
+	}
+	if got := mustGet(t, r, "t1"); got.Title != "a" {
+		t.Errorf("after rollback t1 = %+v", got)
+	}
+
+	res, err = r.Batch(ctx, []repo.BatchOp{
+		{Kind: repo.BatchCreate, Task: task("t3", "c", base)},
+		{Kind: repo.BatchUpdate, ID: "t1", Patch: repo.TaskPatch{Title: &title}},
+		{Kind: repo.BatchDelete, ID: "t2"},
+	}, true)
+	if err != nil {
+		t.Fatalf("Batch: %v", err)
+	}
+	for i, rr := range res {
+		if rr.Failed() {
+			t.Errorf("result %d = %+v", i, rr)
+		}
+	}
+	if res[1].Task.Title != title {
+		t.Errorf("update result = %+v", res[1].Task)
+	}
+	if got := mustList(t, r, repo.ListQuery{}); !slices.Equal(got, []string{"t1", "t3"}) {
+		t.Errorf("after commit List = %v, want [t1 t3]", got)
+	}
+}
+
+func testBatchNonAtomic(t *testing.T, r repo.TaskRepo) {
+	ctx := context.Background()
+	mustCreate(t, r, task("t1", "a", base))
+	title := "changed"
+
+	res, err := r.Batch(ctx, []repo.BatchOp{
+		{Kind: repo.BatchUpdate, ID: "missing", Patch: repo.TaskPatch{Title: &title}},
+		{Kind: repo.BatchUpdate, ID: "t1", Patch: repo.TaskPatch{Title: &title}},
+		{Kind: repo.BatchDelete, ID: "missing"},
+	}, false)
+	if err != nil {
+		t.Fatalf("Batch: %v", err)
+	}
+	if res[0].Found || res[0].Err != nil || res[1].Failed() || res[2].Found || res[2].Err != nil {
+		t.Errorf("Batch results = %+v", res)
+	}
+	if got := mustGet(t, r, "t1"); got.Title != title {
+		t.Errorf("t1 = %+v, want title %q", got, title)
+	}
+}
+
+// 只比较命中集合与总数，相关度的具体数值因实现而异
+func testSearch(t *testing.T, r repo.TaskRepo) {
+	ctx := context.Background()
+	mustCreate(t, r,
+		task("t1", "deploy api server", base),
+		task("t2", "Deploy web", base.Add(time.Second)),
+		task("t3", "write docs", base.Add(2*time.Second)),
+	)
+	title := "deployment notes"
+	if _, ok, err := r.Update(ctx, "t3", repo.TaskPatch{Title: &title}); !ok || err != nil {
+		t.Fatalf("Update = %v, %v", ok, err)
+	}
+	if ok, err := r.Delete(ctx, "t2"); !ok || err != nil {
+		t.Fatalf("Delete = %v, %v", ok, err)
+	}
+
+	cases := []struct {
+		terms []string
+		want  []string
+	}{
+		{[]string{"depl"}, []string{"t1", "t3"}},
+		{[]string{"deploy", "api"}, []string{"t1"}},
+		{[]string{"docs"}, nil},
+		{[]string{"web"}, nil},
+	}
+	for _, c := range cases {
+		hits, total, err := r.Search(ctx, repo.SearchQuery{Terms: c.terms, Limit: 10})
+		if err != nil {
+			t.Fatalf("Search(%v): %v", c.terms, err)
+		}
+		var got []string
+		for _, h := range hits {
+			got = append(got, h.Task.ID)
+		}
+		slices.Sort(got)
+		if !slices.Equal(got, c.want) || total != len(c.want) {
+			t.Errorf("Search(%v) = %v (total %d), want %v", c.terms, got, total, c.want)
+		}
+	}
+
+	hits, total, err := r.Search(ctx, repo.SearchQuery{Terms: []string{"depl"}, Limit: 1, Offset: 1})
+	if err != nil || len(hits) != 1 || total != 2 {
+		t.Errorf("paged Search = %d hits, total %d, %v", len(hits), total, err)
+	}
+}
+
+// equal 比较两个任务中落库的字段；时间用Equal比较，指针字段比较指向的值
+func testLastRank(t *testing.T, r repo.TaskRepo) {
+	ctx := context.Background()
+	mustCreate(t, r,
+		model.Task{ID: "t1", Title: "a", CreatedAt: base, Column: "todo", Rank: "m"},
+		model.Task{ID: "t2", Title: "b", CreatedAt: base, Column: "todo", Rank: "t"},
+		model.Task{ID: "t3", Title: "c", CreatedAt: base, Column: "done", Rank: "z"},
+		model.Task{ID: "t4", Title: "d", CreatedAt: base, Rank: "b"},
+		// 大小写按字节比较："Z" < "a"
+		model.Task{ID: "t5", Title: "e", CreatedAt: base, Column: "todo", Rank: "Z"},
+	)
+	for column, want := range map[string]string{"todo": "t", "done": "z", "": "b", "missing": ""} {
+		if got, err := r.LastRank(ctx, column); err != nil || got != want {
+			t.Errorf("LastRank(%q) = %q, %v, want %q", column, got, err, want)
+		}
+	}
+}
+
+func testSetParent(t *testing.T, r repo.TaskRepo) {
+	ctx := context.Background()
+	a, b, c := task("a", "a", base), task("b", "b", base), task("c", "c", base)
+	b.ParentID, c.ParentID = "a", "b"
+	mustCreate(t, r, a, b, c, task("d", "d", base))
+
+	var seen []string
+	record := func(ancestors []string) error {
+		seen = ancestors
+		return nil
+	}
+	got, ok, err := r.SetParent(ctx, "d", "c", record)
+	if err != nil || !ok || got.ParentID != "c" || fmt.Sprint(seen) != "[c b a]" {
+		t.Fatalf("SetParent(d, c) = %+v, %v, %v; ancestors %v", got, ok, err, seen)
+	}
+	// 祖先链在任务自身处停止；parentID不存在时为空
+	if _, _, err := r.SetParent(ctx, "a", "d", record); err != nil || fmt.Sprint(seen) != "[d c b a]" {
+		t.Errorf("SetParent(a, d) ancestors = %v, %v", seen, err)
+	}
+	if _, _, err := r.SetParent(ctx, "a", "missing", record); err != nil || len(seen) != 0 {
+		t.Errorf("SetParent(a, missing) ancestors = %v, %v", seen, err)
+	}
+
+	// check拒绝时原样返回错误且不修改
+	reject := errors.New("rejected")
+	if _, ok, err := r.SetParent(ctx, "b", "", func([]string) error { return reject }); !ok || !errors.Is(err, reject) {
+		t.Errorf("rejected SetParent = %v, %v", ok, err)
+	}
+	if got := mustGet(t, r, "b"); got.ParentID != "a" {
+		t.Errorf("b.ParentID = %q after rejected SetParent", got.ParentID)
+	}
+	if _, ok, err := r.SetParent(ctx, "missing", "a", record); ok || err != nil {
+		t.Errorf("SetParent(missing) = %v, %v; want not found", ok, err)
+	}
+	if got, ok, err := r.SetParent(ctx, "b", "", nil); err != nil || !ok || got.ParentID != "" {
+		t.Errorf("SetParent(b, top) = %+v, %v, %v", got, ok, err)
+	}
+}
+
+// 并发地把两个任务互相挂到对方下面，检查与写入串行，最多只能成功一个
+func testConcurrentSetParent(t *testing.T, r repo.TaskRepo) {
+	ctx := context.Background()
+	noCycle := func(id string) func([]string) error {
+		return func(ancestors []string) error {
+			if slices.Contains(ancestors, id) {
+				return errors.New("cycle")
+			}
+			return nil
+		}
+	}
+	for i := range 10 {
+		a, b := fmt.Sprintf("a%d", i), fmt.Sprintf("b%d", i)
+		mustCreate(t, r, task(a, a, base), task(b, b, base))
+		var (
+			wg   sync.WaitGroup
+			errs [2]error
+		)
+		wg.Add(2)
+		go func() { defer wg.Done(); _, _, errs[0] = r.SetParent(ctx, a, b, noCycle(a)) }()
+		go func() { defer wg.Done(); _, _, errs[1] = r.SetParent(ctx, b, a, noCycle(b)) }()
+		wg.Wait()
+		if errs[0] == nil && errs[1] == nil {
+			t.Fatalf("both %s and %s moved under each other", a, b)
+		}
+	}
+}
+
+func equal(a, b model.Task) bool {
+	if a.ID != b.ID || a.Title != b.Title || a.Done != b.Done || a.ParentID != b.ParentID ||
+		a.Project != b.Project || a.EstimateMinutes != b.EstimateMinutes || a.Column != b.Column || a.Rank != b.Rank {
+		return false
+	}
+	if !a.CreatedAt.Equal(b.CreatedAt) || (a.DueAt == nil) != (b.DueAt == nil) || !deref(a.DueAt).Equal(deref(b.DueAt)) {
+		return false
+	}
+	if (a.Recurrence == nil) != (b.Recurrence == nil) {
+		return false
+	}
+	if a.Recurrence == nil {
+		return true
+	}
+	ra, rb := *a.Recurrence, *b.Recurrence
+	return ra.RRule == rb.RRule && ra.Timezone == rb.Timezone && ra.SeriesID == rb.SeriesID && ra.Start.Equal(rb.Start)
+}
@@ -0,0 +1,138 @@
+package repotest
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/kitouo/taskhub/internal/model"
+	"github.com/kitouo/taskhub/internal/repo"
+)
+
+// TimeFactory 返回一对空的工时仓库与任务仓库，按项目汇总时工时仓库从该任务仓库读取项目
+type TimeFactory func(t *testing.T) (repo.TimeEntryRepo, repo.TaskRepo)
+
+var timeCases = []struct {
+	name string
+	fn   func(t *testing.T, r repo.TimeEntryRepo, tasks repo.TaskRepo)
+}{
+	{"OneRunningTimerPerUser", testOneRunningTimer},
+	{"Stop", testStopTimer},
+	{"Report", testTimeReport},
+}
+
+// RunTimeEntries 对newRepo创建的工时仓库逐个运行用例，每个用例使用新的仓库
+func RunTimeEntries(t *testing.T, newRepo TimeFactory) {
+	for _, c := range timeCases {
+		t.Run(c.name, func(t *testing.T) {
+			r, tasks := newRepo(t)
+			c.fn(t, r, tasks)
+		})
+	}
+}
+
+// tbase 工时用例的基准时间，取整到微秒：DATETIME(6)只保留到微秒
+var tbase = time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC)
+
+func entry(id, taskID, userID string, start time.Time, end *time.Time) model.TimeEntry {
+	return model.TimeEntry{ID: id, TaskID: taskID, UserID: userID, Start: start, End: end, CreatedAt: start}
+}
+
+func at(d time.Duration) *time.Time {
+	t := tbase.Add(d)
+	return &t
+}
+
+func mustCreateEntries(t *testing.T, r repo.TimeEntryRepo, entries ...model.TimeEntry) {
+	t.Helper()
+	for _, e := range entries {
+		if _, err := r.Create(context.Background(), e); err != nil {
+			t.Fatalf("Create(%s): %v", e.ID, err)
+		}
+	}
+}
+
+func testOneRunningTimer(t *testing.T, r repo.TimeEntryRepo, _ repo.TaskRepo) {
+	ctx := context.Background()
+	mustCreateEntries(t, r, entry("e1", "a", "u1", tbase, nil))
+
+	if _, err := r.Create(ctx, entry("e2", "b", "u1", tbase.Add(time.Minute), nil)); !errors.Is(err, repo.ErrTimerRunning) {
+		t.Errorf("second running timer err = %v, want ErrTimerRunning", err)
+	}
+	// 其它用户的计时器、同一用户已结束的记录不受限制
+	mustCreateEntries(t, r,
+		entry("e3", "a", "u2", tbase, nil),
+		entry("e4", "b", "u1", tbase.Add(-2*time.Hour), at(-time.Hour)),
+	)
+
+	got, ok, err := r.Running(ctx, "u1")
+	if err != nil || !ok || got.ID != "e1" || got.End != nil {
+		t.Errorf("Running(u1) = %+v, %v, %v", got, ok, err)
+	}
+	if _, ok, err := r.Running(ctx, "u3"); ok || err != nil {
+		t.Errorf("Running(u3) = %v, %v; want none", ok, err)
+	}
+}
+
+func testStopTimer(t *testing.T, r repo.TimeEntryRepo, _ repo.TaskRepo) {
+	ctx := context.Background()
+	mustCreateEntries(t, r, entry("e1", "a", "u1", tbase, nil))
+
+	got, ok, err := r.Stop(ctx, "e1", *at(90 * time.Second))
+	if err != nil || !ok || got.End == nil || !got.End.Equal(*at(90 * time.Second)) {
+		t.Fatalf("Stop = %+v, %v, %v", got, ok, err)
+	}
+	// 已结束的记录不能再次停止
+	if _, ok, err := r.Stop(ctx, "e1", *at(time.Hour)); ok || err != nil {
+		t.Errorf("second Stop = %v, %v; want not found", ok, err)
+	}
+	if _, ok, err := r.Stop(ctx, "missing", *at(time.Hour)); ok || err != nil {
+		t.Errorf("Stop(missing) = %v, %v; want not found", ok, err)
+	}
+	if _, ok, _ := r.Running(ctx, "u1"); ok {
+		t.Error("timer still running after Stop")
+	}
+	if e, _, _ := r.Get(ctx, "e1"); e.End == nil || !e.End.Equal(*at(90 * time.Second)) {
+		t.Errorf("Get after Stop = %+v", e)
+	}
+	// 停止后可以启动新的计时器
+	mustCreateEntries(t, r, entry("e2", "b", "u1", tbase.Add(time.Hour), nil))
+}
+
+// 跨越区间边界的记录只计区间内的部分，运行中的计时器与区间外的记录不计入
+func testTimeReport(t *testing.T, r repo.TimeEntryRepo, tasks repo.TaskRepo) {
+	ctx := context.Background()
+	mustCreate(t, tasks,
+		model.Task{ID: "a", Title: "a", CreatedAt: base, Project: "ops"},
+		model.Task{ID: "b", Title: "b", CreatedAt: base},
+	)
+	mustCreateEntries(t, r,
+		entry("e1", "a", "u1", tbase.Add(-time.Hour), at(time.Hour)),                  // 前半段在区间外：计1h
+		entry("e2", "b", "u2", tbase.Add(2*time.Hour), at(3*time.Hour)),               // 完全在区间内：1h
+		entry("e3", "a", "u2", tbase.Add(9*time.Hour), at(11*time.Hour)),              // 后半段在区间外：计1h
+		entry("e4", "b", "u1", tbase.Add(-3*time.Hour), at(-2*time.Hour)),             // 区间外
+		entry("e5", "a", "u3", tbase.Add(4*time.Hour), nil),                           // 运行中
+		entry("e6", "b", "u1", tbase.Add(10*time.Hour), at(10*time.Hour+time.Second)), // 恰好从To开始
+	)
+
+	h := int64(3600)
+	cases := []struct {
+		group model.TimeGroup
+		want  []model.TimeReportRow
+	}{
+		{model.TimeByTask, []model.TimeReportRow{{Key: "a", Seconds: 2 * h, Entries: 2}, {Key: "b", Seconds: h, Entries: 1}}},
+		{model.TimeByProject, []model.TimeReportRow{{Key: "ops", Seconds: 2 * h, Entries: 2}, {Key: "", Seconds: h, Entries: 1}}},
+		{model.TimeByUser, []model.TimeReportRow{{Key: "u2", Seconds: 2 * h, Entries: 2}, {Key: "u1", Seconds: h, Entries: 1}}},
+	}
+	for _, c := range cases {
+		got, err := r.Report(ctx, repo.TimeReportQuery{GroupBy: c.group, From: tbase, To: tbase.Add(10 * time.Hour)})
+		if err != nil {
+			t.Fatalf("Report(%s): %v", c.group, err)
+		}
+		if !slices.Equal(got, c.want) {
+			t.Errorf("Report(%s) = %+v, want %+v", c.group, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,540 @@
+/*
+Package sqliterepo 基于SQLite的repo实现，面向单机部署
+  - 读写分别走db.SQLite的读连接池与单连接写池
+  - 时间以固定宽度的UTC文本保存（timeLayout），字典序即时间顺序，过滤条件中的时间参数也按同样格式传入
+  - 注意：SQLite的LOWER()只处理ASCII，title的大小写不敏感比较对非ASCII字母不生效
+*/
+package sqliterepo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/kitouo/taskhub/internal/db"
+	"github.com/kitouo/taskhub/internal/filter"
+	"github.com/kitouo/taskhub/internal/model"
+	"github.com/kitouo/taskhub/internal/repo"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+type TaskRepo struct {
+	write *sql.DB
+	read  *sql.DB
+}
+
+func NewTaskRepo(conn *db.SQLite) *TaskRepo {
+	return &TaskRepo{write: conn.Write, read: conn.Read}
+}
+
+// querier 抽象*sql.DB与*sql.Tx，使同一套SQL既能单独执行也能放进事务
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+const taskColumns = `id, title, done, created_at, parent_id, due_at, rrule, rrule_tz, rrule_start, series_id, project, estimate_min, board_column, rank_key`
+
+// timeLayout 固定9位小数，保证字典序与时间顺序一致
+const timeLayout = "2006-01-02T15:04:05.000000000Z"
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(timeLayout)
+}
+
+// nullTime nil落库为NULL
+func nullTime(t *time.Time) sql.NullString {
+	if t == nil {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: formatTime(*t), Valid: true}
+}
+
+// nullString 空串落库为NULL
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+// scanner 抽象*sql.Row与*sql.Rows
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+// scanTask 按taskColumns的顺序扫描一行，extra用于接收追加在其后的列
+func scanTask(s scanner, extra ...any) (model.Task, error) {
+	var (
+		t        model.Task
+		ct       string
+		parentID sql.NullString
+		dueAt    sql.NullString
+		rule     sql.NullString
+		tz       sql.NullString
+		start    sql.NullString
+		seriesID sql.NullString
+	)
+	dest := append([]any{&t.ID, &t.Title, &t.Done, &ct, &parentID, &dueAt, &rule, &tz, &start, &seriesID, &t.Project, &t.EstimateMinutes, &t.Column, &t.Rank}, extra...)
+	if err := s.Scan(dest...); err != nil {
+		return model.Task{}, err
+	}
+	var err error
+	if t.CreatedAt, err = time.Parse(timeLayout, ct); err != nil {
+		return model.Task{}, fmt.Errorf("created_at: %w", err)
+	}
+	t.ParentID = parentID.String
+	if dueAt.Valid {
+		d, err := time.Parse(timeLayout, dueAt.String)
+		if err != nil {
+			return model.Task{}, fmt.Errorf("due_at: %w", err)
+		}
+		t.DueAt = &d
+	}
+	if rule.Valid {
+		st, err := time.Parse(timeLayout, start.String)
+		if err != nil {
+			return model.Task{}, fmt.Errorf("rrule_start: %w", err)
+		}
+		t.Recurrence = &model.Recurrence{
+			RRule:    rule.String,
+			Timezone: tz.String,
+			Start:    st,
+			SeriesID: seriesID.String,
+		}
+	}
+	return t, nil
+}
+
+func (r *TaskRepo) Create(ctx context.Context, t model.Task) (model.Task, error) {
+	return create(ctx, r.write, t)
+}
+
+func create(ctx context.Context, q querier, t model.Task) (model.Task, error) {
+	t = repo.Normalize(t)
+	var (
+		rule, tz, seriesID sql.NullString
+		start              sql.NullString
+	)
+	if rec := t.Recurrence; rec != nil {
+		rule, tz, seriesID = nullString(rec.RRule), nullString(rec.Timezone), nullString(rec.SeriesID)
+		start = nullTime(&rec.Start)
+	}
+
+	_, err := q.ExecContext(ctx,
+		`INSERT INTO tasks(`+taskColumns+`)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		t.ID, t.Title, t.Done, formatTime(t.CreatedAt), nullString(t.ParentID),
+		nullTime(t.DueAt), rule, tz, start, seriesID, t.Project, t.EstimateMinutes,
+		t.Column, t.Rank,
+	)
+	var liteErr *sqlite.Error
+	if errors.As(err, &liteErr) && liteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE {
+		return model.Task{}, repo.ErrConflict
+	}
+	if err != nil {
+		return model.Task{}, fmt.Errorf("insert task: %w", err)
+	}
+	return t, nil
+}
+
+func (r *TaskRepo) List(ctx context.Context, q repo.ListQuery) ([]model.Task, error) {
+	where, args := whereClause(q)
+
+	rows, err := r.read.QueryContext(ctx,
+		`SELECT `+taskColumns+` FROM tasks`+where+orderBy(q.Sort),
+		args...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query tasks: %w", err)
+	}
+	defer rows.Close()
+
+	out := make([]model.Task, 0)
+	for rows.Next() {
+		t, err := scanTask(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan: %w", err)
+		}
+		out = append(out, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows err: %w", err)
+	}
+	return out, nil
+}
+
+// whereClause 把ListQuery编译成 " WHERE ..."（无条件时为空串）
+func whereClause(q repo.ListQuery) (string, []any) {
+	var (
+		conds []string
+		args  []any
+	)
+	if q.IDs != nil {
+		if len(q.IDs) == 0 {
+			return " WHERE 1 = 0", nil
+		}
+		conds = append(conds, "id IN ("+placeholders(len(q.IDs))+")")
+		for _, id := range q.IDs {
+			args = append(args, id)
+		}
+	}
+	if q.Filter != nil {
+		sql, fargs := filter.ToSQL(q.Filter, filter.MySQL, len(args))
+		conds = append(conds, sql)
+		for _, a := range fargs {
+			// 时间列按文本比较，参数必须与落库格式一致
+			if t, ok := a.(time.Time); ok {
+				a = formatTime(t)
+			}
+			args = append(args, a)
+		}
+	}
+	if len(conds) == 0 {
+		return "", nil
+	}
+	return " WHERE " + strings.Join(conds, " AND "), args
+}
+
+// placeholders 生成n个以逗号分隔的"?"
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
+
+// eachPageSize Each每次从库里取出的行数
+const eachPageSize = 500
+
+/*
+Each 基于(created_at, id)的键集游标分页：每页读完并关闭结果集后再回调，
+不会在回调期间占着连接，也不会随偏移量增大而变慢
+*/
+func (r *TaskRepo) Each(ctx context.Context, q repo.ListQuery, fn func(model.Task) error) error {
+	where, args := whereClause(q)
+	var lastAt, lastID string
+	for page := 0; ; page++ {
+		cond, pageArgs := where, args
+		if page > 0 {
+			keyset := `(created_at, id) > (?, ?)`
+			if cond == "" {
+				cond = " WHERE " + keyset
+			} else {
+				cond += " AND " + keyset
+			}
+			pageArgs = append(append([]any(nil), args...), lastAt, lastID)
+		}
+
+		rows, err := r.read.QueryContext(ctx,
+			`SELECT `+taskColumns+` FROM tasks`+cond+` ORDER BY created_at ASC, id ASC LIMIT ?`,
+			append(pageArgs, eachPageSize)...,
+		)
+		if err != nil {
+			return fmt.Errorf("query tasks: %w", err)
+		}
+		batch := make([]model.Task, 0, eachPageSize)
+		for rows.Next() {
+			t, err := scanTask(rows)
+			if err != nil {
+				rows.Close()
+				return fmt.Errorf("scan: %w", err)
+			}
+			batch = append(batch, t)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("rows err: %w", err)
+		}
+
+		for _, t := range batch {
+			if err := fn(t); err != nil {
+				return err
+			}
+		}
+		if len(batch) < eachPageSize {
+			return nil
+		}
+		last := batch[len(batch)-1]
+		lastAt, lastID = formatTime(last.CreatedAt), last.ID
+	}
+}
+
+func (r *TaskRepo) Count(ctx context.Context, q repo.ListQuery) (int, error) {
+	where, args := whereClause(q)
+
+	var n int
+	if err := r.read.QueryRowContext(ctx, `SELECT COUNT(*) FROM tasks`+where, args...).Scan(&n); err != nil {
+		return 0, fmt.Errorf("count tasks: %w", err)
+	}
+	return n, nil
+}
+
+// LastRank 由idx_tasks_board(board_column, rank_key)直接给出，不扫描整列
+func (r *TaskRepo) LastRank(ctx context.Context, column string) (string, error) {
+	var last string
+	if err := r.read.QueryRowContext(ctx,
+		`SELECT COALESCE(MAX(rank_key), '') FROM tasks WHERE board_column = ?`, column,
+	).Scan(&last); err != nil {
+		return "", fmt.Errorf("last rank: %w", err)
+	}
+	return last, nil
+}
+
+// sortColumns 排序字段到列名的白名单映射
+var sortColumns = map[repo.SortField]string{
+	repo.SortCreatedAt: "created_at",
+	repo.SortTitle:     "LOWER(title)", // 与memory、MySQL的_ci排序规则一致，不区分大小写
+	repo.SortRank:      "rank_key",
+}
+
+// orderBy 生成ORDER BY子句；末尾追加created_at、id 使列表稳定
+func orderBy(s repo.Sort) string {
+	col, ok := sortColumns[s.Field]
+	if !ok {
+		return ` ORDER BY created_at ASC, id ASC`
+	}
+	dir := "ASC"
+	if s.Desc {
+		dir = "DESC"
+	}
+	return ` ORDER BY ` + col + ` ` + dir + `, created_at ASC, id ASC`
+}
+
+func (r *TaskRepo) Get(ctx context.Context, id string) (model.Task, bool, error) {
+	return get(ctx, r.read, id)
+}
+
+func get(ctx context.Context, q querier, id string) (model.Task, bool, error) {
+	return scanOne(q.QueryRowContext(ctx, `SELECT `+taskColumns+` FROM tasks WHERE id = ?`, id))
+}
+
+// scanOne 扫描单行结果，没有行时返回found=false
+func scanOne(row *sql.Row) (model.Task, bool, error) {
+	t, err := scanTask(row)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.Task{}, false, nil
+		}
+		return model.Task{}, false, fmt.Errorf("get task: %w", err)
+	}
+	return t, true, nil
+}
+
+// MarkDone 用RETURNING在写连接上直接拿到更新后的行，不会读到旧数据
+func (r *TaskRepo) MarkDone(ctx context.Context, id string, done bool) (model.Task, bool, error) {
+	return scanOne(r.write.QueryRowContext(ctx,
+		`UPDATE tasks SET done = ? WHERE id = ? RETURNING `+taskColumns,
+		done, id,
+	))
+}
+
+func (r *TaskRepo) Update(ctx context.Context, id string, p repo.TaskPatch) (model.Task, bool, error) {
+	return update(ctx, r.write, id, p)
+}
+
+func update(ctx context.Context, q querier, id string, p repo.TaskPatch) (model.Task, bool, error) {
+	var (
+		sets []string
+		args []any
+	)
+	if p.Title != nil {
+		sets = append(sets, "title = ?")
+		args = append(args, *p.Title)
+	}
+	if p.Done != nil {
+		sets = append(sets, "done = ?")
+		args = append(args, *p.Done)
+	}
+	if p.ParentID != nil {
+		sets = append(sets, "parent_id = ?")
+		args = append(args, nullString(*p.ParentID))
+	}
+	if p.Project != nil {
+		sets = append(sets, "project = ?")
+		args = append(args, *p.Project)
+	}
+	if p.EstimateMinutes != nil {
+		sets = append(sets, "estimate_min = ?")
+		args = append(args, *p.EstimateMinutes)
+	}
+	if p.Column != nil {
+		sets = append(sets, "board_column = ?")
+		args = append(args, *p.Column)
+	}
+	if p.Rank != nil {
+		sets = append(sets, "rank_key = ?")
+		args = append(args, *p.Rank)
+	}
+	if len(sets) == 0 {
+		return get(ctx, q, id)
+	}
+
+	args = append(args, id)
+	return scanOne(q.QueryRowContext(ctx,
+		`UPDATE tasks SET `+strings.Join(sets, ", ")+` WHERE id = ? RETURNING `+taskColumns,
+		args...,
+	))
+}
+
+// SetParent 在写连接上的一个事务（BEGIN IMMEDIATE）里读取祖先链、检查并写入，与其它写操作串行
+func (r *TaskRepo) SetParent(ctx context.Context, id, parentID string, check func([]string) error) (model.Task, bool, error) {
+	tx, err := r.write.BeginTx(ctx, nil)
+	if err != nil {
+		return model.Task{}, false, fmt.Errorf("begin tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	parentOf := func(cur string) (string, bool, error) {
+		var parent sql.NullString
+		err := tx.QueryRowContext(ctx, `SELECT parent_id FROM tasks WHERE id = ?`, cur).Scan(&parent)
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", false, nil
+		}
+		if err != nil {
+			return "", false, fmt.Errorf("get parent: %w", err)
+		}
+		return parent.String, true, nil
+	}
+	if _, ok, err := parentOf(id); err != nil || !ok {
+		return model.Task{}, ok, err
+	}
+	if check != nil {
+		ancestors, err := repo.Ancestors(id, parentID, parentOf)
+		if err != nil {
+			return model.Task{}, false, err
+		}
+		if err := check(ancestors); err != nil {
+			return model.Task{}, true, err
+		}
+	}
+	t, ok, err := update(ctx, tx, id, repo.TaskPatch{ParentID: &parentID})
+	if err != nil || !ok {
+		return model.Task{}, ok, err
+	}
+	if err := tx.Commit(); err != nil {
+		return model.Task{}, false, fmt.Errorf("commit tx: %w", err)
+	}
+	return t, true, nil
+}
+
+func (r *TaskRepo) Delete(ctx context.Context, id string) (bool, error) {
+	return deleteTask(ctx, r.write, id)
+}
+
+func deleteTask(ctx context.Context, q querier, id string) (bool, error) {
+	res, err := q.ExecContext(ctx, `DELETE FROM tasks WHERE id = ?`, id)
+	if err != nil {
+		return false, fmt.Errorf("delete task: %w", err)
+	}
+	aff, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("rows affected: %w", err)
+	}
+	return aff > 0, nil
+}
+
+/*
+Batch
+  - 非原子：每条操作直接在写连接上执行
+  - 原子：整批放进一个事务（BEGIN IMMEDIATE），任一条失败则Rollback
+*/
+func (r *TaskRepo) Batch(ctx context.Context, ops []repo.BatchOp, atomic bool) ([]repo.BatchResult, error) {
+	if !atomic {
+		results := make([]repo.BatchResult, len(ops))
+		for i, op := range ops {
+			results[i] = applyOp(ctx, r.write, op)
+		}
+		return results, nil
+	}
+
+	tx, err := r.write.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("begin tx: %w", err)
+	}
+
+	results := make([]repo.BatchResult, len(ops))
+	for i, op := range ops {
+		results[i] = applyOp(ctx, tx, op)
+		if results[i].Failed() {
+			_ = tx.Rollback()
+			for j := range results {
+				if j != i {
+					results[j] = repo.BatchResult{Found: true, Err: repo.ErrBatchAborted}
+				}
+			}
+			return results, nil
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit tx: %w", err)
+	}
+	return results, nil
+}
+
+func applyOp(ctx context.Context, q querier, op repo.BatchOp) repo.BatchResult {
+	switch op.Kind {
+	case repo.BatchCreate:
+		t, err := create(ctx, q, op.Task)
+		return repo.BatchResult{Task: t, Found: true, Err: err}
+	case repo.BatchUpdate:
+		t, ok, err := update(ctx, q, op.ID, op.Patch)
+		return repo.BatchResult{Task: t, Found: ok, Err: err}
+	case repo.BatchDelete:
+		ok, err := deleteTask(ctx, q, op.ID)
+		return repo.BatchResult{Found: ok, Err: err}
+	default:
+		return repo.BatchResult{Found: true, Err: fmt.Errorf("unknown batch op: %s", op.Kind)}
+	}
+}
+
+/*
+Search 基于FTS5外部内容表（tasks_fts，由触发器与tasks同步）的全文检索
+每个词元写成 "term"* ：必须命中且按前缀匹配，相关度取bm25的相反数（越大越相关）
+注意：unicode61分词器不切分中文，连续的中文会被当作一个词，只能从词首做前缀匹配
+*/
+func (r *TaskRepo) Search(ctx context.Context, q repo.SearchQuery) ([]repo.SearchHit, int, error) {
+	terms := make([]string, len(q.Terms))
+	for i, t := range q.Terms {
+		terms[i] = `"` + t + `"*`
+	}
+	match := strings.Join(terms, " ")
+
+	var total int
+	if err := r.read.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM tasks_fts WHERE tasks_fts MATCH ?`,
+		match,
+	).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("count search: %w", err)
+	}
+	if total == 0 {
+		return []repo.SearchHit{}, 0, nil
+	}
+
+	rows, err := r.read.QueryContext(ctx,
+		`SELECT `+taskColumns+`, m.score
+		   FROM tasks
+		   JOIN (SELECT rowid AS rid, -bm25(tasks_fts) AS score FROM tasks_fts WHERE tasks_fts MATCH ?) m USING (rid)
+		  ORDER BY m.score DESC, created_at ASC, id ASC
+		  LIMIT ? OFFSET ?`,
+		match, q.Limit, q.Offset,
+	)
+	if err != nil {
+		return nil, 0, fmt.Errorf("search tasks: %w", err)
+	}
+	defer rows.Close()
+
+	hits := make([]repo.SearchHit, 0, q.Limit)
+	for rows.Next() {
+		var score float64
+		t, err := scanTask(rows, &score)
+		if err != nil {
+			return nil, 0, fmt.Errorf("scan: %w", err)
+		}
+		hits = append(hits, repo.SearchHit{Task: t, Score: score})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("rows err: %w", err)
+	}
+	return hits, total, nil
+}
@@ -0,0 +1,24 @@
+package sqliterepo
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/kitouo/taskhub/internal/db"
+	"github.com/kitouo/taskhub/internal/repo"
+	"github.com/kitouo/taskhub/internal/repo/repotest"
+)
+
+func TestTaskRepo(t *testing.T) {
+	repotest.Run(t, func(t *testing.T) repo.TaskRepo {
+		conn, err := db.OpenSQLite(filepath.Join(t.TempDir(), "taskhub.db"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { conn.Close() })
+		if err := db.MigrateSQLite(conn.Write); err != nil {
+			t.Fatal(err)
+		}
+		return NewTaskRepo(conn)
+	})
+}
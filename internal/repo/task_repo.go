@@ -2,13 +2,214 @@ package repo
 
 import (
 	"context"
+	"errors"
+	"time"
 
+	"github.com/kitouo/taskhub/internal/filter"
 	"github.com/kitouo/taskhub/internal/model"
 )
 
+/*
+TaskRepo 任务存储；各实现须满足repotest中的行为约定：
+  - 时间一律以UTC、微秒精度保存和返回（见Normalize）
+  - 不存在的id通过bool返回false表示，不是错误
+*/
 type TaskRepo interface {
+	// Create id已存在时返回ErrConflict（批量create同样如此）
 	Create(ctx context.Context, t model.Task) (model.Task, error)
-	List(ctx context.Context) ([]model.Task, error)
+	List(ctx context.Context, q ListQuery) ([]model.Task, error)
+	Count(ctx context.Context, q ListQuery) (int, error)
+	/*
+		Each 按created_at、id升序逐条回调满足q.Filter/q.IDs的任务（忽略q.Sort），
+		用于导出等大结果集，不会一次把全部结果装入内存；fn返回错误时停止并原样返回
+	*/
+	Each(ctx context.Context, q ListQuery, fn func(model.Task) error) error
 	Get(ctx context.Context, id string) (model.Task, bool, error)
 	MarkDone(ctx context.Context, id string, done bool) (model.Task, bool, error)
+	Update(ctx context.Context, id string, p TaskPatch) (model.Task, bool, error)
+	Delete(ctx context.Context, id string) (bool, error)
+	/*
+		SetParent 修改父任务（parentID为空表示移到顶层）
+		读取新的祖先链、check与写入在同一把锁或同一个事务内完成，并发的移动不会各自通过检查后合起来形成环；
+		check收到Ancestors给出的祖先链，返回错误时不做修改并原样返回该错误
+	*/
+	SetParent(ctx context.Context, id, parentID string, check func(ancestors []string) error) (model.Task, bool, error)
+
+	/*
+		Batch 按顺序执行一组操作，返回与ops一一对应的结果
+			- atomic=false：逐条执行，单条失败不影响其它条目
+			- atomic=true ：全部成功才提交；任一条失败（含not found）则整体回滚，
+			  其余条目的Err为ErrBatchAborted
+		返回的error只表示基础设施层面的失败（例如开启事务失败）
+	*/
+	Batch(ctx context.Context, ops []BatchOp, atomic bool) ([]BatchResult, error)
+
+	// Search 全文检索，按相关度降序返回一页结果以及命中总数
+	Search(ctx context.Context, q SearchQuery) ([]SearchHit, int, error)
+
+	// LastRank 看板列中最大的rank，列为空时返回空串；用于把新任务排到列尾，不必读出整列
+	LastRank(ctx context.Context, column string) (string, error)
+}
+
+/*
+Ancestors 供SetParent的实现使用：从parentID起沿parentOf向上收集祖先，由近到远
+parentID不存在时为空；遇到id本身、不存在的任务或重复出现的id（库里已有环）时停止
+*/
+func Ancestors(id, parentID string, parentOf func(id string) (parent string, ok bool, err error)) ([]string, error) {
+	var out []string
+	seen := make(map[string]bool)
+	for cur := parentID; cur != "" && !seen[cur]; {
+		parent, ok, err := parentOf(cur)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			break
+		}
+		out = append(out, cur)
+		if cur == id {
+			break
+		}
+		seen[cur] = true
+		cur = parent
+	}
+	return out, nil
+}
+
+// ListQuery 列表查询条件，零值表示返回全部
+type ListQuery struct {
+	Filter filter.Node // nil表示不过滤
+	Sort   Sort        // 零值为默认顺序（创建顺序）
+	IDs    []string    // nil表示不限制；非nil时只返回这些id（空切片即返回空）
+}
+
+type SortField string
+
+const (
+	SortCreatedAt SortField = "created_at"
+	SortTitle     SortField = "title"
+	SortRank      SortField = "rank" // 看板列内顺序
+)
+
+// SortFields 允许排序的字段
+var SortFields = map[SortField]bool{
+	SortCreatedAt: true,
+	SortTitle:     true,
+	SortRank:      true,
+}
+
+// Sort 排序方式；同值时统一按created_at、id升序兜底，保证结果稳定
+type Sort struct {
+	Field SortField
+	Desc  bool
+}
+
+// ErrBatchAborted 原子批量中，因其它条目失败而被回滚的条目
+var ErrBatchAborted = errors.New("batch aborted")
+
+// ErrConflict 写入的记录（任务、用户）id已存在
+var ErrConflict = errors.New("id already exists")
+
+// ErrUnavailable 存储暂时不可用（例如熔断器打开），请求没有发往后端，稍后可重试
+var ErrUnavailable = errors.New("task store unavailable")
+
+/*
+Normalize 把任务中的时间转为UTC并截断到微秒，各实现在写入前调用
+MySQL/PostgreSQL只能保存到微秒且读出时不带原时区，统一之后不论哪个后端，写入与读回的值都相等
+*/
+func Normalize(t model.Task) model.Task {
+	t.CreatedAt = normalizeTime(t.CreatedAt)
+	if t.DueAt != nil {
+		d := normalizeTime(*t.DueAt)
+		t.DueAt = &d
+	}
+	if t.Recurrence != nil {
+		rec := *t.Recurrence
+		rec.Start = normalizeTime(rec.Start)
+		t.Recurrence = &rec
+	}
+	return t
+}
+
+func normalizeTime(t time.Time) time.Time {
+	return t.UTC().Truncate(time.Microsecond)
+}
+
+// TaskPatch 局部更新：nil字段表示不修改
+type TaskPatch struct {
+	Title    *string
+	Done     *bool
+	ParentID *string // 空串表示移到顶层
+	Project  *string // 空串表示不属于任何项目
+	// EstimateMinutes 0表示清除预估
+	EstimateMinutes *int
+	Column          *string
+	Rank            *string
+}
+
+// Apply 把patch应用到t上，返回新的Task
+func (p TaskPatch) Apply(t model.Task) model.Task {
+	if p.Title != nil {
+		t.Title = *p.Title
+	}
+	if p.Done != nil {
+		t.Done = *p.Done
+	}
+	if p.ParentID != nil {
+		t.ParentID = *p.ParentID
+	}
+	if p.Project != nil {
+		t.Project = *p.Project
+	}
+	if p.EstimateMinutes != nil {
+		t.EstimateMinutes = *p.EstimateMinutes
+	}
+	if p.Column != nil {
+		t.Column = *p.Column
+	}
+	if p.Rank != nil {
+		t.Rank = *p.Rank
+	}
+	return t
+}
+
+type BatchOpKind string
+
+const (
+	BatchCreate BatchOpKind = "create"
+	BatchUpdate BatchOpKind = "update"
+	BatchDelete BatchOpKind = "delete"
+)
+
+type BatchOp struct {
+	Kind  BatchOpKind
+	ID    string     // update/delete 使用
+	Task  model.Task // create 使用
+	Patch TaskPatch  // update 使用
+}
+
+type BatchResult struct {
+	Task  model.Task // create/update 成功后的任务
+	Found bool       // update/delete：id是否存在；create恒为true
+	Err   error
+}
+
+// Failed 条目是否失败（出错或目标不存在）
+func (r BatchResult) Failed() bool {
+	return r.Err != nil || !r.Found
+}
+
+/*
+SearchQuery 全文检索条件
+Terms 为已经过search.Tokenize的词元，每个词元按前缀匹配，且全部词元都必须命中
+*/
+type SearchQuery struct {
+	Terms  []string
+	Limit  int
+	Offset int
+}
+
+type SearchHit struct {
+	Task  model.Task
+	Score float64
 }
@@ -0,0 +1,33 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/kitouo/taskhub/internal/model"
+)
+
+// ErrTimerRunning 用户已有一个运行中的计时器
+var ErrTimerRunning = errors.New("timer already running")
+
+type TimeEntryRepo interface {
+	// Create End为nil时即启动计时器，同一用户已有运行中的计时器时返回ErrTimerRunning
+	Create(ctx context.Context, e model.TimeEntry) (model.TimeEntry, error)
+	Get(ctx context.Context, id string) (model.TimeEntry, bool, error)
+	// List 任务下的全部记录，按开始时间、id升序
+	List(ctx context.Context, taskID string) ([]model.TimeEntry, error)
+	// Running 用户当前运行中的计时器
+	Running(ctx context.Context, userID string) (model.TimeEntry, bool, error)
+	// Stop 结束运行中的计时器；已经结束的记录视为不存在
+	Stop(ctx context.Context, id string, end time.Time) (model.TimeEntry, bool, error)
+	Delete(ctx context.Context, id string) (bool, error)
+	// Report 汇总已结束的记录与[From, To)的重叠部分，按Seconds降序、Key升序
+	Report(ctx context.Context, q TimeReportQuery) ([]model.TimeReportRow, error)
+}
+
+type TimeReportQuery struct {
+	GroupBy model.TimeGroup
+	From    time.Time
+	To      time.Time
+}
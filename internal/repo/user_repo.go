@@ -0,0 +1,26 @@
+package repo
+
+import (
+	"context"
+
+	"github.com/kitouo/taskhub/internal/model"
+)
+
+type UserRepo interface {
+	// Create id已存在时返回ErrConflict
+	Create(ctx context.Context, u model.User) (model.User, error)
+	Get(ctx context.Context, id string) (model.User, bool, error)
+	// List 按id升序；active非nil时只返回对应状态的用户
+	List(ctx context.Context, active *bool) ([]model.User, error)
+	// Update 修改显示名、邮箱与启用状态
+	Update(ctx context.Context, u model.User) (model.User, bool, error)
+}
+
+type AssignmentRepo interface {
+	// Replace 把任务的负责人整体替换为userIDs（空表示清空）
+	Replace(ctx context.Context, taskID string, userIDs []string) error
+	// ByTasks 返回taskIDs中各任务的全部关联
+	ByTasks(ctx context.Context, taskIDs []string) ([]model.Assignment, error)
+	// ByUsers 返回userIDs中各用户的全部关联
+	ByUsers(ctx context.Context, userIDs []string) ([]model.Assignment, error)
+}
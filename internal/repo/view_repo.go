@@ -0,0 +1,17 @@
+package repo
+
+import (
+	"context"
+
+	"github.com/kitouo/taskhub/internal/model"
+)
+
+type ViewRepo interface {
+	Create(ctx context.Context, v model.SavedView) (model.SavedView, error)
+	Get(ctx context.Context, id string) (model.SavedView, bool, error)
+	// ListVisible 返回ownerID自己的视图以及所有team视图，按创建时间升序
+	ListVisible(ctx context.Context, ownerID string) ([]model.SavedView, error)
+	// Update 整体覆盖除ID、OwnerID、CreatedAt以外的字段
+	Update(ctx context.Context, v model.SavedView) (model.SavedView, bool, error)
+	Delete(ctx context.Context, id string) (bool, error)
+}
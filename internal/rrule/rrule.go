@@ -0,0 +1,299 @@
+/*
+Package rrule 实现RFC 5545 RRULE的一个子集：
+
+	FREQ=DAILY|WEEKLY|MONTHLY
+	INTERVAL=n
+	BYDAY=MO,WE 或（仅MONTHLY）1MO、-1FR
+	COUNT=n 或 UNTIL=20240131T000000Z / 20240131
+
+所有计算都在DTSTART所在时区的墙上时间进行，跨夏令时时保持“每天9点”这类语义
+*/
+package rrule
+
+import (
+	"errors"
+	"fmt"
+	"iter"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Freq string
+
+const (
+	Daily   Freq = "DAILY"
+	Weekly  Freq = "WEEKLY"
+	Monthly Freq = "MONTHLY"
+)
+
+// ByDay BYDAY中的一项；N为0表示“每个”，MONTHLY下可为第N个/倒数第N个
+type ByDay struct {
+	Weekday time.Weekday
+	N       int
+}
+
+type Rule struct {
+	Freq     Freq
+	Interval int
+	ByDay    []ByDay
+	Count    int       // 0表示不限
+	Until    time.Time // 零值表示不限，包含该时刻
+}
+
+var ErrInvalid = errors.New("invalid rrule")
+
+var weekdays = map[string]time.Weekday{
+	"SU": time.Sunday, "MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday,
+	"TH": time.Thursday, "FR": time.Friday, "SA": time.Saturday,
+}
+
+// maxIterations 展开次数上限，避免异常规则（例如永远匹配不到的BYDAY）导致死循环
+const maxIterations = 100000
+
+// Parse 解析RRULE，允许带"RRULE:"前缀，键不区分大小写
+func Parse(s string) (Rule, error) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "RRULE:")
+	if s == "" {
+		return Rule{}, fmt.Errorf("%w: empty", ErrInvalid)
+	}
+
+	r := Rule{Interval: 1}
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(s, ";") {
+		k, v, ok := strings.Cut(part, "=")
+		k = strings.ToUpper(strings.TrimSpace(k))
+		v = strings.ToUpper(strings.TrimSpace(v))
+		if !ok || v == "" {
+			return Rule{}, fmt.Errorf("%w: malformed part %q", ErrInvalid, part)
+		}
+		if seen[k] {
+			return Rule{}, fmt.Errorf("%w: duplicate %s", ErrInvalid, k)
+		}
+		seen[k] = true
+
+		switch k {
+		case "FREQ":
+			switch Freq(v) {
+			case Daily, Weekly, Monthly:
+				r.Freq = Freq(v)
+			default:
+				return Rule{}, fmt.Errorf("%w: unsupported FREQ %s", ErrInvalid, v)
+			}
+		case "INTERVAL":
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 1 || n > 1000 {
+				return Rule{}, fmt.Errorf("%w: INTERVAL must be 1..1000", ErrInvalid)
+			}
+			r.Interval = n
+		case "COUNT":
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 1 {
+				return Rule{}, fmt.Errorf("%w: COUNT must be positive", ErrInvalid)
+			}
+			r.Count = n
+		case "UNTIL":
+			t, err := parseUntil(v)
+			if err != nil {
+				return Rule{}, err
+			}
+			r.Until = t
+		case "BYDAY":
+			for _, d := range strings.Split(v, ",") {
+				bd, err := parseByDay(d)
+				if err != nil {
+					return Rule{}, err
+				}
+				r.ByDay = append(r.ByDay, bd)
+			}
+		default:
+			return Rule{}, fmt.Errorf("%w: unsupported part %s", ErrInvalid, k)
+		}
+	}
+
+	if r.Freq == "" {
+		return Rule{}, fmt.Errorf("%w: FREQ is required", ErrInvalid)
+	}
+	if r.Count > 0 && !r.Until.IsZero() {
+		return Rule{}, fmt.Errorf("%w: COUNT and UNTIL are mutually exclusive", ErrInvalid)
+	}
+	for _, bd := range r.ByDay {
+		if bd.N != 0 && r.Freq != Monthly {
+			return Rule{}, fmt.Errorf("%w: BYDAY ordinals are only supported with FREQ=MONTHLY", ErrInvalid)
+		}
+	}
+	return r, nil
+}
+
+func parseUntil(v string) (time.Time, error) {
+	for _, layout := range []string{"20060102T150405Z", "20060102"} {
+		if t, err := time.Parse(layout, v); err == nil {
+			if layout == "20060102" {
+				// 纯日期的UNTIL包含当天
+				t = t.Add(24*time.Hour - time.Nanosecond)
+			}
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("%w: invalid UNTIL %s", ErrInvalid, v)
+}
+
+func parseByDay(s string) (ByDay, error) {
+	s = strings.TrimSpace(s)
+	if len(s) < 2 {
+		return ByDay{}, fmt.Errorf("%w: invalid BYDAY %q", ErrInvalid, s)
+	}
+	wd, ok := weekdays[s[len(s)-2:]]
+	if !ok {
+		return ByDay{}, fmt.Errorf("%w: invalid BYDAY %q", ErrInvalid, s)
+	}
+	bd := ByDay{Weekday: wd}
+	if prefix := s[:len(s)-2]; prefix != "" {
+		n, err := strconv.Atoi(prefix)
+		if err != nil || n == 0 || n < -5 || n > 5 {
+			return ByDay{}, fmt.Errorf("%w: invalid BYDAY ordinal %q", ErrInvalid, s)
+		}
+		bd.N = n
+	}
+	return bd, nil
+}
+
+// String 规范化输出，便于存储与比较
+func (r Rule) String() string {
+	parts := []string{"FREQ=" + string(r.Freq)}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if len(r.ByDay) > 0 {
+		days := make([]string, len(r.ByDay))
+		for i, bd := range r.ByDay {
+			days[i] = strings.ToUpper(bd.Weekday.String()[:2])
+			if bd.N != 0 {
+				days[i] = strconv.Itoa(bd.N) + days[i]
+			}
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	if !r.Until.IsZero() {
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format("20060102T150405Z"))
+	}
+	return strings.Join(parts, ";")
+}
+
+/*
+Occurrences 从dtstart开始按时间升序展开所有发生时刻
+dtstart的时区决定墙上时间；早于dtstart的候选会被跳过
+*/
+func (r Rule) Occurrences(dtstart time.Time) iter.Seq[time.Time] {
+	return func(yield func(time.Time) bool) {
+		emitted := 0
+		for period := 0; period < maxIterations; period++ {
+			for _, t := range r.period(dtstart, period) {
+				if t.Before(dtstart) {
+					continue
+				}
+				if !r.Until.IsZero() && t.After(r.Until) {
+					return
+				}
+				if !yield(t) {
+					return
+				}
+				emitted++
+				if r.Count > 0 && emitted >= r.Count {
+					return
+				}
+			}
+		}
+	}
+}
+
+// Next 返回严格晚于after的下一次发生时刻
+func (r Rule) Next(dtstart, after time.Time) (time.Time, bool) {
+	for t := range r.Occurrences(dtstart) {
+		if t.After(after) {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// Between 返回(after, before]区间内的发生时刻
+func (r Rule) Between(dtstart, after, before time.Time) []time.Time {
+	var out []time.Time
+	for t := range r.Occurrences(dtstart) {
+		if t.After(before) {
+			break
+		}
+		if t.After(after) {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+// period 返回第n个周期内的候选时刻（升序）
+func (r Rule) period(dtstart time.Time, n int) []time.Time {
+	loc := dtstart.Location()
+	y, m, d := dtstart.Date()
+	hh, mm, ss := dtstart.Clock()
+	at := func(y int, m time.Month, d int) time.Time {
+		return time.Date(y, m, d, hh, mm, ss, dtstart.Nanosecond(), loc)
+	}
+
+	switch r.Freq {
+	case Daily:
+		t := at(y, m, d+n*r.Interval)
+		if len(r.ByDay) > 0 && !slices.ContainsFunc(r.ByDay, func(bd ByDay) bool { return bd.Weekday == t.Weekday() }) {
+			return nil
+		}
+		return []time.Time{t}
+
+	case Weekly:
+		// 周从周一开始（WKST=MO）
+		offset := (int(dtstart.Weekday()) + 6) % 7
+		monday := at(y, m, d-offset+7*n*r.Interval)
+		days := r.ByDay
+		if len(days) == 0 {
+			days = []ByDay{{Weekday: dtstart.Weekday()}}
+		}
+		out := make([]time.Time, 0, len(days))
+		for _, bd := range days {
+			wy, wm, wd := monday.Date()
+			out = append(out, at(wy, wm, wd+(int(bd.Weekday)+6)%7))
+		}
+		slices.SortFunc(out, func(a, b time.Time) int { return a.Compare(b) })
+		return slices.Compact(out)
+
+	case Monthly:
+		first := time.Date(y, m+time.Month(n*r.Interval), 1, 0, 0, 0, 0, loc)
+		fy, fm, _ := first.Date()
+		daysIn := time.Date(fy, fm+1, 0, 0, 0, 0, 0, loc).Day()
+
+		if len(r.ByDay) == 0 {
+			// 按DTSTART的日期；当月没有这一天（例如31号）则跳过
+			if d > daysIn {
+				return nil
+			}
+			return []time.Time{at(fy, fm, d)}
+		}
+
+		var out []time.Time
+		for day := 1; day <= daysIn; day++ {
+			wd := time.Date(fy, fm, day, 0, 0, 0, 0, loc).Weekday()
+			nth := (day-1)/7 + 1
+			nthLast := -((daysIn-day)/7 + 1)
+			for _, bd := range r.ByDay {
+				if bd.Weekday == wd && (bd.N == 0 || bd.N == nth || bd.N == nthLast) {
+					out = append(out, at(fy, fm, day))
+					break
+				}
+			}
+		}
+		return out
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package rrule
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func collect(t *testing.T, rule string, dtstart time.Time, n int) []time.Time {
+	t.Helper()
+	r, err := Parse(rule)
+	if err != nil {
+		t.Fatalf("Parse(%q): %v", rule, err)
+	}
+	var out []time.Time
+	for ts := range r.Occurrences(dtstart) {
+		out = append(out, ts)
+		if len(out) == n {
+			break
+		}
+	}
+	return out
+}
+
+func dates(ts []time.Time) []string {
+	out := make([]string, len(ts))
+	for i, t := range ts {
+		out[i] = t.Format("2006-01-02 15:04 MST")
+	}
+	return out
+}
+
+func TestOccurrences(t *testing.T) {
+	utc := time.UTC
+	cases := []struct {
+		rule  string
+		start time.Time
+		n     int
+		want  []string
+	}{
+		{"FREQ=DAILY;INTERVAL=2", time.Date(2024, 1, 30, 9, 0, 0, 0, utc), 3,
+			[]string{"2024-01-30 09:00 UTC", "2024-02-01 09:00 UTC", "2024-02-03 09:00 UTC"}},
+		// 2024-01-01 周一
+		{"FREQ=WEEKLY;BYDAY=MO,FR", time.Date(2024, 1, 3, 9, 0, 0, 0, utc), 3,
+			[]string{"2024-01-05 09:00 UTC", "2024-01-08 09:00 UTC", "2024-01-12 09:00 UTC"}},
+		{"FREQ=WEEKLY;INTERVAL=2", time.Date(2024, 1, 1, 9, 0, 0, 0, utc), 3,
+			[]string{"2024-01-01 09:00 UTC", "2024-01-15 09:00 UTC", "2024-01-29 09:00 UTC"}},
+		{"FREQ=MONTHLY", time.Date(2024, 1, 31, 9, 0, 0, 0, utc), 3,
+			[]string{"2024-01-31 09:00 UTC", "2024-03-31 09:00 UTC", "2024-05-31 09:00 UTC"}},
+		{"FREQ=MONTHLY;BYDAY=-1FR", time.Date(2024, 1, 1, 9, 0, 0, 0, utc), 3,
+			[]string{"2024-01-26 09:00 UTC", "2024-02-23 09:00 UTC", "2024-03-29 09:00 UTC"}},
+		{"FREQ=MONTHLY;BYDAY=1MO", time.Date(2024, 1, 1, 9, 0, 0, 0, utc), 2,
+			[]string{"2024-01-01 09:00 UTC", "2024-02-05 09:00 UTC"}},
+		{"FREQ=DAILY;COUNT=2", time.Date(2024, 1, 1, 9, 0, 0, 0, utc), 10,
+			[]string{"2024-01-01 09:00 UTC", "2024-01-02 09:00 UTC"}},
+		{"FREQ=DAILY;UNTIL=20240102", time.Date(2024, 1, 1, 9, 0, 0, 0, utc), 10,
+			[]string{"2024-01-01 09:00 UTC", "2024-01-02 09:00 UTC"}},
+	}
+	for _, c := range cases {
+		got := dates(collect(t, c.rule, c.start, c.n))
+		if len(got) != len(c.want) {
+			t.Errorf("%s: got %v, want %v", c.rule, got, c.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != c.want[i] {
+				t.Errorf("%s[%d]: got %s, want %s", c.rule, i, got[i], c.want[i])
+			}
+		}
+	}
+}
+
+func TestOccurrencesKeepWallClockAcrossDST(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip("tzdata unavailable")
+	}
+	// 2024-03-10 美东进入夏令时
+	got := collect(t, "FREQ=DAILY", time.Date(2024, 3, 9, 9, 0, 0, 0, ny), 2)
+	if got[1].Hour() != 9 || got[1].Sub(got[0]) != 23*time.Hour {
+		t.Fatalf("got %v", dates(got))
+	}
+}
+
+func TestNextAndBetween(t *testing.T) {
+	r, _ := Parse("FREQ=WEEKLY;BYDAY=MO")
+	start := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+
+	next, ok := r.Next(start, start)
+	if !ok || !next.Equal(start.AddDate(0, 0, 7)) {
+		t.Fatalf("Next = %v, %v", next, ok)
+	}
+	if got := r.Between(start, start, start.AddDate(0, 0, 21)); len(got) != 3 {
+		t.Fatalf("Between = %v", dates(got))
+	}
+
+	r, _ = Parse("FREQ=DAILY;COUNT=1")
+	if _, ok := r.Next(start, start); ok {
+		t.Fatal("expected no occurrence after COUNT is exhausted")
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, s := range []string{
+		"",
+		"INTERVAL=2",
+		"FREQ=YEARLY",
+		"FREQ=DAILY;INTERVAL=0",
+		"FREQ=DAILY;COUNT=2;UNTIL=20240101",
+		"FREQ=WEEKLY;BYDAY=1MO",
+		"FREQ=WEEKLY;BYDAY=XX",
+		"FREQ=DAILY;FREQ=WEEKLY",
+		"FREQ=DAILY;BYHOUR=9",
+	} {
+		if _, err := Parse(s); !errors.Is(err, ErrInvalid) {
+			t.Errorf("Parse(%q) err = %v, want ErrInvalid", s, err)
+		}
+	}
+}
+
+func TestStringRoundTrip(t *testing.T) {
+	r, err := Parse("RRULE:freq=monthly;interval=2;byday=-1fr,1mo;count=5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := r.String(), "FREQ=MONTHLY;INTERVAL=2;BYDAY=-1FR,1MO;COUNT=5"; got != want {
+		t.Fatalf("String() = %q, want %q", got, want)
+	}
+}
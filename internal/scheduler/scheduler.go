@@ -0,0 +1,91 @@
+/*
+Package scheduler 进程内的周期任务调度
+每个Job在独立的goroutine里按Interval执行；Exclusive的Job在执行前先抢租约，
+多副本部署时同一时刻只有持有租约的实例会执行
+*/
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/kitouo/taskhub/internal/logx"
+	"github.com/kitouo/taskhub/internal/repo"
+)
+
+type Job struct {
+	Name      string
+	Interval  time.Duration
+	Exclusive bool // 需要在副本之间互斥
+	Run       func(ctx context.Context) error
+}
+
+type Scheduler struct {
+	leases repo.LeaseRepo
+	holder string
+	logger logx.Logger
+	jobs   []Job
+}
+
+// New holder标识当前实例，取 hostname-pid
+func New(leases repo.LeaseRepo, logger logx.Logger) *Scheduler {
+	host, _ := os.Hostname()
+	return &Scheduler{
+		leases: leases,
+		holder: fmt.Sprintf("%s-%d", host, os.Getpid()),
+		logger: logger,
+	}
+}
+
+func (s *Scheduler) Add(j Job) {
+	s.jobs = append(s.jobs, j)
+}
+
+// Run 启动所有Job并阻塞到ctx结束、所有Job退出
+func (s *Scheduler) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, j := range s.jobs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.loop(ctx, j)
+		}()
+	}
+	wg.Wait()
+}
+
+// loop 立即执行一次，之后每隔Interval执行一次
+func (s *Scheduler) loop(ctx context.Context, j Job) {
+	t := time.NewTicker(j.Interval)
+	defer t.Stop()
+	for {
+		s.tick(ctx, j)
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+func (s *Scheduler) tick(ctx context.Context, j Job) {
+	if j.Exclusive {
+		// 租约时长取3个周期：持有者每个周期续期一次，宕机后其它实例最多等3个周期接手
+		ok, err := s.leases.Acquire(ctx, j.Name, s.holder, 3*j.Interval)
+		if err != nil {
+			if ctx.Err() == nil {
+				s.logger.Error("acquire lease failed", "job="+j.Name, "err=", err)
+			}
+			return
+		}
+		if !ok {
+			return
+		}
+	}
+	if err := j.Run(ctx); err != nil && ctx.Err() == nil {
+		s.logger.Error("scheduled job failed", "job="+j.Name, "err=", err)
+	}
+}
@@ -0,0 +1,128 @@
+package search
+
+import (
+	"html"
+	"strings"
+	"unicode"
+)
+
+/*
+Tokenize 把文本切成小写词元
+  - 字母/数字连续段为一个词元（"Go1.25" -> "go1", "25"）
+  - 中日韩字符没有空格分词，这里按单字切分，保证“文档”能命中“项目文档”
+*/
+func Tokenize(s string) []string {
+	var (
+		out []string
+		cur strings.Builder
+	)
+	flush := func() {
+		if cur.Len() > 0 {
+			out = append(out, cur.String())
+			cur.Reset()
+		}
+	}
+
+	for _, r := range strings.ToLower(s) {
+		switch {
+		case isCJK(r):
+			flush()
+			out = append(out, string(r))
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			cur.WriteRune(r)
+		default:
+			flush()
+		}
+	}
+	flush()
+	return out
+}
+
+func isCJK(r rune) bool {
+	return unicode.Is(unicode.Han, r) ||
+		unicode.Is(unicode.Hiragana, r) ||
+		unicode.Is(unicode.Katakana, r) ||
+		unicode.Is(unicode.Hangul, r)
+}
+
+// snippetRunes 摘要最多保留的字符数
+const snippetRunes = 120
+
+/*
+Highlight 生成带<mark>高亮的摘要
+  - 以前缀方式匹配terms（与检索语义一致）
+  - 文本较长时以第一个命中位置为中心截取
+  - 非高亮部分做HTML转义，前端可以直接渲染
+*/
+func Highlight(text string, terms []string) string {
+	runes := []rune(text)
+	lower := []rune(strings.ToLower(text))
+	if len(lower) != len(runes) {
+		// 极少数字符小写后长度变化，退化为不高亮
+		return html.EscapeString(text)
+	}
+
+	// 标记每个命中词元的区间
+	marked := make([]bool, len(runes))
+	first := -1
+	for i := 0; i < len(runes); {
+		if !isTokenRune(runes[i]) {
+			i++
+			continue
+		}
+		j := i + 1
+		if !isCJK(runes[i]) {
+			for j < len(runes) && isTokenRune(runes[j]) && !isCJK(runes[j]) {
+				j++
+			}
+		}
+		tok := string(lower[i:j])
+		for _, term := range terms {
+			if strings.HasPrefix(tok, term) {
+				n := len([]rune(term))
+				for k := i; k < i+n; k++ {
+					marked[k] = true
+				}
+				if first < 0 {
+					first = i
+				}
+				break
+			}
+		}
+		i = j
+	}
+
+	start, end := 0, len(runes)
+	if len(runes) > snippetRunes {
+		if first > snippetRunes/3 {
+			start = first - snippetRunes/3
+		}
+		end = min(start+snippetRunes, len(runes))
+	}
+
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString("…")
+	}
+	for i := start; i < end; {
+		j := i
+		for j < end && marked[j] == marked[i] {
+			j++
+		}
+		seg := html.EscapeString(string(runes[i:j]))
+		if marked[i] {
+			b.WriteString("<mark>" + seg + "</mark>")
+		} else {
+			b.WriteString(seg)
+		}
+		i = j
+	}
+	if end < len(runes) {
+		b.WriteString("…")
+	}
+	return b.String()
+}
+
+func isTokenRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
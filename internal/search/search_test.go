@@ -0,0 +1,22 @@
+package search
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	got := Tokenize("Fix Go1.25 build, 完成文档!")
+	want := []string{"fix", "go1", "25", "build", "完", "成", "文", "档"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestHighlight(t *testing.T) {
+	got := Highlight("Deploy <api> to staging", []string{"dep", "stag"})
+	want := "<mark>Dep</mark>loy &lt;api&gt; to <mark>stag</mark>ing"
+	if got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,196 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/kitouo/taskhub/internal/blob"
+	"github.com/kitouo/taskhub/internal/model"
+	"github.com/kitouo/taskhub/internal/repo"
+)
+
+var ErrAttachmentTooLarge = errors.New("attachment too large")
+
+// DefaultAttachmentMaxBytes 单个附件的默认大小上限
+const DefaultAttachmentMaxBytes = 10 << 20
+
+type AttachmentService struct {
+	repo     repo.AttachmentRepo
+	tasks    repo.TaskRepo
+	blobs    blob.Store
+	maxBytes int64
+}
+
+func NewAttachmentService(repo repo.AttachmentRepo, tasks repo.TaskRepo, blobs blob.Store, maxBytes int64) *AttachmentService {
+	if maxBytes <= 0 {
+		maxBytes = DefaultAttachmentMaxBytes
+	}
+	return &AttachmentService{repo: repo, tasks: tasks, blobs: blobs, maxBytes: maxBytes}
+}
+
+// MaxBytes 单个附件的大小上限
+func (s *AttachmentService) MaxBytes() int64 { return s.maxBytes }
+
+/*
+Upload 流式保存一个附件
+  - 内容先落到临时文件，同时计算sha256、大小并保留开头512字节用于嗅探类型
+  - 超过上限返回ErrAttachmentTooLarge，不会写入blob
+  - 元数据写库失败时删除已写入的blob
+*/
+func (s *AttachmentService) Upload(ctx context.Context, userID, taskID, name string, r io.Reader) (model.Attachment, error) {
+	if _, ok, err := s.tasks.Get(ctx, taskID); err != nil {
+		return model.Attachment{}, err
+	} else if !ok {
+		return model.Attachment{}, ErrTaskNotFound
+	}
+
+	tmp, err := os.CreateTemp("", "taskhub-upload-*")
+	if err != nil {
+		return model.Attachment{}, err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	var (
+		h    = sha256.New()
+		head = &headBuffer{limit: 512}
+	)
+	n, err := io.Copy(io.MultiWriter(tmp, h, head), io.LimitReader(r, s.maxBytes+1))
+	if err != nil {
+		return model.Attachment{}, err
+	}
+	if n > s.maxBytes {
+		return model.Attachment{}, ErrAttachmentTooLarge
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return model.Attachment{}, err
+	}
+
+	name = sanitizeFilename(name)
+	a := model.Attachment{
+		ID:          NewID(),
+		TaskID:      taskID,
+		Name:        name,
+		Size:        n,
+		ContentType: sniffContentType(head.buf, name),
+		SHA256:      hex.EncodeToString(h.Sum(nil)),
+		UploaderID:  userID,
+		CreatedAt:   time.Now().UTC(),
+	}
+	a.BlobKey = a.ID
+
+	if err := s.blobs.Put(ctx, a.BlobKey, tmp, blob.PutMeta{Size: a.Size, ContentType: a.ContentType, SHA256: a.SHA256}); err != nil {
+		return model.Attachment{}, err
+	}
+	created, err := s.repo.Create(ctx, a)
+	if err != nil {
+		_ = s.blobs.Delete(context.WithoutCancel(ctx), a.BlobKey)
+		return model.Attachment{}, err
+	}
+	return created, nil
+}
+
+func (s *AttachmentService) List(ctx context.Context, taskID string) ([]model.Attachment, error) {
+	if _, ok, err := s.tasks.Get(ctx, taskID); err != nil {
+		return nil, err
+	} else if !ok {
+		return nil, ErrTaskNotFound
+	}
+	return s.repo.List(ctx, taskID)
+}
+
+// Get 附件不属于该任务时视为不存在
+func (s *AttachmentService) Get(ctx context.Context, taskID, id string) (model.Attachment, bool, error) {
+	a, ok, err := s.repo.Get(ctx, id)
+	if err != nil || !ok || a.TaskID != taskID {
+		return model.Attachment{}, false, err
+	}
+	return a, true, nil
+}
+
+// Open 读取附件内容的[offset, offset+length)，length<0表示到末尾
+func (s *AttachmentService) Open(ctx context.Context, a model.Attachment, offset, length int64) (io.ReadCloser, error) {
+	return s.blobs.Get(ctx, a.BlobKey, offset, length)
+}
+
+// Delete 只有上传者可以删除；先删元数据再删内容，内容删除失败只会留下无人引用的blob
+func (s *AttachmentService) Delete(ctx context.Context, userID, taskID, id string) (bool, error) {
+	a, ok, err := s.Get(ctx, taskID, id)
+	if err != nil || !ok {
+		return ok, err
+	}
+	if a.UploaderID != userID {
+		return true, ErrForbidden
+	}
+	if ok, err := s.repo.Delete(ctx, id); err != nil || !ok {
+		return ok, err
+	}
+	_ = s.blobs.Delete(ctx, a.BlobKey)
+	return true, nil
+}
+
+// headBuffer 只保留写入内容的前limit个字节
+type headBuffer struct {
+	buf   []byte
+	limit int
+}
+
+func (b *headBuffer) Write(p []byte) (int, error) {
+	if room := b.limit - len(b.buf); room > 0 {
+		b.buf = append(b.buf, p[:min(room, len(p))]...)
+	}
+	return len(p), nil
+}
+
+/*
+sniffContentType 以内容嗅探为准（http.DetectContentType）
+嗅探不出具体类型时才参考扩展名，避免客户端把HTML声明成图片之类
+*/
+func sniffContentType(head []byte, name string) string {
+	ct := http.DetectContentType(head)
+	if ct == "application/octet-stream" {
+		if byExt := mime.TypeByExtension(filepath.Ext(name)); byExt != "" && !strings.HasPrefix(byExt, "text/html") {
+			return byExt
+		}
+	}
+	return ct
+}
+
+// sanitizeFilename 去掉路径与控制字符，限制长度
+func sanitizeFilename(name string) string {
+	name = filepath.Base(strings.ReplaceAll(name, `\`, "/"))
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) || r == '"' {
+			return -1
+		}
+		return r
+	}, name)
+	name = strings.TrimSpace(name)
+	if name == "" || name == "." || name == "/" {
+		return "file"
+	}
+	// 一次截断到255字节以内，不切断多字节字符
+	if len(name) > 255 {
+		n := 0
+		for n < len(name) {
+			_, size := utf8.DecodeRuneInString(name[n:])
+			if n+size > 255 {
+				break
+			}
+			n += size
+		}
+		name = name[:n]
+	}
+	return name
+}
@@ -0,0 +1,28 @@
+package service
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestSanitizeFilename(t *testing.T) {
+	cases := []struct{ in, want string }{
+		{`C:\tmp\report.pdf`, "report.pdf"},
+		{"../../etc/passwd", "passwd"},
+		{"a\x00b\"c.txt", "abc.txt"},
+	}
+	for _, c := range cases {
+		if got := sanitizeFilename(c.in); got != c.want {
+			t.Errorf("sanitizeFilename(%q) = %q, want %q", c.in, got, c.want)
+		}
+	}
+
+	// 超长的名字截断到255字节以内，且不会切断多字节字符
+	for _, long := range []string{strings.Repeat("a", 100000), strings.Repeat("文", 30000)} {
+		got := sanitizeFilename(long)
+		if len(got) > 255 || len(got) < 253 || !utf8.ValidString(got) {
+			t.Errorf("truncated to %d bytes, valid utf8 %v", len(got), utf8.ValidString(got))
+		}
+	}
+}
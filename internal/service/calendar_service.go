@@ -0,0 +1,207 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/kitouo/taskhub/internal/ical"
+	"github.com/kitouo/taskhub/internal/model"
+)
+
+var (
+	ErrCalendarDisabled     = errors.New("calendar feeds are disabled")
+	ErrInvalidCalendarToken = errors.New("invalid calendar token")
+	ErrInvalidCalendarScope = errors.New("invalid calendar scope")
+	ErrInvalidCalendarKind  = errors.New("invalid calendar kind")
+)
+
+// CalendarScope 订阅范围：某个用户负责的任务，或某个项目的任务
+type CalendarScope string
+
+const (
+	CalendarUser    CalendarScope = "user"
+	CalendarProject CalendarScope = "project"
+)
+
+// CalendarKind 任务以哪种组件出现在日历里
+type CalendarKind string
+
+const (
+	CalendarTodo  CalendarKind = "todo"  // VTODO，DUE为截止时间
+	CalendarEvent CalendarKind = "event" // VEVENT，以截止时间结束，有预估工时时从截止前预估时长开始
+)
+
+// calendarMACSize token中签名截取的字节数
+const calendarMACSize = 16
+
+/*
+CalendarService 生成可订阅的iCalendar feed
+token = base64url(范围) + "." + base64url(HMAC-SHA256(secret, 范围)[:16])，不落库；
+日历客户端无法带X-User-ID，因此token本身就是凭证，更换secret即可让所有旧token失效
+*/
+type CalendarService struct {
+	tasks  *TaskService
+	secret []byte
+}
+
+// NewCalendarService secret为空时feed功能关闭
+func NewCalendarService(tasks *TaskService, secret string) *CalendarService {
+	return &CalendarService{tasks: tasks, secret: []byte(secret)}
+}
+
+// Token 为scope/subject签发token；subject是用户id或项目名
+func (s *CalendarService) Token(scope CalendarScope, subject string) (string, error) {
+	if len(s.secret) == 0 {
+		return "", ErrCalendarDisabled
+	}
+	subject = strings.TrimSpace(subject)
+	switch scope {
+	case CalendarUser, CalendarProject:
+	default:
+		return "", ErrInvalidCalendarScope
+	}
+	if subject == "" {
+		return "", ErrInvalidCalendarScope
+	}
+	payload := []byte(string(scope) + ":" + subject)
+	enc := base64.RawURLEncoding
+	return enc.EncodeToString(payload) + "." + enc.EncodeToString(s.mac(payload)), nil
+}
+
+// parseToken 校验签名并取出scope与subject
+func (s *CalendarService) parseToken(token string) (CalendarScope, string, error) {
+	if len(s.secret) == 0 {
+		return "", "", ErrCalendarDisabled
+	}
+	p, m, ok := strings.Cut(token, ".")
+	if !ok {
+		return "", "", ErrInvalidCalendarToken
+	}
+	enc := base64.RawURLEncoding
+	payload, err := enc.DecodeString(p)
+	if err != nil {
+		return "", "", ErrInvalidCalendarToken
+	}
+	sig, err := enc.DecodeString(m)
+	if err != nil || !hmac.Equal(sig, s.mac(payload)) {
+		return "", "", ErrInvalidCalendarToken
+	}
+	scope, subject, ok := strings.Cut(string(payload), ":")
+	if !ok || subject == "" {
+		return "", "", ErrInvalidCalendarToken
+	}
+	return CalendarScope(scope), subject, nil
+}
+
+func (s *CalendarService) mac(payload []byte) []byte {
+	h := hmac.New(sha256.New, s.secret)
+	h.Write(payload)
+	return h.Sum(nil)[:calendarMACSize]
+}
+
+/*
+Feed 渲染token对应的日历，只包含有截止时间的任务
+输出只依赖任务数据（DTSTAMP取任务的创建时间），内容不变时字节完全相同，便于调用方做ETag
+*/
+func (s *CalendarService) Feed(ctx context.Context, token string, kind CalendarKind) ([]byte, error) {
+	switch kind {
+	case CalendarTodo, CalendarEvent:
+	default:
+		return nil, ErrInvalidCalendarKind
+	}
+	scope, subject, err := s.parseToken(token)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := ListOptions{Sort: "created_at"}
+	var name string
+	switch scope {
+	case CalendarUser:
+		opts.Assignee = subject
+		name = "taskhub: " + subject
+	case CalendarProject:
+		opts.Filter = "project = " + quoteFilterString(subject)
+		name = "taskhub: " + subject
+	default:
+		return nil, ErrInvalidCalendarToken
+	}
+	tasks, err := s.tasks.List(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	w := ical.NewWriter(&buf)
+	w.Begin("VCALENDAR")
+	w.Raw("VERSION", "2.0")
+	w.Raw("PRODID", "-//taskhub//calendar//EN")
+	w.Raw("CALSCALE", "GREGORIAN")
+	w.Text("X-WR-CALNAME", name)
+	for _, t := range tasks {
+		if t.DueAt == nil {
+			continue
+		}
+		writeCalendarTask(w, t, kind)
+	}
+	w.End("VCALENDAR")
+	if err := w.Flush(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func writeCalendarTask(w *ical.Writer, t model.Task, kind CalendarKind) {
+	comp := "VTODO"
+	if kind == CalendarEvent {
+		comp = "VEVENT"
+	}
+	w.Begin(comp)
+	// UID只由任务id决定，客户端据此识别同一条目的更新
+	w.Text("UID", t.ID+"@taskhub")
+	w.Time("DTSTAMP", t.CreatedAt)
+	w.Text("SUMMARY", t.Title)
+	if t.Project != "" {
+		w.Text("CATEGORIES", t.Project)
+	}
+	due := *t.DueAt
+	if kind == CalendarEvent {
+		start := due
+		if t.EstimateMinutes > 0 {
+			start = due.Add(-time.Duration(t.EstimateMinutes) * time.Minute)
+		}
+		// 没有DTEND时DATE-TIME类型的事件视为在DTSTART瞬间结束
+		w.Time("DTSTART", start)
+		if start.Before(due) {
+			w.Time("DTEND", due)
+		}
+		// VEVENT没有“已完成”状态：已完成的任务不再占用忙闲时间
+		w.Raw("STATUS", "CONFIRMED")
+		if t.Done {
+			w.Raw("TRANSP", "TRANSPARENT")
+		} else {
+			w.Raw("TRANSP", "OPAQUE")
+		}
+	} else {
+		w.Time("DUE", due)
+		if t.Done {
+			w.Raw("STATUS", "COMPLETED")
+			w.Raw("PERCENT-COMPLETE", "100")
+		} else {
+			w.Raw("STATUS", "NEEDS-ACTION")
+		}
+	}
+	w.End(comp)
+}
+
+// quoteFilterString 把任意字符串写成filter表达式里的字符串字面量
+func quoteFilterString(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return `"` + strings.ReplaceAll(s, `"`, `\"`) + `"`
+}
@@ -0,0 +1,132 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/kitouo/taskhub/internal/mention"
+	"github.com/kitouo/taskhub/internal/model"
+	"github.com/kitouo/taskhub/internal/repo"
+)
+
+var (
+	ErrInvalidComment = errors.New("invalid comment body")
+	ErrTaskNotFound   = errors.New("task not found")
+)
+
+const (
+	// MaxCommentLength 评论正文的最大字节数
+	MaxCommentLength   = 10000
+	DefaultCommentPage = 50
+	MaxCommentPage     = 200
+)
+
+type CommentService struct {
+	repo  repo.CommentRepo
+	tasks repo.TaskRepo
+}
+
+func NewCommentService(repo repo.CommentRepo, tasks repo.TaskRepo) *CommentService {
+	return &CommentService{repo: repo, tasks: tasks}
+}
+
+// CommentPage 一页评论
+type CommentPage struct {
+	Comments []model.Comment
+	Total    int
+	Limit    int
+	Offset   int
+}
+
+func normalizeBody(body string) (string, error) {
+	body = strings.TrimSpace(body)
+	if body == "" || len(body) > MaxCommentLength {
+		return "", ErrInvalidComment
+	}
+	return body, nil
+}
+
+func (s *CommentService) Create(ctx context.Context, userID, taskID, body string) (model.Comment, error) {
+	body, err := normalizeBody(body)
+	if err != nil {
+		return model.Comment{}, err
+	}
+	if _, ok, err := s.tasks.Get(ctx, taskID); err != nil {
+		return model.Comment{}, err
+	} else if !ok {
+		return model.Comment{}, ErrTaskNotFound
+	}
+
+	c := model.Comment{
+		ID:        NewID(),
+		TaskID:    taskID,
+		AuthorID:  userID,
+		Body:      body,
+		Mentions:  mention.Parse(body),
+		CreatedAt: time.Now().UTC(),
+	}
+	return s.repo.Create(ctx, c)
+}
+
+// List 分页返回任务下未删除的评论；limit<=0时取默认值
+func (s *CommentService) List(ctx context.Context, taskID string, limit, offset int) (CommentPage, error) {
+	if _, ok, err := s.tasks.Get(ctx, taskID); err != nil {
+		return CommentPage{}, err
+	} else if !ok {
+		return CommentPage{}, ErrTaskNotFound
+	}
+	if limit <= 0 {
+		limit = DefaultCommentPage
+	}
+	limit = min(limit, MaxCommentPage)
+
+	comments, total, err := s.repo.List(ctx, taskID, limit, offset)
+	if err != nil {
+		return CommentPage{}, err
+	}
+	return CommentPage{Comments: comments, Total: total, Limit: limit, Offset: offset}, nil
+}
+
+// get 取任务下未删除的评论，评论不属于该任务时视为不存在
+func (s *CommentService) get(ctx context.Context, taskID, id string) (model.Comment, bool, error) {
+	c, ok, err := s.repo.Get(ctx, id)
+	if err != nil || !ok || c.TaskID != taskID || c.DeletedAt != nil {
+		return model.Comment{}, false, err
+	}
+	return c, true, nil
+}
+
+// Update 只有作者可以编辑，编辑后重新解析@提及
+func (s *CommentService) Update(ctx context.Context, userID, taskID, id, body string) (model.Comment, bool, error) {
+	body, err := normalizeBody(body)
+	if err != nil {
+		return model.Comment{}, false, err
+	}
+	c, ok, err := s.get(ctx, taskID, id)
+	if err != nil || !ok {
+		return model.Comment{}, ok, err
+	}
+	if c.AuthorID != userID {
+		return model.Comment{}, true, ErrForbidden
+	}
+
+	now := time.Now().UTC()
+	c.Body = body
+	c.Mentions = mention.Parse(body)
+	c.EditedAt = &now
+	return s.repo.Update(ctx, c)
+}
+
+// Delete 软删除，只有作者可以删除
+func (s *CommentService) Delete(ctx context.Context, userID, taskID, id string) (bool, error) {
+	c, ok, err := s.get(ctx, taskID, id)
+	if err != nil || !ok {
+		return ok, err
+	}
+	if c.AuthorID != userID {
+		return true, ErrForbidden
+	}
+	return s.repo.SoftDelete(ctx, id, time.Now().UTC())
+}
@@ -0,0 +1,84 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/kitouo/taskhub/internal/filter"
+	"github.com/kitouo/taskhub/internal/model"
+	"github.com/kitouo/taskhub/internal/notify"
+	"github.com/kitouo/taskhub/internal/repo"
+)
+
+// DefaultReminderLead 截止前多久发送“即将到期”提醒
+const DefaultReminderLead = time.Hour
+
+// ReminderLookback 过期超过这么久的任务不再扫描，扫描的开销不随积压的过期任务增长
+const ReminderLookback = 24 * time.Hour
+
+type ReminderService struct {
+	tasks    repo.TaskRepo
+	sent     repo.ReminderRepo
+	notifier notify.Notifier
+	lead     time.Duration
+}
+
+func NewReminderService(tasks repo.TaskRepo, sent repo.ReminderRepo, notifier notify.Notifier, lead time.Duration) *ReminderService {
+	if lead <= 0 {
+		lead = DefaultReminderLead
+	}
+	return &ReminderService{tasks: tasks, sent: sent, notifier: notifier, lead: lead}
+}
+
+/*
+Scan 为未完成且 now-ReminderLookback < due_at <= now+lead 的任务发送提醒，返回发送条数
+  - due_at晚于now为due_soon，否则为overdue；同一任务两种提醒各发一次
+  - 服务停机超过ReminderLookback期间过期的任务不再补发overdue
+  - 先Claim再发送，发送失败时Release，下次扫描会重试
+  - 单条发送失败不影响其它任务，最后返回遇到的第一个错误
+*/
+func (s *ReminderService) Scan(ctx context.Context, now time.Time) (int, error) {
+	tasks, err := s.tasks.List(ctx, repo.ListQuery{
+		Filter: filter.And{
+			Left: filter.Compare{Field: "done", Op: filter.OpEq, Value: false},
+			Right: filter.And{
+				Left:  filter.Compare{Field: "due_at", Op: filter.OpGt, Value: now.Add(-ReminderLookback)},
+				Right: filter.Compare{Field: "due_at", Op: filter.OpLe, Value: now.Add(s.lead)},
+			},
+		},
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	var (
+		sent     int
+		firstErr error
+	)
+	for _, t := range tasks {
+		if t.DueAt == nil {
+			continue
+		}
+		r := model.Reminder{TaskID: t.ID, Kind: model.ReminderDueSoon, DueAt: *t.DueAt, SentAt: now}
+		if !t.DueAt.After(now) {
+			r.Kind = model.ReminderOverdue
+		}
+
+		ok, err := s.sent.Claim(ctx, r)
+		if err != nil {
+			return sent, err
+		}
+		if !ok {
+			continue
+		}
+		if err := s.notifier.Notify(ctx, t, r); err != nil {
+			_ = s.sent.Release(ctx, r)
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		sent++
+	}
+	return sent, firstErr
+}
@@ -0,0 +1,94 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/kitouo/taskhub/internal/model"
+	"github.com/kitouo/taskhub/internal/repo/memory"
+)
+
+// recordingNotifier 记录每次发送；fail非空时发送失败
+type recordingNotifier struct {
+	got  []string
+	fail error
+}
+
+func (n *recordingNotifier) Notify(ctx context.Context, t model.Task, r model.Reminder) error {
+	if n.fail != nil {
+		return n.fail
+	}
+	n.got = append(n.got, t.ID+":"+string(r.Kind))
+	return nil
+}
+
+func newReminderFixture(t *testing.T, now time.Time) (*ReminderService, *recordingNotifier) {
+	t.Helper()
+	tasks := memory.NewTaskRepo()
+	at := func(d time.Duration) *time.Time { return ptr(now.Add(d)) }
+	for _, tk := range []model.Task{
+		{ID: "soon", Title: "soon", DueAt: at(30 * time.Minute)},
+		{ID: "later", Title: "later", DueAt: at(2 * time.Hour)},
+		{ID: "overdue", Title: "overdue", DueAt: at(-time.Hour)},
+		{ID: "stale", Title: "stale", DueAt: at(-ReminderLookback - time.Hour)},
+		{ID: "done", Title: "done", Done: true, DueAt: at(-time.Hour)},
+		{ID: "nodue", Title: "nodue"},
+	} {
+		tk.CreatedAt = now.Add(-72 * time.Hour)
+		if _, err := tasks.Create(context.Background(), tk); err != nil {
+			t.Fatal(err)
+		}
+	}
+	n := &recordingNotifier{}
+	return NewReminderService(tasks, memory.NewReminderRepo(), n, time.Hour), n
+}
+
+func TestReminderScan(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 3, 10, 9, 0, 0, 0, time.UTC)
+	s, n := newReminderFixture(t, now)
+
+	sent, err := s.Scan(ctx, now)
+	if err != nil || sent != 2 {
+		t.Fatalf("Scan = %d, %v, want 2", sent, err)
+	}
+	slices.Sort(n.got)
+	if want := []string{"overdue:overdue", "soon:due_soon"}; !slices.Equal(n.got, want) {
+		t.Errorf("sent %v, want %v", n.got, want)
+	}
+
+	// 同一时刻再扫一次不重复发送
+	n.got = nil
+	if sent, err := s.Scan(ctx, now.Add(time.Minute)); err != nil || sent != 0 || len(n.got) != 0 {
+		t.Errorf("second Scan = %d, %v, sent %v, want nothing", sent, err, n.got)
+	}
+
+	// 过了截止时间再补一条overdue；later进入提前量窗口
+	n.got = nil
+	if sent, err := s.Scan(ctx, now.Add(90*time.Minute)); err != nil || sent != 2 {
+		t.Fatalf("Scan after due = %d, %v, want 2", sent, err)
+	}
+	slices.Sort(n.got)
+	if want := []string{"later:due_soon", "soon:overdue"}; !slices.Equal(n.got, want) {
+		t.Errorf("sent %v, want %v", n.got, want)
+	}
+}
+
+// 发送失败时释放登记，下次扫描重试
+func TestReminderScanRetriesFailedSend(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 3, 10, 9, 0, 0, 0, time.UTC)
+	s, n := newReminderFixture(t, now)
+
+	n.fail = errors.New("smtp down")
+	if sent, err := s.Scan(ctx, now); !errors.Is(err, n.fail) || sent != 0 {
+		t.Fatalf("Scan = %d, %v, want 0, %v", sent, err, n.fail)
+	}
+	n.fail = nil
+	if sent, err := s.Scan(ctx, now); err != nil || sent != 2 {
+		t.Errorf("retry Scan = %d, %v, want 2", sent, err)
+	}
+}
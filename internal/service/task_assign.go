@@ -0,0 +1,123 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/kitouo/taskhub/internal/model"
+	"github.com/kitouo/taskhub/internal/repo"
+)
+
+var (
+	ErrUserNotFound     = errors.New("user not found")
+	ErrUserInactive     = errors.New("user is inactive")
+	ErrTooManyAssignees = errors.New("too many assignees")
+	errNoAssignees      = errors.New("assignees are not enabled")
+)
+
+// MaxAssignees 单个任务最多的负责人数
+const MaxAssignees = 20
+
+// WithAssignees 启用负责人；未设置时任务没有assignees，按负责人过滤返回空
+func WithAssignees(assigns repo.AssignmentRepo, users repo.UserRepo) TaskOption {
+	return func(s *TaskService) {
+		s.assigns = assigns
+		s.users = users
+	}
+}
+
+/*
+SetAssignees 把任务的负责人整体替换为userIDs（去重，空表示清空）
+新加入的负责人必须存在且处于启用状态；已经在任务上的停用用户可以保留，便于逐步交接
+ok=false 表示任务不存在
+*/
+func (s *TaskService) SetAssignees(ctx context.Context, taskID string, userIDs []string) (model.Task, bool, error) {
+	if s.assigns == nil {
+		return model.Task{}, false, errNoAssignees
+	}
+	t, ok, err := s.repo.Get(ctx, taskID)
+	if err != nil || !ok {
+		return model.Task{}, ok, err
+	}
+
+	cur, err := s.assigns.ByTasks(ctx, []string{taskID})
+	if err != nil {
+		return model.Task{}, true, err
+	}
+	already := make(map[string]bool, len(cur))
+	for _, a := range cur {
+		already[a.UserID] = true
+	}
+
+	ids := make([]string, 0, len(userIDs))
+	seen := make(map[string]bool)
+	for _, uid := range userIDs {
+		if uid == "" || seen[uid] {
+			continue
+		}
+		seen[uid] = true
+		ids = append(ids, uid)
+	}
+	if len(ids) > MaxAssignees {
+		return model.Task{}, true, ErrTooManyAssignees
+	}
+	for _, uid := range ids {
+		u, ok, err := s.users.Get(ctx, uid)
+		if err != nil {
+			return model.Task{}, true, err
+		}
+		if !ok {
+			return model.Task{}, true, ErrUserNotFound
+		}
+		if !u.Active && !already[uid] {
+			return model.Task{}, true, ErrUserInactive
+		}
+	}
+
+	if err := s.assigns.Replace(ctx, taskID, ids); err != nil {
+		return model.Task{}, true, err
+	}
+	if err := s.decorate(ctx, &t); err != nil {
+		return model.Task{}, false, err
+	}
+	return t, true, nil
+}
+
+// assignedTaskIDs 分配给userID的任务id；未启用负责人时返回空集合
+func (s *TaskService) assignedTaskIDs(ctx context.Context, userID string) ([]string, error) {
+	if s.assigns == nil {
+		return []string{}, nil
+	}
+	as, err := s.assigns.ByUsers(ctx, []string{userID})
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, len(as))
+	for i, a := range as {
+		ids[i] = a.TaskID
+	}
+	return ids, nil
+}
+
+// markAssignees 批量填充Assignees
+func (s *TaskService) markAssignees(ctx context.Context, tasks []model.Task) error {
+	if s.assigns == nil || len(tasks) == 0 {
+		return nil
+	}
+	ids := make([]string, len(tasks))
+	for i, t := range tasks {
+		ids[i] = t.ID
+	}
+	as, err := s.assigns.ByTasks(ctx, ids)
+	if err != nil {
+		return err
+	}
+	byTask := make(map[string][]string)
+	for _, a := range as {
+		byTask[a.TaskID] = append(byTask[a.TaskID], a.UserID)
+	}
+	for i := range tasks {
+		tasks[i].Assignees = byTask[tasks[i].ID]
+	}
+	return nil
+}
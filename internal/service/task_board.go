@@ -0,0 +1,174 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+
+	"github.com/kitouo/taskhub/internal/filter"
+	"github.com/kitouo/taskhub/internal/model"
+	"github.com/kitouo/taskhub/internal/rank"
+	"github.com/kitouo/taskhub/internal/repo"
+)
+
+var (
+	ErrInvalidColumn         = errors.New("invalid column")
+	ErrInvalidMove           = errors.New("task cannot be its own neighbour")
+	ErrNeighbourNotFound     = errors.New("neighbour not found in column")
+	ErrNeighboursNotAdjacent = errors.New("neighbours are not adjacent")
+)
+
+/*
+MoveCardInput 把任务移动到看板某列的某个位置
+After/Before 为移动后紧挨着的上/下邻居（可只给一个，都不给表示移到列尾）
+*/
+type MoveCardInput struct {
+	Column *string // nil表示留在原列
+	After  string
+	Before string
+}
+
+/*
+MoveCard 拖动看板卡片
+  - 正常情况下只为被移动的任务生成一个介于两邻居之间的新rank，只改一行
+  - 新rank超过rank.MaxLength、邻居rank相同或为空（旧数据）时，整列重新均匀分配
+  - 两个邻居都给出但已不相邻（看板已被别人改动）时返回ErrNeighboursNotAdjacent
+*/
+func (s *TaskService) MoveCard(ctx context.Context, id string, in MoveCardInput) (model.Task, bool, error) {
+	t, ok, err := s.repo.Get(ctx, id)
+	if err != nil || !ok {
+		return model.Task{}, ok, err
+	}
+	if in.After == id || in.Before == id {
+		return model.Task{}, true, ErrInvalidMove
+	}
+	column := t.Column
+	if in.Column != nil {
+		if column, err = normalizeColumn(*in.Column); err != nil {
+			return model.Task{}, true, err
+		}
+	}
+
+	cards, err := s.columnTasks(ctx, column)
+	if err != nil {
+		return model.Task{}, true, err
+	}
+	cards = slices.DeleteFunc(cards, func(c model.Task) bool { return c.ID == id })
+
+	pos, err := insertPosition(cards, in.After, in.Before)
+	if err != nil {
+		return model.Task{}, true, err
+	}
+	lo, hi := "", ""
+	if pos > 0 {
+		lo = cards[pos-1].Rank
+	}
+	if pos < len(cards) {
+		hi = cards[pos].Rank
+	}
+
+	key, err := rank.Between(lo, hi)
+	legacy := (pos > 0 && lo == "") || (pos < len(cards) && hi == "")
+	if err == nil && !legacy && len(key) <= rank.MaxLength {
+		t, ok, err = s.repo.Update(ctx, id, repo.TaskPatch{Column: &column, Rank: &key})
+	} else {
+		t.Column = column
+		t, ok, err = s.rebalance(ctx, slices.Insert(cards, pos, t), id)
+	}
+	if err != nil || !ok {
+		return model.Task{}, ok, err
+	}
+	if err := s.decorate(ctx, &t); err != nil {
+		return model.Task{}, false, err
+	}
+	return t, true, nil
+}
+
+// insertPosition 根据邻居计算在cards（已去掉被移动的任务）中的插入下标
+func insertPosition(cards []model.Task, after, before string) (int, error) {
+	indexOf := func(id string) (int, error) {
+		i := slices.IndexFunc(cards, func(c model.Task) bool { return c.ID == id })
+		if i < 0 {
+			return 0, ErrNeighbourNotFound
+		}
+		return i, nil
+	}
+	switch {
+	case after != "" && before != "":
+		ai, err := indexOf(after)
+		if err != nil {
+			return 0, err
+		}
+		bi, err := indexOf(before)
+		if err != nil {
+			return 0, err
+		}
+		if bi != ai+1 {
+			return 0, ErrNeighboursNotAdjacent
+		}
+		return bi, nil
+	case after != "":
+		ai, err := indexOf(after)
+		return ai + 1, err
+	case before != "":
+		return indexOf(before)
+	default:
+		return len(cards), nil
+	}
+}
+
+/*
+rebalance 按ordered的顺序给整列重新分配等距rank，放在一个原子批量里执行
+movedID为被移动的任务，同时写入它的新列；返回它更新后的状态
+*/
+func (s *TaskService) rebalance(ctx context.Context, ordered []model.Task, movedID string) (model.Task, bool, error) {
+	keys := rank.Spread(len(ordered))
+	ops := make([]repo.BatchOp, len(ordered))
+	for i, c := range ordered {
+		p := repo.TaskPatch{Rank: &keys[i]}
+		if c.ID == movedID {
+			p.Column = &c.Column
+		}
+		ops[i] = repo.BatchOp{Kind: repo.BatchUpdate, ID: c.ID, Patch: p}
+	}
+	results, err := s.repo.Batch(ctx, ops, true)
+	if err != nil {
+		return model.Task{}, false, err
+	}
+	for i, res := range results {
+		if res.Err != nil && !errors.Is(res.Err, repo.ErrBatchAborted) {
+			return model.Task{}, false, res.Err
+		}
+		if !res.Found && ordered[i].ID == movedID {
+			return model.Task{}, false, nil
+		}
+	}
+	return s.repo.Get(ctx, movedID)
+}
+
+// columnTasks 某列的全部任务，按rank升序
+func (s *TaskService) columnTasks(ctx context.Context, column string) ([]model.Task, error) {
+	return s.repo.List(ctx, repo.ListQuery{
+		Filter: filter.Compare{Field: "column", Op: filter.OpEq, Value: column},
+		Sort:   repo.Sort{Field: repo.SortRank},
+	})
+}
+
+// appendRank 为新任务生成排在所在列末尾的rank
+func (s *TaskService) appendRank(ctx context.Context, t *model.Task) error {
+	last, err := s.repo.LastRank(ctx, t.Column)
+	if err != nil {
+		return err
+	}
+	t.Rank = rank.After(last)
+	return nil
+}
+
+func normalizeColumn(column string) (string, error) {
+	column = strings.TrimSpace(column)
+	if len(column) > 64 {
+		return "", ErrInvalidColumn
+	}
+	return column, nil
+}
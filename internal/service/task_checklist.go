@@ -0,0 +1,213 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/kitouo/taskhub/internal/model"
+	"github.com/kitouo/taskhub/internal/repo"
+)
+
+var (
+	ErrInvalidChecklistItem  = errors.New("invalid checklist item")
+	ErrInvalidChecklistOrder = errors.New("invalid checklist order")
+	ErrChecklistFull         = errors.New("too many checklist items")
+	ErrChecklistIncomplete   = errors.New("checklist has unchecked items")
+	errNoChecklists          = errors.New("checklists are not enabled")
+)
+
+// MaxChecklistItems 单个任务的检查项上限
+const MaxChecklistItems = 200
+
+// WithChecklists 启用任务内检查项；未设置时任务上不显示检查项进度
+func WithChecklists(checklists repo.ChecklistRepo) TaskOption {
+	return func(s *TaskService) {
+		s.checklists = checklists
+	}
+}
+
+// ChecklistItemInput 修改检查项的参数，nil字段表示不修改
+type ChecklistItemInput struct {
+	Text     *string
+	Checked  *bool
+	Position *int // 移动到该位置，越界时移到首/尾
+}
+
+// Checklist 按顺序返回检查项，ok=false表示任务不存在
+func (s *TaskService) Checklist(ctx context.Context, taskID string) ([]model.ChecklistItem, bool, error) {
+	if s.checklists == nil {
+		return nil, false, errNoChecklists
+	}
+	if _, ok, err := s.repo.Get(ctx, taskID); err != nil || !ok {
+		return nil, ok, err
+	}
+	items, err := s.checklists.List(ctx, taskID)
+	return items, err == nil, err
+}
+
+// AddChecklistItem 新增检查项，position为nil时追加到末尾
+func (s *TaskService) AddChecklistItem(ctx context.Context, taskID, text string, position *int) (model.ChecklistItem, bool, error) {
+	text, err := normalizeChecklistText(text)
+	if err != nil {
+		return model.ChecklistItem{}, true, err
+	}
+	items, ok, err := s.Checklist(ctx, taskID)
+	if err != nil || !ok {
+		return model.ChecklistItem{}, ok, err
+	}
+	if len(items) >= MaxChecklistItems {
+		return model.ChecklistItem{}, true, ErrChecklistFull
+	}
+
+	it, err := s.checklists.Create(ctx, model.ChecklistItem{
+		ID:        NewID(),
+		TaskID:    taskID,
+		Text:      text,
+		Position:  len(items),
+		CreatedAt: time.Now().UTC(),
+	})
+	if err != nil || position == nil {
+		return it, true, err
+	}
+	ids := append(itemIDs(items), it.ID)
+	return s.moveChecklistItem(ctx, it, ids, *position)
+}
+
+/*
+UpdateChecklistItem 修改文本、勾选状态或位置
+ok=false表示任务或检查项不存在（检查项不属于该任务也视为不存在）
+*/
+func (s *TaskService) UpdateChecklistItem(ctx context.Context, taskID, itemID string, in ChecklistItemInput) (model.ChecklistItem, bool, error) {
+	it, ok, err := s.checklistItem(ctx, taskID, itemID)
+	if err != nil || !ok {
+		return model.ChecklistItem{}, ok, err
+	}
+	if in.Text != nil {
+		if it.Text, err = normalizeChecklistText(*in.Text); err != nil {
+			return model.ChecklistItem{}, true, err
+		}
+	}
+	if in.Checked != nil {
+		it.Checked = *in.Checked
+	}
+	if in.Text != nil || in.Checked != nil {
+		if it, ok, err = s.checklists.Update(ctx, it); err != nil || !ok {
+			return model.ChecklistItem{}, ok, err
+		}
+	}
+	if in.Position == nil {
+		return it, true, nil
+	}
+	items, err := s.checklists.List(ctx, taskID)
+	if err != nil {
+		return model.ChecklistItem{}, true, err
+	}
+	return s.moveChecklistItem(ctx, it, itemIDs(items), *in.Position)
+}
+
+// DeleteChecklistItem 删除后重新编号，保持position连续
+func (s *TaskService) DeleteChecklistItem(ctx context.Context, taskID, itemID string) (bool, error) {
+	if _, ok, err := s.checklistItem(ctx, taskID, itemID); err != nil || !ok {
+		return ok, err
+	}
+	if ok, err := s.checklists.Delete(ctx, itemID); err != nil || !ok {
+		return ok, err
+	}
+	items, err := s.checklists.List(ctx, taskID)
+	if err != nil {
+		return true, err
+	}
+	return true, s.checklists.Reorder(ctx, taskID, itemIDs(items))
+}
+
+// ReorderChecklist 按ids重新排列，ids必须恰好是该任务全部检查项的一个排列
+func (s *TaskService) ReorderChecklist(ctx context.Context, taskID string, ids []string) ([]model.ChecklistItem, bool, error) {
+	items, ok, err := s.Checklist(ctx, taskID)
+	if err != nil || !ok {
+		return nil, ok, err
+	}
+	want, got := itemIDs(items), slices.Clone(ids)
+	slices.Sort(want)
+	slices.Sort(got)
+	if !slices.Equal(want, got) {
+		return nil, true, ErrInvalidChecklistOrder
+	}
+	if err := s.checklists.Reorder(ctx, taskID, ids); err != nil {
+		return nil, true, err
+	}
+	items, err = s.checklists.List(ctx, taskID)
+	return items, true, err
+}
+
+func (s *TaskService) checklistItem(ctx context.Context, taskID, itemID string) (model.ChecklistItem, bool, error) {
+	if s.checklists == nil {
+		return model.ChecklistItem{}, false, errNoChecklists
+	}
+	it, ok, err := s.checklists.Get(ctx, itemID)
+	if err != nil || !ok || it.TaskID != taskID {
+		return model.ChecklistItem{}, false, err
+	}
+	return it, true, nil
+}
+
+// moveChecklistItem 把it移动到pos（按ids的当前顺序计算），返回移动后的检查项
+func (s *TaskService) moveChecklistItem(ctx context.Context, it model.ChecklistItem, ids []string, pos int) (model.ChecklistItem, bool, error) {
+	ids = slices.DeleteFunc(ids, func(id string) bool { return id == it.ID })
+	pos = min(max(pos, 0), len(ids))
+	ids = slices.Insert(ids, pos, it.ID)
+	if err := s.checklists.Reorder(ctx, it.TaskID, ids); err != nil {
+		return model.ChecklistItem{}, true, err
+	}
+	it.Position = pos
+	return it, true, nil
+}
+
+// attachChecklist 计算检查项进度，没有检查项时保持nil
+func (s *TaskService) attachChecklist(ctx context.Context, t *model.Task) error {
+	if s.checklists == nil {
+		return nil
+	}
+	items, err := s.checklists.List(ctx, t.ID)
+	if err != nil || len(items) == 0 {
+		return err
+	}
+	p := &model.Progress{Total: len(items)}
+	for _, it := range items {
+		if it.Checked {
+			p.Done++
+		}
+	}
+	t.Checklist = p
+	return nil
+}
+
+// checklistComplete 任务的检查项是否全部勾选（没有检查项视为完成）
+func (s *TaskService) checklistComplete(ctx context.Context, taskID string) (bool, error) {
+	if s.checklists == nil {
+		return true, nil
+	}
+	items, err := s.checklists.List(ctx, taskID)
+	if err != nil {
+		return false, err
+	}
+	return !slices.ContainsFunc(items, func(it model.ChecklistItem) bool { return !it.Checked }), nil
+}
+
+func itemIDs(items []model.ChecklistItem) []string {
+	ids := make([]string, len(items))
+	for i, it := range items {
+		ids[i] = it.ID
+	}
+	return ids
+}
+
+func normalizeChecklistText(text string) (string, error) {
+	text = strings.TrimSpace(text)
+	if text == "" || len([]rune(text)) > 500 {
+		return "", ErrInvalidChecklistItem
+	}
+	return text, nil
+}
@@ -0,0 +1,152 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/kitouo/taskhub/internal/model"
+	"github.com/kitouo/taskhub/internal/repo/memory"
+)
+
+func newChecklistService() *TaskService {
+	return NewTaskService(memory.NewTaskRepo(), WithChecklists(memory.NewChecklistRepo()))
+}
+
+func mustAddItems(t *testing.T, s *TaskService, taskID string, texts ...string) []model.ChecklistItem {
+	t.Helper()
+	out := make([]model.ChecklistItem, len(texts))
+	for i, text := range texts {
+		it, _, err := s.AddChecklistItem(context.Background(), taskID, text, nil)
+		if err != nil {
+			t.Fatalf("AddChecklistItem(%s): %v", text, err)
+		}
+		out[i] = it
+	}
+	return out
+}
+
+// checklistOrder 按当前顺序返回检查项文本，并确认position从0连续编号
+func checklistOrder(t *testing.T, s *TaskService, taskID string) string {
+	t.Helper()
+	items, _, err := s.Checklist(context.Background(), taskID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	texts := make([]string, len(items))
+	for i, it := range items {
+		if it.Position != i {
+			t.Errorf("%s at index %d has position %d", it.Text, i, it.Position)
+		}
+		texts[i] = it.Text
+	}
+	return fmt.Sprint(texts)
+}
+
+func TestChecklistPositions(t *testing.T) {
+	ctx := context.Background()
+	s := newChecklistService()
+	task := mustCreate(t, s, "task")
+	items := mustAddItems(t, s, task.ID, "a", "b", "c")
+
+	// 插入到指定位置，越界时放到首/尾
+	if _, _, err := s.AddChecklistItem(ctx, task.ID, "first", ptr(-3)); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := s.AddChecklistItem(ctx, task.ID, "mid", ptr(2)); err != nil {
+		t.Fatal(err)
+	}
+	if got := checklistOrder(t, s, task.ID); got != "[first a mid b c]" {
+		t.Errorf("after inserts = %s", got)
+	}
+
+	moved, _, err := s.UpdateChecklistItem(ctx, task.ID, items[2].ID, ChecklistItemInput{Position: ptr(0)})
+	if err != nil || moved.Position != 0 {
+		t.Fatalf("move c to 0 = %+v, %v", moved, err)
+	}
+	if _, _, err := s.UpdateChecklistItem(ctx, task.ID, items[0].ID, ChecklistItemInput{Position: ptr(99)}); err != nil {
+		t.Fatal(err)
+	}
+	if got := checklistOrder(t, s, task.ID); got != "[c first mid b a]" {
+		t.Errorf("after moves = %s", got)
+	}
+
+	if _, err := s.DeleteChecklistItem(ctx, task.ID, items[1].ID); err != nil {
+		t.Fatal(err)
+	}
+	if got := checklistOrder(t, s, task.ID); got != "[c first mid a]" {
+		t.Errorf("after delete = %s", got)
+	}
+}
+
+func TestChecklistValidation(t *testing.T) {
+	ctx := context.Background()
+	s := newChecklistService()
+	task, other := mustCreate(t, s, "task"), mustCreate(t, s, "other")
+	items := mustAddItems(t, s, task.ID, "a", "b")
+	foreign := mustAddItems(t, s, other.ID, "x")
+
+	if _, _, err := s.AddChecklistItem(ctx, task.ID, "  ", nil); !errors.Is(err, ErrInvalidChecklistItem) {
+		t.Errorf("blank text err = %v", err)
+	}
+	if _, ok, err := s.AddChecklistItem(ctx, "missing", "a", nil); ok || err != nil {
+		t.Errorf("AddChecklistItem(missing task) = %v, %v; want not found", ok, err)
+	}
+	// 其它任务的检查项视为不存在
+	if _, ok, err := s.UpdateChecklistItem(ctx, task.ID, foreign[0].ID, ChecklistItemInput{Checked: ptr(true)}); ok || err != nil {
+		t.Errorf("update foreign item = %v, %v; want not found", ok, err)
+	}
+
+	a, b := items[0].ID, items[1].ID
+	for _, ids := range [][]string{{a}, {a, a}, {a, b, foreign[0].ID}, {a, "missing"}, nil} {
+		if _, _, err := s.ReorderChecklist(ctx, task.ID, ids); !errors.Is(err, ErrInvalidChecklistOrder) {
+			t.Errorf("ReorderChecklist(%v) err = %v, want ErrInvalidChecklistOrder", ids, err)
+		}
+	}
+	got, ok, err := s.ReorderChecklist(ctx, task.ID, []string{b, a})
+	if err != nil || !ok || len(got) != 2 || got[0].ID != b || got[1].Position != 1 {
+		t.Errorf("ReorderChecklist = %+v, %v, %v", got, ok, err)
+	}
+}
+
+// GET /tasks/{id}的检查项进度，以及RequireChecklist对完成的限制
+func TestChecklistProgressAndGuard(t *testing.T) {
+	ctx := context.Background()
+	s := newChecklistService()
+	task := mustCreate(t, s, "task")
+	if got, _ := mustGetTask(t, s, task.ID); got.Checklist != nil {
+		t.Errorf("progress without items = %+v, want nil", got.Checklist)
+	}
+	items := mustAddItems(t, s, task.ID, "a", "b", "c")
+	if _, _, err := s.UpdateChecklistItem(ctx, task.ID, items[1].ID, ChecklistItemInput{Checked: ptr(true)}); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := mustGetTask(t, s, task.ID); got.Checklist == nil || got.Checklist.Done != 1 || got.Checklist.Total != 3 {
+		t.Errorf("progress = %+v, want 1/3", got.Checklist)
+	}
+
+	guard := MarkDoneOptions{RequireChecklist: true, Force: true}
+	if _, _, err := s.MarkDone(ctx, task.ID, true, guard); !errors.Is(err, ErrChecklistIncomplete) {
+		t.Fatalf("MarkDone err = %v, want ErrChecklistIncomplete", err)
+	}
+	if got, _ := mustGetTask(t, s, task.ID); got.Done {
+		t.Error("task completed with unchecked items")
+	}
+	for _, it := range []model.ChecklistItem{items[0], items[2]} {
+		if _, _, err := s.UpdateChecklistItem(ctx, task.ID, it.ID, ChecklistItemInput{Checked: ptr(true)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	got, _, err := s.MarkDone(ctx, task.ID, true, guard)
+	if err != nil || !got.Done || got.Checklist.Done != 3 {
+		t.Errorf("MarkDone = %+v, %v", got, err)
+	}
+
+	// 不要求检查项时，未勾选也可以完成
+	other := mustCreate(t, s, "other")
+	mustAddItems(t, s, other.ID, "x")
+	if _, _, err := s.MarkDone(ctx, other.ID, true, MarkDoneOptions{}); err != nil {
+		t.Errorf("MarkDone without guard err = %v", err)
+	}
+}
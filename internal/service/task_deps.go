@@ -0,0 +1,290 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/kitouo/taskhub/internal/filter"
+	"github.com/kitouo/taskhub/internal/model"
+	"github.com/kitouo/taskhub/internal/repo"
+)
+
+var (
+	ErrSelfDependency  = errors.New("task cannot depend on itself")
+	ErrBlockerNotFound = errors.New("blocker not found")
+	ErrDependencyCycle = errors.New("dependency would create a cycle")
+	ErrBlocked         = errors.New("task is blocked by open dependencies")
+	errNoDependencies  = errors.New("dependencies are not enabled")
+)
+
+// WithDependencies 启用任务依赖；未设置时依赖相关接口不可用，任务永远不是blocked
+func WithDependencies(deps repo.DependencyRepo) TaskOption {
+	return func(s *TaskService) {
+		s.deps = deps
+	}
+}
+
+/*
+AddDependency 声明taskID被blockerID阻塞
+加入新边之前检查：从blocker沿“被谁阻塞”方向能否走回task，能则说明会成环
+ok=false 表示taskID不存在
+*/
+func (s *TaskService) AddDependency(ctx context.Context, taskID, blockerID string) (bool, error) {
+	if s.deps == nil {
+		return false, errNoDependencies
+	}
+	if taskID == blockerID {
+		return true, ErrSelfDependency
+	}
+	if _, ok, err := s.repo.Get(ctx, taskID); err != nil || !ok {
+		return ok, err
+	}
+	if _, ok, err := s.repo.Get(ctx, blockerID); err != nil {
+		return true, err
+	} else if !ok {
+		return true, ErrBlockerNotFound
+	}
+
+	// 检查放在repo的锁内执行，避免两个并发请求各自通过检查后合起来成环
+	return true, s.deps.Add(ctx, model.Dependency{
+		TaskID:    taskID,
+		BlockerID: blockerID,
+		CreatedAt: time.Now().UTC(),
+	}, func(edges []model.Dependency) error {
+		return checkAcyclic(edges, taskID, blockerID)
+	})
+}
+
+// checkAcyclic 加入taskID<-blockerID之前，从blocker沿“被谁阻塞”方向能否走回task
+func checkAcyclic(edges []model.Dependency, taskID, blockerID string) error {
+	blockersOf := make(map[string][]string)
+	for _, e := range edges {
+		blockersOf[e.TaskID] = append(blockersOf[e.TaskID], e.BlockerID)
+	}
+	seen := map[string]bool{blockerID: true}
+	queue := []string{blockerID}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		for _, b := range blockersOf[cur] {
+			if b == taskID {
+				return ErrDependencyCycle
+			}
+			if !seen[b] {
+				seen[b] = true
+				queue = append(queue, b)
+			}
+		}
+	}
+	return nil
+}
+
+func (s *TaskService) RemoveDependency(ctx context.Context, taskID, blockerID string) (bool, error) {
+	if s.deps == nil {
+		return false, errNoDependencies
+	}
+	return s.deps.Remove(ctx, taskID, blockerID)
+}
+
+// Dependencies 返回阻塞该任务的任务（前置任务）
+func (s *TaskService) Dependencies(ctx context.Context, id string) ([]model.Task, bool, error) {
+	if s.deps == nil {
+		return nil, false, errNoDependencies
+	}
+	if _, ok, err := s.repo.Get(ctx, id); err != nil || !ok {
+		return nil, ok, err
+	}
+	edges, err := s.deps.Blockers(ctx, []string{id})
+	if err != nil {
+		return nil, true, err
+	}
+	ids := make([]string, len(edges))
+	for i, e := range edges {
+		ids[i] = e.BlockerID
+	}
+	tasks, err := s.listByIDs(ctx, ids)
+	return tasks, true, err
+}
+
+// Dependents 返回被该任务阻塞的任务（后续任务）
+func (s *TaskService) Dependents(ctx context.Context, id string) ([]model.Task, bool, error) {
+	if s.deps == nil {
+		return nil, false, errNoDependencies
+	}
+	if _, ok, err := s.repo.Get(ctx, id); err != nil || !ok {
+		return nil, ok, err
+	}
+	edges, err := s.deps.Dependents(ctx, id)
+	if err != nil {
+		return nil, true, err
+	}
+	ids := make([]string, len(edges))
+	for i, e := range edges {
+		ids[i] = e.TaskID
+	}
+	tasks, err := s.listByIDs(ctx, ids)
+	return tasks, true, err
+}
+
+func (s *TaskService) listByIDs(ctx context.Context, ids []string) ([]model.Task, error) {
+	tasks, err := s.repo.List(ctx, repo.ListQuery{IDs: ids})
+	if err != nil {
+		return nil, err
+	}
+	if err := s.markBlocked(ctx, tasks); err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
+
+/*
+TopologicalOrder 按依赖关系给出执行顺序：前置任务总在后续任务之前
+  - 只考虑opts筛选出的任务之间的边
+  - 多个任务同时可执行时保持opts给出的顺序（Kahn算法 + 稳定选择）
+*/
+func (s *TaskService) TopologicalOrder(ctx context.Context, opts ListOptions) ([]model.Task, error) {
+	tasks, err := s.List(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	if s.deps == nil || len(tasks) == 0 {
+		return tasks, nil
+	}
+
+	pos := make(map[string]int, len(tasks))
+	ids := make([]string, len(tasks))
+	for i, t := range tasks {
+		pos[t.ID] = i
+		ids[i] = t.ID
+	}
+	edges, err := s.deps.Blockers(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	indegree := make([]int, len(tasks))
+	next := make(map[int][]int)
+	for _, e := range edges {
+		from, ok1 := pos[e.BlockerID]
+		to, ok2 := pos[e.TaskID]
+		if !ok1 || !ok2 {
+			continue
+		}
+		next[from] = append(next[from], to)
+		indegree[to]++
+	}
+
+	out := make([]model.Task, 0, len(tasks))
+	emitted := make([]bool, len(tasks))
+	for len(out) < len(tasks) {
+		// 选出原顺序中最靠前、入度为0的任务；任务量为列表级别，O(n^2)可以接受
+		pick := -1
+		for i := range tasks {
+			if !emitted[i] && indegree[i] == 0 {
+				pick = i
+				break
+			}
+		}
+		if pick < 0 {
+			// 数据异常导致存在环：剩余任务按原顺序追加，避免接口失败
+			for i := range tasks {
+				if !emitted[i] {
+					out = append(out, tasks[i])
+				}
+			}
+			break
+		}
+		emitted[pick] = true
+		out = append(out, tasks[pick])
+		for _, n := range next[pick] {
+			indegree[n]--
+		}
+	}
+	return out, nil
+}
+
+// isBlocked 任务是否存在未完成的前置任务
+func (s *TaskService) isBlocked(ctx context.Context, id string) (bool, error) {
+	t := []model.Task{{ID: id}}
+	if err := s.markBlocked(ctx, t); err != nil {
+		return false, err
+	}
+	return t[0].Blocked, nil
+}
+
+// blockedOutside ids中是否有任务被ids之外的未完成任务阻塞；一起完成的任务之间的依赖不算
+func (s *TaskService) blockedOutside(ctx context.Context, ids []string) (bool, error) {
+	if s.deps == nil || len(ids) == 0 {
+		return false, nil
+	}
+	edges, err := s.deps.Blockers(ctx, ids)
+	if err != nil {
+		return false, err
+	}
+	inside := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		inside[id] = true
+	}
+	var blockerIDs []string
+	for _, e := range edges {
+		if !inside[e.BlockerID] {
+			inside[e.BlockerID] = true
+			blockerIDs = append(blockerIDs, e.BlockerID)
+		}
+	}
+	if len(blockerIDs) == 0 {
+		return false, nil
+	}
+	n, err := s.repo.Count(ctx, repo.ListQuery{
+		IDs:    blockerIDs,
+		Filter: filter.Compare{Field: "done", Op: filter.OpEq, Value: false},
+	})
+	return n > 0, err
+}
+
+// markBlocked 批量计算Blocked：一次查边，一次查前置任务中仍未完成的
+func (s *TaskService) markBlocked(ctx context.Context, tasks []model.Task) error {
+	if s.deps == nil || len(tasks) == 0 {
+		return nil
+	}
+	ids := make([]string, len(tasks))
+	for i, t := range tasks {
+		ids[i] = t.ID
+	}
+	edges, err := s.deps.Blockers(ctx, ids)
+	if err != nil || len(edges) == 0 {
+		return err
+	}
+
+	blockerIDs := make([]string, 0, len(edges))
+	seen := make(map[string]bool)
+	for _, e := range edges {
+		if !seen[e.BlockerID] {
+			seen[e.BlockerID] = true
+			blockerIDs = append(blockerIDs, e.BlockerID)
+		}
+	}
+	open, err := s.repo.List(ctx, repo.ListQuery{
+		IDs:    blockerIDs,
+		Filter: filter.Compare{Field: "done", Op: filter.OpEq, Value: false},
+	})
+	if err != nil {
+		return err
+	}
+	openSet := make(map[string]bool, len(open))
+	for _, t := range open {
+		openSet[t.ID] = true
+	}
+
+	blocked := make(map[string]bool)
+	for _, e := range edges {
+		if openSet[e.BlockerID] {
+			blocked[e.TaskID] = true
+		}
+	}
+	for i := range tasks {
+		tasks[i].Blocked = blocked[tasks[i].ID]
+	}
+	return nil
+}
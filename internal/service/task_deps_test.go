@@ -0,0 +1,149 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/kitouo/taskhub/internal/model"
+	"github.com/kitouo/taskhub/internal/repo/memory"
+)
+
+func newDepsService(opts ...TaskOption) *TaskService {
+	return NewTaskService(memory.NewTaskRepo(), append(opts, WithDependencies(memory.NewDependencyRepo()))...)
+}
+
+func mustDepend(t *testing.T, s *TaskService, taskID, blockerID string) {
+	t.Helper()
+	if _, err := s.AddDependency(context.Background(), taskID, blockerID); err != nil {
+		t.Fatalf("AddDependency(%s, %s): %v", taskID, blockerID, err)
+	}
+}
+
+func TestAddDependencyRejectsCycle(t *testing.T) {
+	ctx := context.Background()
+	s := newDepsService()
+	a, b, c := mustCreate(t, s, "a"), mustCreate(t, s, "b"), mustCreate(t, s, "c")
+	mustDepend(t, s, b.ID, a.ID)
+	mustDepend(t, s, c.ID, b.ID)
+
+	if _, err := s.AddDependency(ctx, a.ID, c.ID); !errors.Is(err, ErrDependencyCycle) {
+		t.Errorf("AddDependency(a, c) err = %v, want ErrDependencyCycle", err)
+	}
+	if _, err := s.AddDependency(ctx, a.ID, a.ID); !errors.Is(err, ErrSelfDependency) {
+		t.Errorf("AddDependency(a, a) err = %v, want ErrSelfDependency", err)
+	}
+	if _, err := s.AddDependency(ctx, a.ID, "missing"); !errors.Is(err, ErrBlockerNotFound) {
+		t.Errorf("AddDependency(a, missing) err = %v, want ErrBlockerNotFound", err)
+	}
+	if ok, err := s.AddDependency(ctx, "missing", a.ID); ok || err != nil {
+		t.Errorf("AddDependency(missing, a) = %v, %v; want not found", ok, err)
+	}
+	// 重复添加同一条边是幂等的
+	mustDepend(t, s, c.ID, a.ID)
+	mustDepend(t, s, c.ID, a.ID)
+	deps, _, err := s.Dependencies(ctx, c.ID)
+	if err != nil || len(deps) != 2 {
+		t.Errorf("Dependencies(c) = %+v, %v", deps, err)
+	}
+}
+
+// 并发地加入方向相反的边，最多只能成功一条
+func TestAddDependencyConcurrent(t *testing.T) {
+	ctx := context.Background()
+	for range 50 {
+		s := newDepsService()
+		a, b := mustCreate(t, s, "a"), mustCreate(t, s, "b")
+		var (
+			wg   sync.WaitGroup
+			errs [2]error
+		)
+		wg.Add(2)
+		go func() { defer wg.Done(); _, errs[0] = s.AddDependency(ctx, a.ID, b.ID) }()
+		go func() { defer wg.Done(); _, errs[1] = s.AddDependency(ctx, b.ID, a.ID) }()
+		wg.Wait()
+		if errs[0] == nil && errs[1] == nil {
+			t.Fatal("both directions added: dependency cycle")
+		}
+	}
+}
+
+func TestBlockedFlag(t *testing.T) {
+	ctx := context.Background()
+	s := newDepsService()
+	blocker, task := mustCreate(t, s, "blocker"), mustCreate(t, s, "task")
+	mustDepend(t, s, task.ID, blocker.ID)
+
+	if got, _ := mustGetTask(t, s, task.ID); !got.Blocked {
+		t.Error("task not blocked by open blocker")
+	}
+	if _, _, err := s.MarkDone(ctx, task.ID, true, MarkDoneOptions{}); !errors.Is(err, ErrBlocked) {
+		t.Fatalf("MarkDone(task) err = %v, want ErrBlocked", err)
+	}
+	if _, _, err := s.MarkDone(ctx, blocker.ID, true, MarkDoneOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := mustGetTask(t, s, task.ID); got.Blocked {
+		t.Error("task still blocked after blocker completed")
+	}
+	if _, _, err := s.MarkDone(ctx, task.ID, true, MarkDoneOptions{}); err != nil {
+		t.Errorf("MarkDone(task) err = %v", err)
+	}
+}
+
+// 级联完成时，后代被树外未完成的任务阻塞则整体拒绝；树内之间的依赖不算
+func TestCascadeRespectsBlockers(t *testing.T) {
+	ctx := context.Background()
+	s := newDepsService(WithParentCompletion(ParentCompletionCascade))
+	parent := mustCreate(t, s, "parent")
+	first := mustCreateChild(t, s, "first", parent.ID)
+	second := mustCreateChild(t, s, "second", parent.ID)
+	outside := mustCreate(t, s, "outside")
+	mustDepend(t, s, second.ID, first.ID)
+	mustDepend(t, s, second.ID, outside.ID)
+
+	if _, _, err := s.MarkDone(ctx, parent.ID, true, MarkDoneOptions{}); !errors.Is(err, ErrBlocked) {
+		t.Fatalf("MarkDone(parent) err = %v, want ErrBlocked", err)
+	}
+	for _, id := range []string{parent.ID, first.ID, second.ID} {
+		if got, _ := mustGetTask(t, s, id); got.Done {
+			t.Errorf("%s completed by a rejected cascade", got.Title)
+		}
+	}
+
+	if _, err := s.RemoveDependency(ctx, second.ID, outside.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := s.MarkDone(ctx, parent.ID, true, MarkDoneOptions{}); err != nil {
+		t.Fatalf("MarkDone(parent) err = %v", err)
+	}
+	if got, _ := mustGetTask(t, s, second.ID); !got.Done {
+		t.Error("second not completed by cascade")
+	}
+}
+
+func TestTopologicalOrder(t *testing.T) {
+	s := newDepsService()
+	tasks := make(map[string]model.Task)
+	for _, name := range []string{"deploy", "test", "build", "docs", "lint"} {
+		tasks[name] = mustCreate(t, s, name)
+	}
+	mustDepend(t, s, tasks["deploy"].ID, tasks["test"].ID)
+	mustDepend(t, s, tasks["test"].ID, tasks["build"].ID)
+	mustDepend(t, s, tasks["test"].ID, tasks["lint"].ID)
+
+	got, err := s.TopologicalOrder(context.Background(), ListOptions{Sort: "title"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var order []string
+	for _, task := range got {
+		order = append(order, task.Title)
+	}
+	// 可执行的任务之间保持按标题排序
+	if want := "[build docs lint test deploy]"; fmt.Sprint(order) != want {
+		t.Errorf("order = %v, want %s", order, want)
+	}
+}
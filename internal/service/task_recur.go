@@ -0,0 +1,171 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/kitouo/taskhub/internal/filter"
+	"github.com/kitouo/taskhub/internal/model"
+	"github.com/kitouo/taskhub/internal/repo"
+	"github.com/kitouo/taskhub/internal/rrule"
+)
+
+var (
+	ErrInvalidRRule    = rrule.ErrInvalid
+	ErrInvalidTimezone = errors.New("invalid timezone")
+	ErrDueRequired     = errors.New("due_at is required for recurring tasks")
+)
+
+// DefaultRecurrenceHorizon 后台物化器默认提前生成多久之内的实例
+const DefaultRecurrenceHorizon = 7 * 24 * time.Hour
+
+/*
+applyRecurrence 校验并把周期规则挂到新任务上
+DTSTART取任务的due_at，并换算到指定时区展开；tz为空时按UTC
+新任务自身就是系列的第一个实例，SeriesID即其id
+*/
+func applyRecurrence(t *model.Task, rule, tz string) error {
+	r, err := rrule.Parse(rule)
+	if err != nil {
+		return err
+	}
+	if tz == "" {
+		tz = "UTC"
+	}
+	if _, err := time.LoadLocation(tz); err != nil {
+		return ErrInvalidTimezone
+	}
+	if t.DueAt == nil {
+		return ErrDueRequired
+	}
+	t.Recurrence = &model.Recurrence{
+		RRule:    r.String(),
+		Timezone: tz,
+		Start:    *t.DueAt,
+		SeriesID: t.ID,
+	}
+	return nil
+}
+
+// occurrenceID 同一系列同一时刻的实例id固定，重启或多副本重复生成时不会产生重复任务
+func occurrenceID(seriesID string, at time.Time) string {
+	sum := sha256.Sum256([]byte(seriesID + "|" + at.UTC().Format(time.RFC3339Nano)))
+	return hex.EncodeToString(sum[:16])
+}
+
+// schedule 解析任务上的周期规则，返回规则与时区化后的DTSTART
+func schedule(rec *model.Recurrence) (rrule.Rule, time.Time, error) {
+	r, err := rrule.Parse(rec.RRule)
+	if err != nil {
+		return rrule.Rule{}, time.Time{}, err
+	}
+	loc, err := time.LoadLocation(rec.Timezone)
+	if err != nil {
+		return rrule.Rule{}, time.Time{}, err
+	}
+	return r, rec.Start.In(loc), nil
+}
+
+/*
+createOccurrence 以prev为模板创建at时刻的实例，created表示是否由本次调用写入
+实例已存在时直接返回；Create失败但回读发现已存在（并发的另一方先写入）也视为成功
+*/
+func (s *TaskService) createOccurrence(ctx context.Context, prev model.Task, at time.Time) (t model.Task, created bool, err error) {
+	id := occurrenceID(prev.Recurrence.SeriesID, at)
+	if t, ok, err := s.repo.Get(ctx, id); err != nil || ok {
+		return t, false, err
+	}
+
+	due := at.UTC()
+	rec := *prev.Recurrence
+	t = model.Task{
+		ID:              id,
+		Title:           prev.Title,
+		CreatedAt:       time.Now().UTC(),
+		ParentID:        prev.ParentID,
+		DueAt:           &due,
+		Recurrence:      &rec,
+		Project:         prev.Project,
+		EstimateMinutes: prev.EstimateMinutes,
+		Column:          prev.Column,
+	}
+	if err := s.appendRank(ctx, &t); err != nil {
+		return model.Task{}, false, err
+	}
+	t, err = s.repo.Create(ctx, t)
+	if err != nil {
+		if existing, ok, gerr := s.repo.Get(ctx, id); gerr == nil && ok {
+			return existing, false, nil
+		}
+		return model.Task{}, false, err
+	}
+	return t, true, nil
+}
+
+// scheduleNext 周期任务的一个实例完成后，生成紧随其后的下一个实例（规则已结束则什么都不做）
+func (s *TaskService) scheduleNext(ctx context.Context, t model.Task) error {
+	if t.Recurrence == nil || t.DueAt == nil {
+		return nil
+	}
+	r, start, err := schedule(t.Recurrence)
+	if err != nil {
+		return err
+	}
+	next, ok := r.Next(start, *t.DueAt)
+	if !ok {
+		return nil
+	}
+	_, _, err = s.createOccurrence(ctx, t, next)
+	return err
+}
+
+/*
+MaterializeRecurring 为每个周期系列提前生成(max(最新实例, now), now+horizon]内的实例，返回新建数量
+  - 以系列中due_at最晚的实例为模板，不回填已经错过的时刻
+  - 实例id由系列与时刻决定，反复执行、重启或多副本同时执行都不会产生重复
+*/
+func (s *TaskService) MaterializeRecurring(ctx context.Context, now time.Time, horizon time.Duration) (int, error) {
+	tasks, err := s.repo.List(ctx, repo.ListQuery{
+		Filter: filter.Compare{Field: "series_id", Op: filter.OpNe, Value: ""},
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	latest := make(map[string]model.Task)
+	for _, t := range tasks {
+		if t.Recurrence == nil || t.DueAt == nil {
+			continue
+		}
+		cur, ok := latest[t.Recurrence.SeriesID]
+		if !ok || t.DueAt.After(*cur.DueAt) {
+			latest[t.Recurrence.SeriesID] = t
+		}
+	}
+
+	created := 0
+	for _, prev := range latest {
+		r, start, err := schedule(prev.Recurrence)
+		if err != nil {
+			// 单个系列的规则损坏不影响其它系列
+			continue
+		}
+		after := *prev.DueAt
+		if now.After(after) {
+			after = now
+		}
+		for _, at := range r.Between(start, after, now.Add(horizon)) {
+			_, ok, err := s.createOccurrence(ctx, prev, at)
+			if err != nil {
+				return created, err
+			}
+			if ok {
+				created++
+			}
+		}
+	}
+	return created, nil
+}
@@ -0,0 +1,112 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/kitouo/taskhub/internal/repo/memory"
+)
+
+func mustLoadLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Skipf("tzdata unavailable: %v", err)
+	}
+	return loc
+}
+
+// seriesDue 系列中全部实例的due_at（UTC，升序）
+func seriesDue(t *testing.T, s *TaskService, seriesID string) []time.Time {
+	t.Helper()
+	tasks, err := s.List(context.Background(), ListOptions{Filter: `series = "` + seriesID + `"`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := make([]time.Time, 0, len(tasks))
+	for _, tk := range tasks {
+		out = append(out, tk.DueAt.UTC())
+	}
+	slices.SortFunc(out, time.Time.Compare)
+	return out
+}
+
+// 完成一个实例后生成下一个，时刻按规则所在时区展开：跨过夏令时后仍是当地09:00
+func TestRecurringCompletionSchedulesNext(t *testing.T) {
+	ctx := context.Background()
+	berlin := mustLoadLocation(t, "Europe/Berlin")
+	s := NewTaskService(memory.NewTaskRepo())
+
+	due := time.Date(2024, 3, 30, 9, 0, 0, 0, berlin)
+	first, err := s.Create(ctx, CreateInput{Title: "standup", DueAt: &due, RRule: "FREQ=DAILY", Timezone: "Europe/Berlin", Project: "ops"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := s.MarkDone(ctx, first.ID, true, MarkDoneOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []time.Time{
+		time.Date(2024, 3, 30, 8, 0, 0, 0, time.UTC), // CET
+		time.Date(2024, 3, 31, 7, 0, 0, 0, time.UTC), // CEST
+	}
+	if got := seriesDue(t, s, first.ID); !slices.EqualFunc(got, want, time.Time.Equal) {
+		t.Fatalf("series due = %v, want %v", got, want)
+	}
+	next, ok := mustGetTask(t, s, occurrenceID(first.ID, want[1]))
+	if !ok || next.Done || next.Title != "standup" || next.Project != "ops" || next.Recurrence.Timezone != "Europe/Berlin" {
+		t.Errorf("next = %+v, %v", next, ok)
+	}
+
+	// 重复完成同一个实例不会再生成一份
+	if _, _, err := s.MarkDone(ctx, first.ID, true, MarkDoneOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if got := seriesDue(t, s, first.ID); len(got) != 2 {
+		t.Errorf("series after repeated completion = %v", got)
+	}
+}
+
+// 反复扫描只生成一次；实例在规则时区的同一时刻，跨夏令时后UTC时间随之变化
+func TestMaterializeRecurringIdempotent(t *testing.T) {
+	ctx := context.Background()
+	ny := mustLoadLocation(t, "America/New_York")
+	s := NewTaskService(memory.NewTaskRepo())
+
+	due := time.Date(2024, 3, 4, 9, 0, 0, 0, ny)
+	first, err := s.Create(ctx, CreateInput{Title: "review", DueAt: &due, RRule: "FREQ=WEEKLY;BYDAY=MO", Timezone: "America/New_York"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 另一个系列不受影响
+	other := time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+	if _, err := s.Create(ctx, CreateInput{Title: "other", DueAt: &other, RRule: "FREQ=MONTHLY"}); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC)
+	for i, wantCreated := range []int{2, 0} {
+		n, err := s.MaterializeRecurring(ctx, now, 14*24*time.Hour)
+		if err != nil || n != wantCreated {
+			t.Fatalf("scan %d: created %d, %v, want %d", i+1, n, err, wantCreated)
+		}
+	}
+	want := []time.Time{
+		time.Date(2024, 3, 4, 14, 0, 0, 0, time.UTC),  // EST
+		time.Date(2024, 3, 11, 13, 0, 0, 0, time.UTC), // EDT
+		time.Date(2024, 3, 18, 13, 0, 0, 0, time.UTC),
+	}
+	if got := seriesDue(t, s, first.ID); !slices.EqualFunc(got, want, time.Time.Equal) {
+		t.Errorf("series due = %v, want %v", got, want)
+	}
+
+	// 完成已物化的实例：下一个已存在，不会重复创建
+	if _, _, err := s.MarkDone(ctx, occurrenceID(first.ID, want[1]), true, MarkDoneOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if got := seriesDue(t, s, first.ID); len(got) != 3 {
+		t.Errorf("series after completion = %v", got)
+	}
+}
@@ -0,0 +1,54 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/kitouo/taskhub/internal/model"
+	"github.com/kitouo/taskhub/internal/repo"
+	"github.com/kitouo/taskhub/internal/search"
+)
+
+var ErrInvalidQuery = errors.New("invalid search query")
+
+const (
+	DefaultSearchLimit = 20
+	MaxSearchLimit     = 100
+)
+
+type SearchResult struct {
+	Task    model.Task
+	Score   float64
+	Snippet string // 带<mark>高亮、已HTML转义的标题摘要
+}
+
+/*
+Search 全文检索
+limit<=0 时使用默认值，超过上限时截断；offset<0 视为0
+*/
+func (s *TaskService) Search(ctx context.Context, query string, limit, offset int) ([]SearchResult, int, error) {
+	terms := search.Tokenize(query)
+	if len(terms) == 0 {
+		return nil, 0, ErrInvalidQuery
+	}
+	if limit <= 0 {
+		limit = DefaultSearchLimit
+	}
+	limit = min(limit, MaxSearchLimit)
+	offset = max(offset, 0)
+
+	hits, total, err := s.repo.Search(ctx, repo.SearchQuery{Terms: terms, Limit: limit, Offset: offset})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	out := make([]SearchResult, len(hits))
+	for i, h := range hits {
+		out[i] = SearchResult{
+			Task:    h.Task,
+			Score:   h.Score,
+			Snippet: search.Highlight(h.Task.Title, terms),
+		}
+	}
+	return out, total, nil
+}
@@ -5,47 +5,555 @@ import (
 	"crypto/rand"
 	"encoding/hex"
 	"errors"
+	"fmt"
 	"strings"
 	"time"
 
+	"github.com/kitouo/taskhub/internal/filter"
+	"github.com/kitouo/taskhub/internal/logx"
 	"github.com/kitouo/taskhub/internal/model"
+	"github.com/kitouo/taskhub/internal/rank"
 	"github.com/kitouo/taskhub/internal/repo"
 )
 
-var ErrInvalidTitle = errors.New("invalid title")
+var (
+	ErrInvalidTitle  = errors.New("invalid title")
+	ErrEmptyBatch    = errors.New("empty batch")
+	ErrBatchTooLarge = errors.New("batch too large")
+	ErrInvalidOp     = errors.New("invalid batch op")
+	ErrMissingID     = errors.New("missing id")
+	ErrInvalidFilter = errors.New("invalid filter")
+	ErrInvalidSort   = errors.New("invalid sort")
+
+	ErrInvalidProject  = errors.New("invalid project")
+	ErrInvalidEstimate = errors.New("invalid estimate")
+)
+
+// MaxEstimateMinutes 预估工时上限（分钟）
+const MaxEstimateMinutes = 100000
+
+// DefaultBatchMaxSize 单次批量请求允许的最大操作数
+const DefaultBatchMaxSize = 100
 
 type TaskService struct {
-	repo repo.TaskRepo
+	repo             repo.TaskRepo
+	deps             repo.DependencyRepo
+	assigns          repo.AssignmentRepo
+	users            repo.UserRepo
+	checklists       repo.ChecklistRepo
+	batchMaxSize     int
+	parentCompletion ParentCompletion
+	logger           logx.Logger
 }
 
-func NewTaskService(repo repo.TaskRepo) *TaskService {
-	return &TaskService{repo: repo}
+// TaskOption 用于定制TaskService的可选参数
+type TaskOption func(*TaskService)
+
+// WithBatchMaxSize 设置批量操作上限，n<=0时忽略
+func WithBatchMaxSize(n int) TaskOption {
+	return func(s *TaskService) {
+		if n > 0 {
+			s.batchMaxSize = n
+		}
+	}
 }
 
-func (s *TaskService) Create(ctx context.Context, title string) (model.Task, error) {
-	title = strings.TrimSpace(title)
-	if title == "" || len(title) > 200 {
-		return model.Task{}, ErrInvalidTitle
+// WithParentCompletion 设置完成父任务时对未完成子任务的处理策略
+func WithParentCompletion(p ParentCompletion) TaskOption {
+	return func(s *TaskService) {
+		if p != "" {
+			s.parentCompletion = p
+		}
 	}
-	t := model.Task{
-		ID:        NewID(),
-		Title:     title,
-		Done:      false,
-		CreatedAt: time.Now().UTC(),
+}
+
+// WithLogger 设置记录后台性失败（例如生成周期任务的下一个实例）的日志
+func WithLogger(logger logx.Logger) TaskOption {
+	return func(s *TaskService) {
+		s.logger = logger
+	}
+}
+
+func NewTaskService(repo repo.TaskRepo, opts ...TaskOption) *TaskService {
+	s := &TaskService{
+		repo:             repo,
+		batchMaxSize:     DefaultBatchMaxSize,
+		parentCompletion: ParentCompletionReject,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// CreateInput 创建任务的参数（来自API层，尚未校验）
+type CreateInput struct {
+	Title    string
+	ParentID string     // 可选，父任务必须存在
+	DueAt    *time.Time // 可选，周期任务必填（作为DTSTART）
+	RRule    string     // 可选，RRULE子集，例如 FREQ=WEEKLY;BYDAY=MO
+	Timezone string     // 可选，IANA时区名，周期规则在该时区下展开，默认UTC
+	Project  string     // 可选，所属项目
+	// EstimateMinutes 可选，预估工时（分钟）
+	EstimateMinutes int
+	Column          string // 可选，看板列，新任务排在该列末尾
+}
+
+func (s *TaskService) Create(ctx context.Context, in CreateInput) (model.Task, error) {
+	title, err := normalizeTitle(in.Title)
+	if err != nil {
+		return model.Task{}, err
+	}
+	t := newTask(title)
+	if t.Project, err = normalizeProject(in.Project); err != nil {
+		return model.Task{}, err
+	}
+	if !validEstimate(in.EstimateMinutes) {
+		return model.Task{}, ErrInvalidEstimate
+	}
+	t.EstimateMinutes = in.EstimateMinutes
+	if t.Column, err = normalizeColumn(in.Column); err != nil {
+		return model.Task{}, err
+	}
+	if in.DueAt != nil {
+		due := in.DueAt.UTC()
+		t.DueAt = &due
+	}
+	if in.RRule != "" {
+		if err := applyRecurrence(&t, in.RRule, in.Timezone); err != nil {
+			return model.Task{}, err
+		}
+	}
+
+	if in.ParentID != "" {
+		if _, ok, err := s.repo.Get(ctx, in.ParentID); err != nil {
+			return model.Task{}, err
+		} else if !ok {
+			return model.Task{}, ErrParentNotFound
+		}
+		t.ParentID = in.ParentID
+	}
+	if err := s.appendRank(ctx, &t); err != nil {
+		return model.Task{}, err
 	}
 	return s.repo.Create(ctx, t)
 }
 
-func (s *TaskService) List(ctx context.Context) ([]model.Task, error) {
-	return s.repo.List(ctx)
+// ListOptions 列表参数（来自API层，尚未校验）
+type ListOptions struct {
+	Filter string // 过滤表达式，语法见filter包
+	Sort   string // 排序字段，"-"前缀表示降序，例如 -created_at
+	// Assignee 只返回分配给该用户的任务（已解析为用户id，"me"由API层处理）
+	Assignee string
+}
+
+/*
+List 返回满足条件的任务
+过滤表达式非法时返回的error可以用errors.As取出*filter.Error（含出错位置）
+*/
+func (s *TaskService) List(ctx context.Context, opts ListOptions) ([]model.Task, error) {
+	q, err := s.buildListQuery(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	tasks, err := s.repo.List(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.markBlocked(ctx, tasks); err != nil {
+		return nil, err
+	}
+	if err := s.markAssignees(ctx, tasks); err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
+
+// Count 返回满足条件的任务数，参数语义与List一致（Sort被忽略）
+func (s *TaskService) Count(ctx context.Context, opts ListOptions) (int, error) {
+	q, err := s.buildListQuery(ctx, opts)
+	if err != nil {
+		return 0, err
+	}
+	return s.repo.Count(ctx, q)
+}
+
+func (s *TaskService) buildListQuery(ctx context.Context, opts ListOptions) (repo.ListQuery, error) {
+	var q repo.ListQuery
+	if opts.Assignee != "" {
+		ids, err := s.assignedTaskIDs(ctx, opts.Assignee)
+		if err != nil {
+			return repo.ListQuery{}, err
+		}
+		q.IDs = ids
+	}
+	if strings.TrimSpace(opts.Filter) != "" {
+		n, err := filter.Parse(opts.Filter, time.Now().UTC())
+		if err != nil {
+			return repo.ListQuery{}, fmt.Errorf("%w: %w", ErrInvalidFilter, err)
+		}
+		q.Filter = n
+	}
+	sort, err := ParseSort(opts.Sort)
+	if err != nil {
+		return repo.ListQuery{}, err
+	}
+	q.Sort = sort
+	return q, nil
+}
+
+// ParseSort 解析排序参数：空串为默认顺序，"-"前缀表示降序
+func ParseSort(s string) (repo.Sort, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return repo.Sort{}, nil
+	}
+	var out repo.Sort
+	if strings.HasPrefix(s, "-") {
+		out.Desc = true
+		s = s[1:]
+	}
+	out.Field = repo.SortField(s)
+	if !repo.SortFields[out.Field] {
+		return repo.Sort{}, ErrInvalidSort
+	}
+	return out, nil
 }
 
+// Get 返回任务，存在子任务时附带完成进度
 func (s *TaskService) Get(ctx context.Context, id string) (model.Task, bool, error) {
-	return s.repo.Get(ctx, id)
+	t, ok, err := s.repo.Get(ctx, id)
+	if err != nil || !ok {
+		return t, ok, err
+	}
+	if err := s.decorate(ctx, &t); err != nil {
+		return model.Task{}, false, err
+	}
+	return t, true, nil
 }
 
-func (s *TaskService) MarkDone(ctx context.Context, id string, done bool) (model.Task, bool, error) {
-	return s.repo.MarkDone(ctx, id, done)
+// MarkDoneOptions 完成任务时的可选行为
+type MarkDoneOptions struct {
+	Force bool // 忽略前置任务未完成的限制
+	// RequireChecklist 仍有未勾选的检查项时拒绝完成（ErrChecklistIncomplete），不受Force影响
+	RequireChecklist bool
+}
+
+/*
+MarkDone 修改完成状态
+  - 存在未完成的前置任务时拒绝完成（ErrBlocked），除非opts.Force；级联完成的后代同样检查
+  - opts.RequireChecklist时，检查项未全部勾选则拒绝完成（ErrChecklistIncomplete）
+  - 完成一个仍有未完成子任务的父任务时，按parentCompletion策略拒绝或级联完成
+  - 完成周期任务的实例后生成下一个实例；此时完成状态已经落库，生成失败只记日志，由MaterializeRecurring补上
+*/
+func (s *TaskService) MarkDone(ctx context.Context, id string, done bool, opts MarkDoneOptions) (model.Task, bool, error) {
+	return s.markDone(ctx, id, done, opts, repo.TaskPatch{})
+}
+
+// markDone 修改完成状态，patch中的其它字段随完成状态一起写入；任一检查不通过时什么都不写
+func (s *TaskService) markDone(ctx context.Context, id string, done bool, opts MarkDoneOptions, patch repo.TaskPatch) (model.Task, bool, error) {
+	var (
+		t   model.Task
+		ok  bool
+		err error
+	)
+	if done {
+		ops, found, cerr := s.completionOps(ctx, id, opts, patch)
+		if cerr != nil || !found {
+			return model.Task{}, found, cerr
+		}
+		t, err = s.applyCompletion(ctx, id, ops)
+		ok = true
+		if err == nil {
+			if serr := s.scheduleNext(ctx, t); serr != nil {
+				s.logger.Warn("schedule next occurrence failed", "task="+t.ID, "err=", serr)
+			}
+		}
+	} else {
+		patch.Done = &done
+		t, ok, err = s.repo.Update(ctx, id, patch)
+	}
+	if err != nil || !ok {
+		return model.Task{}, ok, err
+	}
+	if err := s.decorate(ctx, &t); err != nil {
+		return model.Task{}, false, err
+	}
+	return t, true, nil
+}
+
+/*
+completionOps 完成任务前的全部检查，返回需要一起原子写入的操作；MarkDone、Update与批量操作都经过这里
+  - opts.RequireChecklist时，检查项未全部勾选返回ErrChecklistIncomplete
+  - 除非opts.Force，任务本身被阻塞时返回ErrBlocked
+  - 有未完成的后代时按parentCompletion策略处理，见completeTree
+*/
+func (s *TaskService) completionOps(ctx context.Context, id string, opts MarkDoneOptions, patch repo.TaskPatch) ([]repo.BatchOp, bool, error) {
+	if _, ok, err := s.repo.Get(ctx, id); err != nil || !ok {
+		return nil, ok, err
+	}
+	if opts.RequireChecklist {
+		complete, err := s.checklistComplete(ctx, id)
+		if err != nil {
+			return nil, false, err
+		}
+		if !complete {
+			return nil, true, ErrChecklistIncomplete
+		}
+	}
+	if !opts.Force {
+		blocked, err := s.isBlocked(ctx, id)
+		if err != nil {
+			return nil, false, err
+		}
+		if blocked {
+			return nil, true, ErrBlocked
+		}
+	}
+	ops, err := s.completeTree(ctx, id, opts.Force, patch)
+	if err != nil {
+		return nil, true, err
+	}
+	return ops, true, nil
+}
+
+// UpdateInput 修改任务属性，nil字段表示不修改
+type UpdateInput struct {
+	Project         *string
+	EstimateMinutes *int // 0表示清除预估
+	// Done 同时修改完成状态，规则同MarkDone（DoneOptions对应其opts）
+	Done        *bool
+	DoneOptions MarkDoneOptions
+}
+
+/*
+Update 修改项目、预估工时与完成状态
+所有字段先校验，再作为一次修改写入：完成被拒绝（blocked、检查项未完成等）时项目与预估也不会落库
+*/
+func (s *TaskService) Update(ctx context.Context, id string, in UpdateInput) (model.Task, bool, error) {
+	var p repo.TaskPatch
+	if in.Project != nil {
+		project, err := normalizeProject(*in.Project)
+		if err != nil {
+			return model.Task{}, false, err
+		}
+		p.Project = &project
+	}
+	if in.EstimateMinutes != nil {
+		if !validEstimate(*in.EstimateMinutes) {
+			return model.Task{}, false, ErrInvalidEstimate
+		}
+		p.EstimateMinutes = in.EstimateMinutes
+	}
+	if in.Done != nil {
+		return s.markDone(ctx, id, *in.Done, in.DoneOptions, p)
+	}
+	t, ok, err := s.repo.Update(ctx, id, p)
+	if err != nil || !ok {
+		return model.Task{}, ok, err
+	}
+	if err := s.decorate(ctx, &t); err != nil {
+		return model.Task{}, false, err
+	}
+	return t, true, nil
+}
+
+// decorate 计算单个任务上不落库的字段（进度、检查项进度、是否被阻塞、负责人）
+func (s *TaskService) decorate(ctx context.Context, t *model.Task) error {
+	if err := s.attachProgress(ctx, t); err != nil {
+		return err
+	}
+	if err := s.attachChecklist(ctx, t); err != nil {
+		return err
+	}
+	tasks := []model.Task{*t}
+	if err := s.markBlocked(ctx, tasks); err != nil {
+		return err
+	}
+	if err := s.markAssignees(ctx, tasks); err != nil {
+		return err
+	}
+	*t = tasks[0]
+	return nil
+}
+
+// BatchOp 批量请求中的一条操作（来自API层，尚未校验）
+type BatchOp struct {
+	Op    string
+	ID    string
+	Title *string
+	Done  *bool
+}
+
+/*
+Batch 校验并执行一批操作
+  - 校验失败的条目直接得到对应错误，不会下发到repo
+  - update带done=true时与MarkDone做同样的检查（被阻塞、父任务策略），级联完成的后代随同一批写入；
+    完成周期任务的实例后同样生成下一个实例
+  - atomic模式下只要有一条校验失败，整批都不会执行
+*/
+func (s *TaskService) Batch(ctx context.Context, in []BatchOp, atomic bool) ([]repo.BatchResult, error) {
+	if len(in) == 0 {
+		return nil, ErrEmptyBatch
+	}
+	if len(in) > s.batchMaxSize {
+		return nil, ErrBatchTooLarge
+	}
+
+	results := make([]repo.BatchResult, len(in))
+	ops := make([]repo.BatchOp, 0, len(in))
+	index := make([]int, 0, len(in)) // ops[k] 对应 in[index[k]]；级联完成的后代为-1
+	completes := make(map[int]bool)  // 完成任务的ops下标，成功后生成周期任务的下一个实例
+	invalid := false
+
+	for i, item := range in {
+		op, err := buildBatchOp(item)
+		if err == nil && op.Kind == repo.BatchUpdate && op.Patch.Done != nil && *op.Patch.Done {
+			var (
+				cops  []repo.BatchOp
+				found bool
+			)
+			patch := op.Patch
+			patch.Done = nil
+			cops, found, err = s.completionOps(ctx, op.ID, MarkDoneOptions{}, patch)
+			// 不存在的任务照常下发，由repo给出not found
+			if err == nil && found {
+				for _, c := range cops[:len(cops)-1] {
+					ops = append(ops, c)
+					index = append(index, -1)
+				}
+				op = cops[len(cops)-1]
+				completes[len(ops)] = true
+			}
+		}
+		if err != nil {
+			results[i] = repo.BatchResult{Found: true, Err: err}
+			invalid = true
+			continue
+		}
+		ops = append(ops, op)
+		index = append(index, i)
+	}
+
+	if atomic && invalid {
+		for i := range results {
+			if results[i].Err == nil {
+				results[i] = repo.BatchResult{Found: true, Err: repo.ErrBatchAborted}
+			}
+		}
+		return results, nil
+	}
+	if len(ops) == 0 {
+		return results, nil
+	}
+	if err := s.appendBatchRanks(ctx, ops); err != nil {
+		return nil, err
+	}
+
+	out, err := s.repo.Batch(ctx, ops, atomic)
+	if err != nil {
+		return nil, err
+	}
+	for k, res := range out {
+		if index[k] >= 0 {
+			results[index[k]] = res
+		}
+		if completes[k] && !res.Failed() {
+			if serr := s.scheduleNext(ctx, res.Task); serr != nil {
+				s.logger.Warn("schedule next occurrence failed", "task="+res.Task.ID, "err=", serr)
+			}
+		}
+	}
+	return results, nil
+}
+
+// appendBatchRanks 批量新建的任务按请求中的顺序依次排到各自所在列的末尾
+func (s *TaskService) appendBatchRanks(ctx context.Context, ops []repo.BatchOp) error {
+	last := make(map[string]string)
+	for i := range ops {
+		if ops[i].Kind != repo.BatchCreate {
+			continue
+		}
+		t := &ops[i].Task
+		prev, ok := last[t.Column]
+		if !ok {
+			var err error
+			if prev, err = s.repo.LastRank(ctx, t.Column); err != nil {
+				return err
+			}
+		}
+		t.Rank = rank.After(prev)
+		last[t.Column] = t.Rank
+	}
+	return nil
+}
+
+func buildBatchOp(item BatchOp) (repo.BatchOp, error) {
+	switch repo.BatchOpKind(item.Op) {
+	case repo.BatchCreate:
+		if item.Title == nil {
+			return repo.BatchOp{}, ErrInvalidTitle
+		}
+		title, err := normalizeTitle(*item.Title)
+		if err != nil {
+			return repo.BatchOp{}, err
+		}
+		t := newTask(title)
+		if item.Done != nil {
+			t.Done = *item.Done
+		}
+		return repo.BatchOp{Kind: repo.BatchCreate, Task: t}, nil
+	case repo.BatchUpdate:
+		if item.ID == "" {
+			return repo.BatchOp{}, ErrMissingID
+		}
+		p := repo.TaskPatch{Done: item.Done}
+		if item.Title != nil {
+			title, err := normalizeTitle(*item.Title)
+			if err != nil {
+				return repo.BatchOp{}, err
+			}
+			p.Title = &title
+		}
+		return repo.BatchOp{Kind: repo.BatchUpdate, ID: item.ID, Patch: p}, nil
+	case repo.BatchDelete:
+		if item.ID == "" {
+			return repo.BatchOp{}, ErrMissingID
+		}
+		return repo.BatchOp{Kind: repo.BatchDelete, ID: item.ID}, nil
+	default:
+		return repo.BatchOp{}, ErrInvalidOp
+	}
+}
+
+func normalizeTitle(title string) (string, error) {
+	title = strings.TrimSpace(title)
+	if title == "" || len(title) > 200 {
+		return "", ErrInvalidTitle
+	}
+	return title, nil
+}
+
+// normalizeProject 项目名可以为空；按原样保存（大小写敏感），只去掉首尾空白
+func normalizeProject(project string) (string, error) {
+	project = strings.TrimSpace(project)
+	if len(project) > 64 {
+		return "", ErrInvalidProject
+	}
+	return project, nil
+}
+
+func validEstimate(minutes int) bool {
+	return minutes >= 0 && minutes <= MaxEstimateMinutes
+}
+
+func newTask(title string) model.Task {
+	return model.Task{
+		ID:        NewID(),
+		Title:     title,
+		Done:      false,
+		CreatedAt: time.Now().UTC(),
+	}
 }
 
 func NewID() string {
@@ -0,0 +1,257 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/kitouo/taskhub/internal/model"
+	"github.com/kitouo/taskhub/internal/repo"
+	"github.com/kitouo/taskhub/internal/repo/memory"
+)
+
+func ptr[T any](v T) *T { return &v }
+
+func mustCreate(t *testing.T, s *TaskService, title string) model.Task {
+	t.Helper()
+	task, err := s.Create(context.Background(), CreateInput{Title: title})
+	if err != nil {
+		t.Fatalf("Create(%s): %v", title, err)
+	}
+	return task
+}
+
+func mustGetTask(t *testing.T, s *TaskService, id string) (model.Task, bool) {
+	t.Helper()
+	task, ok, err := s.Get(context.Background(), id)
+	if err != nil {
+		t.Fatalf("Get(%s): %v", id, err)
+	}
+	return task, ok
+}
+
+// 原子批量：任一条在repo中失败（not found）时整批不生效
+func TestBatchAtomicRollsBack(t *testing.T) {
+	ctx := context.Background()
+	s := NewTaskService(memory.NewTaskRepo())
+	a := mustCreate(t, s, "a")
+	b := mustCreate(t, s, "b")
+
+	res, err := s.Batch(ctx, []BatchOp{
+		{Op: "create", Title: ptr("c")},
+		{Op: "update", ID: a.ID, Title: ptr("renamed"), Done: ptr(true)},
+		{Op: "delete", ID: b.ID},
+		{Op: "update", ID: "missing", Done: ptr(true)},
+	}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res[3].Found {
+		t.Errorf("missing task reported as found: %+v", res[3])
+	}
+	for i := range 3 {
+		if !errors.Is(res[i].Err, repo.ErrBatchAborted) {
+			t.Errorf("result %d err = %v, want ErrBatchAborted", i, res[i].Err)
+		}
+	}
+
+	if got, _ := mustGetTask(t, s, a.ID); got.Title != "a" || got.Done {
+		t.Errorf("a was modified: %+v", got)
+	}
+	if _, ok := mustGetTask(t, s, b.ID); !ok {
+		t.Error("b was deleted")
+	}
+	if n, _ := s.Count(ctx, ListOptions{}); n != 2 {
+		t.Errorf("task count = %d, want 2", n)
+	}
+}
+
+// 原子批量：校验失败的条目让整批都不下发到repo
+func TestBatchAtomicInvalidItem(t *testing.T) {
+	ctx := context.Background()
+	s := NewTaskService(memory.NewTaskRepo())
+	a := mustCreate(t, s, "a")
+
+	res, err := s.Batch(ctx, []BatchOp{
+		{Op: "update", ID: a.ID, Done: ptr(true)},
+		{Op: "create", Title: ptr("  ")},
+	}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !errors.Is(res[0].Err, repo.ErrBatchAborted) || !errors.Is(res[1].Err, ErrInvalidTitle) {
+		t.Errorf("results = %+v", res)
+	}
+	if got, _ := mustGetTask(t, s, a.ID); got.Done {
+		t.Error("a was completed by an aborted batch")
+	}
+}
+
+// 非原子批量：失败的条目各自报错，其余照常生效
+func TestBatchNonAtomic(t *testing.T) {
+	ctx := context.Background()
+	s := NewTaskService(memory.NewTaskRepo())
+	a := mustCreate(t, s, "a")
+	b := mustCreate(t, s, "b")
+
+	res, err := s.Batch(ctx, []BatchOp{
+		{Op: "update", ID: a.ID, Title: ptr("renamed")},
+		{Op: "update", ID: "missing", Done: ptr(true)},
+		{Op: "bogus"},
+		{Op: "delete", ID: b.ID},
+		{Op: "create", Title: ptr("c")},
+	}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res[0].Err != nil || !res[0].Found || res[0].Task.Title != "renamed" {
+		t.Errorf("update result = %+v", res[0])
+	}
+	if res[1].Err != nil || res[1].Found {
+		t.Errorf("missing result = %+v, want not found", res[1])
+	}
+	if !errors.Is(res[2].Err, ErrInvalidOp) {
+		t.Errorf("bogus op err = %v, want ErrInvalidOp", res[2].Err)
+	}
+	if res[3].Err != nil || !res[3].Found {
+		t.Errorf("delete result = %+v", res[3])
+	}
+	if res[4].Err != nil {
+		t.Errorf("create result = %+v", res[4])
+	}
+
+	if got, _ := mustGetTask(t, s, a.ID); got.Title != "renamed" {
+		t.Errorf("a.Title = %q", got.Title)
+	}
+	if _, ok := mustGetTask(t, s, b.ID); ok {
+		t.Error("b was not deleted")
+	}
+	if n, _ := s.Count(ctx, ListOptions{}); n != 2 {
+		t.Errorf("task count = %d, want 2", n)
+	}
+}
+
+func TestBatchLimits(t *testing.T) {
+	s := NewTaskService(memory.NewTaskRepo(), WithBatchMaxSize(1))
+	if _, err := s.Batch(context.Background(), nil, false); !errors.Is(err, ErrEmptyBatch) {
+		t.Errorf("empty batch err = %v", err)
+	}
+	ops := []BatchOp{{Op: "delete", ID: "a"}, {Op: "delete", ID: "b"}}
+	if _, err := s.Batch(context.Background(), ops, false); !errors.Is(err, ErrBatchTooLarge) {
+		t.Errorf("oversized batch err = %v", err)
+	}
+}
+
+// 批量中的done=true与MarkDone走同样的检查：被阻塞、仍有未完成子任务的任务不能完成
+func TestBatchCompletionGuards(t *testing.T) {
+	ctx := context.Background()
+	s := newDepsService()
+	blocker, blocked := mustCreate(t, s, "blocker"), mustCreate(t, s, "blocked")
+	mustDepend(t, s, blocked.ID, blocker.ID)
+	parent := mustCreate(t, s, "parent")
+	mustCreateChild(t, s, "child", parent.ID)
+	free := mustCreate(t, s, "free")
+
+	res, err := s.Batch(ctx, []BatchOp{
+		{Op: "update", ID: blocked.ID, Done: ptr(true)},
+		{Op: "update", ID: parent.ID, Done: ptr(true)},
+		{Op: "update", ID: free.ID, Done: ptr(true)},
+	}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !errors.Is(res[0].Err, ErrBlocked) || !errors.Is(res[1].Err, ErrOpenChildren) || res[2].Err != nil {
+		t.Errorf("results = %+v", res)
+	}
+	for _, id := range []string{blocked.ID, parent.ID} {
+		if got, _ := mustGetTask(t, s, id); got.Done {
+			t.Errorf("%s completed by batch", got.Title)
+		}
+	}
+	if got, _ := mustGetTask(t, s, free.ID); !got.Done {
+		t.Error("free not completed")
+	}
+
+	// 原子模式下被拒绝的完成让整批都不执行
+	res, _ = s.Batch(ctx, []BatchOp{
+		{Op: "update", ID: blocker.ID, Title: ptr("renamed")},
+		{Op: "update", ID: blocked.ID, Done: ptr(true)},
+	}, true)
+	if !errors.Is(res[0].Err, repo.ErrBatchAborted) || !errors.Is(res[1].Err, ErrBlocked) {
+		t.Errorf("atomic results = %+v", res)
+	}
+	if got, _ := mustGetTask(t, s, blocker.ID); got.Title != "blocker" {
+		t.Errorf("blocker renamed by an aborted batch: %+v", got)
+	}
+}
+
+// cascade策略下批量完成父任务会一起完成后代
+func TestBatchCompletionCascade(t *testing.T) {
+	s := NewTaskService(memory.NewTaskRepo(), WithParentCompletion(ParentCompletionCascade))
+	parent := mustCreate(t, s, "parent")
+	child := mustCreateChild(t, s, "child", parent.ID)
+	grandchild := mustCreateChild(t, s, "grandchild", child.ID)
+
+	res, err := s.Batch(context.Background(), []BatchOp{{Op: "update", ID: parent.ID, Title: ptr("root"), Done: ptr(true)}}, true)
+	if err != nil || len(res) != 1 || res[0].Err != nil || res[0].Task.Title != "root" || !res[0].Task.Done {
+		t.Fatalf("Batch = %+v, %v", res, err)
+	}
+	for _, id := range []string{child.ID, grandchild.ID} {
+		if got, _ := mustGetTask(t, s, id); !got.Done {
+			t.Errorf("%s not completed by cascade", got.Title)
+		}
+	}
+}
+
+// 同时修改项目与完成状态时，完成被拒绝则项目也不落库
+func TestUpdateWithDoneIsAllOrNothing(t *testing.T) {
+	ctx := context.Background()
+	s := NewTaskService(memory.NewTaskRepo(), WithDependencies(memory.NewDependencyRepo()))
+	blocker, task := mustCreate(t, s, "blocker"), mustCreate(t, s, "task")
+	if _, err := s.AddDependency(ctx, task.ID, blocker.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	in := UpdateInput{Project: ptr("ops"), EstimateMinutes: ptr(30), Done: ptr(true)}
+	if _, _, err := s.Update(ctx, task.ID, in); !errors.Is(err, ErrBlocked) {
+		t.Fatalf("Update err = %v, want ErrBlocked", err)
+	}
+	if got, _ := mustGetTask(t, s, task.ID); got.Project != "" || got.EstimateMinutes != 0 || got.Done {
+		t.Errorf("task changed by a rejected update: %+v", got)
+	}
+	invalid := UpdateInput{EstimateMinutes: ptr(-1), Done: ptr(true), DoneOptions: MarkDoneOptions{Force: true}}
+	if _, _, err := s.Update(ctx, task.ID, invalid); !errors.Is(err, ErrInvalidEstimate) {
+		t.Errorf("Update err = %v, want ErrInvalidEstimate", err)
+	}
+	if got, _ := mustGetTask(t, s, task.ID); got.Done {
+		t.Error("task completed by an invalid update")
+	}
+
+	in.DoneOptions.Force = true
+	got, ok, err := s.Update(ctx, task.ID, in)
+	if err != nil || !ok || got.Project != "ops" || got.EstimateMinutes != 30 || !got.Done {
+		t.Errorf("forced Update = %+v, %v, %v", got, ok, err)
+	}
+}
+
+// 新建任务（含批量新建）依次排在所在列的末尾
+func TestCreateAppendsRank(t *testing.T) {
+	ctx := context.Background()
+	s := NewTaskService(memory.NewTaskRepo())
+	first := mustCreate(t, s, "first")
+	res, err := s.Batch(ctx, []BatchOp{
+		{Op: "create", Title: ptr("second")},
+		{Op: "create", Title: ptr("third")},
+	}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	last := mustCreate(t, s, "last")
+
+	ranks := []string{first.Rank, res[0].Task.Rank, res[1].Task.Rank, last.Rank}
+	for i := 1; i < len(ranks); i++ {
+		if ranks[i-1] == "" || ranks[i] <= ranks[i-1] {
+			t.Fatalf("ranks not increasing: %q", ranks)
+		}
+	}
+}
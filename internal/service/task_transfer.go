@@ -0,0 +1,242 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/kitouo/taskhub/internal/model"
+	"github.com/kitouo/taskhub/internal/rank"
+	"github.com/kitouo/taskhub/internal/repo"
+	"github.com/kitouo/taskhub/internal/transfer"
+)
+
+var (
+	ErrInvalidTaskID = errors.New("invalid task id")
+	ErrTaskExists    = errors.New("task id already exists")
+	ErrDuplicateID   = errors.New("duplicate id in import")
+)
+
+// MaxImportErrors 导入结果中最多返回的逐行错误数
+const MaxImportErrors = 1000
+
+// Export 按创建顺序逐条输出满足过滤条件的任务，不会一次装入全部结果
+func (s *TaskService) Export(ctx context.Context, filterExpr string, fn func(transfer.Record) error) error {
+	q, err := s.buildListQuery(ctx, ListOptions{Filter: filterExpr})
+	if err != nil {
+		return err
+	}
+	return s.repo.Each(ctx, q, func(t model.Task) error {
+		return fn(toRecord(t))
+	})
+}
+
+func toRecord(t model.Task) transfer.Record {
+	created := t.CreatedAt
+	rec := transfer.Record{
+		ID:              t.ID,
+		Title:           t.Title,
+		Done:            t.Done,
+		CreatedAt:       &created,
+		ParentID:        t.ParentID,
+		DueAt:           t.DueAt,
+		Project:         t.Project,
+		EstimateMinutes: t.EstimateMinutes,
+		Column:          t.Column,
+	}
+	if t.Recurrence != nil {
+		rec.RRule, rec.Timezone = t.Recurrence.RRule, t.Recurrence.Timezone
+	}
+	return rec
+}
+
+/*
+ImportOptions
+  - DryRun 只校验不写入，返回的结果与真正导入时一致
+  - PreserveIDs 沿用文件中的id与created_at（id已存在则该行失败）；
+    否则生成新id，文件内parent_id引用的是文件中的id时自动换成新id
+*/
+type ImportOptions struct {
+	DryRun      bool
+	PreserveIDs bool
+}
+
+type ImportError struct {
+	Line  int    `json:"line"`
+	ID    string `json:"id,omitempty"`
+	Error string `json:"error"`
+}
+
+type ImportResult struct {
+	DryRun   bool          `json:"dry_run"`
+	Total    int           `json:"total"`
+	Imported int           `json:"imported"`
+	Failed   int           `json:"failed"`
+	Errors   []ImportError `json:"errors"`
+	// Truncated 错误超过MaxImportErrors条时只保留前面的部分
+	Truncated bool `json:"errors_truncated,omitempty"`
+	// Aborted 输入中途无法继续读取时的原因，此前的行已按结果处理
+	Aborted string `json:"aborted,omitempty"`
+}
+
+func (res *ImportResult) fail(line int, id string, err error) {
+	res.Failed++
+	if len(res.Errors) >= MaxImportErrors {
+		res.Truncated = true
+		return
+	}
+	res.Errors = append(res.Errors, ImportError{Line: line, ID: id, Error: err.Error()})
+}
+
+// ImportReadError 输入无法继续读取（格式错误、读取失败），此前处理的行仍按结果生效
+type ImportReadError struct{ Err error }
+
+func (e *ImportReadError) Error() string { return e.Err.Error() }
+func (e *ImportReadError) Unwrap() error { return e.Err }
+
+// importStoreError 校验某一行时访问存储失败，中止整个导入而不是记为该行的错误
+type importStoreError struct{ err error }
+
+func (e importStoreError) Error() string { return e.err.Error() }
+
+// importState 一次导入过程中的上下文
+type importState struct {
+	ids      map[string]string // 文件中的id -> 实际id
+	lastRank map[string]string // 列 -> 已分配的最大rank
+}
+
+/*
+Import 逐行校验并写入，规则与Create相同（标题、项目、预估、列、周期规则、父任务）
+  - 不是原子的：失败的行被跳过并记录在结果中，其余行照常导入；可以先DryRun检查
+  - 父任务必须已存在，或在文件中出现在子任务之前
+  - 返回*ImportReadError表示输入无法继续读取，此时结果中是已处理部分的统计
+  - 其它error来自存储或ctx，导入中止，调用方应按服务端错误处理
+*/
+func (s *TaskService) Import(ctx context.Context, r transfer.Reader, opts ImportOptions) (ImportResult, error) {
+	res := ImportResult{DryRun: opts.DryRun, Errors: []ImportError{}}
+	st := &importState{ids: make(map[string]string), lastRank: make(map[string]string)}
+	for {
+		rec, line, err := r.Next()
+		if err == io.EOF {
+			return res, nil
+		}
+		var lineErr *transfer.LineError
+		if errors.As(err, &lineErr) {
+			res.Total++
+			res.fail(line, "", lineErr.Err)
+			continue
+		}
+		if err != nil {
+			return res, &ImportReadError{Err: err}
+		}
+		if err := ctx.Err(); err != nil {
+			return res, err
+		}
+
+		res.Total++
+		t, err := s.importTask(ctx, rec, opts, st)
+		var storeErr importStoreError
+		if errors.As(err, &storeErr) {
+			return res, storeErr.err
+		}
+		if err != nil {
+			res.fail(line, rec.ID, err)
+			continue
+		}
+		if !opts.DryRun {
+			_, err := s.repo.Create(ctx, t)
+			if errors.Is(err, repo.ErrConflict) {
+				// 校验之后、写入之前被其它请求抢先创建
+				res.fail(line, rec.ID, ErrTaskExists)
+				continue
+			}
+			if err != nil {
+				return res, err
+			}
+		}
+		if rec.ID != "" {
+			st.ids[rec.ID] = t.ID
+		}
+		st.lastRank[t.Column] = t.Rank
+		res.Imported++
+	}
+}
+
+func (s *TaskService) importTask(ctx context.Context, rec transfer.Record, opts ImportOptions, st *importState) (model.Task, error) {
+	title, err := normalizeTitle(rec.Title)
+	if err != nil {
+		return model.Task{}, err
+	}
+	t := newTask(title)
+	t.Done = rec.Done
+
+	if rec.ID != "" {
+		if _, dup := st.ids[rec.ID]; dup {
+			return model.Task{}, ErrDuplicateID
+		}
+	}
+	if opts.PreserveIDs {
+		if rec.ID != "" {
+			if !validID(rec.ID) {
+				return model.Task{}, ErrInvalidTaskID
+			}
+			if _, ok, err := s.repo.Get(ctx, rec.ID); err != nil {
+				return model.Task{}, importStoreError{err}
+			} else if ok {
+				return model.Task{}, ErrTaskExists
+			}
+			t.ID = rec.ID
+		}
+		if rec.CreatedAt != nil {
+			t.CreatedAt = rec.CreatedAt.UTC()
+		}
+	}
+
+	if t.Project, err = normalizeProject(rec.Project); err != nil {
+		return model.Task{}, err
+	}
+	if !validEstimate(rec.EstimateMinutes) {
+		return model.Task{}, ErrInvalidEstimate
+	}
+	t.EstimateMinutes = rec.EstimateMinutes
+	if t.Column, err = normalizeColumn(rec.Column); err != nil {
+		return model.Task{}, err
+	}
+	if rec.DueAt != nil {
+		due := rec.DueAt.UTC()
+		t.DueAt = &due
+	}
+	if rec.RRule != "" {
+		if err := applyRecurrence(&t, rec.RRule, rec.Timezone); err != nil {
+			return model.Task{}, err
+		}
+	}
+
+	if rec.ParentID != "" {
+		if mapped, ok := st.ids[rec.ParentID]; ok {
+			t.ParentID = mapped
+		} else if _, ok, err := s.repo.Get(ctx, rec.ParentID); err != nil {
+			return model.Task{}, importStoreError{err}
+		} else if !ok {
+			return model.Task{}, ErrParentNotFound
+		} else {
+			t.ParentID = rec.ParentID
+		}
+	}
+
+	last, ok := st.lastRank[t.Column]
+	if !ok {
+		var err error
+		if last, err = s.repo.LastRank(ctx, t.Column); err != nil {
+			return model.Task{}, importStoreError{err}
+		}
+	}
+	t.Rank = rank.After(last)
+	return t, nil
+}
+
+// validID 用户指定的id：非空、不超过64字节，不含空白、逗号与斜杠（会出现在URL路径与逗号列表中）
+func validID(id string) bool {
+	return id != "" && len(id) <= 64 && !strings.ContainsAny(id, "/ ,\t\r\n")
+}
@@ -0,0 +1,151 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kitouo/taskhub/internal/model"
+	"github.com/kitouo/taskhub/internal/repo"
+	"github.com/kitouo/taskhub/internal/repo/memory"
+	"github.com/kitouo/taskhub/internal/transfer"
+)
+
+// importInput 第3行起每行各有一种错误
+const importInput = `{"id":"p","title":"parent","created_at":"2024-01-02T03:04:05Z"}
+{"id":"c","title":"child","parent_id":"p"}
+{"id":"x","title":"  "}
+{"id":"p","title":"again"}
+not json
+{"title":"orphan","parent_id":"missing"}
+`
+
+func runImport(t *testing.T, s *TaskService, input string, opts ImportOptions) ImportResult {
+	t.Helper()
+	res, err := s.Import(context.Background(), transfer.NewReader(strings.NewReader(input), transfer.JSONL), opts)
+	if err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+	return res
+}
+
+func errorLines(res ImportResult) []int {
+	out := make([]int, 0, len(res.Errors))
+	for _, e := range res.Errors {
+		out = append(out, e.Line)
+	}
+	return out
+}
+
+func TestImport(t *testing.T) {
+	ctx := context.Background()
+	r := memory.NewTaskRepo()
+	s := NewTaskService(r)
+
+	res := runImport(t, s, importInput, ImportOptions{})
+	if res.Total != 6 || res.Imported != 2 || res.Failed != 4 || res.Aborted != "" {
+		t.Fatalf("result = %+v", res)
+	}
+	if got, want := errorLines(res), []int{3, 4, 5, 6}; !slices.Equal(got, want) {
+		t.Errorf("error lines = %v, want %v", got, want)
+	}
+	if res.Errors[1].Error != ErrDuplicateID.Error() || res.Errors[3].Error != ErrParentNotFound.Error() {
+		t.Errorf("errors = %+v", res.Errors)
+	}
+
+	// 生成新id，文件内的parent_id换成父任务的新id
+	tasks, err := r.List(ctx, repo.ListQuery{})
+	if err != nil || len(tasks) != 2 {
+		t.Fatalf("List = %d tasks, %v", len(tasks), err)
+	}
+	parent, child := tasks[0], tasks[1]
+	if parent.Title != "parent" || child.Title != "child" {
+		parent, child = child, parent
+	}
+	if parent.ID == "p" || child.ID == "c" {
+		t.Errorf("ids were preserved without preserve_ids: %s, %s", parent.ID, child.ID)
+	}
+	if child.ParentID != parent.ID {
+		t.Errorf("child.ParentID = %q, want %q", child.ParentID, parent.ID)
+	}
+}
+
+func TestImportDryRun(t *testing.T) {
+	r := memory.NewTaskRepo()
+	s := NewTaskService(r)
+
+	dry := runImport(t, s, importInput, ImportOptions{DryRun: true})
+	if !dry.DryRun || dry.Imported != 2 || dry.Failed != 4 {
+		t.Fatalf("dry run result = %+v", dry)
+	}
+	if tasks, _ := r.List(context.Background(), repo.ListQuery{}); len(tasks) != 0 {
+		t.Fatalf("dry run wrote %d tasks", len(tasks))
+	}
+	// 与真正导入的结果一致
+	res := runImport(t, s, importInput, ImportOptions{})
+	if dry.Imported != res.Imported || !slices.Equal(errorLines(dry), errorLines(res)) {
+		t.Errorf("dry run %+v differs from import %+v", dry, res)
+	}
+}
+
+func TestImportPreserveIDs(t *testing.T) {
+	s := NewTaskService(memory.NewTaskRepo())
+
+	res := runImport(t, s, importInput, ImportOptions{PreserveIDs: true})
+	if res.Imported != 2 {
+		t.Fatalf("result = %+v", res)
+	}
+	p, ok := mustGetTask(t, s, "p")
+	if !ok || !p.CreatedAt.Equal(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)) {
+		t.Errorf("p = %+v, %v, want created_at from the file", p, ok)
+	}
+	if c, ok := mustGetTask(t, s, "c"); !ok || c.ParentID != "p" {
+		t.Errorf("c = %+v, %v, want parent p", c, ok)
+	}
+
+	// 再导入一次：已存在的id逐行失败
+	again := runImport(t, s, `{"id":"p","title":"p"}`+"\n"+`{"id":"bad/id","title":"x"}`+"\n", ImportOptions{PreserveIDs: true})
+	if again.Imported != 0 || len(again.Errors) != 2 ||
+		again.Errors[0].Error != ErrTaskExists.Error() || again.Errors[1].Error != ErrInvalidTaskID.Error() {
+		t.Errorf("re-import = %+v", again)
+	}
+}
+
+// createFailingRepo 写入一律失败
+type createFailingRepo struct {
+	*memory.TaskRepo
+	err error
+}
+
+func (r createFailingRepo) Create(context.Context, model.Task) (model.Task, error) {
+	return model.Task{}, r.err
+}
+
+// 输入无法读取时返回*ImportReadError；存储与ctx的错误原样返回，不算作某一行的失败
+func TestImportErrors(t *testing.T) {
+	ctx := context.Background()
+
+	s := NewTaskService(memory.NewTaskRepo())
+	res, err := s.Import(ctx, transfer.NewReader(strings.NewReader("id,name\n1,x\n"), transfer.CSV), ImportOptions{})
+	var readErr *ImportReadError
+	if !errors.As(err, &readErr) || res.Total != 0 {
+		t.Errorf("bad header: %+v, %v, want *ImportReadError", res, err)
+	}
+
+	storeErr := errors.New("driver: bad connection")
+	s = NewTaskService(createFailingRepo{memory.NewTaskRepo(), storeErr})
+	res, err = s.Import(ctx, transfer.NewReader(strings.NewReader(importInput), transfer.JSONL), ImportOptions{})
+	if !errors.Is(err, storeErr) || errors.As(err, &readErr) || res.Failed != 0 {
+		t.Errorf("store failure: %+v, %v, want %v", res, err, storeErr)
+	}
+
+	cctx, cancel := context.WithCancel(ctx)
+	cancel()
+	res, err = NewTaskService(memory.NewTaskRepo()).Import(cctx, transfer.NewReader(strings.NewReader(importInput), transfer.JSONL), ImportOptions{})
+	if !errors.Is(err, context.Canceled) || res.Imported != 0 {
+		t.Errorf("cancelled: %+v, %v, want context.Canceled", res, err)
+	}
+}
@@ -0,0 +1,169 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/kitouo/taskhub/internal/filter"
+	"github.com/kitouo/taskhub/internal/model"
+	"github.com/kitouo/taskhub/internal/repo"
+)
+
+var (
+	ErrParentNotFound = errors.New("parent not found")
+	ErrCycle          = errors.New("parent would create a cycle")
+	ErrOpenChildren   = errors.New("task has open children")
+)
+
+// ParentCompletion 完成父任务时，对仍未完成的子任务的处理策略
+type ParentCompletion string
+
+const (
+	ParentCompletionReject  ParentCompletion = "reject"  // 拒绝完成，返回ErrOpenChildren
+	ParentCompletionCascade ParentCompletion = "cascade" // 连同所有后代一起完成
+)
+
+// maxTreeDepth 防御性上限：数据异常（例如库里已有环）时避免无限循环
+const maxTreeDepth = 1000
+
+func childrenQuery(parentID string) repo.ListQuery {
+	return repo.ListQuery{Filter: filter.Compare{Field: "parent_id", Op: filter.OpEq, Value: parentID}}
+}
+
+// Children 返回直接子任务（带各自的进度）；父任务不存在时ok=false
+func (s *TaskService) Children(ctx context.Context, id string) ([]model.Task, bool, error) {
+	if _, ok, err := s.repo.Get(ctx, id); err != nil || !ok {
+		return nil, ok, err
+	}
+	children, err := s.repo.List(ctx, childrenQuery(id))
+	if err != nil {
+		return nil, true, err
+	}
+	for i := range children {
+		if err := s.attachProgress(ctx, &children[i]); err != nil {
+			return nil, true, err
+		}
+	}
+	if err := s.markBlocked(ctx, children); err != nil {
+		return nil, true, err
+	}
+	return children, true, nil
+}
+
+/*
+Move 把任务挂到新的父任务下，parentID为空表示移到顶层
+新父任务的祖先链中出现任务自身则说明会形成环；检查与写入由repo在同一把锁或事务内完成
+*/
+func (s *TaskService) Move(ctx context.Context, id, parentID string) (model.Task, bool, error) {
+	t, ok, err := s.repo.SetParent(ctx, id, parentID, func(ancestors []string) error {
+		switch {
+		case parentID != "" && len(ancestors) == 0:
+			return ErrParentNotFound
+		case slices.Contains(ancestors, id) || len(ancestors) > maxTreeDepth:
+			return ErrCycle
+		}
+		return nil
+	})
+	if err != nil || !ok {
+		return model.Task{}, ok, err
+	}
+	if err := s.decorate(ctx, &t); err != nil {
+		return model.Task{}, false, err
+	}
+	return t, true, nil
+}
+
+/*
+completeTree 返回完成任务需要的操作：未完成的后代在前，任务本身（连同patch中的其它字段）在最后
+  - 没有未完成的后代：只有任务本身
+  - reject：返回ErrOpenChildren
+  - cascade：同时完成所有未完成的后代；除非force，有后代被树外未完成的任务阻塞时返回ErrBlocked
+*/
+func (s *TaskService) completeTree(ctx context.Context, id string, force bool, patch repo.TaskPatch) ([]repo.BatchOp, error) {
+	open, err := s.openDescendants(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(open) > 0 && s.parentCompletion != ParentCompletionCascade {
+		return nil, ErrOpenChildren
+	}
+	if len(open) > 0 && !force {
+		blocked, err := s.blockedOutside(ctx, append([]string{id}, open...))
+		if err != nil {
+			return nil, err
+		}
+		if blocked {
+			return nil, ErrBlocked
+		}
+	}
+
+	done := true
+	ops := make([]repo.BatchOp, 0, len(open)+1)
+	for _, cid := range open {
+		ops = append(ops, repo.BatchOp{Kind: repo.BatchUpdate, ID: cid, Patch: repo.TaskPatch{Done: &done}})
+	}
+	patch.Done = &done
+	return append(ops, repo.BatchOp{Kind: repo.BatchUpdate, ID: id, Patch: patch}), nil
+}
+
+// applyCompletion 在一个原子批量中写入completeTree给出的操作，返回任务本身
+func (s *TaskService) applyCompletion(ctx context.Context, id string, ops []repo.BatchOp) (model.Task, error) {
+	results, err := s.repo.Batch(ctx, ops, true)
+	if err != nil {
+		return model.Task{}, err
+	}
+	for _, res := range results {
+		// 子任务在此期间被删除等并发情况：整批已回滚
+		if res.Failed() {
+			return model.Task{}, fmt.Errorf("cascade complete %s: %w", id, errors.Join(res.Err, repo.ErrBatchAborted))
+		}
+	}
+	return results[len(results)-1].Task, nil
+}
+
+// openDescendants 广度优先收集所有未完成的后代id
+func (s *TaskService) openDescendants(ctx context.Context, id string) ([]string, error) {
+	var (
+		out   []string
+		queue = []string{id}
+		seen  = map[string]bool{id: true}
+	)
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+
+		children, err := s.repo.List(ctx, childrenQuery(cur))
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range children {
+			if seen[c.ID] {
+				continue
+			}
+			seen[c.ID] = true
+			queue = append(queue, c.ID)
+			if !c.Done {
+				out = append(out, c.ID)
+			}
+		}
+	}
+	return out, nil
+}
+
+// attachProgress 统计直接子任务的完成情况，没有子任务时保持nil
+func (s *TaskService) attachProgress(ctx context.Context, t *model.Task) error {
+	q := childrenQuery(t.ID)
+	total, err := s.repo.Count(ctx, q)
+	if err != nil || total == 0 {
+		return err
+	}
+	q.Filter = filter.And{Left: q.Filter, Right: filter.Compare{Field: "done", Op: filter.OpEq, Value: true}}
+	done, err := s.repo.Count(ctx, q)
+	if err != nil {
+		return err
+	}
+	t.Progress = &model.Progress{Done: done, Total: total}
+	return nil
+}
@@ -0,0 +1,122 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/kitouo/taskhub/internal/model"
+	"github.com/kitouo/taskhub/internal/repo/memory"
+)
+
+func mustCreateChild(t *testing.T, s *TaskService, title, parentID string) model.Task {
+	t.Helper()
+	task, err := s.Create(context.Background(), CreateInput{Title: title, ParentID: parentID})
+	if err != nil {
+		t.Fatalf("Create(%s): %v", title, err)
+	}
+	return task
+}
+
+// 移动到自身或自己的后代下会形成环
+func TestMoveRejectsCycle(t *testing.T) {
+	ctx := context.Background()
+	s := NewTaskService(memory.NewTaskRepo())
+	a := mustCreate(t, s, "a")
+	b := mustCreateChild(t, s, "b", a.ID)
+	c := mustCreateChild(t, s, "c", b.ID)
+
+	for _, parent := range []string{a.ID, b.ID, c.ID} {
+		if _, _, err := s.Move(ctx, a.ID, parent); !errors.Is(err, ErrCycle) {
+			t.Errorf("Move(a, %s) err = %v, want ErrCycle", parent, err)
+		}
+	}
+	if _, _, err := s.Move(ctx, a.ID, "missing"); !errors.Is(err, ErrParentNotFound) {
+		t.Errorf("Move(a, missing) err = %v, want ErrParentNotFound", err)
+	}
+	if got, _ := mustGetTask(t, s, a.ID); got.ParentID != "" {
+		t.Errorf("a.ParentID = %q after rejected moves", got.ParentID)
+	}
+
+	// 移到顶层后原来的子树可以挂到它下面
+	moved, ok, err := s.Move(ctx, c.ID, "")
+	if err != nil || !ok || moved.ParentID != "" {
+		t.Fatalf("Move(c, top) = %+v, %v, %v", moved, ok, err)
+	}
+	if _, _, err := s.Move(ctx, a.ID, c.ID); err != nil {
+		t.Errorf("Move(a, c) err = %v", err)
+	}
+	children, _, err := s.Children(ctx, b.ID)
+	if err != nil || len(children) != 0 {
+		t.Errorf("Children(b) = %+v, %v", children, err)
+	}
+}
+
+// 并发地把两个任务互相挂到对方下面，最多只能成功一个
+func TestMoveConcurrent(t *testing.T) {
+	ctx := context.Background()
+	for range 50 {
+		s := NewTaskService(memory.NewTaskRepo())
+		a, b := mustCreate(t, s, "a"), mustCreate(t, s, "b")
+		var (
+			wg   sync.WaitGroup
+			errs [2]error
+		)
+		wg.Add(2)
+		go func() { defer wg.Done(); _, _, errs[0] = s.Move(ctx, a.ID, b.ID) }()
+		go func() { defer wg.Done(); _, _, errs[1] = s.Move(ctx, b.ID, a.ID) }()
+		wg.Wait()
+		if errs[0] == nil && errs[1] == nil {
+			t.Fatal("both moves succeeded: parent cycle")
+		}
+	}
+}
+
+func TestCompleteParentReject(t *testing.T) {
+	ctx := context.Background()
+	s := NewTaskService(memory.NewTaskRepo())
+	parent := mustCreate(t, s, "parent")
+	child := mustCreateChild(t, s, "child", parent.ID)
+
+	if _, _, err := s.MarkDone(ctx, parent.ID, true, MarkDoneOptions{}); !errors.Is(err, ErrOpenChildren) {
+		t.Fatalf("MarkDone(parent) err = %v, want ErrOpenChildren", err)
+	}
+	if got, _ := mustGetTask(t, s, parent.ID); got.Done {
+		t.Error("parent completed despite open child")
+	}
+
+	if _, _, err := s.MarkDone(ctx, child.ID, true, MarkDoneOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	got, _, err := s.MarkDone(ctx, parent.ID, true, MarkDoneOptions{})
+	if err != nil || !got.Done {
+		t.Fatalf("MarkDone(parent) = %+v, %v", got, err)
+	}
+	if got.Progress == nil || got.Progress.Done != 1 || got.Progress.Total != 1 {
+		t.Errorf("parent progress = %+v", got.Progress)
+	}
+}
+
+// 级联完成所有层级的后代，顶层的其它任务不受影响
+func TestCompleteParentCascade(t *testing.T) {
+	ctx := context.Background()
+	s := NewTaskService(memory.NewTaskRepo(), WithParentCompletion(ParentCompletionCascade))
+	parent := mustCreate(t, s, "parent")
+	child := mustCreateChild(t, s, "child", parent.ID)
+	grandchild := mustCreateChild(t, s, "grandchild", child.ID)
+	other := mustCreate(t, s, "other")
+
+	got, ok, err := s.MarkDone(ctx, parent.ID, true, MarkDoneOptions{})
+	if err != nil || !ok || !got.Done {
+		t.Fatalf("MarkDone(parent) = %+v, %v, %v", got, ok, err)
+	}
+	for _, id := range []string{child.ID, grandchild.ID} {
+		if task, _ := mustGetTask(t, s, id); !task.Done {
+			t.Errorf("descendant %s not completed", task.Title)
+		}
+	}
+	if task, _ := mustGetTask(t, s, other.ID); task.Done {
+		t.Error("unrelated task completed")
+	}
+}
@@ -0,0 +1,245 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/kitouo/taskhub/internal/model"
+	"github.com/kitouo/taskhub/internal/repo"
+)
+
+var (
+	ErrInvalidTimeEntry = errors.New("invalid time entry")
+	ErrTimerRunning     = repo.ErrTimerRunning
+	ErrNoRunningTimer   = errors.New("no running timer")
+	ErrInvalidTimeRange = errors.New("invalid time range")
+	ErrInvalidTimeGroup = errors.New("invalid time group")
+)
+
+const (
+	// MaxTimeEntry 单条手工记录的最长时长
+	MaxTimeEntry = 24 * time.Hour
+	// MaxTimeReportRange 报表允许的最大时间跨度
+	MaxTimeReportRange = 366 * 24 * time.Hour
+	maxTimeNoteLength  = 1000
+)
+
+type TimeService struct {
+	repo  repo.TimeEntryRepo
+	tasks repo.TaskRepo
+	now   func() time.Time
+}
+
+func NewTimeService(repo repo.TimeEntryRepo, tasks repo.TaskRepo) *TimeService {
+	return &TimeService{repo: repo, tasks: tasks, now: time.Now}
+}
+
+/*
+TimeEntryInput 手工补录一条工时
+  - Start + End，或 Start + Duration
+  - 只给Duration时，视为截至当前时间
+*/
+type TimeEntryInput struct {
+	Start    *time.Time
+	End      *time.Time
+	Duration time.Duration
+	Note     string
+}
+
+// TaskTime 任务下的工时记录及合计
+type TaskTime struct {
+	Entries         []model.TimeEntry `json:"entries"`
+	TotalSeconds    int64             `json:"total_seconds"`
+	EstimateMinutes int               `json:"estimate_minutes,omitempty"`
+}
+
+func (s *TimeService) Add(ctx context.Context, userID, taskID string, in TimeEntryInput) (model.TimeEntry, error) {
+	if _, err := s.getTask(ctx, taskID); err != nil {
+		return model.TimeEntry{}, err
+	}
+	note, err := normalizeNote(in.Note)
+	if err != nil {
+		return model.TimeEntry{}, err
+	}
+
+	now := s.now().UTC()
+	var start, end time.Time
+	switch {
+	case in.End != nil && in.Duration != 0, in.End != nil && in.Start == nil:
+		return model.TimeEntry{}, ErrInvalidTimeEntry
+	case in.End != nil:
+		start, end = in.Start.UTC(), in.End.UTC()
+	case in.Start != nil:
+		start = in.Start.UTC()
+		end = start.Add(in.Duration)
+	default:
+		end = now
+		start = end.Add(-in.Duration)
+	}
+	start, end = start.Truncate(time.Second), end.Truncate(time.Second)
+	if d := end.Sub(start); d <= 0 || d > MaxTimeEntry {
+		return model.TimeEntry{}, ErrInvalidTimeEntry
+	}
+
+	e, err := s.repo.Create(ctx, model.TimeEntry{
+		ID:        NewID(),
+		TaskID:    taskID,
+		UserID:    userID,
+		Start:     start,
+		End:       &end,
+		Note:      note,
+		CreatedAt: now,
+	})
+	if err != nil {
+		return model.TimeEntry{}, err
+	}
+	return s.withSeconds(e), nil
+}
+
+func (s *TimeService) List(ctx context.Context, taskID string) (TaskTime, error) {
+	t, err := s.getTask(ctx, taskID)
+	if err != nil {
+		return TaskTime{}, err
+	}
+	entries, err := s.repo.List(ctx, taskID)
+	if err != nil {
+		return TaskTime{}, err
+	}
+	out := TaskTime{Entries: entries, EstimateMinutes: t.EstimateMinutes}
+	for i := range entries {
+		entries[i] = s.withSeconds(entries[i])
+		out.TotalSeconds += entries[i].Seconds
+	}
+	return out, nil
+}
+
+// Start 在任务上启动计时器，每个用户同时只能有一个运行中的计时器（ErrTimerRunning）
+func (s *TimeService) Start(ctx context.Context, userID, taskID, note string) (model.TimeEntry, error) {
+	if _, err := s.getTask(ctx, taskID); err != nil {
+		return model.TimeEntry{}, err
+	}
+	note, err := normalizeNote(note)
+	if err != nil {
+		return model.TimeEntry{}, err
+	}
+	now := s.now().UTC().Truncate(time.Second)
+	e, err := s.repo.Create(ctx, model.TimeEntry{
+		ID:        NewID(),
+		TaskID:    taskID,
+		UserID:    userID,
+		Start:     now,
+		Note:      note,
+		CreatedAt: now,
+	})
+	if err != nil {
+		return model.TimeEntry{}, err
+	}
+	return s.withSeconds(e), nil
+}
+
+// Stop 停止用户在该任务上运行中的计时器
+func (s *TimeService) Stop(ctx context.Context, userID, taskID string) (model.TimeEntry, error) {
+	e, ok, err := s.repo.Running(ctx, userID)
+	if err != nil {
+		return model.TimeEntry{}, err
+	}
+	if !ok || e.TaskID != taskID {
+		return model.TimeEntry{}, ErrNoRunningTimer
+	}
+	// 不足一秒按一秒记，避免出现时长为0的记录
+	end := s.now().UTC().Truncate(time.Second)
+	if floor := e.Start.Add(time.Second); end.Before(floor) {
+		end = floor
+	}
+	e, ok, err = s.repo.Stop(ctx, e.ID, end)
+	if err != nil {
+		return model.TimeEntry{}, err
+	}
+	if !ok {
+		// 并发的另一个请求已经停止了它
+		return model.TimeEntry{}, ErrNoRunningTimer
+	}
+	return s.withSeconds(e), nil
+}
+
+// Delete 只能删除自己的记录；记录不属于该任务时视为不存在
+func (s *TimeService) Delete(ctx context.Context, userID, taskID, id string) (bool, error) {
+	e, ok, err := s.repo.Get(ctx, id)
+	if err != nil || !ok || e.TaskID != taskID {
+		return false, err
+	}
+	if e.UserID != userID {
+		return true, ErrForbidden
+	}
+	return s.repo.Delete(ctx, id)
+}
+
+/*
+Report 按任务、项目或用户汇总[from, to)内的工时
+  - 跨越区间边界的记录只计算区间内的部分
+  - 运行中的计时器不计入
+*/
+func (s *TimeService) Report(ctx context.Context, group model.TimeGroup, from, to time.Time) ([]model.TimeReportRow, error) {
+	switch group {
+	case model.TimeByTask, model.TimeByProject, model.TimeByUser:
+	default:
+		return nil, ErrInvalidTimeGroup
+	}
+	if !to.After(from) || to.Sub(from) > MaxTimeReportRange {
+		return nil, ErrInvalidTimeRange
+	}
+	rows, err := s.repo.Report(ctx, repo.TimeReportQuery{GroupBy: group, From: from, To: to})
+	if err != nil || group != model.TimeByTask || len(rows) == 0 {
+		return rows, err
+	}
+
+	ids := make([]string, len(rows))
+	for i, row := range rows {
+		ids[i] = row.Key
+	}
+	tasks, err := s.tasks.List(ctx, repo.ListQuery{IDs: ids})
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[string]model.Task, len(tasks))
+	for _, t := range tasks {
+		byID[t.ID] = t
+	}
+	for i := range rows {
+		t := byID[rows[i].Key]
+		rows[i].Title, rows[i].EstimateMinutes = t.Title, t.EstimateMinutes
+	}
+	return rows, nil
+}
+
+func (s *TimeService) getTask(ctx context.Context, taskID string) (model.Task, error) {
+	t, ok, err := s.tasks.Get(ctx, taskID)
+	if err != nil {
+		return model.Task{}, err
+	}
+	if !ok {
+		return model.Task{}, ErrTaskNotFound
+	}
+	return t, nil
+}
+
+// withSeconds 计算时长，运行中的计时器按当前时间计
+func (s *TimeService) withSeconds(e model.TimeEntry) model.TimeEntry {
+	end := s.now().UTC()
+	if e.End != nil {
+		end = *e.End
+	}
+	e.Running = e.End == nil
+	e.Seconds = max(int64(end.Sub(e.Start)/time.Second), 0)
+	return e
+}
+
+func normalizeNote(note string) (string, error) {
+	note = strings.TrimSpace(note)
+	if len([]rune(note)) > maxTimeNoteLength {
+		return "", ErrInvalidTimeEntry
+	}
+	return note, nil
+}
@@ -0,0 +1,144 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/kitouo/taskhub/internal/model"
+	"github.com/kitouo/taskhub/internal/repo"
+)
+
+var (
+	ErrInvalidUserID      = errors.New("invalid user id")
+	ErrInvalidDisplayName = errors.New("invalid display name")
+	ErrInvalidEmail       = errors.New("invalid email")
+	ErrUserExists         = errors.New("user already exists")
+)
+
+type UserService struct {
+	repo  repo.UserRepo
+	tasks *TaskService
+}
+
+func NewUserService(repo repo.UserRepo, tasks *TaskService) *UserService {
+	return &UserService{repo: repo, tasks: tasks}
+}
+
+/*
+UserInput 创建/修改用户的参数，nil字段表示不修改
+ID 只在创建时使用，应与请求头X-User-ID中的身份一致
+*/
+type UserInput struct {
+	ID          string
+	DisplayName *string
+	Email       *string
+	Active      *bool
+}
+
+// InactiveAssignment 停用用户及其仍未完成的任务
+type InactiveAssignment struct {
+	User  model.User   `json:"user"`
+	Tasks []model.Task `json:"tasks"`
+}
+
+func (s *UserService) Create(ctx context.Context, in UserInput) (model.User, error) {
+	id := strings.TrimSpace(in.ID)
+	if !validID(id) {
+		return model.User{}, ErrInvalidUserID
+	}
+	if in.DisplayName == nil {
+		return model.User{}, ErrInvalidDisplayName
+	}
+
+	u, err := applyUserInput(model.User{ID: id, Active: true, CreatedAt: time.Now().UTC()}, in)
+	if err != nil {
+		return model.User{}, err
+	}
+	// 由repo的唯一约束判断重复，先查后插在并发下不可靠
+	u, err = s.repo.Create(ctx, u)
+	if errors.Is(err, repo.ErrConflict) {
+		return model.User{}, ErrUserExists
+	}
+	return u, err
+}
+
+func (s *UserService) Get(ctx context.Context, id string) (model.User, bool, error) {
+	return s.repo.Get(ctx, id)
+}
+
+func (s *UserService) List(ctx context.Context, active *bool) ([]model.User, error) {
+	return s.repo.List(ctx, active)
+}
+
+func (s *UserService) Update(ctx context.Context, id string, in UserInput) (model.User, bool, error) {
+	u, ok, err := s.repo.Get(ctx, id)
+	if err != nil || !ok {
+		return model.User{}, ok, err
+	}
+	u, err = applyUserInput(u, in)
+	if err != nil {
+		return model.User{}, true, err
+	}
+	return s.repo.Update(ctx, u)
+}
+
+// Tasks 分配给用户的任务，ok=false表示用户不存在
+func (s *UserService) Tasks(ctx context.Context, id string, opts ListOptions) ([]model.Task, bool, error) {
+	if _, ok, err := s.repo.Get(ctx, id); err != nil || !ok {
+		return nil, ok, err
+	}
+	opts.Assignee = id
+	tasks, err := s.tasks.List(ctx, opts)
+	return tasks, err == nil, err
+}
+
+/*
+InactiveAssignments 报表：停用用户仍挂着的未完成任务，用于重新分配
+没有未完成任务的停用用户不出现在结果中
+*/
+func (s *UserService) InactiveAssignments(ctx context.Context) ([]InactiveAssignment, error) {
+	inactive := false
+	users, err := s.repo.List(ctx, &inactive)
+	if err != nil || len(users) == 0 {
+		return []InactiveAssignment{}, err
+	}
+
+	out := make([]InactiveAssignment, 0)
+	for _, u := range users {
+		tasks, err := s.tasks.List(ctx, ListOptions{Assignee: u.ID, Filter: "open"})
+		if err != nil {
+			return nil, err
+		}
+		if len(tasks) > 0 {
+			out = append(out, InactiveAssignment{User: u, Tasks: tasks})
+		}
+	}
+	return out, nil
+}
+
+func applyUserInput(u model.User, in UserInput) (model.User, error) {
+	if in.DisplayName != nil {
+		name := strings.TrimSpace(*in.DisplayName)
+		if name == "" || len([]rune(name)) > 100 {
+			return model.User{}, ErrInvalidDisplayName
+		}
+		u.DisplayName = name
+	}
+	if in.Email != nil {
+		email := strings.TrimSpace(*in.Email)
+		if email != "" {
+			addr, err := mail.ParseAddress(email)
+			if err != nil || addr.Address != email || len(email) > 254 {
+				return model.User{}, ErrInvalidEmail
+			}
+		}
+		u.Email = email
+	}
+	if in.Active != nil {
+		u.Active = *in.Active
+	}
+	return u, nil
+}
@@ -0,0 +1,155 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/kitouo/taskhub/internal/model"
+	"github.com/kitouo/taskhub/internal/repo/memory"
+)
+
+func newUserService() (*UserService, *TaskService) {
+	users := memory.NewUserRepo()
+	tasks := NewTaskService(memory.NewTaskRepo(), WithAssignees(memory.NewAssignmentRepo(), users))
+	return NewUserService(users, tasks), tasks
+}
+
+func mustCreateUser(t *testing.T, s *UserService, id string) model.User {
+	t.Helper()
+	u, err := s.Create(context.Background(), UserInput{ID: id, DisplayName: ptr(id)})
+	if err != nil {
+		t.Fatalf("Create user %s: %v", id, err)
+	}
+	return u
+}
+
+func mustAssign(t *testing.T, s *TaskService, taskID string, userIDs ...string) {
+	t.Helper()
+	if _, _, err := s.SetAssignees(context.Background(), taskID, userIDs); err != nil {
+		t.Fatalf("SetAssignees(%s, %v): %v", taskID, userIDs, err)
+	}
+}
+
+// 重复的id由repo判定，并发创建同一个用户时只有一个成功
+func TestCreateUserExists(t *testing.T) {
+	s, _ := newUserService()
+	ctx := context.Background()
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		created int
+	)
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := s.Create(ctx, UserInput{ID: "alice", DisplayName: ptr("Alice")})
+			switch {
+			case err == nil:
+				mu.Lock()
+				created++
+				mu.Unlock()
+			case !errors.Is(err, ErrUserExists):
+				t.Errorf("Create err = %v, want ErrUserExists", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if created != 1 {
+		t.Errorf("created %d times, want 1", created)
+	}
+}
+
+// 没有任何分配的用户按负责人过滤得到空列表，而不是全部任务
+func TestUserTasksEmptyAssignments(t *testing.T) {
+	s, tasks := newUserService()
+	ctx := context.Background()
+	mustCreateUser(t, s, "alice")
+	mustCreateUser(t, s, "bob")
+	a := mustCreate(t, tasks, "a")
+	mustCreate(t, tasks, "b")
+
+	got, ok, err := s.Tasks(ctx, "bob", ListOptions{})
+	if err != nil || !ok || len(got) != 0 {
+		t.Errorf("Tasks(bob) = %+v, %v, %v; want empty", got, ok, err)
+	}
+	if n, err := tasks.Count(ctx, ListOptions{Assignee: "bob"}); err != nil || n != 0 {
+		t.Errorf("Count(assignee=bob) = %d, %v; want 0", n, err)
+	}
+
+	mustAssign(t, tasks, a.ID, "alice")
+	got, _, err = s.Tasks(ctx, "alice", ListOptions{})
+	if err != nil || len(got) != 1 || got[0].ID != a.ID || len(got[0].Assignees) != 1 {
+		t.Errorf("Tasks(alice) = %+v, %v", got, err)
+	}
+	if _, ok, _ := s.Tasks(ctx, "missing", ListOptions{}); ok {
+		t.Error("Tasks(missing) reported ok")
+	}
+
+	// 未启用负责人时同样返回空
+	plain := NewTaskService(memory.NewTaskRepo())
+	mustCreate(t, plain, "x")
+	if got, err := plain.List(ctx, ListOptions{Assignee: "alice"}); err != nil || len(got) != 0 {
+		t.Errorf("List without assignees = %+v, %v; want empty", got, err)
+	}
+}
+
+func TestSetAssigneesRules(t *testing.T) {
+	s, tasks := newUserService()
+	ctx := context.Background()
+	mustCreateUser(t, s, "alice")
+	mustCreateUser(t, s, "bob")
+	task := mustCreate(t, tasks, "a")
+
+	if _, _, err := tasks.SetAssignees(ctx, task.ID, []string{"nobody"}); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("unknown user err = %v, want ErrUserNotFound", err)
+	}
+	mustAssign(t, tasks, task.ID, "alice")
+	if _, _, err := s.Update(ctx, "alice", UserInput{Active: ptr(false)}); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := s.Update(ctx, "bob", UserInput{Active: ptr(false)}); err != nil {
+		t.Fatal(err)
+	}
+	// 已在任务上的停用用户可以保留，新加入的停用用户被拒绝
+	mustAssign(t, tasks, task.ID, "alice", "alice")
+	if _, _, err := tasks.SetAssignees(ctx, task.ID, []string{"alice", "bob"}); !errors.Is(err, ErrUserInactive) {
+		t.Errorf("inactive user err = %v, want ErrUserInactive", err)
+	}
+}
+
+// 报表只列出仍挂着未完成任务的停用用户，且只含未完成的任务
+func TestInactiveAssignments(t *testing.T) {
+	s, tasks := newUserService()
+	ctx := context.Background()
+	for _, id := range []string{"active", "idle", "left"} {
+		mustCreateUser(t, s, id)
+	}
+	open := mustCreate(t, tasks, "open")
+	done := mustCreate(t, tasks, "done")
+	other := mustCreate(t, tasks, "other")
+	mustAssign(t, tasks, open.ID, "left", "active")
+	mustAssign(t, tasks, done.ID, "left", "idle")
+	mustAssign(t, tasks, other.ID, "active")
+	if _, _, err := tasks.MarkDone(ctx, done.ID, true, MarkDoneOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{"idle", "left"} {
+		if _, _, err := s.Update(ctx, id, UserInput{Active: ptr(false)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	report, err := s.InactiveAssignments(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report) != 1 || report[0].User.ID != "left" {
+		t.Fatalf("report = %+v, want only left", report)
+	}
+	if got := report[0].Tasks; len(got) != 1 || got[0].ID != open.ID {
+		t.Errorf("left's tasks = %+v, want only the open task", got)
+	}
+}
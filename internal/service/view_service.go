@@ -0,0 +1,189 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/kitouo/taskhub/internal/filter"
+	"github.com/kitouo/taskhub/internal/model"
+	"github.com/kitouo/taskhub/internal/repo"
+)
+
+var (
+	ErrInvalidViewName   = errors.New("invalid view name")
+	ErrInvalidVisibility = errors.New("invalid visibility")
+	ErrForbidden         = errors.New("forbidden")
+)
+
+type ViewService struct {
+	repo  repo.ViewRepo
+	tasks *TaskService
+}
+
+func NewViewService(repo repo.ViewRepo, tasks *TaskService) *ViewService {
+	return &ViewService{repo: repo, tasks: tasks}
+}
+
+// ViewInput 创建/修改视图的参数，nil字段表示不修改（创建时取默认值）
+type ViewInput struct {
+	Name       *string
+	Filter     *string
+	Sort       *string
+	Visibility *string
+}
+
+// ViewCount 视图及其当前命中的任务数
+type ViewCount struct {
+	View  model.SavedView
+	Count int
+}
+
+func (s *ViewService) Create(ctx context.Context, userID string, in ViewInput) (model.SavedView, error) {
+	now := time.Now().UTC()
+	v := model.SavedView{
+		ID:         NewID(),
+		OwnerID:    userID,
+		Visibility: model.VisibilityPrivate,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	if in.Name == nil {
+		return model.SavedView{}, ErrInvalidViewName
+	}
+	v, err := applyViewInput(v, in)
+	if err != nil {
+		return model.SavedView{}, err
+	}
+	return s.repo.Create(ctx, v)
+}
+
+/*
+Get 返回userID可见的视图
+他人的私有视图按不存在处理，避免泄露其是否存在
+*/
+func (s *ViewService) Get(ctx context.Context, userID, id string) (model.SavedView, bool, error) {
+	v, ok, err := s.repo.Get(ctx, id)
+	if err != nil || !ok {
+		return model.SavedView{}, false, err
+	}
+	if v.OwnerID != userID && v.Visibility != model.VisibilityTeam {
+		return model.SavedView{}, false, nil
+	}
+	return v, true, nil
+}
+
+func (s *ViewService) List(ctx context.Context, userID string) ([]model.SavedView, error) {
+	return s.repo.ListVisible(ctx, userID)
+}
+
+/*
+ListWithCounts 返回可见视图以及各自命中的任务数，供看板展示
+过滤表达式已失效的视图Count为-1，不影响其它视图
+*/
+func (s *ViewService) ListWithCounts(ctx context.Context, userID string) ([]ViewCount, error) {
+	views, err := s.repo.ListVisible(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]ViewCount, len(views))
+	for i, v := range views {
+		n, err := s.tasks.Count(ctx, ListOptions{Filter: v.Filter})
+		if errors.Is(err, ErrInvalidFilter) {
+			n = -1
+		} else if err != nil {
+			return nil, err
+		}
+		out[i] = ViewCount{View: v, Count: n}
+	}
+	return out, nil
+}
+
+// Update 仅创建者可修改；对他人可见的team视图返回ErrForbidden
+func (s *ViewService) Update(ctx context.Context, userID, id string, in ViewInput) (model.SavedView, bool, error) {
+	v, ok, err := s.Get(ctx, userID, id)
+	if err != nil || !ok {
+		return model.SavedView{}, false, err
+	}
+	if v.OwnerID != userID {
+		return model.SavedView{}, false, ErrForbidden
+	}
+	v, err = applyViewInput(v, in)
+	if err != nil {
+		return model.SavedView{}, false, err
+	}
+	v.UpdatedAt = time.Now().UTC()
+	return s.repo.Update(ctx, v)
+}
+
+func (s *ViewService) Delete(ctx context.Context, userID, id string) (bool, error) {
+	v, ok, err := s.Get(ctx, userID, id)
+	if err != nil || !ok {
+		return false, err
+	}
+	if v.OwnerID != userID {
+		return false, ErrForbidden
+	}
+	return s.repo.Delete(ctx, id)
+}
+
+// Tasks 通过TaskService.List执行视图
+func (s *ViewService) Tasks(ctx context.Context, userID, id string) ([]model.Task, bool, error) {
+	v, ok, err := s.Get(ctx, userID, id)
+	if err != nil || !ok {
+		return nil, false, err
+	}
+	tasks, err := s.tasks.List(ctx, ListOptions{Filter: v.Filter, Sort: v.Sort})
+	if err != nil {
+		return nil, true, err
+	}
+	return tasks, true, nil
+}
+
+func (s *ViewService) Count(ctx context.Context, userID, id string) (int, bool, error) {
+	v, ok, err := s.Get(ctx, userID, id)
+	if err != nil || !ok {
+		return 0, false, err
+	}
+	n, err := s.tasks.Count(ctx, ListOptions{Filter: v.Filter})
+	if err != nil {
+		return 0, true, err
+	}
+	return n, true, nil
+}
+
+func applyViewInput(v model.SavedView, in ViewInput) (model.SavedView, error) {
+	if in.Name != nil {
+		name := strings.TrimSpace(*in.Name)
+		if name == "" || len(name) > 100 {
+			return model.SavedView{}, ErrInvalidViewName
+		}
+		v.Name = name
+	}
+	if in.Filter != nil {
+		f := strings.TrimSpace(*in.Filter)
+		if f != "" {
+			if _, err := filter.Parse(f, time.Now().UTC()); err != nil {
+				return model.SavedView{}, fmt.Errorf("%w: %w", ErrInvalidFilter, err)
+			}
+		}
+		v.Filter = f
+	}
+	if in.Sort != nil {
+		if _, err := ParseSort(*in.Sort); err != nil {
+			return model.SavedView{}, err
+		}
+		v.Sort = strings.TrimSpace(*in.Sort)
+	}
+	if in.Visibility != nil {
+		switch *in.Visibility {
+		case model.VisibilityPrivate, model.VisibilityTeam:
+			v.Visibility = *in.Visibility
+		default:
+			return model.SavedView{}, ErrInvalidVisibility
+		}
+	}
+	return v, nil
+}
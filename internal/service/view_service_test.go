@@ -0,0 +1,128 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/kitouo/taskhub/internal/model"
+	"github.com/kitouo/taskhub/internal/repo/memory"
+)
+
+func newViewService() (*ViewService, *TaskService) {
+	tasks := NewTaskService(memory.NewTaskRepo())
+	return NewViewService(memory.NewViewRepo(), tasks), tasks
+}
+
+func mustCreateView(t *testing.T, s *ViewService, owner string, in ViewInput) model.SavedView {
+	t.Helper()
+	v, err := s.Create(context.Background(), owner, in)
+	if err != nil {
+		t.Fatalf("Create view: %v", err)
+	}
+	return v
+}
+
+func TestViewValidation(t *testing.T) {
+	s, _ := newViewService()
+	ctx := context.Background()
+	cases := []struct {
+		in   ViewInput
+		want error
+	}{
+		{ViewInput{}, ErrInvalidViewName},
+		{ViewInput{Name: ptr("  ")}, ErrInvalidViewName},
+		{ViewInput{Name: ptr("v"), Filter: ptr("open AND")}, ErrInvalidFilter},
+		{ViewInput{Name: ptr("v"), Sort: ptr("priority")}, ErrInvalidSort},
+		{ViewInput{Name: ptr("v"), Visibility: ptr("public")}, ErrInvalidVisibility},
+	}
+	for _, c := range cases {
+		if _, err := s.Create(ctx, "u1", c.in); !errors.Is(err, c.want) {
+			t.Errorf("Create(%+v) err = %v, want %v", c.in, err, c.want)
+		}
+	}
+	v := mustCreateView(t, s, "u1", ViewInput{Name: ptr(" mine ")})
+	if v.Name != "mine" || v.Visibility != model.VisibilityPrivate || v.OwnerID != "u1" {
+		t.Errorf("created view = %+v", v)
+	}
+}
+
+// 私有视图对他人不可见；team视图可见但只有创建者可以修改、删除
+func TestViewVisibilityAndOwnership(t *testing.T) {
+	s, _ := newViewService()
+	ctx := context.Background()
+	private := mustCreateView(t, s, "owner", ViewInput{Name: ptr("private")})
+	team := mustCreateView(t, s, "owner", ViewInput{Name: ptr("team"), Visibility: ptr(model.VisibilityTeam)})
+
+	if _, ok, _ := s.Get(ctx, "other", private.ID); ok {
+		t.Error("private view visible to another user")
+	}
+	if _, ok, _ := s.Get(ctx, "other", team.ID); !ok {
+		t.Error("team view not visible to another user")
+	}
+	views, err := s.List(ctx, "other")
+	if err != nil || len(views) != 1 || views[0].ID != team.ID {
+		t.Errorf("List(other) = %+v, %v", views, err)
+	}
+
+	// 他人的私有视图按不存在处理
+	if _, ok, err := s.Update(ctx, "other", private.ID, ViewInput{Name: ptr("x")}); ok || err != nil {
+		t.Errorf("Update(other, private) = %v, %v; want not found", ok, err)
+	}
+	if _, _, err := s.Update(ctx, "other", team.ID, ViewInput{Name: ptr("x")}); !errors.Is(err, ErrForbidden) {
+		t.Errorf("Update(other, team) err = %v, want ErrForbidden", err)
+	}
+	if _, err := s.Delete(ctx, "other", team.ID); !errors.Is(err, ErrForbidden) {
+		t.Errorf("Delete(other, team) err = %v, want ErrForbidden", err)
+	}
+	if v, _, _ := s.Get(ctx, "owner", team.ID); v.Name != "team" {
+		t.Errorf("team view changed by another user: %+v", v)
+	}
+
+	updated, ok, err := s.Update(ctx, "owner", team.ID, ViewInput{Name: ptr("renamed"), Visibility: ptr(model.VisibilityPrivate)})
+	if err != nil || !ok || updated.Name != "renamed" {
+		t.Fatalf("Update(owner) = %+v, %v, %v", updated, ok, err)
+	}
+	if _, ok, _ := s.Get(ctx, "other", team.ID); ok {
+		t.Error("view still visible after being made private")
+	}
+	if ok, err := s.Delete(ctx, "owner", team.ID); !ok || err != nil {
+		t.Errorf("Delete(owner) = %v, %v", ok, err)
+	}
+	if _, ok, _ := s.Get(ctx, "owner", team.ID); ok {
+		t.Error("view still exists after Delete")
+	}
+}
+
+// 执行视图时使用保存的过滤与排序条件
+func TestViewAppliesSavedFilter(t *testing.T) {
+	s, tasks := newViewService()
+	ctx := context.Background()
+	mustCreate(t, tasks, "deploy api")
+	mustCreate(t, tasks, "deploy web")
+	done := mustCreate(t, tasks, "deploy db")
+	mustCreate(t, tasks, "write docs")
+	if _, _, err := tasks.MarkDone(ctx, done.ID, true, MarkDoneOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	v := mustCreateView(t, s, "u1", ViewInput{Name: ptr("deploys"), Filter: ptr(`open AND title ~ "deploy"`), Sort: ptr("-title")})
+	got, ok, err := s.Tasks(ctx, "u1", v.ID)
+	if err != nil || !ok {
+		t.Fatalf("Tasks = %v, %v", ok, err)
+	}
+	if len(got) != 2 || got[0].Title != "deploy web" || got[1].Title != "deploy api" {
+		t.Errorf("Tasks = %+v, want deploy web, deploy api", got)
+	}
+	if n, _, err := s.Count(ctx, "u1", v.ID); err != nil || n != 2 {
+		t.Errorf("Count = %d, %v", n, err)
+	}
+	if _, ok, _ := s.Tasks(ctx, "other", v.ID); ok {
+		t.Error("another user ran a private view")
+	}
+
+	counts, err := s.ListWithCounts(ctx, "u1")
+	if err != nil || len(counts) != 1 || counts[0].Count != 2 {
+		t.Errorf("ListWithCounts = %+v, %v", counts, err)
+	}
+}
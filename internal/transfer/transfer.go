@@ -0,0 +1,269 @@
+/*
+Package transfer 任务的批量导入导出格式：CSV与JSON Lines
+  - 两种格式字段相同（见Record）；CSV第一行为表头，按列名对应，未知列忽略
+  - 时间为RFC3339，布尔为true/false，空值表示未设置
+  - 读取时单行出错返回*LineError，调用方可以记录后继续读下一行
+*/
+package transfer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Format string
+
+const (
+	CSV   Format = "csv"
+	JSONL Format = "jsonl"
+)
+
+var ErrUnknownFormat = errors.New("unknown format, want csv or jsonl")
+
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(strings.ToLower(strings.TrimSpace(s))); f {
+	case CSV, JSONL:
+		return f, nil
+	case "ndjson":
+		return JSONL, nil
+	default:
+		return "", ErrUnknownFormat
+	}
+}
+
+// ContentType 响应使用的MIME类型
+func (f Format) ContentType() string {
+	if f == CSV {
+		return "text/csv; charset=utf-8"
+	}
+	return "application/x-ndjson"
+}
+
+// Record 一行导入导出数据，只包含落库的字段（不含进度、负责人等计算字段）
+type Record struct {
+	ID              string     `json:"id,omitempty"`
+	Title           string     `json:"title"`
+	Done            bool       `json:"done"`
+	CreatedAt       *time.Time `json:"created_at,omitempty"`
+	ParentID        string     `json:"parent_id,omitempty"`
+	DueAt           *time.Time `json:"due_at,omitempty"`
+	Project         string     `json:"project,omitempty"`
+	EstimateMinutes int        `json:"estimate_minutes,omitempty"`
+	Column          string     `json:"column,omitempty"`
+	RRule           string     `json:"rrule,omitempty"`
+	Timezone        string     `json:"timezone,omitempty"`
+}
+
+// Columns CSV的列顺序
+var Columns = []string{
+	"id", "title", "done", "created_at", "parent_id", "due_at",
+	"project", "estimate_minutes", "column", "rrule", "timezone",
+}
+
+// LineError 某一行无法解析；Line从1开始（CSV含表头行）
+type LineError struct {
+	Line int
+	Err  error
+}
+
+func (e *LineError) Error() string { return fmt.Sprintf("line %d: %v", e.Line, e.Err) }
+func (e *LineError) Unwrap() error { return e.Err }
+
+// Writer 逐行写出，调用方结束时必须Flush
+type Writer interface {
+	Write(Record) error
+	Flush() error
+}
+
+func NewWriter(w io.Writer, f Format) Writer {
+	if f == CSV {
+		return &csvWriter{w: csv.NewWriter(w)}
+	}
+	bw := bufio.NewWriter(w)
+	return &jsonlWriter{bw: bw, enc: json.NewEncoder(bw)}
+}
+
+type csvWriter struct {
+	w           *csv.Writer
+	wroteHeader bool
+}
+
+func (c *csvWriter) Write(r Record) error {
+	if !c.wroteHeader {
+		c.wroteHeader = true
+		if err := c.w.Write(Columns); err != nil {
+			return err
+		}
+	}
+	return c.w.Write([]string{
+		r.ID, r.Title, strconv.FormatBool(r.Done), formatTime(r.CreatedAt), r.ParentID, formatTime(r.DueAt),
+		r.Project, formatInt(r.EstimateMinutes), r.Column, r.RRule, r.Timezone,
+	})
+}
+
+// Flush 没有任何数据时也写出表头
+func (c *csvWriter) Flush() error {
+	if !c.wroteHeader {
+		c.wroteHeader = true
+		if err := c.w.Write(Columns); err != nil {
+			return err
+		}
+	}
+	c.w.Flush()
+	return c.w.Error()
+}
+
+type jsonlWriter struct {
+	bw  *bufio.Writer
+	enc *json.Encoder
+}
+
+func (j *jsonlWriter) Write(r Record) error { return j.enc.Encode(r) }
+func (j *jsonlWriter) Flush() error         { return j.bw.Flush() }
+
+/*
+Reader 逐行读取
+Next在输入结束时返回io.EOF；返回*LineError时可以继续调用Next
+其它错误（例如底层读取失败、CSV缺少表头）表示无法继续
+*/
+type Reader interface {
+	Next() (Record, int, error)
+}
+
+// MaxLineBytes JSON Lines单行的长度上限
+const MaxLineBytes = 1 << 20
+
+func NewReader(r io.Reader, f Format) Reader {
+	if f == CSV {
+		cr := csv.NewReader(r)
+		cr.FieldsPerRecord = -1
+		cr.ReuseRecord = true
+		return &csvReader{r: cr}
+	}
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64<<10), MaxLineBytes)
+	return &jsonlReader{sc: sc}
+}
+
+type csvReader struct {
+	r      *csv.Reader
+	header map[string]int
+}
+
+func (c *csvReader) Next() (Record, int, error) {
+	if c.header == nil {
+		row, err := c.r.Read()
+		if err == io.EOF {
+			return Record{}, 0, io.EOF
+		}
+		if err != nil {
+			return Record{}, 1, fmt.Errorf("read csv header: %w", err)
+		}
+		c.header = make(map[string]int, len(row))
+		for i, name := range row {
+			c.header[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+		}
+		if _, ok := c.header["title"]; !ok {
+			return Record{}, 1, errors.New(`csv header must contain a "title" column`)
+		}
+	}
+
+	row, err := c.r.Read()
+	line, _ := c.r.FieldPos(0)
+	if err == io.EOF {
+		return Record{}, 0, io.EOF
+	}
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return Record{}, parseErr.StartLine, &LineError{Line: parseErr.StartLine, Err: parseErr.Err}
+	}
+	if err != nil {
+		return Record{}, line, err
+	}
+
+	get := func(name string) string {
+		if i, ok := c.header[name]; ok && i < len(row) {
+			return strings.TrimSpace(row[i])
+		}
+		return ""
+	}
+	var rec Record
+	rec.ID, rec.Title, rec.ParentID = get("id"), get("title"), get("parent_id")
+	rec.Project, rec.Column, rec.RRule, rec.Timezone = get("project"), get("column"), get("rrule"), get("timezone")
+	if v := get("done"); v != "" {
+		if rec.Done, err = strconv.ParseBool(v); err != nil {
+			return Record{}, line, &LineError{Line: line, Err: fmt.Errorf("done: %q is not a boolean", v)}
+		}
+	}
+	if v := get("estimate_minutes"); v != "" {
+		if rec.EstimateMinutes, err = strconv.Atoi(v); err != nil {
+			return Record{}, line, &LineError{Line: line, Err: fmt.Errorf("estimate_minutes: %q is not an integer", v)}
+		}
+	}
+	if rec.CreatedAt, err = parseTime(get("created_at")); err != nil {
+		return Record{}, line, &LineError{Line: line, Err: fmt.Errorf("created_at: %w", err)}
+	}
+	if rec.DueAt, err = parseTime(get("due_at")); err != nil {
+		return Record{}, line, &LineError{Line: line, Err: fmt.Errorf("due_at: %w", err)}
+	}
+	return rec, line, nil
+}
+
+type jsonlReader struct {
+	sc   *bufio.Scanner
+	line int
+}
+
+func (j *jsonlReader) Next() (Record, int, error) {
+	for j.sc.Scan() {
+		j.line++
+		b := j.sc.Bytes()
+		if len(bytes.TrimSpace(b)) == 0 {
+			continue
+		}
+		var rec Record
+		dec := json.NewDecoder(bytes.NewReader(b))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&rec); err != nil {
+			return Record{}, j.line, &LineError{Line: j.line, Err: err}
+		}
+		return rec, j.line, nil
+	}
+	if err := j.sc.Err(); err != nil {
+		return Record{}, j.line + 1, fmt.Errorf("read line %d: %w", j.line+1, err)
+	}
+	return Record{}, 0, io.EOF
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+func formatInt(n int) string {
+	if n == 0 {
+		return ""
+	}
+	return strconv.Itoa(n)
+}
+
+func parseTime(s string) (*time.Time, error) {
+	if s == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return nil, fmt.Errorf("%q is not an RFC3339 time", s)
+	}
+	return &t, nil
+}
@@ -0,0 +1,94 @@
+package transfer
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRoundTrip(t *testing.T) {
+	created := time.Date(2024, 1, 2, 3, 4, 5, 600, time.UTC)
+	due := time.Date(2024, 2, 1, 9, 0, 0, 0, time.UTC)
+	recs := []Record{
+		{ID: "a1", Title: `say "hi", then go`, Done: true, CreatedAt: &created, Project: "acme", EstimateMinutes: 90},
+		{ID: "b2", Title: "多行\n标题", ParentID: "a1", DueAt: &due, Column: "doing", RRule: "FREQ=WEEKLY;BYDAY=MO", Timezone: "Asia/Shanghai"},
+	}
+	for _, f := range []Format{CSV, JSONL} {
+		var buf bytes.Buffer
+		w := NewWriter(&buf, f)
+		for _, r := range recs {
+			if err := w.Write(r); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := w.Flush(); err != nil {
+			t.Fatal(err)
+		}
+
+		r := NewReader(&buf, f)
+		var got []Record
+		for {
+			rec, _, err := r.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatalf("%s: %v", f, err)
+			}
+			got = append(got, rec)
+		}
+		if !reflect.DeepEqual(got, recs) {
+			t.Errorf("%s round trip:\n got %+v\nwant %+v", f, got, recs)
+		}
+	}
+}
+
+func TestLineErrors(t *testing.T) {
+	cases := []struct {
+		f     Format
+		in    string
+		lines []int // 出错的行号
+		ok    int   // 成功解析的行数
+	}{
+		{CSV, "title,done\nok,true\nbad,maybe\nfine,\n", []int{3}, 2},
+		{CSV, "title,due_at\nx,2024-13-01\ny,2024-01-01T00:00:00Z\n", []int{2}, 1},
+		{JSONL, "{\"title\":\"a\"}\n\n{oops}\n{\"title\":\"b\",\"extra\":1}\n{\"title\":\"c\"}\n", []int{3, 4}, 2},
+	}
+	for _, c := range cases {
+		r := NewReader(strings.NewReader(c.in), c.f)
+		var lines []int
+		ok := 0
+		for {
+			_, _, err := r.Next()
+			if err == io.EOF {
+				break
+			}
+			var le *LineError
+			if errors.As(err, &le) {
+				lines = append(lines, le.Line)
+				continue
+			}
+			if err != nil {
+				t.Fatalf("%q: %v", c.in, err)
+			}
+			ok++
+		}
+		if !reflect.DeepEqual(lines, c.lines) || ok != c.ok {
+			t.Errorf("%q: error lines %v ok %d, want %v ok %d", c.in, lines, ok, c.lines, c.ok)
+		}
+	}
+}
+
+func TestCSVHeader(t *testing.T) {
+	if _, _, err := NewReader(strings.NewReader("name\nx\n"), CSV).Next(); err == nil {
+		t.Error("missing title column should fail")
+	}
+	rec, line, err := NewReader(strings.NewReader("\ufeffTitle,Unknown\nhello,1\n"), CSV).Next()
+	if err != nil || rec.Title != "hello" || line != 2 {
+		t.Errorf("got %+v line %d err %v", rec, line, err)
+	}
+}
@@ -0,0 +1,444 @@
+/*
+Package wal 追加写日志 + 快照，给内存实现提供持久化
+  - 日志按段（segment）保存为 dir/<seq>.wal，记录格式：4字节长度 | 4字节CRC32C | payload（大端）
+  - 快照 dir/snapshot 记录它覆盖到哪个段为止（不含），恢复时先加载快照，再按顺序重放其后的段
+  - 进程崩溃时最后一段末尾可能有写了一半的记录：恢复时截断到最后一条完整记录；其它位置的损坏直接报错
+  - 日志只负责字节，payload的含义（以及重放逻辑）由调用方决定
+*/
+package wal
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SyncPolicy 每次追加后何时fsync
+type SyncPolicy string
+
+const (
+	SyncAlways   SyncPolicy = "always"   // 每条记录都fsync，返回即落盘
+	SyncInterval SyncPolicy = "interval" // 后台每隔SyncInterval fsync一次，崩溃最多丢这段时间内的写入
+	SyncNone     SyncPolicy = "none"     // 交给操作系统，只保证进程崩溃不丢，掉电可能丢
+)
+
+type Options struct {
+	Sync         SyncPolicy
+	SyncInterval time.Duration // Sync=interval时使用，<=0取1s
+}
+
+// Recovery Open时的恢复情况
+type Recovery struct {
+	Snapshot       bool  // 是否加载了快照
+	Records        int   // 重放的日志记录数
+	TruncatedBytes int64 // 最后一段末尾被丢弃的残缺字节数
+}
+
+var (
+	ErrCorrupt = errors.New("wal: corrupt")
+	ErrClosed  = errors.New("wal: closed")
+
+	// errTornTail 残缺的记录一直延伸到文件末尾，是写到一半时崩溃留下的，可以截断；同时也是ErrCorrupt
+	errTornTail = errors.New("torn tail")
+)
+
+const (
+	headerSize = 8
+	// maxRecord 单条记录的上限，长度字段超过它视为损坏
+	maxRecord = 64 << 20
+
+	snapshotName  = "snapshot"
+	snapshotMagic = "taskhub-snapshot 1\n"
+	segmentExt    = ".wal"
+)
+
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+type Log struct {
+	dir  string
+	opts Options
+
+	mu     sync.Mutex
+	f      *os.File
+	seq    uint64 // 当前写入的段
+	size   int64  // 当前段的长度
+	dirty  bool   // 有未fsync的写入
+	closed bool
+	broken error // 写失败且无法回退时置位，之后的写入全部失败
+
+	snapMu sync.Mutex // 同一时刻只做一次快照
+	stop   chan struct{}
+	done   chan struct{}
+}
+
+/*
+Open 打开（必要时创建）dir下的日志并完成恢复：
+loadSnapshot收到快照内容（没有快照时不调用），apply按顺序收到其后每条记录的payload
+*/
+func Open(dir string, opts Options, loadSnapshot func(io.Reader) error, apply func([]byte) error) (*Log, Recovery, error) {
+	var rec Recovery
+	switch opts.Sync {
+	case SyncAlways, SyncInterval, SyncNone:
+	default:
+		return nil, rec, fmt.Errorf("wal: invalid sync policy %q", opts.Sync)
+	}
+	if opts.SyncInterval <= 0 {
+		opts.SyncInterval = time.Second
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, rec, fmt.Errorf("wal dir: %w", err)
+	}
+
+	// 1. 快照
+	first := uint64(1)
+	if data, err := os.ReadFile(filepath.Join(dir, snapshotName)); err == nil {
+		seq, body, err := decodeSnapshot(data)
+		if err != nil {
+			return nil, rec, err
+		}
+		if err := loadSnapshot(bytes.NewReader(body)); err != nil {
+			return nil, rec, fmt.Errorf("wal: load snapshot: %w", err)
+		}
+		first, rec.Snapshot = seq, true
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, rec, fmt.Errorf("wal: read snapshot: %w", err)
+	}
+
+	// 2. 快照之后的段
+	segs, err := segments(dir)
+	if err != nil {
+		return nil, rec, err
+	}
+	var live []uint64
+	for _, s := range segs {
+		if s < first {
+			// 快照已覆盖：上次快照写完后、删除旧段前进程退出留下的
+			_ = os.Remove(segmentPath(dir, s))
+			continue
+		}
+		live = append(live, s)
+	}
+	for i, s := range live {
+		last := i == len(live)-1
+		n, good, size, err := replaySegment(segmentPath(dir, s), apply)
+		rec.Records += n
+		if err != nil {
+			if !last || !errors.Is(err, errTornTail) {
+				return nil, rec, fmt.Errorf("wal: segment %d: %w", s, err)
+			}
+			if err := os.Truncate(segmentPath(dir, s), good); err != nil {
+				return nil, rec, fmt.Errorf("wal: truncate segment %d: %w", s, err)
+			}
+			rec.TruncatedBytes = size - good
+		}
+	}
+
+	// 3. 继续写最后一段
+	seq := first
+	if len(live) > 0 {
+		seq = live[len(live)-1]
+	}
+	f, err := openSegment(dir, seq)
+	if err != nil {
+		return nil, rec, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return nil, rec, fmt.Errorf("wal: stat segment: %w", err)
+	}
+	l := &Log{dir: dir, opts: opts, f: f, seq: seq, size: info.Size()}
+	if opts.Sync == SyncInterval {
+		l.stop, l.done = make(chan struct{}), make(chan struct{})
+		go l.syncLoop()
+	}
+	return l, rec, nil
+}
+
+// Append 追加一条记录；Sync=always时返回前已fsync
+func (l *Log) Append(payload []byte) error {
+	if len(payload) > maxRecord {
+		return fmt.Errorf("wal: record too large (%d bytes)", len(payload))
+	}
+	buf := make([]byte, headerSize+len(payload))
+	binary.BigEndian.PutUint32(buf[0:4], uint32(len(payload)))
+	binary.BigEndian.PutUint32(buf[4:8], crc32.Checksum(payload, castagnoli))
+	copy(buf[headerSize:], payload)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed {
+		return ErrClosed
+	}
+	if l.broken != nil {
+		return l.broken
+	}
+	if _, err := l.f.Write(buf); err != nil {
+		// 写了一半的记录会让之后的记录在恢复时被当作残缺尾部丢弃，必须回退
+		if terr := l.f.Truncate(l.size); terr != nil {
+			l.broken = fmt.Errorf("wal: unusable after failed write: %w", terr)
+		}
+		return fmt.Errorf("wal: write: %w", err)
+	}
+	if l.opts.Sync == SyncAlways {
+		if err := l.f.Sync(); err != nil {
+			/*
+				fsync失败后页缓存的状态不可信，不能再保证后续写入落盘；
+				调用方会撤销这次修改，这条记录也要尽量去掉，免得重启后被重放
+			*/
+			_ = l.f.Truncate(l.size)
+			l.broken = fmt.Errorf("wal: unusable after failed sync: %w", err)
+			return fmt.Errorf("wal: sync: %w", err)
+		}
+		l.size += int64(len(buf))
+		return nil
+	}
+	l.size += int64(len(buf))
+	l.dirty = true
+	return nil
+}
+
+/*
+Rotate 切换到新的段，返回新段号；调用方此时捕获的状态正好覆盖新段之前的全部记录
+调用方必须保证Rotate与Append之间没有并发（通常在持有自己写锁时调用），之后再在锁外调用WriteSnapshot
+*/
+func (l *Log) Rotate() (uint64, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed {
+		return 0, ErrClosed
+	}
+	if l.broken != nil {
+		return 0, l.broken
+	}
+	// 旧段必须先落盘：否则掉电后旧段可能残缺，而新段已经存在，恢复时无法区分
+	if err := l.f.Sync(); err != nil {
+		return 0, fmt.Errorf("wal: sync: %w", err)
+	}
+	f, err := openSegment(l.dir, l.seq+1)
+	if err != nil {
+		return 0, err
+	}
+	_ = l.f.Close()
+	l.f, l.seq, l.size, l.dirty = f, l.seq+1, 0, false
+	return l.seq, nil
+}
+
+/*
+WriteSnapshot 写出覆盖到seq（不含）为止的快照，随后删除已被覆盖的段
+先写临时文件并fsync，再rename，保证任何时刻磁盘上都有一份完整的快照
+*/
+func (l *Log) WriteSnapshot(seq uint64, write func(io.Writer) error) error {
+	l.snapMu.Lock()
+	defer l.snapMu.Unlock()
+
+	tmp := filepath.Join(l.dir, snapshotName+".tmp")
+	f, err := os.Create(tmp)
+	if err != nil {
+		return fmt.Errorf("wal: create snapshot: %w", err)
+	}
+	defer os.Remove(tmp)
+
+	crc := crc32.New(castagnoli)
+	bw := bufio.NewWriter(io.MultiWriter(f, crc))
+	err = func() error {
+		if _, err := io.WriteString(f, snapshotMagic); err != nil {
+			return err
+		}
+		var s [8]byte
+		binary.BigEndian.PutUint64(s[:], seq)
+		if _, err := bw.Write(s[:]); err != nil {
+			return err
+		}
+		if err := write(bw); err != nil {
+			return err
+		}
+		if err := bw.Flush(); err != nil {
+			return err
+		}
+		var c [4]byte
+		binary.BigEndian.PutUint32(c[:], crc.Sum32())
+		if _, err := f.Write(c[:]); err != nil {
+			return err
+		}
+		return f.Sync()
+	}()
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("wal: write snapshot: %w", err)
+	}
+	if err := os.Rename(tmp, filepath.Join(l.dir, snapshotName)); err != nil {
+		return fmt.Errorf("wal: rename snapshot: %w", err)
+	}
+	if err := syncDir(l.dir); err != nil {
+		return err
+	}
+
+	segs, err := segments(l.dir)
+	if err != nil {
+		return err
+	}
+	for _, s := range segs {
+		if s < seq {
+			_ = os.Remove(segmentPath(l.dir, s))
+		}
+	}
+	return nil
+}
+
+// Close 停止后台fsync，把未落盘的写入fsync后关闭文件
+func (l *Log) Close() error {
+	l.mu.Lock()
+	if l.closed {
+		l.mu.Unlock()
+		return nil
+	}
+	l.closed = true
+	err := errors.Join(l.f.Sync(), l.f.Close())
+	l.mu.Unlock()
+
+	if l.stop != nil {
+		close(l.stop)
+		<-l.done
+	}
+	return err
+}
+
+func (l *Log) syncLoop() {
+	defer close(l.done)
+	t := time.NewTicker(l.opts.SyncInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-t.C:
+		}
+		l.mu.Lock()
+		if l.dirty && !l.closed {
+			// 失败时保留dirty，下个周期重试；Close时还会再同步一次
+			if l.f.Sync() == nil {
+				l.dirty = false
+			}
+		}
+		l.mu.Unlock()
+	}
+}
+
+/*
+replaySegment 逐条重放一个段
+返回重放的记录数、最后一条完整记录之后的偏移和文件大小；遇到残缺或校验失败的记录返回ErrCorrupt
+只有坏记录一直延伸到文件末尾时才同时带上errTornTail：校验失败但后面还有数据说明是中间损坏，不能截断
+*/
+func replaySegment(path string, apply func([]byte) error) (n int, good, size int64, err error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	size = int64(len(data))
+	for off := 0; off < len(data); {
+		if len(data)-off < headerSize {
+			return n, good, size, fmt.Errorf("%w: %w: short header at offset %d", ErrCorrupt, errTornTail, off)
+		}
+		length := binary.BigEndian.Uint32(data[off : off+4])
+		sum := binary.BigEndian.Uint32(data[off+4 : off+8])
+		if length > maxRecord || int(length) > len(data)-off-headerSize {
+			if length > maxRecord {
+				return n, good, size, fmt.Errorf("%w: invalid record length at offset %d", ErrCorrupt, off)
+			}
+			return n, good, size, fmt.Errorf("%w: %w: short record at offset %d", ErrCorrupt, errTornTail, off)
+		}
+		payload := data[off+headerSize : off+headerSize+int(length)]
+		if crc32.Checksum(payload, castagnoli) != sum {
+			if off+headerSize+int(length) == len(data) {
+				return n, good, size, fmt.Errorf("%w: %w: checksum mismatch at offset %d", ErrCorrupt, errTornTail, off)
+			}
+			return n, good, size, fmt.Errorf("%w: checksum mismatch at offset %d", ErrCorrupt, off)
+		}
+		if err := apply(payload); err != nil {
+			return n, good, size, fmt.Errorf("apply record at offset %d: %w", off, err)
+		}
+		n++
+		off += headerSize + int(length)
+		good = int64(off)
+	}
+	return n, good, size, nil
+}
+
+func decodeSnapshot(data []byte) (uint64, []byte, error) {
+	rest, ok := bytes.CutPrefix(data, []byte(snapshotMagic))
+	if !ok || len(rest) < 12 {
+		return 0, nil, fmt.Errorf("%w: invalid snapshot header", ErrCorrupt)
+	}
+	body, tail := rest[:len(rest)-4], rest[len(rest)-4:]
+	if crc32.Checksum(body, castagnoli) != binary.BigEndian.Uint32(tail) {
+		return 0, nil, fmt.Errorf("%w: snapshot checksum mismatch", ErrCorrupt)
+	}
+	return binary.BigEndian.Uint64(body[:8]), body[8:], nil
+}
+
+// segments 按段号升序列出dir下的段
+func segments(dir string) ([]uint64, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("wal: list segments: %w", err)
+	}
+	var out []uint64
+	for _, e := range entries {
+		name, ok := strings.CutSuffix(e.Name(), segmentExt)
+		if !ok || e.IsDir() {
+			continue
+		}
+		if s, err := strconv.ParseUint(name, 10, 64); err == nil {
+			out = append(out, s)
+		}
+	}
+	slices.Sort(out)
+	return out, nil
+}
+
+func segmentPath(dir string, seq uint64) string {
+	return filepath.Join(dir, fmt.Sprintf("%020d%s", seq, segmentExt))
+}
+
+// openSegment 以追加方式打开段；新建文件时fsync目录，保证文件本身在掉电后仍然存在
+func openSegment(dir string, seq uint64) (*os.File, error) {
+	path := segmentPath(dir, seq)
+	_, statErr := os.Stat(path)
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("wal: open segment: %w", err)
+	}
+	if errors.Is(statErr, os.ErrNotExist) {
+		if err := syncDir(dir); err != nil {
+			_ = f.Close()
+			return nil, err
+		}
+	}
+	return f, nil
+}
+
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return fmt.Errorf("wal: open dir: %w", err)
+	}
+	defer d.Close()
+	if err := d.Sync(); err != nil {
+		return fmt.Errorf("wal: sync dir: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,205 @@
+package wal
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// state 测试用的“状态机”：快照内容与重放的记录依次拼起来
+type state struct {
+	snapshot string
+	records  []string
+}
+
+func open(t *testing.T, dir string) (*Log, *state, Recovery) {
+	t.Helper()
+	st := &state{}
+	l, rec, err := Open(dir, Options{Sync: SyncAlways},
+		func(r io.Reader) error {
+			b, err := io.ReadAll(r)
+			st.snapshot = string(b)
+			return err
+		},
+		func(p []byte) error {
+			st.records = append(st.records, string(p))
+			return nil
+		},
+	)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	return l, st, rec
+}
+
+func appendAll(t *testing.T, l *Log, recs ...string) {
+	t.Helper()
+	for _, r := range recs {
+		if err := l.Append([]byte(r)); err != nil {
+			t.Fatalf("Append(%q): %v", r, err)
+		}
+	}
+}
+
+func TestReplay(t *testing.T) {
+	dir := t.TempDir()
+	l, _, _ := open(t, dir)
+	appendAll(t, l, "a", "", "ccc")
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	l, st, rec := open(t, dir)
+	defer l.Close()
+	if want := []string{"a", "", "ccc"}; !slices.Equal(st.records, want) || rec.Records != 3 {
+		t.Fatalf("replayed %q (%d), want %q", st.records, rec.Records, want)
+	}
+	if rec.Snapshot || rec.TruncatedBytes != 0 {
+		t.Errorf("unexpected recovery %+v", rec)
+	}
+}
+
+func TestTornTail(t *testing.T) {
+	for _, cut := range []int{1, headerSize - 1, headerSize, headerSize + 2} {
+		t.Run(fmt.Sprint(cut), func(t *testing.T) {
+			dir := t.TempDir()
+			l, _, _ := open(t, dir)
+			appendAll(t, l, "first", "second", "third!")
+			l.Close()
+
+			// 模拟写最后一条记录时崩溃：只留下前cut个字节
+			path := segmentPath(dir, 1)
+			info, _ := os.Stat(path)
+			lastLen := int64(headerSize + len("third!"))
+			if err := os.Truncate(path, info.Size()-lastLen+int64(cut)); err != nil {
+				t.Fatal(err)
+			}
+
+			l, st, rec := open(t, dir)
+			if want := []string{"first", "second"}; !slices.Equal(st.records, want) {
+				t.Fatalf("replayed %q, want %q", st.records, want)
+			}
+			if rec.TruncatedBytes != int64(cut) {
+				t.Errorf("truncated %d bytes, want %d", rec.TruncatedBytes, cut)
+			}
+			// 截断之后继续追加，重启后不会再看到残缺数据
+			appendAll(t, l, "fourth")
+			l.Close()
+			l, st, rec = open(t, dir)
+			defer l.Close()
+			if want := []string{"first", "second", "fourth"}; !slices.Equal(st.records, want) || rec.TruncatedBytes != 0 {
+				t.Fatalf("replayed %q (%+v), want %q", st.records, rec, want)
+			}
+		})
+	}
+}
+
+func TestChecksumMismatchAtTail(t *testing.T) {
+	dir := t.TempDir()
+	l, _, _ := open(t, dir)
+	appendAll(t, l, "one", "two")
+	l.Close()
+
+	path := segmentPath(dir, 1)
+	data, _ := os.ReadFile(path)
+	data[len(data)-1] ^= 0xff
+	os.WriteFile(path, data, 0o644)
+
+	l, st, rec := open(t, dir)
+	defer l.Close()
+	if !slices.Equal(st.records, []string{"one"}) || rec.TruncatedBytes != int64(headerSize+3) {
+		t.Fatalf("replayed %q, recovery %+v", st.records, rec)
+	}
+}
+
+// 最后一段中间的记录校验失败、其后还有完整记录时不是残缺尾部，不能截断丢掉后面的记录
+func TestChecksumMismatchBeforeTail(t *testing.T) {
+	dir := t.TempDir()
+	l, _, _ := open(t, dir)
+	appendAll(t, l, "one", "two", "three")
+	l.Close()
+
+	path := segmentPath(dir, 1)
+	data, _ := os.ReadFile(path)
+	data[2*headerSize+len("one")] ^= 0xff // "two"的第一个字节
+	os.WriteFile(path, data, 0o644)
+
+	_, _, err := Open(dir, Options{Sync: SyncAlways}, func(io.Reader) error { return nil }, func([]byte) error { return nil })
+	if !errors.Is(err, ErrCorrupt) {
+		t.Fatalf("Open err = %v, want ErrCorrupt", err)
+	}
+	if info, _ := os.Stat(path); info.Size() != int64(len(data)) {
+		t.Errorf("segment truncated to %d bytes, want %d", info.Size(), len(data))
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	l, _, _ := open(t, dir)
+	appendAll(t, l, "a", "b")
+	seq, err := l.Rotate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	appendAll(t, l, "c")
+	if err := l.WriteSnapshot(seq, func(w io.Writer) error {
+		_, err := io.WriteString(w, "ab")
+		return err
+	}); err != nil {
+		t.Fatal(err)
+	}
+	l.Close()
+
+	if _, err := os.Stat(segmentPath(dir, 1)); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("segment 1 should be removed after snapshot, stat err = %v", err)
+	}
+	l, st, rec := open(t, dir)
+	defer l.Close()
+	if st.snapshot != "ab" || !slices.Equal(st.records, []string{"c"}) || !rec.Snapshot {
+		t.Fatalf("snapshot %q, records %q, recovery %+v", st.snapshot, st.records, rec)
+	}
+}
+
+func TestCorruptSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	l, _, _ := open(t, dir)
+	seq, _ := l.Rotate()
+	l.WriteSnapshot(seq, func(w io.Writer) error {
+		_, err := io.WriteString(w, "state")
+		return err
+	})
+	l.Close()
+
+	path := filepath.Join(dir, snapshotName)
+	data, _ := os.ReadFile(path)
+	data[len(snapshotMagic)+9] ^= 0xff
+	os.WriteFile(path, data, 0o644)
+
+	_, _, err := Open(dir, Options{Sync: SyncAlways}, func(io.Reader) error { return nil }, func([]byte) error { return nil })
+	if !errors.Is(err, ErrCorrupt) {
+		t.Fatalf("Open err = %v, want ErrCorrupt", err)
+	}
+}
+
+// 非最后一段的损坏不是崩溃造成的，不能静默截断
+func TestCorruptMiddleSegment(t *testing.T) {
+	dir := t.TempDir()
+	l, _, _ := open(t, dir)
+	appendAll(t, l, "a", "b")
+	l.Rotate()
+	appendAll(t, l, "c")
+	l.Close()
+
+	path := segmentPath(dir, 1)
+	info, _ := os.Stat(path)
+	os.Truncate(path, info.Size()-1)
+
+	_, _, err := Open(dir, Options{Sync: SyncAlways}, func(io.Reader) error { return nil }, func([]byte) error { return nil })
+	if !errors.Is(err, ErrCorrupt) {
+		t.Fatalf("Open err = %v, want ErrCorrupt", err)
+	}
+}